@@ -0,0 +1,828 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/YAITS/api/backup"
+	"github.com/YAITS/api/mail"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+)
+
+const adminUsage = `Usage: yaits-server [command] [--config FILE]
+
+Commands:
+  serve            run the API, the default command
+  migrate          apply the pending schema migrations
+  seed [--demo]    add the default issue types, along with demo issues into an empty database with --demo
+  backup           write a portable archive of the database and the attachments [--output FILE], to the standard
+                   output by default, or a dump of the database with mysqldump with --sql
+  restore FILE     load an archive into an empty database, or into a SQLite file with --sqlite FILE, or with
+                   --sql --yes a dump, overwriting the tables
+  verify-backup    FILE, check an archive is intact
+  create-admin     NAME, create or replace the API token of an admin user, printed once
+  rotate-token     NAME, replace the API token of a user, printed once
+  reindex-search   refresh the statistics of the indexes issue listings and searches rely on
+  check-config     check the config, the database and the services it points to
+
+Flags of every command:
+  --config FILE    config file, ./conf/conf.toml by default
+`
+
+// tokenBytes is the number of random bytes of the API tokens created
+const tokenBytes = 32
+
+// adminCommand runs a command of the server binary with the arguments following its name
+type adminCommand func(args []string) error
+
+var adminCommands = map[string]adminCommand{
+	"serve":          serveCommand,
+	"migrate":        migrateCommand,
+	"seed":           seedCommand,
+	"backup":         backupCommand,
+	"restore":        restoreCommand,
+	"verify-backup":  verifyBackupCommand,
+	"create-admin":   createAdminCommand,
+	"rotate-token":   rotateTokenCommand,
+	"reindex-search": reindexSearchCommand,
+	"check-config":   checkConfigCommand,
+}
+
+// adminUsageError is a command misused, reported along with the usage
+type adminUsageError struct {
+	message string
+}
+
+func (err *adminUsageError) Error() string {
+	return err.message
+}
+
+// defaultIssueTypes are the issue types seed adds when missing, as the issue types migration does
+var defaultIssueTypes = []models.NewIssueTypeRequest{
+	{
+		Name:                "Bug",
+		RequiredFields:      []string{"steps to reproduce"},
+		DefaultPriority:     3,
+		DescriptionTemplate: "Expected behaviour:\n\nActual behaviour:",
+	},
+	{Name: "Feature", DefaultPriority: 5, DescriptionTemplate: "As a ... I want ... so that ..."},
+	{Name: "Task", DefaultPriority: 5},
+}
+
+// demoIssue is an issue seed --demo adds, along with its comments, logged work and watchers
+type demoIssue struct {
+	issue    models.NewIssueRequest
+	comments []string
+	minutes  int64
+	watchers []string
+	status   string
+}
+
+var demoIssues = []demoIssue{
+	{
+		issue: models.NewIssueRequest{
+			Summary:     "Login page shows a blank screen",
+			Description: "Expected behaviour: the login form shows\n\nActual behaviour: the page stays blank",
+			Priority:    1,
+			Assignee:    "alice",
+			Reporter:    "bob",
+			Type:        "Bug",
+			Fields:      map[string]string{"steps to reproduce": "Open /login in Firefox"},
+		},
+		comments: []string{"Reproduced on Firefox 78, Chrome works", "The bundle fails to load, looking into it"},
+		minutes:  90,
+		watchers: []string{"bob", "carol"},
+		status:   "in progress",
+	},
+	{
+		issue: models.NewIssueRequest{
+			Summary:          "Export issues to CSV",
+			Description:      "As a manager I want to export issues so that I can report on them",
+			Priority:         5,
+			Assignee:         "carol",
+			Reporter:         "alice",
+			Type:             "Feature",
+			OriginalEstimate: 480,
+		},
+		comments: []string{"Shipped with the export endpoint"},
+		minutes:  420,
+		watchers: []string{"alice"},
+		status:   "closed",
+	},
+	{
+		issue: models.NewIssueRequest{
+			Summary:     "Upgrade the database to MySQL 8",
+			Description: "Plan the upgrade of the production database",
+			Priority:    4,
+			Reporter:    "carol",
+			Type:        "Task",
+		},
+		watchers: []string{"alice", "carol"},
+	},
+}
+
+// runCommand runs the command of args, returning the exit code: 1 when the command failed and 2 when it was misused
+func runCommand(args []string) int {
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Print(adminUsage)
+		return 0
+	}
+
+	cmd, ok := adminCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "yaits-server: unknown command %q\n\n%s", args[0], adminUsage)
+		return 2
+	}
+
+	err := cmd(args[1:])
+
+	var misuse *adminUsageError
+	switch {
+	case err == nil:
+		return 0
+	case err == pflag.ErrHelp:
+		fmt.Print(adminUsage)
+		return 0
+	case errors.As(err, &misuse):
+		fmt.Fprintf(os.Stderr, "yaits-server %s: %s\n\n%s", args[0], err, adminUsage)
+		return 2
+	}
+
+	fmt.Fprintf(os.Stderr, "yaits-server %s: %s\n", args[0], err)
+	return 1
+}
+
+// newAdminFlagSet creates the flag set of a command, along with the --config flag of every command
+func newAdminFlagSet(name string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("yaits-server "+name, pflag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.String("config", defaultConfigPath, "config file")
+
+	return flags
+}
+
+// parseAdmin parses the flags of a command, expecting count positional arguments, and reads the config
+func parseAdmin(flags *pflag.FlagSet, args []string, count int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil, err
+		}
+		return nil, &adminUsageError{message: err.Error()}
+	}
+
+	positional := flags.Args()
+	if len(positional) != count {
+		return nil, &adminUsageError{message: fmt.Sprintf("got %d arguments, expecting %d", len(positional), count)}
+	}
+
+	configPath, _ := flags.GetString("config")
+	if err := readConfig(configPath); err != nil {
+		return nil, fmt.Errorf("read config error: %s", err)
+	}
+
+	return positional, nil
+}
+
+func serveCommand(args []string) error {
+	if _, err := parseAdmin(newAdminFlagSet("serve"), args, 0); err != nil {
+		return err
+	}
+
+	serve()
+	return nil
+}
+
+func migrateCommand(args []string) error {
+	if _, err := parseAdmin(newAdminFlagSet("migrate"), args, 0); err != nil {
+		return err
+	}
+
+	storage, err := initDB()
+	if err != nil {
+		return err
+	}
+
+	applied, err := storage.Migrate()
+	for _, migration := range applied {
+		fmt.Printf("applied migration %d: %s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Printf("schema up to date at version %d\n", latestMigration())
+	}
+
+	return nil
+}
+
+func seedCommand(args []string) error {
+	flags := newAdminFlagSet("seed")
+	demo := flags.Bool("demo", false, "add demo issues, comments, logged work and watchers")
+	if _, err := parseAdmin(flags, args, 0); err != nil {
+		return err
+	}
+
+	storage, err := initDB()
+	if err != nil {
+		return err
+	}
+
+	for _, issueType := range defaultIssueTypes {
+		_, err := storage.RetrieveIssueType(issueType.Project, issueType.Name)
+		if err == nil {
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		if _, err = storage.CreateIssueType(issueType); err != nil {
+			return err
+		}
+		fmt.Printf("added issue type %s\n", issueType.Name)
+	}
+
+	if !*demo {
+		return nil
+	}
+
+	return seedDemo(storage)
+}
+
+// seedDemo adds the demo issues into an empty database. Their events are not recorded, seeding notifying no one
+func seedDemo(storage *persistence.MysqlStorage) error {
+	issues, err := storage.RetrieveIssues(models.IssueFilterQueryParam{})
+	if err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return errors.New("the database already has issues, demo data is only seeded into an empty database")
+	}
+
+	calendarID, err := storage.CreateBusinessCalendar(models.NewBusinessCalendarRequest{
+		Name:      "Office hours",
+		Timezone:  "UTC",
+		Workdays:  []int{1, 2, 3, 4, 5},
+		StartHour: 9,
+		EndHour:   17,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.CreateSLAPolicy(models.NewSLAPolicyRequest{
+		Name:              "Urgent",
+		Priority:          1,
+		ResponseMinutes:   60,
+		ResolutionMinutes: 480,
+		CalendarID:        calendarID,
+	})
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for _, demo := range demoIssues {
+		issueID, err := storage.CreateIssue(demo.issue)
+		if err != nil {
+			return err
+		}
+
+		for _, comment := range demo.comments {
+			if _, err = storage.UpdateIssue(models.UpdateIssueRequest{Comment: comment}, issueID); err != nil {
+				return err
+			}
+		}
+
+		if demo.minutes > 0 {
+			worklog := models.NewWorklogRequest{User: demo.issue.Assignee, Minutes: demo.minutes, WorkDate: today}
+			if _, err = storage.CreateWorklog(worklog, issueID); err != nil {
+				return err
+			}
+		}
+
+		for _, watcher := range demo.watchers {
+			if err = storage.WatchIssue(issueID, watcher); err != nil {
+				return err
+			}
+		}
+
+		if demo.status != "" {
+			if _, err = storage.UpdateIssue(models.UpdateIssueRequest{Status: demo.status}, issueID); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("added %d demo issues, a business calendar and an SLA policy\n", len(demoIssues))
+	return nil
+}
+
+func backupCommand(args []string) error {
+	flags := newAdminFlagSet("backup")
+	output := flags.StringP("output", "o", "", "file the backup is written to, the standard output by default")
+	dump := flags.Bool("sql", false, "dump the database with mysqldump rather than writing a portable archive")
+	if _, err := parseAdmin(flags, args, 0); err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if *dump {
+		// a single transaction dumps a consistent snapshot of the InnoDB tables without locking them
+		cmd := mysqlCommand("mysqldump", "--single-transaction", "--no-tablespaces")
+		cmd.Stdout = out
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("mysqldump failed: %s", err)
+		}
+	} else {
+		storage, err := initDB()
+		if err != nil {
+			return err
+		}
+
+		attachments, err := initAttachments()
+		if err != nil {
+			return err
+		}
+
+		manifest, err := backup.Write(out, storage, attachments.Store)
+		if err != nil {
+			return err
+		}
+
+		if *output != "" {
+			printManifest(manifest)
+		}
+	}
+
+	if *output != "" {
+		fmt.Printf("database %s backed up to %s\n", viper.GetString("db.database"), *output)
+	}
+
+	return nil
+}
+
+func restoreCommand(args []string) error {
+	flags := newAdminFlagSet("restore")
+	dump := flags.Bool("sql", false, "load a dump of backup --sql with mysql, overwriting the tables of the database")
+	yes := flags.Bool("yes", false, "confirm the tables of the database are overwritten loading a dump")
+	sqlite := flags.String("sqlite", "", "SQLite file the archive is loaded into rather than the database")
+	positional, err := parseAdmin(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if *dump && *sqlite != "" {
+		return &adminUsageError{message: "dumps of backup --sql are only restored into MySQL, not with --sqlite"}
+	}
+	if *dump && !*yes {
+		return &adminUsageError{message: fmt.Sprintf("restoring overwrites the tables of database %s, run again with --yes",
+			viper.GetString("db.database"))}
+	}
+
+	f, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if *dump {
+		cmd := mysqlCommand("mysql")
+		cmd.Stdin = f
+		cmd.Stdout = os.Stdout
+		if err = cmd.Run(); err != nil {
+			return fmt.Errorf("mysql failed: %s", err)
+		}
+
+		fmt.Printf("database %s restored from %s, run migrate if the backup predates the binary\n",
+			viper.GetString("db.database"), positional[0])
+		return nil
+	}
+
+	if *sqlite != "" {
+		return restoreSQLite(f, *sqlite)
+	}
+
+	return restoreArchive(f)
+}
+
+// restoreArchive loads a verified archive into an empty database, migrated to the schema version of the archive
+// first and to the latest one once loaded
+func restoreArchive(f *os.File) error {
+	manifest, err := backup.Verify(f)
+	if err != nil {
+		return err
+	}
+
+	storage, err := initDB()
+	if err != nil {
+		return err
+	}
+
+	attachments, err := initAttachments()
+	if err != nil {
+		return err
+	}
+
+	if _, err = storage.MigrateTo(manifest.SchemaVersion); err != nil {
+		return err
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = backup.Load(f, storage, attachments.Store); err != nil {
+		return err
+	}
+	printManifest(manifest)
+
+	applied, err := storage.Migrate()
+	for _, migration := range applied {
+		fmt.Printf("applied migration %d: %s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("database %s restored from %s\n", viper.GetString("db.database"), f.Name())
+	return nil
+}
+
+// restoreSQLite loads a verified archive into an empty SQLite file, created along with the tables of the schema
+// version of the archive when missing. The attachments are put into the store of the config
+func restoreSQLite(f *os.File, path string) error {
+	manifest, err := backup.Verify(f)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	attachments, err := initAttachments()
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = backup.Load(f, persistence.NewSQLiteStorage(db), attachments.Store); err != nil {
+		return err
+	}
+	printManifest(manifest)
+
+	fmt.Printf("%s restored into SQLite file %s at schema version %d\n", f.Name(), path, manifest.SchemaVersion)
+	return nil
+}
+
+func verifyBackupCommand(args []string) error {
+	positional, err := parseAdmin(newAdminFlagSet("verify-backup"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	manifest, err := backup.Verify(f)
+	if err != nil {
+		return err
+	}
+
+	printManifest(manifest)
+	fmt.Printf("%s is intact, taken %s at schema version %d\n", positional[0],
+		manifest.CreateDate.Format(time.RFC3339), manifest.SchemaVersion)
+	return nil
+}
+
+func printManifest(manifest backup.Manifest) {
+	var rows int64
+	for _, table := range manifest.Tables {
+		rows += table.Rows
+	}
+
+	fmt.Printf("%d rows of %d tables and %d attachments\n", rows, len(manifest.Tables), len(manifest.Attachments))
+}
+
+// mysqlCommand creates a MySQL client command connecting to the database of the config, the password being passed in
+// the environment rather than the arguments other users can list
+func mysqlCommand(name string, args ...string) *exec.Cmd {
+	host, port, err := net.SplitHostPort(viper.GetString("db.host"))
+	if err != nil {
+		host, port = viper.GetString("db.host"), ""
+	}
+
+	args = append(args, "--host", host, "--user", viper.GetString("db.user"))
+	if port != "" {
+		args = append(args, "--port", port)
+	}
+	args = append(args, viper.GetString("db.database"))
+
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "MYSQL_PWD="+viper.GetString("db.password"))
+
+	return cmd
+}
+
+func createAdminCommand(args []string) error {
+	positional, err := parseAdmin(newAdminFlagSet("create-admin"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	storage, err := initDB()
+	if err != nil {
+		return err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	if err = storage.CreateAPIToken(positional[0], token, true); err != nil {
+		return err
+	}
+
+	fmt.Printf("API token of admin %s, shown only once:\n%s\n", positional[0], token)
+	return nil
+}
+
+func rotateTokenCommand(args []string) error {
+	positional, err := parseAdmin(newAdminFlagSet("rotate-token"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	storage, err := initDB()
+	if err != nil {
+		return err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	err = storage.RotateAPIToken(positional[0], token)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user %s has no API token, create one with create-admin", positional[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("new API token of %s, shown only once, the previous one being revoked:\n%s\n", positional[0], token)
+	return nil
+}
+
+// newToken creates a random API token
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func reindexSearchCommand(args []string) error {
+	if _, err := parseAdmin(newAdminFlagSet("reindex-search"), args, 0); err != nil {
+		return err
+	}
+
+	storage, err := initDB()
+	if err != nil {
+		return err
+	}
+
+	tables, err := storage.ReindexSearch()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("reindexed %s\n", strings.Join(tables, ", "))
+	return nil
+}
+
+func checkConfigCommand(args []string) error {
+	if _, err := parseAdmin(newAdminFlagSet("check-config"), args, 0); err != nil {
+		return err
+	}
+
+	checks := map[string]error{
+		"database":       checkDatabase(),
+		"attachments":    checkAttachments(),
+		"server.port":    checkPort("server.port"),
+		"server.baseURL": checkBaseURL("server.baseURL"),
+		"durations":      checkDurations(),
+		"sla":            checkSLA(),
+	}
+	if viper.GetBool("grpc.enabled") {
+		checks["grpc.port"] = checkPort("grpc.port")
+	}
+	if viper.GetBool("email.enabled") {
+		checks["email"] = checkEmail()
+	}
+	if viper.GetBool("inbound.enabled") {
+		checks["inbound"] = checkInbound()
+	}
+
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := 0
+	for _, name := range names {
+		if err := checks[name]; err != nil {
+			fmt.Printf("FAIL %s: %s\n", name, err)
+			failed++
+		} else {
+			fmt.Printf("ok   %s\n", name)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+
+	return nil
+}
+
+// checkDatabase connects to the database, checking its schema is up to date
+func checkDatabase() error {
+	storage, err := initDB()
+	if err != nil {
+		return err
+	}
+
+	version, err := storage.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if latest := latestMigration(); version < latest {
+		return fmt.Errorf("schema at version %d, run migrate to reach version %d", version, latest)
+	}
+
+	return nil
+}
+
+func checkAttachments() error {
+	attachments, err := initAttachments()
+	if err != nil {
+		return err
+	}
+
+	if attachments.MaxSize <= 0 {
+		return fmt.Errorf("attachments.maxSize must be positive, got %d", attachments.MaxSize)
+	}
+
+	// uploads sent with an Idempotency-Key are rejected above the body limit of the idempotency keys
+	if maxBodySize := viper.GetInt64("idempotency.maxBodySize"); maxBodySize < attachments.MaxSize {
+		return fmt.Errorf("idempotency.maxBodySize must be at least attachments.maxSize, got %d", maxBodySize)
+	}
+
+	return nil
+}
+
+func checkPort(key string) error {
+	port, err := strconv.Atoi(viper.GetString(key))
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("%s must be a port, got %q", key, viper.GetString(key))
+	}
+
+	return nil
+}
+
+// checkBaseURL checks the public URL the links sent to users are built from is an absolute HTTP URL
+func checkBaseURL(key string) error {
+	u, err := url.Parse(viper.GetString(key))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an http or https URL, got %q", key, viper.GetString(key))
+	}
+
+	return nil
+}
+
+// checkDurations checks the intervals of the background workers are positive, unparsable ones reading as zero
+func checkDurations() error {
+	keys := []string{
+		"sla.evaluationInterval", "events.relayInterval", "webhooks.dispatchInterval", "webhooks.backoff",
+		"webhooks.maxBackoff", "webhooks.timeout", "idempotency.ttl",
+	}
+	if viper.GetBool("email.enabled") {
+		keys = append(keys, "email.sendInterval", "email.digestInterval")
+	}
+	if viper.GetBool("inbound.enabled") {
+		keys = append(keys, "inbound.pollInterval")
+	}
+
+	var invalid []string
+	for _, key := range keys {
+		if viper.GetDuration(key) <= 0 {
+			invalid = append(invalid, fmt.Sprintf("%s=%q", key, viper.GetString(key)))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("expecting positive durations such as 30s, got %s", strings.Join(invalid, ", "))
+	}
+
+	return nil
+}
+
+func checkSLA() error {
+	if ratio := viper.GetFloat64("sla.atRiskRatio"); ratio < 0 || ratio >= 1 {
+		return fmt.Errorf("sla.atRiskRatio must be between 0 and 1, got %g", ratio)
+	}
+
+	return nil
+}
+
+func checkEmail() error {
+	if viper.GetString("email.host") == "" {
+		return errors.New("email.host is empty")
+	}
+
+	switch tls := viper.GetString("email.tls"); tls {
+	case mail.TLSNone, mail.TLSStartTLS, mail.TLSImplicit:
+	default:
+		return fmt.Errorf("unknown email.tls %q, expecting %s, %s or %s", tls, mail.TLSNone, mail.TLSStartTLS,
+			mail.TLSImplicit)
+	}
+
+	return checkPort("email.port")
+}
+
+func checkInbound() error {
+	if _, err := inboundUsers(); err != nil {
+		return err
+	}
+
+	dir, address := viper.GetString("inbound.maildir"), viper.GetString("inbound.smtpAddress")
+	if dir == "" && address == "" {
+		return errors.New("neither inbound.maildir nor inbound.smtpAddress is set, no email being received")
+	}
+
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("inbound.maildir %s is not a directory", dir)
+		}
+	}
+
+	if address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return fmt.Errorf("invalid inbound.smtpAddress: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// latestMigration returns the version the migrations bring the schema to
+func latestMigration() int64 {
+	return persistence.Migrations[len(persistence.Migrations)-1].Version
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/YAITS/api/backup"
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/persistence"
+)
+
+// writeConfig writes a config file into dir, attachments being stored in dir too
+func writeConfig(t *testing.T, dir string) string {
+	config := `[server]
+port="8080"
+baseURL="http://localhost:8080"
+
+[attachments]
+path="` + filepath.Join(dir, "attachments") + `"
+`
+
+	path := filepath.Join(dir, "conf.toml")
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatalf("couldn't write the config: %s", err)
+	}
+
+	return path
+}
+
+// stubDB makes the commands open a stub database until the returned func is called
+func stubDB(t *testing.T) (sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	open := openDB
+	openDB = func() (*sql.DB, error) {
+		return db, nil
+	}
+
+	return mock, func() {
+		openDB = open
+		_ = db.Close()
+		viper.Reset()
+	}
+}
+
+// expectSchemaVersion expects the schema version to be read
+func expectSchemaVersion(mock sqlmock.Sqlmock, version int64) {
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT MAX\\(version\\) FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+}
+
+func TestRunCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-admin")
+	if err != nil {
+		t.Fatalf("couldn't create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	defer viper.Reset()
+
+	config := writeConfig(t, dir)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "Help", args: []string{"help"}, code: 0},
+		{name: "CommandHelp", args: []string{"seed", "--help"}, code: 0},
+		{name: "UnknownCommand", args: []string{"upgrade"}, code: 2},
+		{name: "UnknownFlag", args: []string{"migrate", "--force", "--config", config}, code: 2},
+		{name: "TooManyArguments", args: []string{"migrate", "now", "--config", config}, code: 2},
+		{name: "MissingArgument", args: []string{"create-admin", "--config", config}, code: 2},
+		{name: "MissingConfig", args: []string{"migrate", "--config", filepath.Join(dir, "missing.toml")}, code: 1},
+		{name: "RestoreUnconfirmed", args: []string{"restore", "yaits.sql", "--sql", "--config", config}, code: 2},
+		{name: "RestoreDumpIntoSQLite", args: []string{"restore", "yaits.sql", "--sql", "--yes", "--sqlite", "yaits.db",
+			"--config", config}, code: 2},
+		{name: "VerifyMissingBackup", args: []string{"verify-backup", filepath.Join(dir, "missing.tar.gz"),
+			"--config", config}, code: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.code, runCommand(test.args))
+		})
+	}
+}
+
+func TestParseAdmin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-admin")
+	if err != nil {
+		t.Fatalf("couldn't create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	defer viper.Reset()
+
+	flags := newAdminFlagSet("restore")
+	dump := flags.Bool("sql", false, "")
+	positional, err := parseAdmin(flags, []string{"--sql", "yaits.sql", "--config", writeConfig(t, dir)}, 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"yaits.sql"}, positional, "flags and arguments may be interleaved")
+	assert.True(t, *dump)
+	assert.Equal(t, "http://localhost:8080", viper.GetString("server.baseURL"), "the config is read")
+	assert.Equal(t, "24h", viper.GetString("idempotency.ttl"), "the defaults apply")
+}
+
+func TestMigrateCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-admin")
+	if err != nil {
+		t.Fatalf("couldn't create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mock, done := stubDB(t)
+	defer done()
+
+	expectSchemaVersion(mock, latestMigration())
+
+	// run the code
+	err = migrateCommand([]string{"--config", writeConfig(t, dir)})
+	assert.Nil(t, err)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestSeedCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-admin")
+	if err != nil {
+		t.Fatalf("couldn't create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	issueTypeColumns := []string{"id", "project", "name", "workflow", "requiredFields", "defaultPriority",
+		"descriptionTemplate"}
+
+	t.Run("IssueTypes", func(t *testing.T) {
+		mock, done := stubDB(t)
+		defer done()
+
+		mock.ExpectQuery("SELECT (.+) FROM issue_types WHERE name = \\?").WithArgs("Bug", "").
+			WillReturnRows(sqlmock.NewRows(issueTypeColumns).AddRow(1, "", "Bug", "default", "", 3, ""))
+		mock.ExpectQuery("SELECT (.+) FROM issue_types WHERE name = \\?").WithArgs("Feature", "").
+			WillReturnRows(sqlmock.NewRows(issueTypeColumns))
+		mock.ExpectExec("INSERT INTO issue_types").
+			WithArgs("", "Feature", "", "", 5, "As a ... I want ... so that ...").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectQuery("SELECT (.+) FROM issue_types WHERE name = \\?").WithArgs("Task", "").
+			WillReturnRows(sqlmock.NewRows(issueTypeColumns).AddRow(3, "", "Task", "default", "", 5, ""))
+
+		// run the code
+		err := seedCommand([]string{"--config", writeConfig(t, dir)})
+		assert.Nil(t, err)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("DemoIntoExistingIssues", func(t *testing.T) {
+		mock, done := stubDB(t)
+		defer done()
+
+		for i, issueType := range defaultIssueTypes {
+			mock.ExpectQuery("SELECT (.+) FROM issue_types WHERE name = \\?").WithArgs(issueType.Name, "").
+				WillReturnRows(sqlmock.NewRows(issueTypeColumns).AddRow(i+1, "", issueType.Name, "default", "", 5, ""))
+		}
+		mock.ExpectQuery("SELECT (.+) FROM issues").WillReturnRows(sqlmock.NewRows([]string{"id", "summary",
+			"description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate",
+			"respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+			AddRow(1, "Login fails", "Blank page", 1, "open", "alice", "bob", time.Now(), "", "", nil, nil, nil, 0, 0, 0, 1))
+		mock.ExpectQuery("SELECT commentID, comment FROM comments").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}))
+
+		// run the code
+		err := seedCommand([]string{"--demo", "--config", writeConfig(t, dir)})
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "only seeded into an empty database")
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+func TestRestoreSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-admin")
+	if err != nil {
+		t.Fatalf("couldn't create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	defer viper.Reset()
+
+	// an archive of a database holding an issue, taken from SQLite as the restored copy can be
+	source, err := sql.Open("sqlite3", filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatalf("couldn't open the SQLite database: %s", err)
+	}
+	defer source.Close()
+
+	err = persistence.NewSQLiteStorage(source).LoadTables(latestMigration(),
+		func(table string, insert func(row map[string]interface{}) error) error {
+			if table != "issues" {
+				return nil
+			}
+			return insert(map[string]interface{}{"id": int64(1), "summary": "Login fails", "priority": int64(2),
+				"status": "open", "createDate": "2020-07-01 10:00:00", "version": int64(1)})
+		})
+	if err != nil {
+		t.Fatalf("couldn't load the source database: %s", err)
+	}
+
+	store, err := blob.NewLocalStore(filepath.Join(dir, "attachments"))
+	if err != nil {
+		t.Fatalf("couldn't create the attachment store: %s", err)
+	}
+
+	archive, err := os.Create(filepath.Join(dir, "yaits.tar.gz"))
+	if err != nil {
+		t.Fatalf("couldn't create the archive: %s", err)
+	}
+	_, err = backup.Write(archive, persistence.NewSQLiteStorage(source), store)
+	archive.Close()
+	if err != nil {
+		t.Fatalf("couldn't write the archive: %s", err)
+	}
+
+	// run the code
+	target := filepath.Join(dir, "restored.db")
+	code := runCommand([]string{"restore", archive.Name(), "--sqlite", target, "--config", writeConfig(t, dir)})
+	assert.Equal(t, 0, code)
+
+	restored, err := sql.Open("sqlite3", target)
+	if err != nil {
+		t.Fatalf("couldn't open the restored database: %s", err)
+	}
+	defer restored.Close()
+
+	var summary string
+	assert.Nil(t, restored.QueryRow(`SELECT summary FROM issues WHERE id = 1`).Scan(&summary))
+	assert.Equal(t, "Login fails", summary)
+
+	code = runCommand([]string{"restore", archive.Name(), "--sqlite", target, "--config", writeConfig(t, dir)})
+	assert.Equal(t, 1, code, "archives are only restored into an empty database")
+}
+
+func TestTokenCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-admin")
+	if err != nil {
+		t.Fatalf("couldn't create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("CreateAdmin", func(t *testing.T) {
+		mock, done := stubDB(t)
+		defer done()
+
+		mock.ExpectExec("INSERT INTO api_tokens").WithArgs("alice", sqlmock.AnyArg(), true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// run the code
+		err := createAdminCommand([]string{"alice", "--config", writeConfig(t, dir)})
+		assert.Nil(t, err)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("RotateUnknownUser", func(t *testing.T) {
+		mock, done := stubDB(t)
+		defer done()
+
+		mock.ExpectExec("UPDATE api_tokens SET tokenHash = \\?").WithArgs(sqlmock.AnyArg(), "bob").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// run the code
+		err := rotateTokenCommand([]string{"bob", "--config", writeConfig(t, dir)})
+		assert.EqualError(t, err, "user bob has no API token, create one with create-admin")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	token, err := newToken()
+	assert.Nil(t, err)
+	assert.Len(t, token, 2*tokenBytes)
+}
+
+func TestReindexSearchCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-admin")
+	if err != nil {
+		t.Fatalf("couldn't create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mock, done := stubDB(t)
+	defer done()
+
+	mock.ExpectQuery("ANALYZE TABLE").WillReturnRows(sqlmock.NewRows([]string{"Table", "Op", "Msg_type", "Msg_text"}).
+		AddRow("yaits.issues", "analyze", "status", "OK").
+		AddRow("yaits.comments", "analyze", "error", "Table 'yaits.comments' doesn't exist"))
+
+	// run the code
+	err = reindexSearchCommand([]string{"--config", writeConfig(t, dir)})
+	assert.EqualError(t, err, "couldn't analyze yaits.comments: Table 'yaits.comments' doesn't exist")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestCheckConfigCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-admin")
+	if err != nil {
+		t.Fatalf("couldn't create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("Valid", func(t *testing.T) {
+		mock, done := stubDB(t)
+		defer done()
+
+		expectSchemaVersion(mock, latestMigration())
+
+		// run the code
+		err := checkConfigCommand([]string{"--config", writeConfig(t, dir)})
+		assert.Nil(t, err)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("OutdatedSchema", func(t *testing.T) {
+		mock, done := stubDB(t)
+		defer done()
+
+		expectSchemaVersion(mock, 1)
+
+		// run the code
+		err := checkConfigCommand([]string{"--config", writeConfig(t, dir)})
+		assert.EqualError(t, err, "1 of 6 checks failed")
+	})
+}
+
+func TestConfigChecks(t *testing.T) {
+	defer viper.Reset()
+
+	t.Run("Port", func(t *testing.T) {
+		for value, valid := range map[string]bool{"8080": true, "http": false, "0": false, "70000": false} {
+			viper.Set("server.port", value)
+			assert.Equal(t, valid, checkPort("server.port") == nil, value)
+		}
+	})
+
+	t.Run("BaseURL", func(t *testing.T) {
+		for value, valid := range map[string]bool{"https://yaits.example.com": true, "localhost:8080": false,
+			"ftp://yaits.example.com": false, "": false} {
+			viper.Set("server.baseURL", value)
+			assert.Equal(t, valid, checkBaseURL("server.baseURL") == nil, value)
+		}
+	})
+
+	t.Run("Durations", func(t *testing.T) {
+		for _, key := range []string{"sla.evaluationInterval", "events.relayInterval", "webhooks.dispatchInterval",
+			"webhooks.backoff", "webhooks.maxBackoff", "webhooks.timeout", "idempotency.ttl"} {
+			viper.Set(key, "1m")
+		}
+		assert.Nil(t, checkDurations())
+
+		viper.Set("events.relayInterval", "soon")
+		assert.EqualError(t, checkDurations(), `expecting positive durations such as 30s, got events.relayInterval="soon"`)
+	})
+
+	t.Run("SLA", func(t *testing.T) {
+		viper.Set("sla.atRiskRatio", 0.2)
+		assert.Nil(t, checkSLA())
+
+		viper.Set("sla.atRiskRatio", 1.5)
+		assert.NotNil(t, checkSLA())
+	})
+
+	t.Run("Email", func(t *testing.T) {
+		viper.Set("email.host", "")
+		assert.EqualError(t, checkEmail(), "email.host is empty")
+
+		viper.Set("email.host", "smtp.example.com")
+		viper.Set("email.tls", "ssl")
+		assert.NotNil(t, checkEmail())
+
+		viper.Set("email.tls", "starttls")
+		viper.Set("email.port", 587)
+		assert.Nil(t, checkEmail())
+	})
+
+	t.Run("Inbound", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "yaits-maildir")
+		if err != nil {
+			t.Fatalf("couldn't create a temporary directory: %s", err)
+		}
+		defer os.RemoveAll(dir)
+
+		assert.NotNil(t, checkInbound(), "email must be received one way or another")
+
+		viper.Set("inbound.maildir", filepath.Join(dir, "missing"))
+		assert.NotNil(t, checkInbound())
+
+		viper.Set("inbound.maildir", dir)
+		viper.Set("inbound.smtpAddress", "2525")
+		assert.NotNil(t, checkInbound(), "the SMTP address needs a port")
+
+		viper.Set("inbound.smtpAddress", ":2525")
+		viper.Set("inbound.users", []string{"jane@example.com"})
+		assert.NotNil(t, checkInbound(), "users are mapped as address=user")
+
+		viper.Set("inbound.users", []string{"jane@example.com=jane"})
+		assert.Nil(t, checkInbound())
+	})
+}
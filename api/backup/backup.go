@@ -0,0 +1,432 @@
+// Package backup writes the data of YAITS to versioned archives that do not depend on the database they come from,
+// and loads them back into an empty database.
+//
+// An archive is a gzipped tarball holding a manifest.json, followed by a tables/<table>.ndjson file of the rows of
+// every table that has some and an attachments/<hash> file of every attachment. The manifest records the layout
+// version of the archive, the schema version of the database and the size and SHA-256 checksum of every file
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/YAITS/api/blob"
+)
+
+const (
+	// Format identifies backup archives
+	Format = "yaits-backup"
+
+	// Version is the version of the archive layout written, archives of later versions being refused
+	Version = 1
+)
+
+const (
+	manifestName   = "manifest.json"
+	tablesDir      = "tables/"
+	attachmentsDir = "attachments/"
+
+	// attachmentsTable holds the hash and content type of the attachments, the hash being their key in the store
+	attachmentsTable = "attachments"
+)
+
+// Manifest describes the content of an archive
+type Manifest struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int64     `json:"schemaVersion"`
+	CreateDate    time.Time `json:"createDate"`
+	Tables        []File    `json:"tables"`
+	Attachments   []File    `json:"attachments"`
+}
+
+// File is a file of an archive, holding either the rows of a table or the content of an attachment
+type File struct {
+	Name        string `json:"name"`
+	Table       string `json:"table,omitempty"`
+	Rows        int64  `json:"rows,omitempty"`
+	Key         string `json:"key,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// Database is the database backups are taken from and loaded into, such as persistence.MysqlStorage or
+// persistence.SQLiteStorage
+type Database interface {
+	SnapshotTables(each func(table string, row map[string]interface{}) error) (int64, error)
+	LoadTables(schemaVersion int64, load func(table string, insert func(row map[string]interface{}) error) error) error
+}
+
+// ErrNoManifest is returned reading an archive that does not start with a manifest
+var ErrNoManifest = errors.New("not a backup archive, it does not start with " + manifestName)
+
+// Write writes an archive of a consistent snapshot of the database and of the attachments it references. The files
+// are spooled to temporary files first, their checksums being part of the manifest written ahead of them
+func Write(w io.Writer, db Database, store blob.Store) (Manifest, error) {
+	manifest := Manifest{
+		Format:      Format,
+		Version:     Version,
+		CreateDate:  time.Now().UTC(),
+		Tables:      []File{},
+		Attachments: []File{},
+	}
+
+	dir, err := ioutil.TempDir("", "yaits-backup")
+	if err != nil {
+		return manifest, err
+	}
+	defer os.RemoveAll(dir)
+
+	var spools []*spool
+	var table *spool
+	var encoder *json.Encoder
+	contentTypes := map[string]string{}
+	var keys []string
+
+	manifest.SchemaVersion, err = db.SnapshotTables(func(name string, row map[string]interface{}) error {
+		if table == nil || table.file.Table != name {
+			var err error
+			if table, err = newSpool(dir, File{Name: tablesDir + name + ".ndjson", Table: name}); err != nil {
+				return err
+			}
+			spools = append(spools, table)
+			encoder = json.NewEncoder(table)
+		}
+
+		if name == attachmentsTable {
+			key, _ := row["hash"].(string)
+			if _, ok := contentTypes[key]; !ok && key != "" {
+				contentTypes[key], _ = row["contentType"].(string)
+				keys = append(keys, key)
+			}
+		}
+
+		table.file.Rows++
+		return encoder.Encode(row)
+	})
+	if err != nil {
+		closeSpools(spools)
+		return manifest, err
+	}
+
+	for _, key := range keys {
+		attachment, err := spoolAttachment(dir, store, key, contentTypes[key])
+		if err != nil {
+			closeSpools(spools)
+			return manifest, err
+		}
+		spools = append(spools, attachment)
+	}
+	defer closeSpools(spools)
+
+	for _, s := range spools {
+		s.file.SHA256 = hex.EncodeToString(s.hash.Sum(nil))
+		if s.file.Table != "" {
+			manifest.Tables = append(manifest.Tables, s.file)
+		} else {
+			manifest.Attachments = append(manifest.Attachments, s.file)
+		}
+	}
+
+	return manifest, writeArchive(w, manifest, spools)
+}
+
+func spoolAttachment(dir string, store blob.Store, key, contentType string) (*spool, error) {
+	content, err := store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read attachment %s: %s", key, err)
+	}
+	defer content.Close()
+
+	s, err := newSpool(dir, File{Name: attachmentsDir + key, Key: key, ContentType: contentType})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = io.Copy(s, content); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("couldn't read attachment %s: %s", key, err)
+	}
+
+	return s, nil
+}
+
+func writeArchive(w io.Writer, manifest Manifest, spools []*spool) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	header := &tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(content)), ModTime: manifest.CreateDate}
+	if err = tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err = tw.Write(content); err != nil {
+		return err
+	}
+
+	for _, s := range spools {
+		header := &tar.Header{Name: s.file.Name, Mode: 0644, Size: s.file.Size, ModTime: manifest.CreateDate}
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if _, err = s.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err = io.Copy(tw, s.f); err != nil {
+			return err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// Verify reads a whole archive, checking its layout version and that its files match the checksums of its manifest
+func Verify(r io.Reader) (Manifest, error) {
+	tr, closer, manifest, err := openArchive(r)
+	if err != nil {
+		return manifest, err
+	}
+	defer closer.Close()
+
+	files := map[string]File{}
+	for _, f := range append(manifest.Tables, manifest.Attachments...) {
+		files[f.Name] = f
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, err
+		}
+
+		f, ok := files[header.Name]
+		if !ok {
+			return manifest, fmt.Errorf("%s is not part of the manifest, or is repeated", header.Name)
+		}
+		delete(files, header.Name)
+
+		h := sha256.New()
+		size, err := io.Copy(h, tr)
+		if err != nil {
+			return manifest, err
+		}
+		if size != f.Size || hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+			return manifest, fmt.Errorf("%s is corrupted, it does not match the checksum of the manifest", f.Name)
+		}
+	}
+
+	for name := range files {
+		return manifest, fmt.Errorf("%s of the manifest is missing from the archive", name)
+	}
+
+	return manifest, nil
+}
+
+// Load loads a verified archive into an empty database of its schema version, then puts its attachments missing from
+// the store. Rows are loaded in one transaction, rolled back when the checksum of a table does not match after all
+func Load(r io.Reader, db Database, store blob.Store) (Manifest, error) {
+	tr, closer, manifest, err := openArchive(r)
+	if err != nil {
+		return manifest, err
+	}
+	defer closer.Close()
+
+	tables := map[string]File{}
+	for _, f := range manifest.Tables {
+		tables[f.Table] = f
+	}
+
+	err = db.LoadTables(manifest.SchemaVersion, func(table string, insert func(row map[string]interface{}) error) error {
+		f, ok := tables[table]
+		if !ok {
+			return nil
+		}
+
+		header, err := tr.Next()
+		if err != nil {
+			return err
+		}
+		if header.Name != f.Name {
+			return fmt.Errorf("expecting %s in the archive, got %s", f.Name, header.Name)
+		}
+
+		return loadRows(tr, f, insert)
+	})
+	if err != nil {
+		return manifest, err
+	}
+
+	attachments := map[string]File{}
+	for _, f := range manifest.Attachments {
+		attachments[f.Name] = f
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return manifest, nil
+		}
+		if err != nil {
+			return manifest, err
+		}
+
+		f, ok := attachments[header.Name]
+		if !ok {
+			return manifest, fmt.Errorf("%s is not an attachment of the manifest", header.Name)
+		}
+
+		exists, err := store.Exists(f.Key)
+		if err != nil {
+			return manifest, err
+		}
+		if !exists {
+			if err = store.Put(f.Key, tr, f.Size, f.ContentType); err != nil {
+				return manifest, fmt.Errorf("couldn't store attachment %s: %s", f.Key, err)
+			}
+		}
+	}
+}
+
+func loadRows(r io.Reader, f File, insert func(row map[string]interface{}) error) error {
+	h := sha256.New()
+	decoder := json.NewDecoder(io.TeeReader(r, h))
+	decoder.UseNumber()
+
+	var rows int64
+	for {
+		var row map[string]interface{}
+		err := decoder.Decode(&row)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("row %d: %s", rows+1, err)
+		}
+
+		for column, value := range row {
+			if number, ok := value.(json.Number); ok {
+				row[column] = numberValue(number)
+			}
+		}
+
+		if err = insert(row); err != nil {
+			return fmt.Errorf("row %d: %s", rows+1, err)
+		}
+		rows++
+	}
+
+	if rows != f.Rows || hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+		return fmt.Errorf("%s is corrupted, it does not match the checksum of the manifest", f.Name)
+	}
+
+	return nil
+}
+
+// numberValue reads the numbers of rows as the int64 or float64 they were written from, integers out of the int64
+// range being kept as strings for the database to convert them without losing precision
+func numberValue(number json.Number) interface{} {
+	if i, err := number.Int64(); err == nil {
+		return i
+	}
+	if !strings.ContainsAny(number.String(), ".eE") {
+		return number.String()
+	}
+	if f, err := number.Float64(); err == nil {
+		return f
+	}
+
+	return number.String()
+}
+
+// openArchive opens a gzipped tarball, reading and checking its manifest
+func openArchive(r io.Reader) (*tar.Reader, io.Closer, Manifest, error) {
+	var manifest Manifest
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, manifest, ErrNoManifest
+	}
+
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if err != nil || header.Name != manifestName {
+		gz.Close()
+		return nil, nil, manifest, ErrNoManifest
+	}
+
+	if err = json.NewDecoder(tr).Decode(&manifest); err != nil {
+		gz.Close()
+		return nil, nil, manifest, fmt.Errorf("invalid manifest: %s", err)
+	}
+
+	switch {
+	case manifest.Format != Format:
+		err = ErrNoManifest
+	case manifest.Version < 1 || manifest.Version > Version:
+		err = fmt.Errorf("archive version %d is not supported, expecting version %d at most", manifest.Version, Version)
+	}
+	if err != nil {
+		gz.Close()
+		return nil, nil, manifest, err
+	}
+
+	return tr, gz, manifest, nil
+}
+
+// spool is a file of an archive written to a temporary file, its size and checksum being computed along the way
+type spool struct {
+	file File
+	f    *os.File
+	hash hash.Hash
+}
+
+func newSpool(dir string, file File) (*spool, error) {
+	f, err := ioutil.TempFile(dir, "spool")
+	if err != nil {
+		return nil, err
+	}
+
+	return &spool{file: file, f: f, hash: sha256.New()}, nil
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	n, err := s.f.Write(p)
+	s.hash.Write(p[:n])
+	s.file.Size += int64(n)
+
+	return n, err
+}
+
+func (s *spool) Close() error {
+	return s.f.Close()
+}
+
+func closeSpools(spools []*spool) {
+	for _, s := range spools {
+		s.Close()
+	}
+}
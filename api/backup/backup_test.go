@@ -0,0 +1,235 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/YAITS/api/blob"
+)
+
+// memoryDatabase keeps the rows of its tables in memory, rows loaded replacing them once all were inserted
+type memoryDatabase struct {
+	version int64
+	tables  []string
+	rows    map[string][]map[string]interface{}
+}
+
+func (db *memoryDatabase) SnapshotTables(each func(table string, row map[string]interface{}) error) (int64, error) {
+	for _, table := range db.tables {
+		for _, row := range db.rows[table] {
+			if err := each(table, row); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return db.version, nil
+}
+
+func (db *memoryDatabase) LoadTables(schemaVersion int64, load func(table string, insert func(row map[string]interface{}) error) error) error {
+	if schemaVersion != db.version {
+		return fmt.Errorf("schema version %d, expecting %d", schemaVersion, db.version)
+	}
+
+	loaded := map[string][]map[string]interface{}{}
+	for _, table := range db.tables {
+		err := load(table, func(row map[string]interface{}) error {
+			loaded[table] = append(loaded[table], row)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	db.rows = loaded
+	return nil
+}
+
+const attachmentContent = "Steps to reproduce: open /login"
+
+func TestBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-backup-test")
+	if err != nil {
+		t.Fatalf("couldn't create the temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source, target := newStore(t, filepath.Join(dir, "source")), newStore(t, filepath.Join(dir, "target"))
+
+	sum := sha256.Sum256([]byte(attachmentContent))
+	hash := hex.EncodeToString(sum[:])
+	err = source.Put(hash, strings.NewReader(attachmentContent), int64(len(attachmentContent)), "text/plain")
+	assert.Nil(t, err)
+
+	db := &memoryDatabase{
+		version: 2,
+		tables:  []string{"issues", "comments", "attachments", "watchers"},
+		rows: map[string][]map[string]interface{}{
+			"issues": {
+				{"id": int64(1), "summary": "Login fails", "priority": int64(2), "createDate": "2020-07-01 10:00:00",
+					"dueDate": nil},
+				{"id": int64(2), "summary": "Export to CSV", "priority": int64(5), "createDate": "2020-07-02 09:30:00",
+					"dueDate": "2020-08-01 00:00:00"},
+			},
+			"comments": {
+				{"commentID": int64(1), "issueID": int64(1), "comment": "Reproduced \"again\"\non Firefox"},
+			},
+			"attachments": {
+				{"id": int64(1), "issueID": int64(1), "hash": hash, "contentType": "text/plain"},
+				{"id": int64(2), "issueID": int64(2), "hash": hash, "contentType": "text/plain"},
+			},
+		},
+	}
+
+	var archive bytes.Buffer
+	manifest, err := Write(&archive, db, source)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), manifest.SchemaVersion)
+	assert.Len(t, manifest.Tables, 3)
+	assert.Equal(t, int64(2), manifest.Tables[0].Rows)
+	assert.Len(t, manifest.Attachments, 1)
+
+	t.Run("Verify", func(t *testing.T) {
+		verified, err := Verify(bytes.NewReader(archive.Bytes()))
+		assert.Nil(t, err)
+		assert.Equal(t, manifest.Tables, verified.Tables)
+		assert.Equal(t, manifest.Attachments, verified.Attachments)
+	})
+
+	t.Run("Load", func(t *testing.T) {
+		restored := &memoryDatabase{version: 2, tables: db.tables}
+		_, err := Load(bytes.NewReader(archive.Bytes()), restored, target)
+		assert.Nil(t, err)
+		assert.Equal(t, db.rows, restored.rows)
+
+		content, err := target.Get(hash)
+		assert.Nil(t, err)
+		defer content.Close()
+		b, _ := ioutil.ReadAll(content)
+		assert.Equal(t, attachmentContent, string(b))
+	})
+
+	t.Run("SchemaVersion", func(t *testing.T) {
+		restored := &memoryDatabase{version: 3, tables: db.tables}
+		_, err := Load(bytes.NewReader(archive.Bytes()), restored, target)
+		assert.NotNil(t, err)
+		assert.Nil(t, restored.rows)
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		corrupted := rewriteArchive(t, archive.Bytes(), func(name string, content []byte) []byte {
+			if name == tablesDir+"comments.ndjson" {
+				return bytes.Replace(content, []byte("Firefox"), []byte("Chrome!"), 1)
+			}
+			return content
+		})
+
+		_, err := Verify(bytes.NewReader(corrupted))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "comments.ndjson is corrupted")
+
+		restored := &memoryDatabase{version: 2, tables: db.tables}
+		_, err = Load(bytes.NewReader(corrupted), restored, target)
+		assert.NotNil(t, err)
+		assert.Nil(t, restored.rows)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		missing := rewriteArchive(t, archive.Bytes(), func(name string, content []byte) []byte {
+			if strings.HasPrefix(name, attachmentsDir) {
+				return nil
+			}
+			return content
+		})
+
+		_, err := Verify(bytes.NewReader(missing))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "missing")
+	})
+
+	t.Run("LaterVersion", func(t *testing.T) {
+		later := rewriteArchive(t, archive.Bytes(), func(name string, content []byte) []byte {
+			if name == manifestName {
+				return bytes.Replace(content, []byte(`"version": 1`), []byte(`"version": 2`), 1)
+			}
+			return content
+		})
+
+		_, err := Verify(bytes.NewReader(later))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "not supported")
+	})
+
+	t.Run("NotAnArchive", func(t *testing.T) {
+		_, err := Verify(strings.NewReader("-- MySQL dump"))
+		assert.Equal(t, ErrNoManifest, err)
+	})
+}
+
+func newStore(t *testing.T, dir string) blob.Store {
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("couldn't create the store: %s", err)
+	}
+
+	return store
+}
+
+// rewriteArchive rewrites the files of an archive, those rewritten as nil being left out
+func rewriteArchive(t *testing.T, archive []byte, rewrite func(name string, content []byte) []byte) []byte {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("couldn't read the archive: %s", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var out bytes.Buffer
+	gzOut := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gzOut)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("couldn't read the archive: %s", err)
+		}
+
+		content, _ := ioutil.ReadAll(tr)
+		if content = rewrite(header.Name, content); content == nil {
+			continue
+		}
+
+		header.Size = int64(len(content))
+		if err = tw.WriteHeader(header); err != nil {
+			t.Fatalf("couldn't write the archive: %s", err)
+		}
+		tw.Write(content)
+	}
+
+	tw.Close()
+	gzOut.Close()
+
+	return out.Bytes()
+}
+
+func TestNumberValue(t *testing.T) {
+	assert.Equal(t, int64(42), numberValue(json.Number("42")))
+	assert.Equal(t, 0.25, numberValue(json.Number("0.25")))
+	assert.Equal(t, "18446744073709551615", numberValue(json.Number("18446744073709551615")))
+}
@@ -0,0 +1,49 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrNotFound is returned when no blob is stored under a key
+var ErrNotFound = errors.New("blob not found")
+
+// Store is an interface to read and write binary contents addressed by a key
+type Store interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Exists(key string) (bool, error)
+	Delete(key string) error
+}
+
+// DetectContentType trusts the declared media type unless it is missing or generic, sniffing the content then
+func DetectContentType(declared string, content []byte) string {
+	mediaType, _, err := mime.ParseMediaType(declared)
+	if err != nil || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(content))
+	}
+
+	return mediaType
+}
+
+// AllowedType tells whether a media type matches one of the allowed media types or wildcards such as image/*, empty
+// allowing any type
+func AllowedType(contentType string, allowedTypes []string) bool {
+	if len(allowedTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range allowedTypes {
+		if allowed == contentType || allowed == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
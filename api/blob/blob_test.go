@@ -0,0 +1,118 @@
+package blob
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKey     = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	testContent = "test"
+)
+
+func TestLocalStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "yaits-blobs")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	store, err := NewLocalStore(root)
+	require.NoError(t, err)
+
+	testStore(t, store)
+}
+
+func TestS3Store(t *testing.T) {
+	stub := newS3Stub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	store := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "yaits",
+		Region:    "eu-west-1",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+
+	testStore(t, store)
+
+	assert.Contains(t, stub.lastAuthorization, "AWS4-HMAC-SHA256 Credential=access/", "requests are signed")
+	assert.Contains(t, stub.lastAuthorization, "/eu-west-1/s3/aws4_request", "signature is scoped to the region")
+	assert.Contains(t, stub.lastAuthorization, "SignedHeaders=host;x-amz-content-sha256;x-amz-date", "host and dates are signed")
+}
+
+func testStore(t *testing.T, store Store) {
+	exists, err := store.Exists(testKey)
+	require.NoError(t, err)
+	assert.False(t, exists, "blob does not exist yet")
+
+	_, err = store.Get(testKey)
+	assert.Equal(t, ErrNotFound, err, "missing blob is reported")
+
+	err = store.Put(testKey, strings.NewReader(testContent), int64(len(testContent)), "text/plain")
+	require.NoError(t, err)
+
+	exists, err = store.Exists(testKey)
+	require.NoError(t, err)
+	assert.True(t, exists, "blob exists once put")
+
+	r, err := store.Get(testKey)
+	require.NoError(t, err)
+	content, _ := ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, testContent, string(content), "blob content is read back")
+
+	require.NoError(t, store.Delete(testKey))
+	require.NoError(t, store.Delete(testKey), "deleting a missing blob is not an error")
+
+	exists, err = store.Exists(testKey)
+	require.NoError(t, err)
+	assert.False(t, exists, "blob is deleted")
+}
+
+// s3Stub is an in-memory stand-in for an S3-compatible object store
+type s3Stub struct {
+	mu                sync.Mutex
+	objects           map[string][]byte
+	lastAuthorization string
+}
+
+func newS3Stub() *s3Stub {
+	return &s3Stub{objects: make(map[string][]byte)}
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastAuthorization = r.Header.Get("Authorization")
+	if !strings.HasPrefix(s.lastAuthorization, "AWS4-HMAC-SHA256 ") || r.Header.Get("X-Amz-Date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := ioutil.ReadAll(r.Body)
+		s.objects[r.URL.Path] = body
+	case http.MethodGet, http.MethodHead:
+		body, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = bytes.NewReader(body).WriteTo(w)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
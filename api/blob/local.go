@@ -0,0 +1,89 @@
+package blob
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps blobs as files under a root directory, sharded by the first characters of their key
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore creates a LocalStore, creating its root directory if needed
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{root: root}, nil
+}
+
+// Put writes the blob to a temporary file first so that readers never see partial contents
+func (st *LocalStore) Put(key string, r io.Reader, _ int64, _ string) error {
+	path := st.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the blob stored under the key
+func (st *LocalStore) Get(key string) (io.ReadCloser, error) {
+	f, err := os.Open(st.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return f, err
+}
+
+// Exists tells whether a blob is stored under the key
+func (st *LocalStore) Exists(key string) (bool, error) {
+	_, err := os.Stat(st.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Delete removes the blob, deleting a missing blob is not an error
+func (st *LocalStore) Delete(key string) error {
+	err := os.Remove(st.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (st *LocalStore) path(key string) string {
+	// keys never escape the root directory
+	key = strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(key)
+
+	if len(key) > 2 {
+		return filepath.Join(st.root, key[:2], key)
+	}
+
+	return filepath.Join(st.root, key)
+}
@@ -0,0 +1,313 @@
+// Package client is a typed Go client of the YAITS REST API.
+//
+// Every route of the API has its method, apart from the Slack commands and VCS webhooks meant to be called by Slack
+// and the Git hosts with their own signatures, and the swagger documentation. Idempotent requests are retried with an
+// exponential backoff when the API is unreachable or unavailable, and error responses are returned as *Error.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// userHeader is the header the API reads the calling user from
+const userHeader = "X-YAITS-User"
+
+// Client calls the YAITS API found at BaseURL, such as http://localhost:8080
+type Client struct {
+	BaseURL string
+	// Token is sent as a bearer token when set
+	Token string
+	// User is sent as the X-YAITS-User header when set, the API attributing the changes to this user
+	User string
+
+	HTTPClient *http.Client
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NewClient creates a Client of the API found at baseURL, retrying idempotent requests 3 times starting 200
+// milliseconds apart by default
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: 3,
+		Backoff:    200 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	}
+}
+
+// Error is an error response of the API, decoded from its models.ErrorWrapper
+type Error struct {
+	StatusCode int
+	Errors     []models.StandardError
+}
+
+func (err *Error) Error() string {
+	messages := make([]string, 0, len(err.Errors))
+	for _, standardError := range err.Errors {
+		message := standardError.Title
+		if standardError.Description != "" {
+			message += ": " + standardError.Description
+		}
+		messages = append(messages, message)
+	}
+
+	return fmt.Sprintf("yaits: %d %s", err.StatusCode, strings.Join(messages, ", "))
+}
+
+// IsNotFound tells whether err is a 404 response of the API
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// request describes a call to the API, body being sent again on every attempt
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	contentType string
+	body        []byte
+	// stream requests aren't bound by the timeout of the HTTP client
+	stream bool
+	// raw requests return the error responses instead of turning them into *Error
+	raw bool
+}
+
+// do sends a request with the JSON encoding of in as body, decoding the response into out unless it is nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	req := request{method: method, path: path, query: query}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		req.contentType = "application/json"
+		req.body = body
+	}
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends a request, retrying idempotent ones when the API can't be reached or answers that it is unavailable.
+// The body of the response returned must be closed
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if req.stream && httpClient.Timeout != 0 {
+		streamClient := *httpClient
+		streamClient.Timeout = 0
+		httpClient = &streamClient
+	}
+
+	target := c.BaseURL + "/api" + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	retries := 0
+	if idempotent(req.method) {
+		retries = c.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequest(req.method, target, bytes.NewReader(req.body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq = httpReq.WithContext(ctx)
+		c.setHeaders(httpReq, req)
+
+		resp, err := httpClient.Do(httpReq)
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			delay = c.backoff(attempt)
+		case retryable(resp.StatusCode):
+			delay = retryAfter(resp, c.backoff(attempt))
+		case resp.StatusCode >= http.StatusBadRequest && !req.raw:
+			defer resp.Body.Close()
+			return nil, decodeError(resp)
+		default:
+			return resp, nil
+		}
+
+		if attempt >= retries {
+			if err != nil {
+				return nil, err
+			}
+			if req.raw {
+				return resp, nil
+			}
+			defer resp.Body.Close()
+			return nil, decodeError(resp)
+		}
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) setHeaders(httpReq *http.Request, req request) {
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if httpReq.Header.Get("Accept") == "" {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	httpReq.Header.Set("User-Agent", "yaits-go-client")
+	if c.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.User != "" {
+		httpReq.Header.Set(userHeader, c.User)
+	}
+}
+
+// backoff returns the delay before the retry following the given attempt, doubling every attempt with some jitter
+// so that clients don't retry in step
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.Backoff
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if delay >= c.MaxBackoff {
+			delay = c.MaxBackoff
+			break
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter reads the delay of the Retry-After header of a response, in seconds, falling back to delay
+func retryAfter(resp *http.Response, delay time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return delay
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// idempotent tells whether a request can be sent again without repeating its effect
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func retryable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// decodeError reads an error response, responses other than a models.ErrorWrapper having their body as description
+func decodeError(resp *http.Response) error {
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+
+	return newError(resp.StatusCode, body)
+}
+
+func newError(statusCode int, body []byte) *Error {
+	var wrapper models.ErrorWrapper
+	if err := json.Unmarshal(body, &wrapper); err == nil && len(wrapper.Errors) > 0 {
+		return &Error{StatusCode: statusCode, Errors: wrapper.Errors}
+	}
+
+	description := strings.TrimSpace(string(body))
+	var message string
+	if err := json.Unmarshal(body, &message); err == nil {
+		description = message
+	}
+
+	return &Error{StatusCode: statusCode, Errors: []models.StandardError{{Code: statusCode,
+		Title: http.StatusText(statusCode), Description: description}}}
+}
+
+func itoa(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+// filterQuery encodes the project and type filters shared by the issue listings
+func filterQuery(filter models.IssueFilterQueryParam) url.Values {
+	query := url.Values{}
+	setString(query, "project", filter.Project)
+	setString(query, "type", filter.Type)
+	return query
+}
+
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setInt(query url.Values, key string, value int64) {
+	if value != 0 {
+		query.Set(key, itoa(value))
+	}
+}
+
+func setBool(query url.Values, key string, value bool) {
+	if value {
+		query.Set(key, "true")
+	}
+}
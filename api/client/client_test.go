@@ -0,0 +1,290 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	persistence "github.com/YAITS/api/persistence/mock"
+	"github.com/YAITS/api/server"
+	"github.com/YAITS/api/server/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	hub := events.NewHub(10)
+	baseURL, closeServer := getServer(t, hub)
+	defer closeServer()
+
+	c := NewClient(baseURL)
+	c.User = persistence.Assignee
+	ctx := context.Background()
+
+	t.Run("GetIssue", func(t *testing.T) {
+		issue, err := c.GetIssue(ctx, persistence.IssueID)
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.Summary, issue.Summary)
+	})
+
+	t.Run("ListIssues", func(t *testing.T) {
+		issues, err := c.ListIssues(ctx, models.IssueFilterQueryParam{Project: "API"})
+		assert.Nil(t, err)
+		assert.Len(t, issues, 1)
+
+		issues, err = c.ListIssuesByStatus(ctx, "open", models.IssueFilterQueryParam{})
+		assert.Nil(t, err)
+		assert.Len(t, issues, 1)
+
+		issues, err = c.ListIssuesByPriority(ctx, models.PriorityQueryParam{PriorityStart: 1, PriorityEnd: 3},
+			models.IssueFilterQueryParam{})
+		assert.Nil(t, err)
+		assert.Len(t, issues, 1)
+	})
+
+	t.Run("CreateIssue", func(t *testing.T) {
+		id, err := c.CreateIssue(ctx, models.NewIssueRequest{Summary: "Login fails", Description: "Blank page",
+			Priority: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.IssueID, id)
+
+		t.Run("Invalid", func(t *testing.T) {
+			_, err := c.CreateIssue(ctx, models.NewIssueRequest{Description: "Blank page", Priority: 2})
+
+			apiErr, ok := err.(*Error)
+			if assert.True(t, ok, "expected an *Error, got %v", err) {
+				assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+				if assert.Len(t, apiErr.Errors, 1) {
+					assert.Equal(t, http.StatusText(http.StatusBadRequest), apiErr.Errors[0].Title)
+				}
+			}
+		})
+	})
+
+	t.Run("UpdateIssue", func(t *testing.T) {
+		issue, err := c.UpdateIssue(ctx, persistence.IssueID, models.UpdateIssueRequest{Status: "closed"})
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.IssueID, issue.ID)
+	})
+
+	t.Run("BulkChangeIssues", func(t *testing.T) {
+		job, err := c.BulkChangeIssues(ctx, models.BulkIssueRequest{IDs: []int64{persistence.IssueID, 2},
+			Operation: "setStatus", Status: "closed"})
+		assert.Nil(t, err)
+		assert.Equal(t, []models.BulkItemResult{{IssueID: persistence.IssueID, Success: true},
+			{IssueID: 2, Error: "could not find issue"}}, job.Results)
+
+		t.Run("Invalid", func(t *testing.T) {
+			_, err := c.BulkChangeIssues(ctx, models.BulkIssueRequest{IDs: []int64{persistence.IssueID}})
+			assert.Equal(t, http.StatusBadRequest, err.(*Error).StatusCode)
+		})
+	})
+
+	t.Run("GetBulkJob", func(t *testing.T) {
+		job, err := c.GetBulkJob(ctx, persistence.MockBulkJob.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.MockBulkJob.Status, job.Status)
+		assert.Equal(t, persistence.MockBulkJob.Results, job.Results)
+	})
+
+	t.Run("Attachments", func(t *testing.T) {
+		attachment, err := c.UploadAttachment(ctx, persistence.IssueID, "notes.txt", strings.NewReader("some notes"))
+		assert.Nil(t, err)
+		assert.Equal(t, "notes.txt", attachment.Filename)
+
+		attachments, err := c.ListAttachments(ctx, persistence.IssueID)
+		assert.Nil(t, err)
+		assert.Len(t, attachments, 1)
+	})
+
+	t.Run("Worklogs", func(t *testing.T) {
+		id, err := c.LogWork(ctx, persistence.IssueID, models.NewWorklogRequest{User: persistence.Assignee, Minutes: 30})
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.MockWorklog.ID, id)
+
+		totals, err := c.WorklogTotals(ctx, models.WorklogQueryParam{GroupBy: "issue"})
+		assert.Nil(t, err)
+		assert.Len(t, totals, 1)
+	})
+
+	t.Run("Watchers", func(t *testing.T) {
+		assert.Nil(t, c.Watch(ctx, persistence.IssueID, "Jane Doe"))
+
+		watchers, err := c.ListWatchers(ctx, persistence.IssueID)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{persistence.Assignee}, watchers)
+	})
+
+	t.Run("Notifications", func(t *testing.T) {
+		var notifications []models.Notification
+		it := c.Notifications(ctx, models.NotificationQueryParam{Unread: true})
+		for it.Next() {
+			notifications = append(notifications, it.Notification())
+		}
+
+		assert.Nil(t, it.Err())
+		assert.Equal(t, []models.Notification{persistence.MockNotification}, notifications)
+
+		t.Run("MarkRead", func(t *testing.T) {
+			_, err := c.MarkNotificationRead(ctx, persistence.MockNotification.ID)
+			assert.Nil(t, err)
+		})
+
+		t.Run("Anonymous", func(t *testing.T) {
+			it := NewClient(baseURL).Notifications(ctx, models.NotificationQueryParam{})
+			assert.False(t, it.Next())
+			assert.Equal(t, http.StatusUnauthorized, it.Err().(*Error).StatusCode)
+		})
+	})
+
+	t.Run("GraphQL", func(t *testing.T) {
+		var data struct {
+			Issue struct {
+				Summary string `json:"summary"`
+			} `json:"issue"`
+		}
+		err := c.GraphQL(ctx, `query($id: Int!) { issue(id: $id) { summary } }`,
+			map[string]interface{}{"id": persistence.IssueID}, &data)
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.Summary, data.Issue.Summary)
+
+		t.Run("Invalid", func(t *testing.T) {
+			err := c.GraphQL(ctx, `{ issues { unknown } }`, nil, nil)
+			_, ok := err.(*GraphQLError)
+			assert.True(t, ok, "expected a *GraphQLError, got %v", err)
+		})
+	})
+
+	t.Run("StreamEvents", func(t *testing.T) {
+		hub.Publish(models.IssueEvent{Event: events.IssueCreated, IssueID: persistence.IssueID})
+		hub.Publish(models.IssueEvent{Event: events.IssueCommented, IssueID: persistence.IssueID, Comment: "Fixed"})
+
+		stop := errors.New("stop")
+		var received []models.IssueEvent
+		err := c.StreamEvents(ctx, persistence.IssueID, 1, func(event models.IssueEvent) error {
+			received = append(received, event)
+			return stop
+		})
+
+		assert.Equal(t, stop, err)
+		if assert.Len(t, received, 1) {
+			assert.Equal(t, "Fixed", received[0].Comment)
+		}
+	})
+
+	t.Run("DeleteIssue", func(t *testing.T) {
+		assert.Nil(t, c.DeleteIssue(ctx, persistence.IssueID))
+	})
+}
+
+func TestClientRetries(t *testing.T) {
+	var attempts int32
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]string{persistence.Assignee})
+	}))
+	defer apiServer.Close()
+
+	c := NewClient(apiServer.URL)
+	c.Backoff = time.Millisecond
+
+	watchers, err := c.ListWatchers(context.Background(), persistence.IssueID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{persistence.Assignee}, watchers)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	t.Run("NotIdempotent", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+
+		_, err := c.CreateIssue(context.Background(), models.NewIssueRequest{Summary: "Login fails"})
+		assert.Equal(t, http.StatusServiceUnavailable, err.(*Error).StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		c.Backoff = time.Hour
+		c.MaxBackoff = time.Hour
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := c.ListWatchers(ctx, persistence.IssueID)
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+}
+
+func TestWebhookDeliveryIterator(t *testing.T) {
+	var requests int32
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+		offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+
+		// 5 deliveries, served by pages of limit
+		deliveries := []models.WebhookDelivery{}
+		for id := offset + 1; id <= 5 && id <= offset+limit; id++ {
+			deliveries = append(deliveries, models.WebhookDelivery{ID: id, WebhookID: 1})
+		}
+		_ = json.NewEncoder(w).Encode(deliveries)
+	}))
+	defer apiServer.Close()
+
+	it := NewClient(apiServer.URL).WebhookDeliveries(context.Background(), 1, models.WebhookDeliveryQueryParam{Limit: 2})
+
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Delivery().ID)
+	}
+
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, ids)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestError(t *testing.T) {
+	err := newError(http.StatusNotFound, []byte(`{"errors":[{"code":404,"title":"Not Found","description":"could not find issue"}]}`))
+	assert.Equal(t, "yaits: 404 Not Found: could not find issue", err.Error())
+	assert.True(t, IsNotFound(err))
+
+	// bodies other than an error wrapper become the description
+	err = newError(http.StatusBadRequest, []byte(`"invalid date"`))
+	assert.Equal(t, "yaits: 400 Bad Request: invalid date", err.Error())
+	assert.False(t, IsNotFound(err))
+}
+
+func getServer(t *testing.T, hub *events.Hub) (string, func()) {
+	blobRoot, err := ioutil.TempDir("", "yaits-attachments")
+	if err != nil {
+		t.Fatalf("couldn't create the attachment store: %s", err)
+	}
+	store, err := blob.NewLocalStore(blobRoot)
+	if err != nil {
+		t.Fatalf("couldn't create the attachment store: %s", err)
+	}
+
+	router := server.BuildRouter(zap.NewNop().Sugar(), persistence.NewMockStorage(), handlers.Attachments{Store: store},
+		hub, handlers.Integrations{}, server.Config{})
+	apiServer := httptest.NewServer(router)
+
+	return apiServer.URL, func() {
+		apiServer.Close()
+		_ = os.RemoveAll(blobRoot)
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/YAITS/api/models"
+)
+
+// ListIssueTypes retrieves the issue types of a project along with the global ones, every issue type when project
+// is empty
+func (c *Client) ListIssueTypes(ctx context.Context, project string) ([]models.IssueType, error) {
+	query := url.Values{}
+	setString(query, "project", project)
+
+	var issueTypes []models.IssueType
+	err := c.do(ctx, http.MethodGet, "/issue-types", query, nil, &issueTypes)
+	return issueTypes, err
+}
+
+// CreateIssueType creates an issue type, returning its id
+func (c *Client) CreateIssueType(ctx context.Context, issueType models.NewIssueTypeRequest) (int64, error) {
+	var resp models.IssueIDResponse
+	err := c.do(ctx, http.MethodPost, "/issue-types", nil, issueType, &resp)
+	return resp.ID, err
+}
+
+// DeleteIssueType deletes an issue type
+func (c *Client) DeleteIssueType(ctx context.Context, issueTypeID int64) error {
+	return c.do(ctx, http.MethodDelete, "/issue-types/"+itoa(issueTypeID), nil, nil, nil)
+}
+
+// ListSLAPolicies retrieves every SLA policy
+func (c *Client) ListSLAPolicies(ctx context.Context) ([]models.SLAPolicy, error) {
+	var policies []models.SLAPolicy
+	err := c.do(ctx, http.MethodGet, "/sla-policies", nil, nil, &policies)
+	return policies, err
+}
+
+// CreateSLAPolicy creates an SLA policy, returning its id
+func (c *Client) CreateSLAPolicy(ctx context.Context, policy models.NewSLAPolicyRequest) (int64, error) {
+	var resp models.IssueIDResponse
+	err := c.do(ctx, http.MethodPost, "/sla-policies", nil, policy, &resp)
+	return resp.ID, err
+}
+
+// DeleteSLAPolicy deletes an SLA policy
+func (c *Client) DeleteSLAPolicy(ctx context.Context, policyID int64) error {
+	return c.do(ctx, http.MethodDelete, "/sla-policies/"+itoa(policyID), nil, nil, nil)
+}
+
+// ListBusinessCalendars retrieves every business calendar
+func (c *Client) ListBusinessCalendars(ctx context.Context) ([]models.BusinessCalendar, error) {
+	var calendars []models.BusinessCalendar
+	err := c.do(ctx, http.MethodGet, "/calendars", nil, nil, &calendars)
+	return calendars, err
+}
+
+// CreateBusinessCalendar creates a business calendar, returning its id
+func (c *Client) CreateBusinessCalendar(ctx context.Context, calendar models.NewBusinessCalendarRequest) (int64, error) {
+	var resp models.IssueIDResponse
+	err := c.do(ctx, http.MethodPost, "/calendars", nil, calendar, &resp)
+	return resp.ID, err
+}
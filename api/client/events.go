@@ -0,0 +1,135 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/YAITS/api/models"
+)
+
+// maxEventSize bounds the size of a line of the event stream
+const maxEventSize = 1 << 20
+
+// GraphQLError is a GraphQL response carrying errors, the data that could be resolved being decoded nonetheless
+type GraphQLError struct {
+	Errors []models.GraphQLError
+}
+
+func (err *GraphQLError) Error() string {
+	messages := make([]string, 0, len(err.Errors))
+	for _, graphQLError := range err.Errors {
+		messages = append(messages, graphQLError.Message)
+	}
+
+	return "yaits: graphql: " + strings.Join(messages, ", ")
+}
+
+// graphQLResponse is a models.GraphQLResponse whose data is decoded into the type the caller asks for
+type graphQLResponse struct {
+	Data   json.RawMessage       `json:"data"`
+	Errors []models.GraphQLError `json:"errors"`
+}
+
+// GraphQL runs a GraphQL query or mutation, decoding its data into out unless it is nil
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(models.GraphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+
+	// queries rejected by the GraphQL validation come back as a GraphQL response rather than an error wrapper
+	resp, err := c.send(ctx, request{method: http.MethodPost, path: "/graphql", contentType: "application/json",
+		body: body, raw: true})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	var graphQLResp graphQLResponse
+	if err = json.NewDecoder(resp.Body).Decode(&graphQLResp); err != nil {
+		return err
+	}
+
+	if out != nil && len(graphQLResp.Data) > 0 && string(graphQLResp.Data) != "null" {
+		if err = json.Unmarshal(graphQLResp.Data, out); err != nil {
+			return err
+		}
+	}
+
+	if len(graphQLResp.Errors) > 0 {
+		return &GraphQLError{Errors: graphQLResp.Errors}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &Error{StatusCode: resp.StatusCode, Errors: []models.StandardError{{Code: resp.StatusCode,
+			Title: http.StatusText(resp.StatusCode)}}}
+	}
+
+	return nil
+}
+
+// StreamEvents calls handle with the events of an issue as they happen, the events of every issue when issueID is 0,
+// after replaying the recent ones that followed lastEventID. It returns once the API ends the stream, which it does
+// for clients lagging behind, when handle returns an error or when ctx is done. Streaming again from the id of the
+// last event handled resumes where the stream stopped
+func (c *Client) StreamEvents(ctx context.Context, issueID, lastEventID int64,
+	handle func(models.IssueEvent) error) error {
+	path := "/events"
+	if issueID != 0 {
+		path = "/issue/" + itoa(issueID) + "/events"
+	}
+
+	header := http.Header{"Accept": {"text/event-stream"}}
+	if lastEventID != 0 {
+		header.Set("Last-Event-ID", itoa(lastEventID))
+	}
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: path, header: header, stream: true})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventSize)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			// a blank line dispatches the event, the id and event fields being repeated in the data
+			if data.Len() == 0 {
+				continue
+			}
+
+			var event models.IssueEvent
+			if err = json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return err
+			}
+			data.Reset()
+
+			if err = handle(event); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return scanner.Err()
+}
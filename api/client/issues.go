@@ -0,0 +1,301 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/YAITS/api/models"
+)
+
+// GetIssue retrieves an issue along with its comments
+func (c *Client) GetIssue(ctx context.Context, issueID int64) (models.IssueResponse, error) {
+	var issue models.IssueResponse
+	err := c.do(ctx, http.MethodGet, "/issue/"+itoa(issueID), nil, nil, &issue)
+	return issue, err
+}
+
+// ListIssues retrieves every issue of the filter
+func (c *Client) ListIssues(ctx context.Context, filter models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
+	var issues []models.IssueResponse
+	err := c.do(ctx, http.MethodGet, "/issues", filterQuery(filter), nil, &issues)
+	return issues, err
+}
+
+// ListIssuesByStatus retrieves the issues of the filter having the given status
+func (c *Client) ListIssuesByStatus(ctx context.Context, status string,
+	filter models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
+	query := filterQuery(filter)
+	setString(query, "status", status)
+
+	var issues []models.IssueResponse
+	err := c.do(ctx, http.MethodGet, "/issues/status", query, nil, &issues)
+	return issues, err
+}
+
+// ListIssuesByPriority retrieves the issues of the filter having a priority between start and end
+func (c *Client) ListIssuesByPriority(ctx context.Context, priority models.PriorityQueryParam,
+	filter models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
+	query := filterQuery(filter)
+	setInt(query, "start", priority.PriorityStart)
+	setInt(query, "end", priority.PriorityEnd)
+
+	var issues []models.IssueResponse
+	err := c.do(ctx, http.MethodGet, "/issues/priority", query, nil, &issues)
+	return issues, err
+}
+
+// ListIssuesBySLA retrieves the issues of the filter whose SLA is at risk or breached, both when state is empty
+func (c *Client) ListIssuesBySLA(ctx context.Context, state string,
+	filter models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
+	query := filterQuery(filter)
+	setString(query, "state", state)
+
+	var issues []models.IssueResponse
+	err := c.do(ctx, http.MethodGet, "/issues/sla", query, nil, &issues)
+	return issues, err
+}
+
+// ExportIssues writes the export of the issues of the query to w, as CSV unless another format is asked for
+func (c *Client) ExportIssues(ctx context.Context, export models.ExportQueryParam, w io.Writer) error {
+	query := filterQuery(export.IssueFilterQueryParam)
+	setString(query, "format", export.Format)
+	setString(query, "status", export.Status)
+	setInt(query, "start", export.PriorityStart)
+	setInt(query, "end", export.PriorityEnd)
+	setString(query, "columns", export.Columns)
+	setBool(query, "comments", export.Comments)
+
+	return c.download(ctx, request{method: http.MethodGet, path: "/issues/export", query: query, stream: true}, w)
+}
+
+// CreateIssue creates an issue, returning its id
+func (c *Client) CreateIssue(ctx context.Context, issue models.NewIssueRequest) (int64, error) {
+	var resp models.IssueIDResponse
+	err := c.do(ctx, http.MethodPost, "/issue", nil, issue, &resp)
+	return resp.ID, err
+}
+
+// UpdateIssue updates an issue, returning it once updated
+func (c *Client) UpdateIssue(ctx context.Context, issueID int64,
+	update models.UpdateIssueRequest) (models.IssueResponse, error) {
+	var issue models.IssueResponse
+	err := c.do(ctx, http.MethodPatch, "/issue/"+itoa(issueID), nil, update, &issue)
+	return issue, err
+}
+
+// DeleteIssue deletes an issue along with its attachments
+func (c *Client) DeleteIssue(ctx context.Context, issueID int64) error {
+	return c.do(ctx, http.MethodDelete, "/issue/"+itoa(issueID), nil, nil, nil)
+}
+
+// BulkChangeIssues applies an operation to several issues, returning the job along with the result of every issue,
+// or only its progress when the operation runs in the background
+func (c *Client) BulkChangeIssues(ctx context.Context, bulk models.BulkIssueRequest) (models.BulkJob, error) {
+	var job models.BulkJob
+	err := c.do(ctx, http.MethodPost, "/issues/bulk", nil, bulk, &job)
+	return job, err
+}
+
+// GetBulkJob retrieves the progress of a bulk operation running in the background
+func (c *Client) GetBulkJob(ctx context.Context, jobID int64) (models.BulkJob, error) {
+	var job models.BulkJob
+	err := c.do(ctx, http.MethodGet, "/issues/bulk/"+itoa(jobID), nil, nil, &job)
+	return job, err
+}
+
+// ListSLABreaches retrieves the SLA breaches of an issue
+func (c *Client) ListSLABreaches(ctx context.Context, issueID int64) ([]models.SLABreach, error) {
+	var breaches []models.SLABreach
+	err := c.do(ctx, http.MethodGet, "/issue/"+itoa(issueID)+"/sla-breaches", nil, nil, &breaches)
+	return breaches, err
+}
+
+// ListIssueLinks retrieves the commits and pull requests referring to an issue
+func (c *Client) ListIssueLinks(ctx context.Context, issueID int64) ([]models.IssueLink, error) {
+	var links []models.IssueLink
+	err := c.do(ctx, http.MethodGet, "/issue/"+itoa(issueID)+"/links", nil, nil, &links)
+	return links, err
+}
+
+// ListWorklogs retrieves the work logged on an issue
+func (c *Client) ListWorklogs(ctx context.Context, issueID int64) ([]models.Worklog, error) {
+	var worklogs []models.Worklog
+	err := c.do(ctx, http.MethodGet, "/issue/"+itoa(issueID)+"/worklogs", nil, nil, &worklogs)
+	return worklogs, err
+}
+
+// LogWork logs work on an issue, returning the id of the worklog
+func (c *Client) LogWork(ctx context.Context, issueID int64, worklog models.NewWorklogRequest) (int64, error) {
+	var resp models.IssueIDResponse
+	err := c.do(ctx, http.MethodPost, "/issue/"+itoa(issueID)+"/worklogs", nil, worklog, &resp)
+	return resp.ID, err
+}
+
+// DeleteWorklog deletes a worklog of an issue
+func (c *Client) DeleteWorklog(ctx context.Context, issueID, worklogID int64) error {
+	return c.do(ctx, http.MethodDelete, "/issue/"+itoa(issueID)+"/worklogs/"+itoa(worklogID), nil, nil, nil)
+}
+
+// WorklogTotals sums the work logged over a period, by issue unless grouped otherwise
+func (c *Client) WorklogTotals(ctx context.Context, totals models.WorklogQueryParam) ([]models.WorklogTotal, error) {
+	query := url.Values{}
+	setString(query, "from", totals.From)
+	setString(query, "to", totals.To)
+	setString(query, "groupBy", totals.GroupBy)
+	setString(query, "user", totals.User)
+	setString(query, "project", totals.Project)
+	setInt(query, "issueID", totals.IssueID)
+
+	var resp []models.WorklogTotal
+	err := c.do(ctx, http.MethodGet, "/worklogs/totals", query, nil, &resp)
+	return resp, err
+}
+
+// Timesheet retrieves the work a user logged over the week of a day (YYYY-MM-DD), the current week when empty
+func (c *Client) Timesheet(ctx context.Context, user, week string) (models.Timesheet, error) {
+	query := url.Values{}
+	setString(query, "week", week)
+
+	var timesheet models.Timesheet
+	err := c.do(ctx, http.MethodGet, "/timesheets/"+url.PathEscape(user), query, nil, &timesheet)
+	return timesheet, err
+}
+
+// ListAttachments retrieves the files attached to an issue and to its comments
+func (c *Client) ListAttachments(ctx context.Context, issueID int64) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := c.do(ctx, http.MethodGet, "/issue/"+itoa(issueID)+"/attachments", nil, nil, &attachments)
+	return attachments, err
+}
+
+// UploadAttachment attaches the content of r to an issue under the given file name
+func (c *Client) UploadAttachment(ctx context.Context, issueID int64, filename string,
+	r io.Reader) (models.Attachment, error) {
+	return c.upload(ctx, "/issue/"+itoa(issueID)+"/attachments", filename, r)
+}
+
+// UploadCommentAttachment attaches the content of r to a comment of an issue under the given file name
+func (c *Client) UploadCommentAttachment(ctx context.Context, issueID, commentID int64, filename string,
+	r io.Reader) (models.Attachment, error) {
+	return c.upload(ctx, "/issue/"+itoa(issueID)+"/comments/"+itoa(commentID)+"/attachments", filename, r)
+}
+
+// DownloadAttachment writes the content of an attachment to w
+func (c *Client) DownloadAttachment(ctx context.Context, attachmentID int64, w io.Writer) error {
+	return c.download(ctx, request{method: http.MethodGet, path: "/attachments/" + itoa(attachmentID), stream: true}, w)
+}
+
+// DeleteAttachment deletes an attachment
+func (c *Client) DeleteAttachment(ctx context.Context, attachmentID int64) error {
+	return c.do(ctx, http.MethodDelete, "/attachments/"+itoa(attachmentID), nil, nil, nil)
+}
+
+// ValidateImport checks the issues of an import file without importing them
+func (c *Client) ValidateImport(ctx context.Context, filename string, r io.Reader,
+	options models.ImportOptions) (models.ImportReport, error) {
+	var report models.ImportReport
+	err := c.sendImport(ctx, filename, r, options, true, &report)
+	return report, err
+}
+
+// Import starts importing the issues of an import file in the background, returning the job to follow
+func (c *Client) Import(ctx context.Context, filename string, r io.Reader,
+	options models.ImportOptions) (models.ImportJob, error) {
+	var job models.ImportJob
+	err := c.sendImport(ctx, filename, r, options, false, &job)
+	return job, err
+}
+
+// GetImportJob retrieves the progress of an import
+func (c *Client) GetImportJob(ctx context.Context, jobID int64) (models.ImportJob, error) {
+	var job models.ImportJob
+	err := c.do(ctx, http.MethodGet, "/import/"+itoa(jobID), nil, nil, &job)
+	return job, err
+}
+
+func (c *Client) upload(ctx context.Context, path, filename string, r io.Reader) (models.Attachment, error) {
+	var attachment models.Attachment
+
+	body, contentType, err := multipartBody(filename, r, nil)
+	if err != nil {
+		return attachment, err
+	}
+
+	resp, err := c.send(ctx, request{method: http.MethodPost, path: path, contentType: contentType, body: body})
+	if err != nil {
+		return attachment, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&attachment)
+	return attachment, err
+}
+
+func (c *Client) sendImport(ctx context.Context, filename string, r io.Reader, options models.ImportOptions,
+	dryRun bool, out interface{}) error {
+	encodedOptions, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	body, contentType, err := multipartBody(filename, r, map[string]string{"options": string(encodedOptions)})
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	setBool(query, "dryRun", dryRun)
+
+	resp, err := c.send(ctx, request{method: http.MethodPost, path: "/import", query: query, contentType: contentType,
+		body: body})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) download(ctx context.Context, req request, w io.Writer) error {
+	req.header = http.Header{"Accept": {"*/*"}}
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// multipartBody encodes the content of r as the file of a form along with its other fields. The content is read in
+// memory for the request to be sent again when retried
+func multipartBody(filename string, r io.Reader, fields map[string]string) ([]byte, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = io.Copy(part, r); err != nil {
+		return nil, "", err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+
+	"github.com/YAITS/api/models"
+)
+
+// defaultPageSize is the page size of the iterators, the default page size of the API
+const defaultPageSize = 50
+
+// pager walks through the pages of a listing, fetching the next page once the current one is consumed and stopping
+// at the first page shorter than the page size
+type pager struct {
+	ctx      context.Context
+	pageSize int64
+	offset   int64
+	// fetch retrieves the page at offset, keeping its items and returning how many there are
+	fetch func(ctx context.Context, limit, offset int64) (int, error)
+
+	index int
+	size  int
+	last  bool
+	err   error
+}
+
+func (p *pager) next() bool {
+	if p.err != nil {
+		return false
+	}
+
+	p.index++
+	if p.index < p.size {
+		return true
+	}
+	if p.last {
+		return false
+	}
+
+	size, err := p.fetch(p.ctx, p.pageSize, p.offset)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	p.index, p.size = 0, size
+	p.offset += int64(size)
+	p.last = int64(size) < p.pageSize
+
+	return size > 0
+}
+
+// NotificationIterator walks through the notifications of the user, a page at a time:
+//
+//	it := c.Notifications(ctx, models.NotificationQueryParam{Unread: true})
+//	for it.Next() {
+//		notification := it.Notification()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type NotificationIterator struct {
+	pager
+	page []models.Notification
+}
+
+// Next advances to the next notification, returning false once there are none left or an error occurred
+func (it *NotificationIterator) Next() bool {
+	return it.next()
+}
+
+// Notification returns the current notification
+func (it *NotificationIterator) Notification() models.Notification {
+	return it.page[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *NotificationIterator) Err() error {
+	return it.err
+}
+
+// WebhookDeliveryIterator walks through the deliveries of a webhook, a page at a time, the way NotificationIterator
+// does
+type WebhookDeliveryIterator struct {
+	pager
+	page []models.WebhookDelivery
+}
+
+// Next advances to the next delivery, returning false once there are none left or an error occurred
+func (it *WebhookDeliveryIterator) Next() bool {
+	return it.next()
+}
+
+// Delivery returns the current delivery
+func (it *WebhookDeliveryIterator) Delivery() models.WebhookDelivery {
+	return it.page[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *WebhookDeliveryIterator) Err() error {
+	return it.err
+}
+
+// pageSize returns the limit of a query as page size, the default page size when there is none
+func pageSize(limit int64) int64 {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+
+	return limit
+}
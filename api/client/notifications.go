@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/YAITS/api/models"
+)
+
+// ListWatchers retrieves the users watching an issue
+func (c *Client) ListWatchers(ctx context.Context, issueID int64) ([]string, error) {
+	var watchers []string
+	err := c.do(ctx, http.MethodGet, "/issue/"+itoa(issueID)+"/watchers", nil, nil, &watchers)
+	return watchers, err
+}
+
+// Watch makes a user watch an issue
+func (c *Client) Watch(ctx context.Context, issueID int64, user string) error {
+	return c.do(ctx, http.MethodPut, "/issue/"+itoa(issueID)+"/watchers/"+url.PathEscape(user), nil, nil, nil)
+}
+
+// Unwatch makes a user stop watching an issue
+func (c *Client) Unwatch(ctx context.Context, issueID int64, user string) error {
+	return c.do(ctx, http.MethodDelete, "/issue/"+itoa(issueID)+"/watchers/"+url.PathEscape(user), nil, nil, nil)
+}
+
+// ListNotifications retrieves a page of the notifications of the user along with the count of the unread ones
+func (c *Client) ListNotifications(ctx context.Context,
+	notifications models.NotificationQueryParam) (models.NotificationsResponse, error) {
+	query := url.Values{}
+	setBool(query, "unread", notifications.Unread)
+	setInt(query, "issueID", notifications.IssueID)
+	setString(query, "kind", notifications.Kind)
+	setInt(query, "limit", notifications.Limit)
+	setInt(query, "offset", notifications.Offset)
+
+	var resp models.NotificationsResponse
+	err := c.do(ctx, http.MethodGet, "/me/notifications", query, nil, &resp)
+	return resp, err
+}
+
+// Notifications iterates over the notifications of the user from the offset of the query, its limit being the page
+// size. Notifications marked as read while iterating over the unread ones shift the pages that follow
+func (c *Client) Notifications(ctx context.Context, query models.NotificationQueryParam) *NotificationIterator {
+	it := &NotificationIterator{}
+	it.pager = pager{ctx: ctx, pageSize: pageSize(query.Limit), offset: query.Offset,
+		fetch: func(ctx context.Context, limit, offset int64) (int, error) {
+			query.Limit, query.Offset = limit, offset
+			resp, err := c.ListNotifications(ctx, query)
+			it.page = resp.Notifications
+			return len(it.page), err
+		}}
+
+	return it
+}
+
+// UnreadNotificationCount counts the unread notifications of the user
+func (c *Client) UnreadNotificationCount(ctx context.Context) (int64, error) {
+	var resp models.NotificationsResponse
+	err := c.do(ctx, http.MethodGet, "/me/notifications/unread", nil, nil, &resp)
+	return resp.UnreadCount, err
+}
+
+// MarkNotificationsRead marks the notifications of an issue as read, every notification of the user when issueID is
+// 0, returning how many are left unread
+func (c *Client) MarkNotificationsRead(ctx context.Context, issueID int64) (int64, error) {
+	query := url.Values{}
+	setInt(query, "issueID", issueID)
+
+	var resp models.NotificationsResponse
+	err := c.do(ctx, http.MethodPost, "/me/notifications/read", query, nil, &resp)
+	return resp.UnreadCount, err
+}
+
+// MarkNotificationRead marks a notification as read, returning how many are left unread
+func (c *Client) MarkNotificationRead(ctx context.Context, notificationID int64) (int64, error) {
+	var resp models.NotificationsResponse
+	err := c.do(ctx, http.MethodPost, "/me/notifications/"+itoa(notificationID)+"/read", nil, nil, &resp)
+	return resp.UnreadCount, err
+}
+
+// EmailPreferences retrieves the email preferences of the user
+func (c *Client) EmailPreferences(ctx context.Context) (models.EmailPreferences, error) {
+	var preferences models.EmailPreferences
+	err := c.do(ctx, http.MethodGet, "/me/email-preferences", nil, nil, &preferences)
+	return preferences, err
+}
+
+// UpdateEmailPreferences replaces the email preferences of the user
+func (c *Client) UpdateEmailPreferences(ctx context.Context,
+	update models.EmailPreferencesRequest) (models.EmailPreferences, error) {
+	var preferences models.EmailPreferences
+	err := c.do(ctx, http.MethodPut, "/me/email-preferences", nil, update, &preferences)
+	return preferences, err
+}
+
+// Unsubscribe turns off every email notification of the user owning an unsubscribe token
+func (c *Client) Unsubscribe(ctx context.Context, token string) error {
+	return c.do(ctx, http.MethodGet, "/unsubscribe", url.Values{"token": {token}}, nil, nil)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/YAITS/api/models"
+)
+
+// ListWebhooks retrieves every webhook
+func (c *Client) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := c.do(ctx, http.MethodGet, "/webhooks", nil, nil, &webhooks)
+	return webhooks, err
+}
+
+// CreateWebhook registers a webhook, returning its id
+func (c *Client) CreateWebhook(ctx context.Context, webhook models.NewWebhookRequest) (int64, error) {
+	var resp models.IssueIDResponse
+	err := c.do(ctx, http.MethodPost, "/webhooks", nil, webhook, &resp)
+	return resp.ID, err
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	return c.do(ctx, http.MethodDelete, "/webhooks/"+itoa(webhookID), nil, nil, nil)
+}
+
+// ListWebhookDeliveries retrieves a page of the deliveries of a webhook, the latest first
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID int64,
+	deliveries models.WebhookDeliveryQueryParam) ([]models.WebhookDelivery, error) {
+	query := url.Values{}
+	setString(query, "status", deliveries.Status)
+	setInt(query, "limit", deliveries.Limit)
+	setInt(query, "offset", deliveries.Offset)
+
+	var resp []models.WebhookDelivery
+	err := c.do(ctx, http.MethodGet, "/webhooks/"+itoa(webhookID)+"/deliveries", query, nil, &resp)
+	return resp, err
+}
+
+// WebhookDeliveries iterates over the deliveries of a webhook from the offset of the query, its limit being the page
+// size
+func (c *Client) WebhookDeliveries(ctx context.Context, webhookID int64,
+	query models.WebhookDeliveryQueryParam) *WebhookDeliveryIterator {
+	it := &WebhookDeliveryIterator{}
+	it.pager = pager{ctx: ctx, pageSize: pageSize(query.Limit), offset: query.Offset,
+		fetch: func(ctx context.Context, limit, offset int64) (int, error) {
+			query.Limit, query.Offset = limit, offset
+			page, err := c.ListWebhookDeliveries(ctx, webhookID, query)
+			it.page = page
+			return len(page), err
+		}}
+
+	return it
+}
+
+// RedeliverWebhookDelivery queues a delivery of a webhook again
+func (c *Client) RedeliverWebhookDelivery(ctx context.Context, webhookID, deliveryID int64) error {
+	return c.do(ctx, http.MethodPost, "/webhooks/"+itoa(webhookID)+"/deliveries/"+itoa(deliveryID)+"/redeliver",
+		nil, nil, nil)
+}
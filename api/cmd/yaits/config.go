@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultProfile = "default"
+	defaultURL     = "http://localhost:8080"
+)
+
+// profile is a server the client talks to, as configured in the [profiles.<name>] sections of the config file:
+//
+//	profile = "work"
+//
+//	[profiles.work]
+//	url = "https://yaits.example.com"
+//	token = "..."
+//	user = "alice"
+type profile struct {
+	Name  string
+	URL   string
+	Token string
+	User  string
+}
+
+// configPath returns the path of the config file, $YAITS_CONFIG or yaits/config.toml in the user config directory
+func configPath() string {
+	if path := os.Getenv("YAITS_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "yaits", "config.toml")
+}
+
+// loadProfile reads a profile of the config file, the profile the file selects when name is empty. Without config
+// file the default profile talks to a local server. The YAITS_URL, YAITS_TOKEN and YAITS_USER environment variables
+// override the profile
+func loadProfile(path, name string) (profile, error) {
+	config := viper.New()
+	config.SetConfigType("toml")
+	config.SetDefault("profile", defaultProfile)
+
+	found := false
+	if path != "" {
+		config.SetConfigFile(path)
+		if err := config.ReadInConfig(); err == nil {
+			found = true
+		} else if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+			return profile{}, fmt.Errorf("couldn't read %s: %s", path, err)
+		}
+	}
+
+	if name == "" {
+		name = config.GetString("profile")
+	}
+
+	key := "profiles." + name
+	if found && !config.IsSet(key) && name != defaultProfile {
+		return profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+	if !found && name != defaultProfile {
+		return profile{}, fmt.Errorf("unknown profile %q, there is no config file at %s", name, path)
+	}
+
+	p := profile{
+		Name:  name,
+		URL:   config.GetString(key + ".url"),
+		Token: config.GetString(key + ".token"),
+		User:  config.GetString(key + ".user"),
+	}
+	if p.URL == "" {
+		p.URL = defaultURL
+	}
+
+	if url := os.Getenv("YAITS_URL"); url != "" {
+		p.URL = url
+	}
+	if token := os.Getenv("YAITS_TOKEN"); token != "" {
+		p.Token = token
+	}
+	if user := os.Getenv("YAITS_USER"); user != "" {
+		p.User = user
+	}
+
+	return p, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultEditor is run when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// errEmptyText is returned when the text written in the editor is empty, aborting the command
+var errEmptyText = errors.New("aborting, nothing was written")
+
+// edit lets the user write a text in their editor, starting from initial. Lines starting with # are left out
+var edit = runEditor
+
+func runEditor(initial string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+
+	f, err := ioutil.TempFile("", "yaits-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(initial)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// editors such as "code --wait" come with arguments
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err = cmd.Run(); err != nil {
+		return "", err
+	}
+
+	content, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return stripComments(string(content)), nil
+}
+
+// stripComments leaves out the lines starting with # and the surrounding blank lines
+func stripComments(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, strings.TrimRight(line, "\r"))
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
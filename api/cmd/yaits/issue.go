@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// statusClosed is the status close sets
+const statusClosed = "closed"
+
+// issueTemplate is the text the editor starts from creating an issue
+const issueTemplate = `%s
+
+%s
+# Write the summary of the issue on the first line and its description below it.
+# Lines starting with # are left out, an empty summary aborts the creation.
+`
+
+// commentTemplate is the text the editor starts from commenting an issue
+const commentTemplate = `
+# Write the comment of issue %d.
+# Lines starting with # are left out, an empty comment aborts.
+`
+
+func createIssue(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("create")
+	summary := flags.StringP("summary", "s", "", "summary of the issue")
+	description := flags.StringP("description", "d", "", "description of the issue")
+	priority := flags.Int64("priority", 0, "priority of the issue")
+	assignee := flags.StringP("assignee", "a", "", "user the issue is assigned to")
+	project := flags.String("project", "", "project key")
+	issueType := flags.StringP("type", "t", "", "issue type")
+	due := flags.String("due", "", "due date (YYYY-MM-DD)")
+	if _, err := a.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	req := models.NewIssueRequest{
+		Summary:     *summary,
+		Description: *description,
+		Priority:    *priority,
+		Assignee:    *assignee,
+		Project:     *project,
+		Type:        *issueType,
+	}
+
+	if *due != "" {
+		dueDate, err := time.Parse("2006-01-02", *due)
+		if err != nil {
+			return &usageError{message: fmt.Sprintf("invalid due date %q, expecting YYYY-MM-DD", *due)}
+		}
+		req.DueDate = &dueDate
+	}
+
+	if !flags.Changed("description") {
+		text, err := edit(fmt.Sprintf(issueTemplate, req.Summary, req.Description))
+		if err != nil {
+			return err
+		}
+
+		lines := strings.SplitN(text, "\n", 2)
+		req.Summary = strings.TrimSpace(lines[0])
+		if len(lines) > 1 {
+			req.Description = strings.TrimSpace(lines[1])
+		}
+	}
+	if req.Summary == "" {
+		return errEmptyText
+	}
+
+	id, err := a.client.CreateIssue(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	issue, err := a.client.GetIssue(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.issue(issue)
+}
+
+func listIssues(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("list")
+	status := flags.String("status", "", "status of the issues")
+	priority := flags.String("priority", "", "priority or range of priorities such as 1..3, 2.. or ..3")
+	assignee := flags.StringP("assignee", "a", "", "user the issues are assigned to")
+	project := flags.String("project", "", "project key")
+	issueType := flags.StringP("type", "t", "", "issue type")
+	if _, err := a.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	priorities, err := parsePriorityRange(*priority)
+	if err != nil {
+		return &usageError{message: err.Error()}
+	}
+
+	filter := models.IssueFilterQueryParam{Project: *project, Type: *issueType}
+
+	// the API filters by status or by priority, the other filters being applied here
+	var issues []models.IssueResponse
+	switch {
+	case *status != "":
+		issues, err = a.client.ListIssuesByStatus(ctx, *status, filter)
+	case *priority != "":
+		issues, err = a.client.ListIssuesByPriority(ctx, priorities, filter)
+	default:
+		issues, err = a.client.ListIssues(ctx, filter)
+	}
+	if err != nil {
+		return err
+	}
+
+	matching := issues[:0]
+	for _, issue := range issues {
+		if inPriorityRange(issue.Priority, priorities) && (*assignee == "" || issue.Assignee == *assignee) {
+			matching = append(matching, issue)
+		}
+	}
+
+	return a.printer.issues(matching)
+}
+
+func showIssue(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(newFlagSet("show"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	issueID, err := parseIssueID(args[0])
+	if err != nil {
+		return err
+	}
+
+	issue, err := a.client.GetIssue(ctx, issueID)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.issue(issue)
+}
+
+func commentIssue(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(newFlagSet("comment"), args, 1, 2)
+	if err != nil {
+		return err
+	}
+
+	issueID, err := parseIssueID(args[0])
+	if err != nil {
+		return err
+	}
+
+	var comment string
+	if len(args) > 1 {
+		comment = strings.TrimSpace(args[1])
+	} else if comment, err = edit(fmt.Sprintf(commentTemplate, issueID)); err != nil {
+		return err
+	}
+	if comment == "" {
+		return errEmptyText
+	}
+
+	return a.updateIssue(ctx, issueID, models.UpdateIssueRequest{Comment: comment})
+}
+
+func closeIssue(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("close")
+	comment := flags.StringP("comment", "c", "", "comment explaining why the issue is closed")
+	args, err := a.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	issueID, err := parseIssueID(args[0])
+	if err != nil {
+		return err
+	}
+
+	return a.updateIssue(ctx, issueID, models.UpdateIssueRequest{Status: statusClosed, Comment: *comment})
+}
+
+func assignIssue(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(newFlagSet("assign"), args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	issueID, err := parseIssueID(args[0])
+	if err != nil {
+		return err
+	}
+
+	return a.updateIssue(ctx, issueID, models.UpdateIssueRequest{Assignee: strings.TrimPrefix(args[1], "@")})
+}
+
+func (a *app) updateIssue(ctx context.Context, issueID int64, update models.UpdateIssueRequest) error {
+	issue, err := a.client.UpdateIssue(ctx, issueID, update)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.issue(issue)
+}
+
+// parseIssueID reads an issue id, written as 42, #42 or API-42
+func parseIssueID(s string) (int64, error) {
+	key := strings.TrimPrefix(s, "#")
+	if i := strings.LastIndex(key, "-"); i >= 0 {
+		key = key[i+1:]
+	}
+
+	issueID, err := strconv.ParseInt(key, 10, 64)
+	if err != nil || issueID <= 0 {
+		return 0, &usageError{message: fmt.Sprintf("invalid issue id %q", s)}
+	}
+
+	return issueID, nil
+}
+
+// parsePriorityRange reads a priority or a range of priorities such as 1..3, 2.. or ..3, a zero bound being open
+func parsePriorityRange(s string) (models.PriorityQueryParam, error) {
+	if s == "" {
+		return models.PriorityQueryParam{}, nil
+	}
+
+	bounds := strings.SplitN(s, "..", 2)
+	if len(bounds) == 1 {
+		bounds = append(bounds, bounds[0])
+	}
+
+	var priorities [2]int64
+	for i, bound := range bounds {
+		if bound == "" {
+			continue
+		}
+
+		priority, err := strconv.ParseInt(bound, 10, 64)
+		if err != nil || priority <= 0 {
+			return models.PriorityQueryParam{}, fmt.Errorf("invalid priority range %q, expecting such as 1..3", s)
+		}
+		priorities[i] = priority
+	}
+
+	if priorities[0] == 0 && priorities[1] == 0 {
+		return models.PriorityQueryParam{}, fmt.Errorf("invalid priority range %q, expecting such as 1..3", s)
+	}
+	if priorities[1] != 0 && priorities[0] > priorities[1] {
+		return models.PriorityQueryParam{}, fmt.Errorf("invalid priority range %q, the start is after the end", s)
+	}
+
+	return models.PriorityQueryParam{PriorityStart: priorities[0], PriorityEnd: priorities[1]}, nil
+}
+
+func inPriorityRange(priority int64, priorities models.PriorityQueryParam) bool {
+	return priority >= priorities.PriorityStart && (priorities.PriorityEnd == 0 || priority <= priorities.PriorityEnd)
+}
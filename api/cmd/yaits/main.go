@@ -0,0 +1,180 @@
+// Command yaits manages YAITS issues from the terminal through the REST API, talking to the servers of the profiles
+// of its config file
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/spf13/pflag"
+
+	"github.com/YAITS/api/client"
+)
+
+const usage = `Usage: yaits issue <command> [flags]
+
+Commands:
+  create    [--summary S] [--description D] [--priority N] [--assignee U] [--project P] [--type T] [--due YYYY-MM-DD]
+            opens $EDITOR for the summary and description when no description is given
+  list      [--status S] [--priority 1..3] [--assignee U] [--project P] [--type T]
+  show      ID
+  comment   ID [TEXT], opening $EDITOR when no text is given
+  close     ID [--comment TEXT]
+  assign    ID USER
+
+Flags of every command:
+  -p, --profile NAME     profile of the config file, $YAITS_CONFIG or yaits/config.toml in the user config directory
+  -o, --output FORMAT    table (the default), json or template
+      --template TMPL    Go template executed for every issue, such as '{{.ID}} {{.Summary}}'
+`
+
+// command runs an issue command with the arguments following its name
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]command{
+	"create":  createIssue,
+	"list":    listIssues,
+	"show":    showIssue,
+	"comment": commentIssue,
+	"close":   closeIssue,
+	"assign":  assignIssue,
+}
+
+// usageError is a command misused, reported along with the usage
+type usageError struct {
+	message string
+}
+
+func (err *usageError) Error() string {
+	return err.message
+}
+
+// app is the state shared by the commands, set up by parse
+type app struct {
+	stdout io.Writer
+
+	client  *client.Client
+	printer *printer
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		cancel()
+	}()
+
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	cancel()
+	os.Exit(code)
+}
+
+// run runs the command of args, returning the exit code: 1 when the command failed and 2 when it was misused
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	if args[0] != "issue" || len(args) < 2 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	cmd, ok := commands[args[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "yaits: unknown command %q, expecting one of %v\n\n%s", args[1], commandNames(), usage)
+		return 2
+	}
+
+	err := cmd(ctx, &app{stdout: stdout}, args[2:])
+
+	var misuse *usageError
+	switch {
+	case err == nil:
+		return 0
+	case err == pflag.ErrHelp:
+		fmt.Fprint(stdout, usage)
+		return 0
+	case errors.As(err, &misuse):
+		fmt.Fprintf(stderr, "yaits: %s\n\n%s", err, usage)
+		return 2
+	}
+
+	fmt.Fprintf(stderr, "yaits: %s\n", err)
+	return 1
+}
+
+// newFlagSet creates the flag set of a command, along with the flags shared by every command
+func newFlagSet(name string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("yaits issue "+name, pflag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.StringP("profile", "p", "", "profile of the config file")
+	flags.StringP("output", "o", outputTable, "table, json or template")
+	flags.String("template", "", "Go template executed for every issue")
+
+	return flags
+}
+
+// parse parses the flags of a command, expecting between min and max positional arguments, and connects to the
+// server of the profile
+func (a *app) parse(flags *pflag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil, err
+		}
+		return nil, &usageError{message: err.Error()}
+	}
+
+	positional := flags.Args()
+	if len(positional) < min || len(positional) > max {
+		return nil, &usageError{message: fmt.Sprintf("expecting %s, got %d", argumentCount(min, max), len(positional))}
+	}
+
+	profileName, _ := flags.GetString("profile")
+	p, err := loadProfile(configPath(), profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	a.client = client.NewClient(p.URL)
+	a.client.Token = p.Token
+	a.client.User = p.User
+
+	output, _ := flags.GetString("output")
+	tmpl, _ := flags.GetString("template")
+	if a.printer, err = newPrinter(a.stdout, output, tmpl); err != nil {
+		return nil, &usageError{message: err.Error()}
+	}
+
+	return positional, nil
+}
+
+func argumentCount(min, max int) string {
+	switch {
+	case min == max && min == 1:
+		return "1 argument"
+	case min == max:
+		return fmt.Sprintf("%d arguments", min)
+	}
+
+	return fmt.Sprintf("%d to %d arguments", min, max)
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	persistence "github.com/YAITS/api/persistence/mock"
+	"github.com/YAITS/api/server"
+	"github.com/YAITS/api/server/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-cli")
+	if err != nil {
+		t.Fatalf("couldn't create the temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := blob.NewLocalStore(filepath.Join(dir, "attachments"))
+	if err != nil {
+		t.Fatalf("couldn't create the attachment store: %s", err)
+	}
+	router := server.BuildRouter(zap.NewNop().Sugar(), persistence.NewMockStorage(), handlers.Attachments{Store: store},
+		events.NewHub(10), handlers.Integrations{}, server.Config{})
+	apiServer := httptest.NewServer(router)
+	defer apiServer.Close()
+
+	config := filepath.Join(dir, "config.toml")
+	writeConfig(t, config, fmt.Sprintf("profile = \"local\"\n\n[profiles.local]\nurl = %q\nuser = %q\n",
+		apiServer.URL, persistence.Assignee))
+	setEnv(t, "YAITS_CONFIG", config)
+	defer os.Unsetenv("YAITS_CONFIG")
+
+	t.Run("List", func(t *testing.T) {
+		stdout, code := runCommand("issue", "list", "--status", "open", "--priority", "1..3")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "ID")
+		assert.Contains(t, stdout, persistence.Summary)
+
+		t.Run("OutOfRange", func(t *testing.T) {
+			stdout, code := runCommand("issue", "list", "--priority", "2..3", "-o", "json")
+			assert.Equal(t, 0, code)
+			assert.Equal(t, "[]\n", stdout)
+		})
+
+		t.Run("InvalidRange", func(t *testing.T) {
+			_, code := runCommand("issue", "list", "--priority", "3..1")
+			assert.Equal(t, 2, code)
+		})
+	})
+
+	t.Run("Show", func(t *testing.T) {
+		stdout, code := runCommand("issue", "show", "1", "-o", "json")
+		assert.Equal(t, 0, code)
+
+		var issue models.IssueResponse
+		assert.Nil(t, json.Unmarshal([]byte(stdout), &issue))
+		assert.Equal(t, persistence.IssueID, issue.ID)
+
+		t.Run("Template", func(t *testing.T) {
+			stdout, code := runCommand("issue", "show", "API-1", "--template", "{{.ID}} {{.Status}}")
+			assert.Equal(t, 0, code)
+			assert.Equal(t, "1 "+persistence.Status+"\n", stdout)
+		})
+
+		t.Run("MissingID", func(t *testing.T) {
+			_, code := runCommand("issue", "show")
+			assert.Equal(t, 2, code)
+		})
+
+		t.Run("UnknownProfile", func(t *testing.T) {
+			_, code := runCommand("issue", "show", "1", "--profile", "work")
+			assert.Equal(t, 1, code)
+		})
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		stdout, code := runCommand("issue", "create", "--summary", "Login fails", "--description", "Blank page",
+			"--priority", "2", "--due", "2020-07-01")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, persistence.Summary)
+
+		t.Run("Editor", func(t *testing.T) {
+			var initial string
+			restore := stubEditor(func(text string) (string, error) {
+				initial = text
+				return "Login fails\n\nBlank page since this morning", nil
+			})
+			defer restore()
+
+			_, code := runCommand("issue", "create", "--priority", "2", "-o", "json")
+			assert.Equal(t, 0, code)
+			assert.Contains(t, initial, "# Write the summary")
+		})
+
+		t.Run("EmptyEditor", func(t *testing.T) {
+			restore := stubEditor(func(string) (string, error) { return "", nil })
+			defer restore()
+
+			_, code := runCommand("issue", "create", "--priority", "2")
+			assert.Equal(t, 1, code)
+		})
+	})
+
+	t.Run("Comment", func(t *testing.T) {
+		_, code := runCommand("issue", "comment", "1", "Fixed in the next release")
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		_, code := runCommand("issue", "close", "#1", "--comment", "Fixed")
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("Assign", func(t *testing.T) {
+		_, code := runCommand("issue", "assign", "1", "@alice")
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("UnknownCommand", func(t *testing.T) {
+		_, code := runCommand("issue", "reopen", "1")
+		assert.Equal(t, 2, code)
+	})
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-cli")
+	if err != nil {
+		t.Fatalf("couldn't create the temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := filepath.Join(dir, "config.toml")
+	writeConfig(t, config, `profile = "work"
+
+[profiles.work]
+url = "https://yaits.example.com"
+token = "secret"
+user = "alice"
+
+[profiles.home]
+url = "http://localhost:9000"
+`)
+
+	p, err := loadProfile(config, "")
+	assert.Nil(t, err)
+	assert.Equal(t, profile{Name: "work", URL: "https://yaits.example.com", Token: "secret", User: "alice"}, p)
+
+	p, err = loadProfile(config, "home")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:9000", p.URL)
+
+	_, err = loadProfile(config, "office")
+	assert.NotNil(t, err)
+
+	t.Run("NoConfig", func(t *testing.T) {
+		p, err := loadProfile(filepath.Join(dir, "missing.toml"), "")
+		assert.Nil(t, err)
+		assert.Equal(t, profile{Name: defaultProfile, URL: defaultURL}, p)
+	})
+
+	t.Run("Environment", func(t *testing.T) {
+		setEnv(t, "YAITS_USER", "bob")
+		defer os.Unsetenv("YAITS_USER")
+
+		p, err := loadProfile(config, "")
+		assert.Nil(t, err)
+		assert.Equal(t, "bob", p.User)
+	})
+}
+
+func TestParsePriorityRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected models.PriorityQueryParam
+		valid    bool
+	}{
+		{"", models.PriorityQueryParam{}, true},
+		{"2", models.PriorityQueryParam{PriorityStart: 2, PriorityEnd: 2}, true},
+		{"1..3", models.PriorityQueryParam{PriorityStart: 1, PriorityEnd: 3}, true},
+		{"2..", models.PriorityQueryParam{PriorityStart: 2}, true},
+		{"..3", models.PriorityQueryParam{PriorityEnd: 3}, true},
+		{"..", models.PriorityQueryParam{}, false},
+		{"3..1", models.PriorityQueryParam{}, false},
+		{"high", models.PriorityQueryParam{}, false},
+	}
+
+	for _, test := range tests {
+		priorities, err := parsePriorityRange(test.in)
+		assert.Equal(t, test.valid, err == nil, test.in)
+		assert.Equal(t, test.expected, priorities, test.in)
+	}
+}
+
+func TestStripComments(t *testing.T) {
+	assert.Equal(t, "Login fails\n\nBlank page", stripComments("Login fails\n\nBlank page\n# Write the summary\n"))
+	assert.Equal(t, "", stripComments("\n# Write the comment\n"))
+}
+
+func runCommand(args ...string) (string, int) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return stdout.String(), code
+}
+
+func stubEditor(stub func(string) (string, error)) func() {
+	previous := edit
+	edit = stub
+	return func() {
+		edit = previous
+	}
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("couldn't write the config: %s", err)
+	}
+}
+
+func setEnv(t *testing.T, key, value string) {
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatalf("couldn't set %s: %s", key, err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/YAITS/api/models"
+)
+
+// Output formats
+const (
+	outputTable    = "table"
+	outputJSON     = "json"
+	outputTemplate = "template"
+)
+
+// maxSummaryWidth bounds the summaries of the issue table
+const maxSummaryWidth = 60
+
+// printer writes issues as a table, as JSON or through a Go template executed for every issue, such as
+// '{{.ID}} {{.Summary}}'
+type printer struct {
+	out    io.Writer
+	format string
+	tmpl   *template.Template
+}
+
+// newPrinter creates a printer of the given format, a template implying the template format
+func newPrinter(out io.Writer, format, tmpl string) (*printer, error) {
+	p := &printer{out: out, format: format}
+	if tmpl != "" && format == outputTable {
+		p.format = outputTemplate
+	}
+
+	switch p.format {
+	case outputTable, outputJSON:
+	case outputTemplate:
+		if tmpl == "" {
+			return nil, fmt.Errorf("the template output requires a --template")
+		}
+
+		parsed, err := template.New("output").Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %s", err)
+		}
+		p.tmpl = parsed
+	default:
+		return nil, fmt.Errorf("unknown output %q, expecting table, json or template", format)
+	}
+
+	return p, nil
+}
+
+// issues writes a list of issues, one row per issue in the table format
+func (p *printer) issues(issues []models.IssueResponse) error {
+	switch p.format {
+	case outputJSON:
+		return p.json(issues)
+	case outputTemplate:
+		for _, issue := range issues {
+			if err := p.template(issue); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(p.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPRIORITY\tSTATUS\tASSIGNEE\tSUMMARY")
+	for _, issue := range issues {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", issue.ID, issue.Priority, issue.Status, issue.Assignee,
+			truncate(issue.Summary, maxSummaryWidth))
+	}
+
+	return w.Flush()
+}
+
+// issue writes an issue along with its description and comments
+func (p *printer) issue(issue models.IssueResponse) error {
+	switch p.format {
+	case outputJSON:
+		return p.json(issue)
+	case outputTemplate:
+		return p.template(issue)
+	}
+
+	w := tabwriter.NewWriter(p.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%d\n", issue.ID)
+	fmt.Fprintf(w, "Summary:\t%s\n", issue.Summary)
+	fmt.Fprintf(w, "Status:\t%s\n", issue.Status)
+	fmt.Fprintf(w, "Priority:\t%d\n", issue.Priority)
+	fmt.Fprintf(w, "Assignee:\t%s\n", issue.Assignee)
+	fmt.Fprintf(w, "Reporter:\t%s\n", issue.Reporter)
+	if issue.Project != "" {
+		fmt.Fprintf(w, "Project:\t%s\n", issue.Project)
+	}
+	if issue.Type != "" {
+		fmt.Fprintf(w, "Type:\t%s\n", issue.Type)
+	}
+	fmt.Fprintf(w, "Created:\t%s\n", issue.CreateDate)
+	if issue.DueDate != "" {
+		fmt.Fprintf(w, "Due:\t%s\n", issue.DueDate)
+	}
+	names := make([]string, 0, len(issue.Fields))
+	for name := range issue.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s:\t%s\n", name, issue.Fields[name])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if issue.Description != "" {
+		fmt.Fprintf(p.out, "\n%s\n", issue.Description)
+	}
+
+	if len(issue.Comments) > 0 {
+		fmt.Fprintf(p.out, "\nComments:\n")
+		for _, comment := range issue.Comments {
+			fmt.Fprintf(p.out, "  #%d %s\n", comment.ID, strings.Replace(comment.Comment, "\n", "\n  ", -1))
+		}
+	}
+
+	return nil
+}
+
+func (p *printer) json(v interface{}) error {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (p *printer) template(issue models.IssueResponse) error {
+	if err := p.tmpl.Execute(p.out, issue); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(p.out)
+	return err
+}
+
+// truncate shortens s to at most width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+
+	return string(runes[:width-1]) + "…"
+}
@@ -1,84 +1,81 @@
 // GENERATED BY THE COMMAND ABOVE; DO NOT EDIT
-// This file was generated by swaggo/swag at
-// 2020-08-26 23:36:18.676969 -0400 EDT m=+0.058398009
+// This file was generated by swaggo/swag
 
 package docs
 
 import (
 	"bytes"
+	"encoding/json"
+	"strings"
 
 	"github.com/alecthomas/template"
 	"github.com/swaggo/swag"
 )
 
 var doc = `{
+    "schemes": {{ marshal .Schemes }},
     "swagger": "2.0",
     "info": {
-        "description": "Swagger API for Yet Another Issue Tracking System.",
-        "title": "YAITS Swagger API",
+        "description": "{{.Description}}",
+        "title": "{{.Title}}",
         "contact": {
             "name": "API Support",
             "email": "anhkhoi.vunguyen@gmai.com"
         },
         "license": {},
-        "version": "1.0"
+        "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
-    "basePath": "/api",
+    "basePath": "{{.BasePath}}",
     "paths": {
-        "/issue": {
-            "post": {
-                "description": "Create a new issue",
-                "consumes": [
-                    "application/json"
-                ],
+        "/attachments/{id}": {
+            "get": {
+                "description": "Downloads the content of an attachment",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "Creation"
+                    "Attachments"
                 ],
-                "summary": "Create an issue",
+                "summary": "Download an attachment",
                 "parameters": [
                     {
-                        "description": "YAITS creation request",
-                        "name": "issueRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.NewIssueRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID of the attachment",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.IssueIDResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     }
                 }
-            }
-        },
-        "/issue/{id}": {
-            "get": {
-                "description": "Retrieves an issue given issue id",
+            },
+            "delete": {
+                "description": "Deletes an attachment, its content being removed once no other attachment shares it",
                 "consumes": [
                     "application/json"
                 ],
@@ -86,51 +83,79 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Retrieval"
+                    "Attachments"
                 ],
-                "summary": "Retrieves an issue given issue id",
+                "summary": "Delete an attachment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID of the issue",
+                        "description": "ID of the attachment",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.IssueResponse"
+                            "type": ""
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendars": {
+            "get": {
+                "description": "Retrieves every business-hours calendar usable by SLA policies",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLA"
+                ],
+                "summary": "Retrieves business-hours calendars",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.BusinessCalendar"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Deletes an issue given an issue id",
+            "post": {
+                "description": "Creates a calendar of working days (0 being sunday) and hours in a timezone",
                 "consumes": [
                     "application/json"
                 ],
@@ -138,50 +163,85 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Deletion"
+                    "SLA"
                 ],
-                "summary": "Delete an issue",
+                "summary": "Create a business-hours calendar",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID of the issue",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "YAITS calendar creation request",
+                        "name": "calendarRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewBusinessCalendarRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/models.IssueIDResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Streams the issue.created, issue.updated, issue.deleted and issue.commented events as server-sent\nevents. Reconnecting with the Last-Event-ID header (or lastEventID query parameter) replays the recent\nevents that were missed",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Streams issue events",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received, for clients unable to set headers",
+                        "name": "lastEventID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueEvent"
+                        }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Updates an issue given an issue id",
+            }
+        },
+        "/graphql": {
+            "post": {
+                "description": "Runs a GraphQL query or mutation over issues, comments, users and projects. Mutations mirror the creation,\nupdate and deletion routes. Queries whose estimated cost exceeds a complexity limit are rejected, list\nfields multiplying the cost of their selections by the number of items they may return",
                 "consumes": [
                     "application/json"
                 ],
@@ -189,25 +249,17 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Update"
+                    "GraphQL"
                 ],
-                "summary": "Update an issue",
+                "summary": "GraphQL endpoint",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID of the issue",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "YAITS update request",
-                        "name": "updateIssueRequest",
+                        "description": "GraphQL query and variables",
+                        "name": "graphQLRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.UpdateIssueRequest"
+                            "$ref": "#/definitions/models.GraphQLRequest"
                         }
                     }
                 ],
@@ -215,37 +267,84 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.IssueResponse"
+                            "$ref": "#/definitions/models.GraphQLResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.ErrorWrapper"
+                            "$ref": "#/definitions/models.GraphQLResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/import": {
+            "post": {
+                "description": "Imports the issues of a csv, ndjson, GitHub issues JSON or Jira csv export file. Options map the\ncolumns of csv files to issue fields and the users, statuses and priorities of the file to those of\nYAITS. A dry run reports what prevents each row from being imported; otherwise the valid rows are\nimported in the background by a job whose progress is retrieved from /import/{jobID}",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Import"
+                ],
+                "summary": "Import issues",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "file to import",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
                     },
-                    "404": {
-                        "description": "Not Found",
+                    {
+                        "type": "string",
+                        "description": "JSON import options such as {\\",
+                        "name": "options",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only validate the file",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     }
                 }
             }
         },
-        "/issues": {
+        "/import/{jobID}": {
             "get": {
-                "description": "Retrieves all issues",
+                "description": "Retrieves the status and the progress of an import along with the rows that were not imported",
                 "consumes": [
                     "application/json"
                 ],
@@ -253,44 +352,49 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Retrieval"
+                    "Import"
+                ],
+                "summary": "Retrieves an import job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the import job",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Retrieves all existing issues",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.IssueResponse"
+                            "$ref": "#/definitions/models.ImportJob"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     }
                 }
             }
         },
-        "/issues/priority": {
-            "get": {
-                "description": "Retrieves an issue given priority",
+        "/issue": {
+            "post": {
+                "description": "Create a new issue, applying the defaults and required fields of its issue type, and notify the subscribed webhooks",
                 "consumes": [
                     "application/json"
                 ],
@@ -298,51 +402,45 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Retrieval"
+                    "Creation"
                 ],
-                "summary": "Retrieves an issue given priority",
+                "summary": "Create an issue",
                 "parameters": [
                     {
-                        "type": "models.PriorityQueryParam",
-                        "description": "priority start bound",
-                        "name": "start",
-                        "in": "query"
-                    },
-                    {
-                        "type": "models.PriorityQueryParam",
-                        "description": "priority end bound",
-                        "name": "end",
-                        "in": "query"
+                        "description": "YAITS creation request",
+                        "name": "issueRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewIssueRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.IssueResponse"
+                            "$ref": "#/definitions/models.IssueIDResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     }
                 }
             }
         },
-        "/issues/status": {
+        "/issue-types": {
             "get": {
-                "description": "Retrieves an issue given status (open, closed, in progress)",
+                "description": "Retrieves the issue types available to a project, including the shared ones",
                 "consumes": [
                     "application/json"
                 ],
@@ -350,14 +448,14 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Retrieval"
+                    "Issue Types"
                 ],
-                "summary": "Retrieves an issue given status",
+                "summary": "Retrieves issue types",
                 "parameters": [
                     {
-                        "type": "models.StatusQueryParam",
-                        "description": "issue priority request",
-                        "name": "status",
+                        "type": "string",
+                        "description": "project key",
+                        "name": "project",
                         "in": "query"
                     }
                 ],
@@ -365,113 +463,2805 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/models.IssueResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.IssueType"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
+                    }
+                }
+            },
+            "post": {
+                "description": "Configures a new issue type for a project, or for all projects when no project is given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Issue Types"
+                ],
+                "summary": "Create an issue type",
+                "parameters": [
+                    {
+                        "description": "YAITS issue type creation request",
+                        "name": "issueTypeRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewIssueTypeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueIDResponse"
+                        }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
                             "$ref": "#/definitions/models.ErrorWrapper"
                         }
                     }
                 }
             }
-        }
-    },
+        },
+        "/issue-types/{id}": {
+            "delete": {
+                "description": "Deletes an issue type given its id, existing issues keep their type",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Issue Types"
+                ],
+                "summary": "Delete an issue type",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue type",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": ""
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}": {
+            "get": {
+                "description": "Retrieves an issue given issue id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Retrieval"
+                ],
+                "summary": "Retrieves an issue given issue id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes an issue given an issue id, along with its attachments, notifying its watchers and the subscribed webhooks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Deletion"
+                ],
+                "summary": "Delete an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": ""
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Updates an issue given an issue id, notifying its watchers and the subscribed webhooks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Update"
+                ],
+                "summary": "Update an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "YAITS update request",
+                        "name": "updateIssueRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateIssueRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/attachments": {
+            "get": {
+                "description": "Retrieves the files attached to an issue and to its comments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attachments"
+                ],
+                "summary": "Retrieves the attachments of an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Attachment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/comments/{commentID}/attachments": {
+            "post": {
+                "description": "Uploads a file attached to an issue, or to one of its comments when a comment id is given",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attachments"
+                ],
+                "summary": "Attach a file",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the comment",
+                        "name": "commentID",
+                        "in": "path"
+                    },
+                    {
+                        "type": "file",
+                        "description": "attached file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Attachment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/events": {
+            "get": {
+                "description": "Streams the events of an issue as server-sent events, resuming like the stream of every issue",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Streams the events of an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received, for clients unable to set headers",
+                        "name": "lastEventID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/links": {
+            "get": {
+                "description": "Retrieves the commits and pull requests referring to an issue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Retrieves the links of an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.IssueLink"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/sla-breaches": {
+            "get": {
+                "description": "Retrieves the SLA breach events recorded for an issue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLA"
+                ],
+                "summary": "Retrieves the SLA breaches of an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SLABreach"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/watchers": {
+            "get": {
+                "description": "Retrieves the users notified of the changes to an issue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Retrieves the watchers of an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/watchers/{user}": {
+            "put": {
+                "description": "Makes a user watch an issue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Watch an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user name",
+                        "name": "user",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": ""
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stops a user from watching an issue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Unwatch an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user name",
+                        "name": "user",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": ""
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/worklogs": {
+            "get": {
+                "description": "Retrieves the work logged on an issue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Retrieves the worklogs of an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Worklog"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Logs work on an issue, reducing its remaining estimate unless adjustEstimate is leave",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Log work on an issue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "YAITS worklog creation request",
+                        "name": "worklogRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewWorklogRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issue/{id}/worklogs/{worklogID}": {
+            "delete": {
+                "description": "Deletes a worklog, giving its time back to the remaining estimate of the issue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Delete a worklog",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the issue",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the worklog",
+                        "name": "worklogID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": ""
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issues": {
+            "get": {
+                "description": "Retrieves all issues, optionally filtered by project and issue type",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Retrieval"
+                ],
+                "summary": "Retrieves all existing issues",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project key",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "issue type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issues/export": {
+            "get": {
+                "description": "Exports the issues matching the filters of the listing endpoints to a csv, ndjson or xlsx file,\nstreamed as issues are read from the database",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "Export issues",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (the default), ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "project key",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "issue type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "lowest priority",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "highest priority",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated columns to export, such as id,summary,status",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "export the comments of the issues",
+                        "name": "comments",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issues/priority": {
+            "get": {
+                "description": "Retrieves an issue given priority",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Retrieval"
+                ],
+                "summary": "Retrieves an issue given priority",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "priorityEnd",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "priorityStart",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "priorityEnd",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "priorityStart",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "project key",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "issue type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issues/sla": {
+            "get": {
+                "description": "Retrieves issues with an SLA target in the given state (at risk, breached), both when no state is given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Retrieval"
+                ],
+                "summary": "Retrieves issues at risk or breaching their SLA",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SLA state (at risk, breached)",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "project key",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "issue type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.IssueResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/issues/status": {
+            "get": {
+                "description": "Retrieves an issue given status (open, closed, in progress)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Retrieval"
+                ],
+                "summary": "Retrieves an issue given status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "project key",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "issue type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/email-preferences": {
+            "get": {
+                "description": "Retrieves the email notifications wanted by the user named by the X-YAITS-User header, every kind\nbeing wanted until an address is set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Retrieves my email preferences",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmailPreferences"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Sets the address and the email notifications wanted by the user named by the X-YAITS-User header,\ndigest batching them into periodic emails. An empty address turns emails off",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Sets my email preferences",
+                "parameters": [
+                    {
+                        "description": "YAITS email preferences",
+                        "name": "preferencesRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmailPreferencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmailPreferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/notifications": {
+            "get": {
+                "description": "Retrieves the notifications of the user named by the X-YAITS-User header, most recent first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Retrieves my notifications",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "issue filter",
+                        "name": "issueID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "kind filter (updated, commented, deleted)",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/notifications/unread": {
+            "get": {
+                "description": "Counts the unread notifications of the user named by the X-YAITS-User header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Counts my unread notifications",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/notifications/{notificationID}/read": {
+            "post": {
+                "description": "Marks a notification as read, or all of them (only those of an issue when issueID is given)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Mark my notifications as read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the notification",
+                        "name": "notificationID",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "issue filter",
+                        "name": "issueID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/sla-policies": {
+            "get": {
+                "description": "Retrieves every SLA policy with its business-hours calendar",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLA"
+                ],
+                "summary": "Retrieves SLA policies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SLAPolicy"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a policy with response and resolution targets in minutes for a project and/or priority",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLA"
+                ],
+                "summary": "Create an SLA policy",
+                "parameters": [
+                    {
+                        "description": "YAITS SLA policy creation request",
+                        "name": "policyRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewSLAPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/sla-policies/{id}": {
+            "delete": {
+                "description": "Deletes an SLA policy given its id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLA"
+                ],
+                "summary": "Delete an SLA policy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the SLA policy",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": ""
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/slack/commands": {
+            "post": {
+                "description": "Runs a /yaits slash command sent by Slack: create, show or assign an issue. Requests must be signed\nwith the signing secret of the Slack app in the X-Slack-Signature header",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Slack"
+                ],
+                "summary": "Run a Slack slash command",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "v0= followed by the hex HMAC-SHA256 of v0:timestamp:body",
+                        "name": "X-Slack-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unix time the request was sent at",
+                        "name": "X-Slack-Request-Timestamp",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "text following the command, such as show API-12",
+                        "name": "text",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slack id of the user running the command",
+                        "name": "user_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slack name of the user running the command",
+                        "name": "user_name",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slack.Message"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/timesheets/{user}": {
+            "get": {
+                "description": "Retrieves the work logged by a user over the week (monday to sunday) containing the given day",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Retrieves the timesheet of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user name",
+                        "name": "user",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "any day of the week (YYYY-MM-DD), defaults to the current week",
+                        "name": "week",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Timesheet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/unsubscribe": {
+            "get": {
+                "description": "Turns off every email notification of the user owning the token, as linked from every email",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Unsubscribe from emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unsubscribe token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/vcs/webhook": {
+            "post": {
+                "description": "Links the commits and pull requests of a GitHub, GitLab or Gitea webhook to the issues they refer to\nas #12 or API-12. Issues preceded by a closing keyword such as \"fixes #12\" are closed by commits pushed\nto the default branch and by merged pull requests. Webhooks must be signed with the configured secret",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Link commits and pull requests to issues",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "sha256= followed by the hex HMAC-SHA256 of the body, for GitHub",
+                        "name": "X-Hub-Signature-256",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "hex HMAC-SHA256 of the body, for Gitea",
+                        "name": "X-Gitea-Signature",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "secret of the webhook, for GitLab",
+                        "name": "X-Gitlab-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VCSWebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Retrieves every webhook subscription, without their secret",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Retrieves webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribes a URL to issue events (issue.created, issue.updated, issue.deleted, issue.commented),\noptionally for a single project. Deliveries are signed with the secret in the X-YAITS-Signature header.\nWebhooks of the slack format post the events as messages to a Slack incoming webhook and need no secret.\nDeliveries to loopback, private and link-local addresses are refused unless webhooks.allowPrivateNetworks is set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Create a webhook",
+                "parameters": [
+                    {
+                        "description": "YAITS webhook creation request",
+                        "name": "webhookRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.IssueIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "description": "Deletes a webhook given its id, along with its delivery log",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": ""
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Retrieves the deliveries of a webhook, most recent first, with the outcome of their last attempt",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Retrieves the deliveries of a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery status: pending, delivered or dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of deliveries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryID}/redeliver": {
+            "post": {
+                "description": "Queues a delivery again with a fresh set of attempts, whether it was delivered or dead-lettered",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Redeliver a webhook delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the delivery",
+                        "name": "deliveryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": ""
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        },
+        "/worklogs/totals": {
+            "get": {
+                "description": "Sums the work logged per issue, user or project over an optional date range",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Aggregates logged work",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "first day included (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last day included (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "issue (default), user or project",
+                        "name": "groupBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user filter",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "project filter",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "issue filter",
+                        "name": "issueID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WorklogTotal"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorWrapper"
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
+        "models.Attachment": {
+            "type": "object",
+            "properties": {
+                "commentID": {
+                    "type": "integer"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "createDate": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issueID": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BusinessCalendar": {
+            "type": "object",
+            "properties": {
+                "endHour": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "startHour": {
+                    "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "workdays": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
         "models.Comment": {
             "type": "object",
             "properties": {
-                "comment": {
+                "comment": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.EmailPreferences": {
+            "type": "object",
+            "properties": {
+                "assigned": {
+                    "type": "boolean"
+                },
+                "commented": {
+                    "type": "boolean"
+                },
+                "digest": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "mentioned": {
+                    "type": "boolean"
+                },
+                "statusChanged": {
+                    "type": "boolean"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EmailPreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "assigned": {
+                    "type": "boolean"
+                },
+                "commented": {
+                    "type": "boolean"
+                },
+                "digest": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "mentioned": {
+                    "type": "boolean"
+                },
+                "statusChanged": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ErrorWrapper": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StandardError"
+                    }
+                }
+            }
+        },
+        "models.GraphQLError": {
+            "type": "object",
+            "properties": {
+                "extensions": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.GraphQLErrorExtensions"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GraphQLErrorLocation"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                }
+            }
+        },
+        "models.GraphQLErrorExtensions": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StandardError"
+                    }
+                }
+            }
+        },
+        "models.GraphQLErrorLocation": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer"
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.GraphQLRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "models.GraphQLResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GraphQLError"
+                    }
+                }
+            }
+        },
+        "models.ImportJob": {
+            "type": "object",
+            "properties": {
+                "createDate": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowError"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updateDate": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportReport": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowError"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.IssueEvent": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "changed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "changes": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issue": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.IssueResponse"
+                },
+                "issueID": {
+                    "type": "integer"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.IssueIDResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.IssueLink": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "createDate": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issueID": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "ref": {
+                    "type": "string"
+                },
+                "repository": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.IssueResponse": {
+            "type": "object",
+            "properties": {
+                "assignee": {
+                    "type": "string"
+                },
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Comment"
+                    }
+                },
+                "createDate": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dueDate": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "originalEstimate": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "remainingEstimate": {
+                    "type": "integer"
+                },
+                "reporter": {
+                    "type": "string"
+                },
+                "resolvedDate": {
+                    "type": "string"
+                },
+                "respondedDate": {
+                    "type": "string"
+                },
+                "sla": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.SLAStatus"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "timeSpent": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.IssueType": {
+            "type": "object",
+            "properties": {
+                "defaultPriority": {
+                    "type": "integer"
+                },
+                "descriptionTemplate": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "requiredFields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "workflow": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NewBusinessCalendarRequest": {
+            "type": "object",
+            "required": [
+                "endHour",
+                "name"
+            ],
+            "properties": {
+                "endHour": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "startHour": {
+                    "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "workdays": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.NewIssueRequest": {
+            "type": "object",
+            "required": [
+                "summary"
+            ],
+            "properties": {
+                "assignee": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dueDate": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "originalEstimate": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "reporter": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NewIssueTypeRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "defaultPriority": {
+                    "type": "integer"
+                },
+                "descriptionTemplate": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "requiredFields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "workflow": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NewSLAPolicyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "calendarID": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "resolutionMinutes": {
+                    "type": "integer"
+                },
+                "responseMinutes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.NewWebhookRequest": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "format": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NewWorklogRequest": {
+            "type": "object",
+            "required": [
+                "minutes",
+                "user"
+            ],
+            "properties": {
+                "adjustEstimate": {
+                    "type": "string"
+                },
+                "minutes": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                },
+                "workDate": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Notification": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "createDate": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issueID": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "read": {
+                    "type": "boolean"
+                },
+                "user": {
                     "type": "string"
                 }
             }
         },
-        "models.ErrorWrapper": {
+        "models.NotificationsResponse": {
             "type": "object",
             "properties": {
-                "errors": {
+                "notifications": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.StandardError"
+                        "$ref": "#/definitions/models.Notification"
                     }
+                },
+                "unreadCount": {
+                    "type": "integer"
                 }
             }
         },
-        "models.IssueIDResponse": {
+        "models.PriorityQueryParam": {
             "type": "object",
             "properties": {
-                "id": {
+                "priorityEnd": {
+                    "type": "integer"
+                },
+                "priorityStart": {
                     "type": "integer"
                 }
             }
         },
-        "models.IssueResponse": {
+        "models.SLABreach": {
             "type": "object",
             "properties": {
-                "assignee": {
+                "breachDate": {
                     "type": "string"
                 },
-                "comments": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Comment"
-                    }
+                "issueID": {
+                    "type": "integer"
                 },
-                "createDate": {
+                "policy": {
                     "type": "string"
                 },
-                "description": {
+                "target": {
                     "type": "string"
+                }
+            }
+        },
+        "models.SLAPolicy": {
+            "type": "object",
+            "properties": {
+                "calendar": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.BusinessCalendar"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "name": {
+                    "type": "string"
+                },
                 "priority": {
                     "type": "integer"
                 },
-                "status": {
+                "project": {
                     "type": "string"
                 },
-                "summary": {
-                    "type": "string"
+                "resolutionMinutes": {
+                    "type": "integer"
+                },
+                "responseMinutes": {
+                    "type": "integer"
                 }
             }
         },
-        "models.NewIssueRequest": {
+        "models.SLAStatus": {
             "type": "object",
-            "required": [
-                "description",
-                "priority",
-                "summary"
-            ],
             "properties": {
-                "assignee": {
+                "policy": {
                     "type": "string"
                 },
-                "description": {
+                "resolutionDue": {
                     "type": "string"
                 },
-                "priority": {
-                    "type": "integer"
+                "resolutionState": {
+                    "type": "string"
                 },
-                "summary": {
+                "responseDue": {
+                    "type": "string"
+                },
+                "responseState": {
                     "type": "string"
                 }
             }
@@ -480,16 +3270,73 @@ var doc = `{
             "type": "object",
             "properties": {
                 "code": {
+                    "description": "HTTP status of the response, the machine-readable code of a violation being its reason",
                     "type": "integer"
                 },
                 "description": {
                     "type": "string"
                 },
+                "field": {
+                    "description": "JSON name of the field in violation, absent when the error is about the whole request",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Machine-readable code of the violation: required, too_long, out_of_range, invalid, invalid_type, malformed, duplicate or not_found",
+                    "type": "string"
+                },
                 "title": {
                     "type": "string"
                 }
             }
         },
+        "models.StatusQueryParam": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Timesheet": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimesheetDay"
+                    }
+                },
+                "totalMinutes": {
+                    "type": "integer"
+                },
+                "user": {
+                    "type": "string"
+                },
+                "weekEnd": {
+                    "type": "string"
+                },
+                "weekStart": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TimesheetDay": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "minutes": {
+                    "type": "integer"
+                },
+                "worklogs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Worklog"
+                    }
+                }
+            }
+        },
         "models.UpdateIssueRequest": {
             "type": "object",
             "properties": {
@@ -502,9 +3349,18 @@ var doc = `{
                 "description": {
                     "type": "string"
                 },
+                "dueDate": {
+                    "type": "string"
+                },
+                "originalEstimate": {
+                    "type": "integer"
+                },
                 "priority": {
                     "type": "integer"
                 },
+                "remainingEstimate": {
+                    "type": "integer"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -512,6 +3368,190 @@ var doc = `{
                     "type": "string"
                 }
             }
+        },
+        "models.VCSWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "closed": {
+                    "type": "integer"
+                },
+                "linked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "createDate": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createDate": {
+                    "type": "string"
+                },
+                "deliveredDate": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "nextAttempt": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "responseStatus": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "webhookID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Worklog": {
+            "type": "object",
+            "properties": {
+                "createDate": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issueID": {
+                    "type": "integer"
+                },
+                "minutes": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                },
+                "workDate": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WorklogTotal": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "minutes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "slack.Attachment": {
+            "type": "object",
+            "properties": {
+                "blocks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slack.Block"
+                    }
+                },
+                "color": {
+                    "type": "string"
+                }
+            }
+        },
+        "slack.Block": {
+            "type": "object",
+            "properties": {
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slack.Text"
+                    }
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slack.Text"
+                    }
+                },
+                "text": {
+                    "type": "object",
+                    "$ref": "#/definitions/slack.Text"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "slack.Message": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slack.Attachment"
+                    }
+                },
+                "blocks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slack.Block"
+                    }
+                },
+                "response_type": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "slack.Text": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -520,23 +3560,39 @@ type swaggerInfo struct {
 	Version     string
 	Host        string
 	BasePath    string
+	Schemes     []string
 	Title       string
 	Description string
 }
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
-var SwaggerInfo swaggerInfo
+var SwaggerInfo = swaggerInfo{
+	Version:     "1.0",
+	Host:        "",
+	BasePath:    "/api",
+	Schemes:     []string{},
+	Title:       "YAITS Swagger API",
+	Description: "Swagger API for Yet Another Issue Tracking System.",
+}
 
 type s struct{}
 
 func (s *s) ReadDoc() string {
-	t, err := template.New("swagger_info").Parse(doc)
+	sInfo := SwaggerInfo
+	sInfo.Description = strings.Replace(sInfo.Description, "\n", "\\n", -1)
+
+	t, err := template.New("swagger_info").Funcs(template.FuncMap{
+		"marshal": func(v interface{}) string {
+			a, _ := json.Marshal(v)
+			return string(a)
+		},
+	}).Parse(doc)
 	if err != nil {
 		return doc
 	}
 
 	var tpl bytes.Buffer
-	if err := t.Execute(&tpl, SwaggerInfo); err != nil {
+	if err := t.Execute(&tpl, sInfo); err != nil {
 		return doc
 	}
 
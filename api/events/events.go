@@ -0,0 +1,23 @@
+package events
+
+// Issue event types, shared by webhooks and event streams
+const (
+	IssueCreated   = "issue.created"
+	IssueUpdated   = "issue.updated"
+	IssueDeleted   = "issue.deleted"
+	IssueCommented = "issue.commented"
+)
+
+// Types lists every issue event type
+var Types = []string{IssueCreated, IssueUpdated, IssueDeleted, IssueCommented}
+
+// Valid tells whether an event type is known
+func Valid(eventType string) bool {
+	for _, known := range Types {
+		if eventType == known {
+			return true
+		}
+	}
+
+	return false
+}
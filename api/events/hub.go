@@ -0,0 +1,138 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/YAITS/api/models"
+)
+
+// subscriptionBuffer is how many events a subscriber may lag behind before being dropped
+const subscriptionBuffer = 64
+
+// Hub is an in-process pub/sub of issue events. It keeps the most recent events so that subscribers can resume after
+// a disconnection, and ignores the events it already published so that publishers can retry
+type Hub struct {
+	mu          sync.Mutex
+	lastID      int64
+	history     []models.IssueEvent
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the events of a Hub, those of a single issue when IssueID is set. Events is closed when
+// unsubscribing or when the subscriber lagged too far behind, in which case it should resume from its last event
+type Subscription struct {
+	IssueID int64
+	Events  <-chan models.IssueEvent
+
+	events chan models.IssueEvent
+}
+
+// NewHub creates a Hub keeping the last historySize events for resumption
+func NewHub(historySize int) *Hub {
+	return &Hub{historySize: historySize, subscribers: make(map[*Subscription]struct{})}
+}
+
+// Publish sends an event to the matching subscribers, numbering it when it has no ID yet, and returns its ID
+func (hub *Hub) Publish(event models.IssueEvent) int64 {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if event.ID == 0 {
+		event.ID = hub.lastID + 1
+	} else if hub.published(event.ID) {
+		return event.ID
+	}
+	if event.ID > hub.lastID {
+		hub.lastID = event.ID
+	}
+
+	hub.history = append(hub.history, event)
+	if len(hub.history) > hub.historySize {
+		hub.history = hub.history[len(hub.history)-hub.historySize:]
+	}
+
+	for subscription := range hub.subscribers {
+		if !subscription.matches(event) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			// never block publishers on a slow subscriber
+			hub.remove(subscription)
+		}
+	}
+
+	return event.ID
+}
+
+// Subscribe starts receiving the events of an issue, or of every issue when issueID is 0, first replaying the
+// retained events published after lastEventID
+func (hub *Hub) Subscribe(issueID, lastEventID int64) *Subscription {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	subscription := &Subscription{IssueID: issueID}
+
+	replay := make([]models.IssueEvent, 0)
+	if lastEventID > 0 {
+		// events may be published out of order, so replay what followed the last event when it is still retained
+		position := -1
+		for i, event := range hub.history {
+			if event.ID == lastEventID {
+				position = i
+			}
+		}
+
+		for i, event := range hub.history {
+			after := i > position
+			if position < 0 {
+				after = event.ID > lastEventID
+			}
+
+			if after && subscription.matches(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	subscription.events = make(chan models.IssueEvent, subscriptionBuffer+len(replay))
+	subscription.Events = subscription.events
+	for _, event := range replay {
+		subscription.events <- event
+	}
+
+	hub.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Unsubscribe stops a subscription, closing its channel
+func (hub *Hub) Unsubscribe(subscription *Subscription) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.remove(subscription)
+}
+
+func (hub *Hub) published(eventID int64) bool {
+	for _, event := range hub.history {
+		if event.ID == eventID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (hub *Hub) remove(subscription *Subscription) {
+	if _, ok := hub.subscribers[subscription]; ok {
+		delete(hub.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+func (subscription *Subscription) matches(event models.IssueEvent) bool {
+	return subscription.IssueID == 0 || subscription.IssueID == event.IssueID
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid(IssueCommented))
+	assert.False(t, Valid("issue.exploded"))
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub(10)
+
+	all := hub.Subscribe(0, 0)
+	issue := hub.Subscribe(2, 0)
+
+	assert.Equal(t, int64(1), hub.Publish(models.IssueEvent{Event: IssueCreated, IssueID: 1}))
+	assert.Equal(t, int64(2), hub.Publish(models.IssueEvent{Event: IssueUpdated, IssueID: 2}))
+
+	assert.Equal(t, int64(1), (<-all.Events).ID)
+	assert.Equal(t, int64(2), (<-all.Events).ID)
+	assert.Equal(t, int64(2), (<-issue.Events).ID, "only the events of the issue are received")
+	assert.Len(t, issue.Events, 0)
+
+	hub.Unsubscribe(all)
+	_, open := <-all.Events
+	assert.False(t, open, "unsubscribing closes the channel")
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	hub := NewHub(3)
+
+	for i := 0; i < 5; i++ {
+		hub.Publish(models.IssueEvent{Event: IssueUpdated, IssueID: int64(i % 2)})
+	}
+
+	t.Run("Resume", func(t *testing.T) {
+		subscription := hub.Subscribe(0, 3)
+		defer hub.Unsubscribe(subscription)
+
+		assert.Equal(t, int64(4), (<-subscription.Events).ID)
+		assert.Equal(t, int64(5), (<-subscription.Events).ID)
+		assert.Len(t, subscription.Events, 0)
+	})
+
+	t.Run("BeyondHistory", func(t *testing.T) {
+		subscription := hub.Subscribe(0, 1)
+		defer hub.Unsubscribe(subscription)
+
+		assert.Len(t, subscription.Events, 3, "only the retained events are replayed")
+	})
+
+	t.Run("New", func(t *testing.T) {
+		subscription := hub.Subscribe(0, 0)
+		defer hub.Unsubscribe(subscription)
+
+		assert.Len(t, subscription.Events, 0, "new subscribers only receive new events")
+	})
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	hub := NewHub(10)
+	subscription := hub.Subscribe(0, 0)
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		hub.Publish(models.IssueEvent{Event: IssueUpdated, IssueID: 1})
+	}
+
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+
+	assert.Equal(t, subscriptionBuffer, received, "lagging subscribers are dropped instead of blocking publishers")
+	hub.Unsubscribe(subscription)
+}
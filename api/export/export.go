@@ -0,0 +1,243 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/YAITS/api/models"
+)
+
+// Formats issues are exported to
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// commentsColumn holds the comments of an issue when they are exported
+const commentsColumn = "comments"
+
+// flushEvery is the number of rows buffered before being flushed to the client
+const flushEvery = 100
+
+// Columns are the issue columns that can be exported, in their default order
+var Columns = []string{"id", "summary", "description", "status", "priority", "assignee", "reporter", "project", "type",
+	"createDate", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent"}
+
+// ErrUnknownFormat is returned exporting to a format other than csv, ndjson or xlsx
+var ErrUnknownFormat = errors.New("format must be one of csv, ndjson or xlsx")
+
+// Writer writes exported issues one at a time, Close finishing the file
+type Writer interface {
+	Write(issue models.IssueResponse) error
+	Close() error
+}
+
+// Flusher is implemented by the destinations of an export that buffer what is written to them, such as HTTP responses
+type Flusher interface {
+	Flush()
+}
+
+// ContentType returns the content type of the files of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// ParseColumns reads a comma separated list of columns, every column being exported when empty. The comments column
+// is appended when comments are exported
+func ParseColumns(columns string, comments bool) ([]string, error) {
+	parsed := make([]string, 0)
+	if strings.TrimSpace(columns) == "" {
+		parsed = append(parsed, Columns...)
+	}
+
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+
+		found := false
+		for _, known := range Columns {
+			if strings.EqualFold(column, known) {
+				parsed = append(parsed, known)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %s", column)
+		}
+	}
+
+	if comments {
+		parsed = append(parsed, commentsColumn)
+	}
+
+	return parsed, nil
+}
+
+// NewWriter creates a Writer of the given format exporting the given columns to w
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns), nil
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w, encoder: json.NewEncoder(w), columns: columns}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// value returns the value of a column of an issue, as a string, a number or a list of comments
+func value(issue models.IssueResponse, column string) interface{} {
+	switch column {
+	case "id":
+		return issue.ID
+	case "summary":
+		return issue.Summary
+	case "description":
+		return issue.Description
+	case "status":
+		return issue.Status
+	case "priority":
+		return issue.Priority
+	case "assignee":
+		return issue.Assignee
+	case "reporter":
+		return issue.Reporter
+	case "project":
+		return issue.Project
+	case "type":
+		return issue.Type
+	case "createDate":
+		return issue.CreateDate
+	case "dueDate":
+		return issue.DueDate
+	case "respondedDate":
+		return issue.RespondedDate
+	case "resolvedDate":
+		return issue.ResolvedDate
+	case "originalEstimate":
+		return issue.OriginalEstimate
+	case "remainingEstimate":
+		return issue.RemainingEstimate
+	case "timeSpent":
+		return issue.TimeSpent
+	case commentsColumn:
+		comments := make([]string, 0, len(issue.Comments))
+		for _, comment := range issue.Comments {
+			if comment.Comment != "" {
+				comments = append(comments, comment.Comment)
+			}
+		}
+		return comments
+	default:
+		return ""
+	}
+}
+
+// text returns the value of a column of an issue as a cell, comments being separated by blank lines
+func text(issue models.IssueResponse, column string) string {
+	switch v := value(issue, column).(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []string:
+		return strings.Join(v, "\n\n")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func flush(w io.Writer) {
+	if flusher, ok := w.(Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type csvWriter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	columns []string
+	rows    int
+}
+
+func newCSVWriter(w io.Writer, columns []string) *csvWriter {
+	writer := &csvWriter{w: w, csv: csv.NewWriter(w), columns: columns}
+	_ = writer.csv.Write(columns)
+
+	return writer
+}
+
+func (writer *csvWriter) Write(issue models.IssueResponse) error {
+	record := make([]string, 0, len(writer.columns))
+	for _, column := range writer.columns {
+		record = append(record, text(issue, column))
+	}
+
+	if err := writer.csv.Write(record); err != nil {
+		return err
+	}
+
+	writer.rows++
+	if writer.rows%flushEvery == 0 {
+		writer.csv.Flush()
+		flush(writer.w)
+	}
+
+	return writer.csv.Error()
+}
+
+func (writer *csvWriter) Close() error {
+	writer.csv.Flush()
+	flush(writer.w)
+
+	return writer.csv.Error()
+}
+
+type ndjsonWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	columns []string
+	rows    int
+}
+
+func (writer *ndjsonWriter) Write(issue models.IssueResponse) error {
+	object := make(map[string]interface{}, len(writer.columns))
+	for _, column := range writer.columns {
+		object[column] = value(issue, column)
+	}
+
+	if err := writer.encoder.Encode(object); err != nil {
+		return err
+	}
+
+	writer.rows++
+	if writer.rows%flushEvery == 0 {
+		flush(writer.w)
+	}
+
+	return nil
+}
+
+func (writer *ndjsonWriter) Close() error {
+	flush(writer.w)
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var testIssues = []models.IssueResponse{
+	{ID: 1, Summary: "Login fails", Description: "Since \"this\" morning, <b>", Status: "open", Priority: 2,
+		Comments: []models.Comment{{ID: 1, Comment: "Can't reproduce"}, {ID: 2, Comment: "Happens on Safari"}}},
+	{ID: 2, Summary: "Export to PDF", Status: "closed", Priority: 3, Comments: []models.Comment{}},
+}
+
+func exportTo(t *testing.T, format string, columns []string) []byte {
+	buffer := &bytes.Buffer{}
+
+	writer, err := NewWriter(format, buffer, columns)
+	assert.Nil(t, err)
+	for _, issue := range testIssues {
+		assert.Nil(t, writer.Write(issue))
+	}
+	assert.Nil(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("", false)
+	assert.Nil(t, err)
+	assert.Equal(t, Columns, columns)
+
+	columns, err = ParseColumns("ID, summary,DueDate", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id", "summary", "dueDate", "comments"}, columns)
+
+	_, err = ParseColumns("id,sla", false)
+	assert.EqualError(t, err, "unknown column sla")
+}
+
+func TestCSV(t *testing.T) {
+	file := exportTo(t, FormatCSV, []string{"id", "description", "priority", "comments"})
+
+	assert.Equal(t, "id,description,priority,comments\n"+
+		"1,\"Since \"\"this\"\" morning, <b>\",2,\"Can't reproduce\n\nHappens on Safari\"\n"+
+		"2,,3,\n", string(file))
+}
+
+func TestNDJSON(t *testing.T) {
+	file := exportTo(t, FormatNDJSON, []string{"id", "status", "comments"})
+
+	assert.Equal(t, `{"comments":["Can't reproduce","Happens on Safari"],"id":1,"status":"open"}`+"\n"+
+		`{"comments":[],"id":2,"status":"closed"}`+"\n", string(file))
+}
+
+func TestXLSX(t *testing.T) {
+	file := exportTo(t, FormatXLSX, []string{"id", "summary", "description"})
+
+	archive, err := zip.NewReader(bytes.NewReader(file), int64(len(file)))
+	assert.Nil(t, err)
+
+	parts := make(map[string]string)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		assert.Nil(t, err)
+		content, _ := ioutil.ReadAll(r)
+		_ = r.Close()
+		parts[f.Name] = string(content)
+	}
+
+	assert.Contains(t, parts, "[Content_Types].xml")
+	assert.Contains(t, parts, "xl/workbook.xml")
+
+	var sheet struct {
+		Rows []struct {
+			Ref   string `xml:"r,attr"`
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	assert.Nil(t, xml.NewDecoder(strings.NewReader(parts["xl/worksheets/sheet1.xml"])).Decode(&sheet))
+
+	assert.Len(t, sheet.Rows, 3)
+	assert.Equal(t, "summary", sheet.Rows[0].Cells[1].Inline)
+	assert.Equal(t, "B2", sheet.Rows[1].Cells[1].Ref)
+	assert.Equal(t, "1", sheet.Rows[1].Cells[0].Value, "numbers are written as numbers")
+	assert.Equal(t, "Since \"this\" morning, <b>", sheet.Rows[1].Cells[2].Inline)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "BA", columnName(52))
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewWriter("pdf", &bytes.Buffer{}, Columns)
+	assert.Equal(t, ErrUnknownFormat, err)
+}
@@ -0,0 +1,164 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/YAITS/api/models"
+)
+
+// maxCellLength is the number of characters a spreadsheet cell holds at most
+const maxCellLength = 32767
+
+// xlsxParts are the parts of a workbook holding a single sheet, written before the sheet itself
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Issues" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxWriter writes a workbook as a zip archive streamed to w, the sheet being the last part so that rows are written
+// as they come with inline strings rather than a shared strings table needing every value upfront
+type xlsxWriter struct {
+	w       io.Writer
+	zip     *zip.Writer
+	sheet   io.Writer
+	columns []string
+	rows    int
+}
+
+func newXLSXWriter(w io.Writer, columns []string) (*xlsxWriter, error) {
+	writer := &xlsxWriter{w: w, zip: zip.NewWriter(w), columns: columns}
+
+	for _, part := range xlsxParts {
+		f, err := writer.zip.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := writer.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	writer.sheet = sheet
+
+	_, err = io.WriteString(sheet, xml.Header+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column)
+	}
+
+	return writer, writer.writeRow(header)
+}
+
+func (writer *xlsxWriter) Write(issue models.IssueResponse) error {
+	cells := make([]interface{}, 0, len(writer.columns))
+	for _, column := range writer.columns {
+		if v, ok := value(issue, column).(int64); ok {
+			cells = append(cells, v)
+		} else {
+			cells = append(cells, text(issue, column))
+		}
+	}
+
+	if err := writer.writeRow(cells); err != nil {
+		return err
+	}
+
+	if writer.rows%flushEvery == 0 {
+		if err := writer.zip.Flush(); err != nil {
+			return err
+		}
+		flush(writer.w)
+	}
+
+	return nil
+}
+
+func (writer *xlsxWriter) Close() error {
+	if _, err := io.WriteString(writer.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	err := writer.zip.Close()
+	flush(writer.w)
+
+	return err
+}
+
+// writeRow writes a row of numbers and inline strings, cells being referenced as A1, B1...
+func (writer *xlsxWriter) writeRow(cells []interface{}) error {
+	writer.rows++
+	row := strconv.Itoa(writer.rows)
+
+	if _, err := io.WriteString(writer.sheet, `<row r="`+row+`">`); err != nil {
+		return err
+	}
+
+	for i, cell := range cells {
+		ref := columnName(i) + row
+
+		var err error
+		switch v := cell.(type) {
+		case int64:
+			_, err = io.WriteString(writer.sheet, `<c r="`+ref+`"><v>`+strconv.FormatInt(v, 10)+`</v></c>`)
+		default:
+			s := v.(string)
+			if runes := []rune(s); len(runes) > maxCellLength {
+				s = string(runes[:maxCellLength])
+			}
+
+			if _, err = io.WriteString(writer.sheet, `<c r="`+ref+`" t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+				return err
+			}
+			if err = xml.EscapeText(writer.sheet, []byte(s)); err != nil {
+				return err
+			}
+			_, err = io.WriteString(writer.sheet, `</t></is></c>`)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(writer.sheet, `</row>`)
+
+	return err
+}
+
+// columnName returns the name of the column of a 0-based index: A to Z, then AA, AB...
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+)
+
+// maxJobErrors bounds the row errors kept in an import job
+const maxJobErrors = 1000
+
+// Importer creates imported issues in the background, a chunk of issues per transaction so that a failing row only
+// rolls its own chunk back, and records the progress of the import job as chunks get committed
+type Importer struct {
+	storage persistence.Storage
+	logger  *zap.SugaredLogger
+
+	ChunkSize int
+}
+
+// NewImporter creates an Importer committing 100 issues at a time by default
+func NewImporter(storage persistence.Storage, logger *zap.SugaredLogger) *Importer {
+	return &Importer{
+		storage:   storage,
+		logger:    logger.With("worker", "importer"),
+		ChunkSize: 100,
+	}
+}
+
+// Start records an import job and imports its issues in the background, the rows of the job already rejected being
+// counted as processed and failed
+func (im *Importer) Start(job models.ImportJob, issues []models.ImportIssue) (models.ImportJob, error) {
+	job.Status = persistence.ImportJobQueued
+	job.Errors = capErrors(job.Errors)
+
+	id, err := im.storage.CreateImportJob(job)
+	if err != nil {
+		return job, err
+	}
+	job.ID = id
+
+	go im.Run(job, issues)
+
+	return job, nil
+}
+
+// Run imports the issues of a job chunk by chunk, the job failing when none of them could be created
+func (im *Importer) Run(job models.ImportJob, issues []models.ImportIssue) models.ImportJob {
+	l := im.logger.With("job", job.ID)
+
+	job.Status = persistence.ImportJobRunning
+	im.update(l, job)
+
+	chunkSize := im.ChunkSize
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	for start := 0; start < len(issues); start += chunkSize {
+		end := start + chunkSize
+		if end > len(issues) {
+			end = len(issues)
+		}
+		chunk := issues[start:end]
+
+		ids, err := im.storage.ImportIssues(chunk)
+		if err != nil {
+			l.Errorf("couldn't import rows %d to %d: %s", chunk[0].Row, chunk[len(chunk)-1].Row, err.Error())
+			job.Failed += int64(len(chunk))
+			for _, issue := range chunk {
+				job.Errors = append(job.Errors, models.ImportRowError{Row: issue.Row, Errors: []string{err.Error()}})
+			}
+			job.Errors = capErrors(job.Errors)
+		} else {
+			job.Created += int64(len(ids))
+		}
+
+		job.Processed += int64(len(chunk))
+		im.update(l, job)
+	}
+
+	job.Status = persistence.ImportJobCompleted
+	if job.Created == 0 && job.Failed > 0 {
+		job.Status = persistence.ImportJobFailed
+	}
+	im.update(l, job)
+
+	l.Infof("import %s: %d issues created, %d rows failed", job.Status, job.Created, job.Failed)
+	return job
+}
+
+func (im *Importer) update(l *zap.SugaredLogger, job models.ImportJob) {
+	if err := im.storage.UpdateImportJob(job); err != nil {
+		l.Errorf("couldn't record import progress: %s", err.Error())
+	}
+}
+
+func capErrors(rowErrors []models.ImportRowError) []models.ImportRowError {
+	if len(rowErrors) > maxJobErrors {
+		return rowErrors[:maxJobErrors]
+	}
+
+	return rowErrors
+}
@@ -0,0 +1,167 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	mock "github.com/YAITS/api/persistence/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStorage fails to import the chunks holding a given row and records the progress of the job
+type failingStorage struct {
+	*mock.Storage
+	failingRow int64
+	jobs       []models.ImportJob
+}
+
+func (storage *failingStorage) ImportIssues(issues []models.ImportIssue) ([]int64, error) {
+	for _, issue := range issues {
+		if issue.Row == storage.failingRow {
+			return nil, errors.New("data too long for column summary")
+		}
+	}
+	return storage.Storage.ImportIssues(issues)
+}
+
+func (storage *failingStorage) UpdateImportJob(job models.ImportJob) error {
+	storage.jobs = append(storage.jobs, job)
+	return nil
+}
+
+func TestReadCSV(t *testing.T) {
+	file := "Title,Body,Priority,State,Owner,Component\n" +
+		"Login fails,Since this morning,high,Open,jdoe,auth\n" +
+		",No summary,2,open,,\n" +
+		"\"Crash on save\",\"Multi\nline\",12,shipped,,editor\n"
+
+	options := models.ImportOptions{
+		Format:     FormatCSV,
+		Project:    "API",
+		Columns:    map[string]string{"summary": "Title", "description": "Body", "status": "State", "assignee": "Owner", "fields.component": "Component"},
+		Users:      map[string]string{"JDoe": "John Doe"},
+		Priorities: map[string]int64{"High": 2},
+	}
+
+	issues, rowErrors, err := Read(strings.NewReader(file), options)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []models.ImportIssue{{Row: 2, Status: "open", NewIssueRequest: models.NewIssueRequest{
+		Summary: "Login fails", Description: "Since this morning", Priority: 2, Assignee: "John Doe", Project: "API",
+		Fields: map[string]string{"component": "auth"},
+	}}}, issues)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 3, Errors: []string{"summary is required"}},
+		{Row: 4, Errors: []string{"priority 12 is out of the 1 to 10 range", "unknown status shipped"}},
+	}, rowErrors)
+
+	_, _, err = Read(strings.NewReader(file), models.ImportOptions{Format: FormatCSV,
+		Columns: map[string]string{"summary": "Name"}})
+	assert.EqualError(t, err, "column Name not found")
+
+	_, _, err = Read(strings.NewReader(file), models.ImportOptions{Format: "xml"})
+	assert.Equal(t, ErrUnknownFormat, err)
+}
+
+func TestReadJira(t *testing.T) {
+	file := "\ufeffSummary,Issue key,Issue Type,Status,Project key,Priority,Assignee,Reporter,Due Date,Original Estimate,Description\n" +
+		"Login fails,API-12,Bug,In Progress,API,Highest,jdoe,asmith,21/Oct/26 5:00 PM,7200,Since this morning\n" +
+		"Export to PDF,API-13,Story,Done,API,Medium,,asmith,,,\n" +
+		"Dark mode,API-14,Story,Waiting for QA,API,Medium,,asmith,someday,,\n"
+
+	issues, rowErrors, err := Read(strings.NewReader(file), models.ImportOptions{Format: FormatJira})
+	assert.Nil(t, err)
+
+	dueDate := time.Date(2026, time.October, 21, 17, 0, 0, 0, time.UTC)
+	assert.Len(t, issues, 2)
+	assert.Equal(t, models.ImportIssue{Row: 2, Status: "in progress", NewIssueRequest: models.NewIssueRequest{
+		Summary: "Login fails", Description: "Since this morning", Priority: 1, Assignee: "jdoe", Reporter: "asmith",
+		Project: "API", Type: "Bug", DueDate: &dueDate, OriginalEstimate: 120,
+	}}, issues[0], "jira estimates are given in seconds")
+	assert.Equal(t, "closed", issues[1].Status)
+	assert.Equal(t, int64(3), issues[1].Priority)
+
+	assert.Equal(t, []models.ImportRowError{{Row: 4, Errors: []string{"unknown status Waiting for QA",
+		"invalid due date someday"}}}, rowErrors)
+}
+
+func TestReadNDJSON(t *testing.T) {
+	file := `{"summary":"Login fails","priority":2,"status":"closed","originalEstimate":90,"fields":{"component":"auth"}}
+
+{"summary":"Crash on save","priority":"urgent"}
+{"summary":`
+
+	issues, rowErrors, err := Read(strings.NewReader(file), models.ImportOptions{Format: FormatNDJSON,
+		Priorities: map[string]int64{"urgent": 1}})
+	assert.Nil(t, err)
+
+	assert.Len(t, issues, 2)
+	assert.Equal(t, models.ImportIssue{Row: 1, Status: "closed", NewIssueRequest: models.NewIssueRequest{
+		Summary: "Login fails", Priority: 2, OriginalEstimate: 90, Fields: map[string]string{"component": "auth"},
+	}}, issues[0])
+	assert.Equal(t, int64(3), issues[1].Row)
+	assert.Equal(t, int64(1), issues[1].Priority)
+
+	assert.Len(t, rowErrors, 1)
+	assert.Equal(t, int64(4), rowErrors[0].Row, "invalid lines are rejected on their own")
+}
+
+func TestReadGitHub(t *testing.T) {
+	file := `[
+		{"number":12,"title":"Login fails","body":"Since this morning","state":"open","user":{"login":"asmith"},
+			"assignee":{"login":"jdoe"},"labels":[{"name":"bug"},{"name":"P1"}],"milestone":{"due_on":"2026-10-21T17:00:00Z"}},
+		{"number":13,"title":"Fix login","state":"closed","user":{"login":"jdoe"},"pull_request":{"url":"https://api.github.com/repos/yaits/api/pulls/13"}},
+		{"number":14,"title":"Export to PDF","state":"closed","user":{"login":"asmith"},"assignee":null,"labels":[]}
+	]`
+
+	issues, rowErrors, err := Read(strings.NewReader(file), models.ImportOptions{Format: FormatGitHub,
+		Users: map[string]string{"jdoe": "John Doe"}, Priorities: map[string]int64{"p1": 1}})
+	assert.Nil(t, err)
+	assert.Empty(t, rowErrors)
+
+	dueDate := time.Date(2026, time.October, 21, 17, 0, 0, 0, time.UTC)
+	assert.Equal(t, []models.ImportIssue{
+		{Row: 1, Status: "open", NewIssueRequest: models.NewIssueRequest{Summary: "Login fails",
+			Description: "Since this morning", Priority: 1, Assignee: "John Doe", Reporter: "asmith", DueDate: &dueDate}},
+		{Row: 3, Status: "closed", NewIssueRequest: models.NewIssueRequest{Summary: "Export to PDF",
+			Reporter: "asmith"}},
+	}, issues, "pull requests are skipped and labels give priorities")
+
+	_, _, err = Read(strings.NewReader(`{"message":"Not Found"}`), models.ImportOptions{Format: FormatGitHub})
+	assert.NotNil(t, err)
+}
+
+func TestImporter_Run(t *testing.T) {
+	storage := &failingStorage{Storage: mock.NewMockStorage(), failingRow: 4}
+	im := NewImporter(storage, zap.NewNop().Sugar())
+	im.ChunkSize = 2
+
+	issues := make([]models.ImportIssue, 0)
+	for row := int64(2); row <= 6; row++ {
+		issues = append(issues, models.ImportIssue{Row: row})
+	}
+
+	job := im.Run(models.ImportJob{ID: 1, Total: 6, Processed: 1, Failed: 1,
+		Errors: []models.ImportRowError{{Row: 7, Errors: []string{"summary is required"}}}}, issues)
+
+	assert.Equal(t, persistence.ImportJobCompleted, job.Status)
+	assert.Equal(t, int64(6), job.Processed)
+	assert.Equal(t, int64(3), job.Created)
+	assert.Equal(t, int64(3), job.Failed, "the rows of a failing chunk are rolled back together")
+	assert.Equal(t, []int64{7, 4, 5}, []int64{job.Errors[0].Row, job.Errors[1].Row, job.Errors[2].Row})
+
+	// running, one update per chunk, then completed
+	assert.Len(t, storage.jobs, 5)
+	assert.Equal(t, persistence.ImportJobRunning, storage.jobs[0].Status)
+	assert.Equal(t, int64(3), storage.jobs[1].Processed)
+
+	storage.failingRow = 2
+	job = im.Run(models.ImportJob{ID: 2, Total: 1}, issues[:1])
+	assert.Equal(t, persistence.ImportJobFailed, job.Status)
+}
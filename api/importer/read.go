@@ -0,0 +1,546 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// Formats of the files issues are imported from: github is the JSON array returned by the issues API of a repository
+// and jira the csv export of a Jira search
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatGitHub = "github"
+	FormatJira   = "jira"
+)
+
+// Limits of the issue columns, rows exceeding them being rejected rather than truncated
+const (
+	maxSummaryLength     = 64
+	maxDescriptionLength = 256
+	maxUserLength        = 64
+	maxProjectLength     = 16
+	maxTypeLength        = 32
+	maxPriority          = 10
+)
+
+// maxLineSize bounds the size of an ndjson line
+const maxLineSize = 1 << 20
+
+// ErrUnknownFormat is returned reading a file of a format other than csv, ndjson, github or jira
+var ErrUnknownFormat = errors.New("format must be one of csv, ndjson, github or jira")
+
+// issueColumns are the issue fields a csv column can be mapped to, along with fields.<name> for custom fields
+var issueColumns = []string{"summary", "description", "priority", "status", "assignee", "reporter", "project", "type",
+	"dueDate", "originalEstimate"}
+
+// jiraColumns are the columns of a Jira csv export
+var jiraColumns = map[string]string{
+	"summary":          "Summary",
+	"description":      "Description",
+	"priority":         "Priority",
+	"status":           "Status",
+	"assignee":         "Assignee",
+	"reporter":         "Reporter",
+	"project":          "Project key",
+	"type":             "Issue Type",
+	"dueDate":          "Due Date",
+	"originalEstimate": "Original Estimate",
+}
+
+// statuses are the statuses of an issue
+var statuses = []string{"open", "in progress", "closed"}
+
+// defaultStatuses map the statuses of the imported files to the ones of YAITS, Jira workflows included
+var defaultStatuses = map[string]string{
+	"open":                     "open",
+	"in progress":              "in progress",
+	"closed":                   "closed",
+	"to do":                    "open",
+	"backlog":                  "open",
+	"selected for development": "open",
+	"reopened":                 "open",
+	"in review":                "in progress",
+	"done":                     "closed",
+	"resolved":                 "closed",
+}
+
+// jiraPriorities map the default priorities of Jira, old and new schemes, to priorities
+var jiraPriorities = map[string]int64{
+	"highest":  1,
+	"blocker":  1,
+	"high":     2,
+	"critical": 2,
+	"medium":   3,
+	"major":    3,
+	"low":      4,
+	"minor":    4,
+	"lowest":   5,
+	"trivial":  5,
+}
+
+// dateLayouts are the layouts due dates are read with, the last ones being the ones of Jira exports
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "02/Jan/06 3:04 PM", "02/Jan/06"}
+
+// record is an issue as found in a file, before its values are mapped and validated
+type record struct {
+	row               int64
+	values            map[string]string
+	fields            map[string]string
+	labels            []string
+	estimateInSeconds bool
+	err               error
+}
+
+// ValidFormat tells whether issues can be imported from files of a format
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatCSV, FormatNDJSON, FormatGitHub, FormatJira:
+		return true
+	default:
+		return false
+	}
+}
+
+// Read reads the issues of a file, returning the ones that can be imported along with what prevents the others from
+// being imported. An error is only returned when the file cannot be read at all
+func Read(r io.Reader, options models.ImportOptions) ([]models.ImportIssue, []models.ImportRowError, error) {
+	var records []record
+	var err error
+
+	switch options.Format {
+	case FormatCSV:
+		records, err = readCSV(r, options.Columns, nil)
+	case FormatJira:
+		records, err = readCSV(r, options.Columns, jiraColumns)
+	case FormatNDJSON:
+		records, err = readNDJSON(r)
+	case FormatGitHub:
+		records, err = readGitHub(r)
+	default:
+		err = ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := newMapper(options)
+	issues := make([]models.ImportIssue, 0, len(records))
+	rowErrors := make([]models.ImportRowError, 0)
+
+	for _, rec := range records {
+		issue, problems := m.issue(rec)
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: rec.row, Errors: problems})
+			continue
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, rowErrors, nil
+}
+
+// readCSV reads a csv file with a header, columns mapping issue fields to the columns of the file. Fields are read
+// from the columns of the same name unless defaults tell otherwise, a missing column only being an error when
+// explicitly mapped
+func readCSV(r io.Reader, columns, defaults map[string]string) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		// Jira repeats columns holding several values, such as labels, the first one being kept
+		if _, ok := index[column]; !ok {
+			index[column] = i
+		}
+	}
+
+	mapping := make(map[string]int)
+	for _, field := range issueColumns {
+		column := field
+		if defaults != nil {
+			column = defaults[field]
+		}
+		if i, ok := index[strings.ToLower(column)]; ok {
+			mapping[field] = i
+		}
+	}
+	for field, column := range columns {
+		if !contains(issueColumns, field) && (!strings.HasPrefix(field, "fields.") || field == "fields.") {
+			return nil, fmt.Errorf("unknown field %s", field)
+		}
+
+		i, ok := index[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return nil, fmt.Errorf("column %s not found", column)
+		}
+		mapping[field] = i
+	}
+
+	records := make([]record, 0)
+	for row := int64(2); ; row++ {
+		line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// only Jira exports, read with default columns, give estimates in seconds
+		rec := record{row: row, values: map[string]string{}, fields: map[string]string{},
+			estimateInSeconds: defaults != nil}
+		for field, i := range mapping {
+			if i >= len(line) || strings.TrimSpace(line[i]) == "" {
+				continue
+			}
+
+			if strings.HasPrefix(field, "fields.") {
+				rec.fields[strings.TrimPrefix(field, "fields.")] = strings.TrimSpace(line[i])
+			} else {
+				rec.values[field] = strings.TrimSpace(line[i])
+			}
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// ndjsonIssue is an issue of an ndjson file, priorities being numbers or names to map and estimates being minutes
+type ndjsonIssue struct {
+	Summary          string            `json:"summary"`
+	Description      string            `json:"description"`
+	Priority         json.RawMessage   `json:"priority"`
+	Status           string            `json:"status"`
+	Assignee         string            `json:"assignee"`
+	Reporter         string            `json:"reporter"`
+	Project          string            `json:"project"`
+	Type             string            `json:"type"`
+	DueDate          string            `json:"dueDate"`
+	OriginalEstimate json.RawMessage   `json:"originalEstimate"`
+	Fields           map[string]string `json:"fields"`
+}
+
+// readNDJSON reads a file holding an issue per line, lines that are not valid JSON being rejected on their own
+func readNDJSON(r io.Reader) ([]record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	records := make([]record, 0)
+	for row := int64(1); scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var issue ndjsonIssue
+		if err := json.Unmarshal([]byte(line), &issue); err != nil {
+			records = append(records, record{row: row, err: fmt.Errorf("invalid JSON: %s", err.Error())})
+			continue
+		}
+
+		fields := issue.Fields
+		if fields == nil {
+			fields = map[string]string{}
+		}
+
+		records = append(records, record{row: row, fields: fields, values: map[string]string{
+			"summary":          issue.Summary,
+			"description":      issue.Description,
+			"priority":         rawString(issue.Priority),
+			"status":           issue.Status,
+			"assignee":         issue.Assignee,
+			"reporter":         issue.Reporter,
+			"project":          issue.Project,
+			"type":             issue.Type,
+			"dueDate":          issue.DueDate,
+			"originalEstimate": rawString(issue.OriginalEstimate),
+		}})
+	}
+
+	return records, scanner.Err()
+}
+
+// githubIssue is an issue as returned by the GitHub issues API, which also lists pull requests
+type githubIssue struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	State string `json:"state"`
+	User  struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Assignee *struct {
+		Login string `json:"login"`
+	} `json:"assignee"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Milestone *struct {
+		DueOn string `json:"due_on"`
+	} `json:"milestone"`
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+// readGitHub reads an array of GitHub issues, pull requests being skipped and labels being matched against
+// priorities
+func readGitHub(r io.Reader) ([]record, error) {
+	var issues []githubIssue
+	if err := json.NewDecoder(r).Decode(&issues); err != nil {
+		return nil, fmt.Errorf("not a JSON array of GitHub issues: %s", err.Error())
+	}
+
+	records := make([]record, 0, len(issues))
+	for i, issue := range issues {
+		if len(issue.PullRequest) > 0 && string(issue.PullRequest) != "null" {
+			continue
+		}
+
+		rec := record{row: int64(i + 1), fields: map[string]string{}, values: map[string]string{
+			"summary":     issue.Title,
+			"description": issue.Body,
+			"status":      issue.State,
+			"reporter":    issue.User.Login,
+		}}
+		if issue.Assignee != nil {
+			rec.values["assignee"] = issue.Assignee.Login
+		}
+		if issue.Milestone != nil {
+			rec.values["dueDate"] = issue.Milestone.DueOn
+		}
+		for _, label := range issue.Labels {
+			rec.labels = append(rec.labels, label.Name)
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// mapper maps the values of the imported files through the tables of the import options, lookups ignoring case
+type mapper struct {
+	options    models.ImportOptions
+	users      map[string]string
+	statuses   map[string]string
+	priorities map[string]int64
+}
+
+func newMapper(options models.ImportOptions) *mapper {
+	m := &mapper{
+		options:    options,
+		users:      map[string]string{},
+		statuses:   map[string]string{},
+		priorities: map[string]int64{},
+	}
+
+	for from, to := range defaultStatuses {
+		m.statuses[from] = to
+	}
+	if options.Format == FormatJira {
+		for from, to := range jiraPriorities {
+			m.priorities[from] = to
+		}
+	}
+
+	for from, to := range options.Users {
+		m.users[strings.ToLower(from)] = to
+	}
+	for from, to := range options.Statuses {
+		m.statuses[strings.ToLower(from)] = to
+	}
+	for from, to := range options.Priorities {
+		m.priorities[strings.ToLower(from)] = to
+	}
+
+	return m
+}
+
+// issue maps and validates a record, returning every problem found rather than the first one
+func (m *mapper) issue(rec record) (models.ImportIssue, []string) {
+	if rec.err != nil {
+		return models.ImportIssue{}, []string{rec.err.Error()}
+	}
+
+	problems := make([]string, 0)
+	value := func(field string) string {
+		return strings.TrimSpace(rec.values[field])
+	}
+
+	issue := models.ImportIssue{Row: rec.row, NewIssueRequest: models.NewIssueRequest{
+		Summary:     value("summary"),
+		Description: value("description"),
+		Assignee:    m.user(value("assignee")),
+		Reporter:    m.user(value("reporter")),
+		Project:     value("project"),
+		Type:        value("type"),
+	}}
+	if len(rec.fields) > 0 {
+		issue.Fields = rec.fields
+	}
+	if m.options.Project != "" {
+		issue.Project = m.options.Project
+	}
+	if m.options.Type != "" {
+		issue.Type = m.options.Type
+	}
+
+	if issue.Summary == "" {
+		problems = append(problems, "summary is required")
+	}
+	problems = checkLength(problems, "summary", issue.Summary, maxSummaryLength)
+	problems = checkLength(problems, "description", issue.Description, maxDescriptionLength)
+	problems = checkLength(problems, "assignee", issue.Assignee, maxUserLength)
+	problems = checkLength(problems, "reporter", issue.Reporter, maxUserLength)
+	problems = checkLength(problems, "project", issue.Project, maxProjectLength)
+	problems = checkLength(problems, "type", issue.Type, maxTypeLength)
+
+	var err error
+	if issue.Priority, err = m.priority(value("priority"), rec.labels); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if issue.Status, err = m.status(value("status")); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if issue.DueDate, err = parseDate(value("dueDate")); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if issue.OriginalEstimate, err = parseEstimate(value("originalEstimate"), rec.estimateInSeconds); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	return issue, problems
+}
+
+func (m *mapper) user(user string) string {
+	if mapped, ok := m.users[strings.ToLower(user)]; ok {
+		return mapped
+	}
+
+	return user
+}
+
+// priority reads a priority given as a number or as a name to map, or else from the first label mapped to one
+func (m *mapper) priority(priority string, labels []string) (int64, error) {
+	if priority == "" {
+		for _, label := range labels {
+			if mapped, ok := m.priorities[strings.ToLower(label)]; ok {
+				return mapped, nil
+			}
+		}
+		return 0, nil
+	}
+
+	mapped, ok := m.priorities[strings.ToLower(priority)]
+	if !ok {
+		var err error
+		if mapped, err = strconv.ParseInt(priority, 10, 64); err != nil {
+			return 0, fmt.Errorf("unknown priority %s", priority)
+		}
+	}
+
+	if mapped < 1 || mapped > maxPriority {
+		return 0, fmt.Errorf("priority %s is out of the 1 to %d range", priority, maxPriority)
+	}
+
+	return mapped, nil
+}
+
+func (m *mapper) status(status string) (string, error) {
+	if status == "" {
+		return "", nil
+	}
+
+	mapped, ok := m.statuses[strings.ToLower(status)]
+	if !ok {
+		return "", fmt.Errorf("unknown status %s", status)
+	}
+	if !contains(statuses, mapped) {
+		return "", fmt.Errorf("status %s is mapped to %s, which is not a status", status, mapped)
+	}
+
+	return mapped, nil
+}
+
+func parseDate(date string) (*time.Time, error) {
+	if date == "" {
+		return nil, nil
+	}
+
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			parsed = parsed.UTC()
+			return &parsed, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid due date %s", date)
+}
+
+// parseEstimate reads an estimate in minutes, or in seconds as Jira exports them
+func parseEstimate(estimate string, seconds bool) (int64, error) {
+	if estimate == "" {
+		return 0, nil
+	}
+
+	minutes, err := strconv.ParseInt(estimate, 10, 64)
+	if err != nil || minutes < 0 {
+		return 0, fmt.Errorf("invalid original estimate %s", estimate)
+	}
+	if seconds {
+		minutes /= 60
+	}
+
+	return minutes, nil
+}
+
+func checkLength(problems []string, field, value string, max int) []string {
+	if length := len([]rune(value)); length > max {
+		return append(problems, fmt.Sprintf("%s is %d characters long, over the limit of %d", field, length, max))
+	}
+
+	return problems
+}
+
+// rawString reads a JSON value given either as a string or as a number
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+
+	return string(raw)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
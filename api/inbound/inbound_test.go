@@ -0,0 +1,323 @@
+package inbound
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	mock "github.com/YAITS/api/persistence/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const newThread = "From: Jane Doe <Jane.Doe@Example.com>\r\n" +
+	"To: support@example.com\r\n" +
+	"Subject: =?utf-8?q?Login_fails_=E2=80=94_again?=\r\n" +
+	"Message-Id: <thread-1@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"I can't log in since this morning =E2=80=94 the page just reloads.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>I can't log in since this morning</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"Content-Disposition: attachment; filename=\"console.log\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"RXJyb3I6IHNlc3Npb24g\r\n" +
+	"ZXhwaXJlZA==\r\n" +
+	"--outer--\r\n"
+
+const reply = "From: bob@example.com\r\n" +
+	"Subject: Re: [YAITS #42] Login fails\r\n" +
+	"Message-Id: <reply-1@example.com>\r\n" +
+	"In-Reply-To: <notification-1@example.com>\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<div>Clearing the cookies fixed it &amp; thanks!</div><br>" +
+	"On Mon, 19 Oct 2026 at 10:00, YAITS wrote:<br>&gt; Your issue was updated\r\n"
+
+// inboundStorage records the issues created and the comments made from emails
+type inboundStorage struct {
+	*mock.Storage
+
+	created     []models.NewIssueRequest
+	comments    map[int64][]string
+	events      []models.IssueEvent
+	messages    map[string]int64
+	watchers    []string
+	attachments []models.Attachment
+}
+
+func (storage *inboundStorage) CreateIssue(issue models.NewIssueRequest, events ...models.IssueEvent) (int64, error) {
+	storage.created = append(storage.created, issue)
+	storage.events = append(storage.events, events...)
+	return int64(100 + len(storage.created)), nil
+}
+
+func (storage *inboundStorage) UpdateIssue(update models.UpdateIssueRequest, issueID int64, events ...models.IssueEvent) (*models.IssueResponse, error) {
+	if issueID != 42 {
+		return nil, sql.ErrNoRows
+	}
+
+	storage.comments[issueID] = append(storage.comments[issueID], update.Comment)
+	storage.events = append(storage.events, events...)
+	return &models.IssueResponse{ID: issueID}, nil
+}
+
+func (storage *inboundStorage) RetrieveUserByEmail(email string) (string, error) {
+	if email == "bob@example.com" {
+		return "bob", nil
+	}
+	return "", sql.ErrNoRows
+}
+
+func (storage *inboundStorage) RetrieveIssueByMessageID(messageIDs ...string) (int64, error) {
+	for _, messageID := range messageIDs {
+		if issueID, ok := storage.messages[messageID]; ok {
+			return issueID, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (storage *inboundStorage) RecordInboundMessage(messageID string, issueID int64) error {
+	storage.messages[messageID] = issueID
+	return nil
+}
+
+func (storage *inboundStorage) WatchIssue(_ int64, user string) error {
+	storage.watchers = append(storage.watchers, user)
+	return nil
+}
+
+func (storage *inboundStorage) CreateAttachment(attachment models.Attachment, put func() error) (int64, error) {
+	if err := put(); err != nil {
+		return 0, err
+	}
+	storage.attachments = append(storage.attachments, attachment)
+	return int64(len(storage.attachments)), nil
+}
+
+// newProcessor creates a Processor storing attachments in a temporary directory the returned function removes
+func newProcessor(t *testing.T) (*Processor, *inboundStorage, func()) {
+	root, err := ioutil.TempDir("", "yaits-inbound")
+	if err != nil {
+		t.Fatalf("couldn't create blob root: %s", err)
+	}
+
+	store, err := blob.NewLocalStore(root)
+	if err != nil {
+		t.Fatalf("couldn't create blob store: %s", err)
+	}
+
+	storage := &inboundStorage{
+		Storage:  mock.NewMockStorage(),
+		comments: map[int64][]string{},
+		messages: map[string]int64{},
+	}
+
+	return NewProcessor(storage, store, zap.NewNop().Sugar()), storage, func() { _ = os.RemoveAll(root) }
+}
+
+func TestParse(t *testing.T) {
+	message, err := Parse(strings.NewReader(newThread))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "thread-1@example.com", message.MessageID)
+	assert.Equal(t, "jane.doe@example.com", message.From, "addresses are lower cased")
+	assert.Equal(t, "Jane Doe", message.FromName)
+	assert.Equal(t, "Login fails — again", message.Subject)
+	assert.Equal(t, "I can't log in since this morning — the page just reloads.", message.Text,
+		"the plain text body is preferred")
+	assert.Equal(t, []Attachment{{Filename: "console.log", ContentType: "text/plain",
+		Content: []byte("Error: session expired")}}, message.Attachments)
+
+	message, err = Parse(strings.NewReader(reply))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"notification-1@example.com"}, message.References)
+	assert.Equal(t, "Clearing the cookies fixed it & thanks!", StripQuoted(message.Text),
+		"html is stripped of markup and quotes")
+
+	_, err = Parse(strings.NewReader("Subject: nobody\r\n\r\nhello\r\n"))
+	assert.Equal(t, ErrNoSender, err)
+}
+
+func TestProcessor_Process(t *testing.T) {
+	t.Run("NewThread", func(t *testing.T) {
+		processor, storage, cleanup := newProcessor(t)
+		defer cleanup()
+
+		issueID, err := processor.Process(strings.NewReader(newThread))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(101), issueID)
+
+		assert.Len(t, storage.created, 1)
+		assert.Equal(t, "Login fails — again", storage.created[0].Summary)
+		assert.Equal(t, "jane.doe@example.com", storage.created[0].Reporter, "unknown senders report by address")
+		assert.Equal(t, []models.IssueEvent{{Event: events.IssueCreated, Actor: "jane.doe@example.com"}}, storage.events)
+		assert.Empty(t, storage.watchers, "unknown senders do not watch")
+
+		assert.Len(t, storage.attachments, 1)
+		assert.Equal(t, "console.log", storage.attachments[0].Filename)
+		assert.Equal(t, int64(101), storage.attachments[0].IssueID)
+
+		issueID, err = processor.Process(strings.NewReader(newThread))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(101), issueID)
+		assert.Len(t, storage.created, 1, "an email is only filed once")
+	})
+
+	t.Run("Reply", func(t *testing.T) {
+		processor, storage, cleanup := newProcessor(t)
+		defer cleanup()
+
+		issueID, err := processor.Process(strings.NewReader(reply))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(42), issueID)
+
+		assert.Empty(t, storage.created)
+		assert.Equal(t, []string{"Clearing the cookies fixed it & thanks!"}, storage.comments[42])
+		assert.Equal(t, events.IssueCommented, storage.events[0].Event)
+		assert.Equal(t, "bob", storage.events[0].Actor, "senders are mapped to users")
+		assert.Equal(t, []string{"bob"}, storage.watchers)
+	})
+
+	t.Run("ReplyByReference", func(t *testing.T) {
+		processor, storage, cleanup := newProcessor(t)
+		defer cleanup()
+		storage.messages["thread-1@example.com"] = 42
+
+		message := strings.Replace(reply, "Subject: Re: [YAITS #42] Login fails", "Subject: Re: Login fails", 1)
+		message = strings.Replace(message, "notification-1@example.com", "thread-1@example.com", 1)
+
+		issueID, err := processor.Process(strings.NewReader(message))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(42), issueID)
+		assert.Len(t, storage.comments[42], 1)
+	})
+
+	t.Run("DeletedIssue", func(t *testing.T) {
+		processor, storage, cleanup := newProcessor(t)
+		defer cleanup()
+
+		message := strings.Replace(reply, "#42", "#7", 1)
+
+		issueID, err := processor.Process(strings.NewReader(message))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(101), issueID, "replies to deleted issues are filed as new issues")
+		assert.Equal(t, "Re: Login fails", storage.created[0].Summary)
+	})
+
+	t.Run("LongText", func(t *testing.T) {
+		processor, storage, cleanup := newProcessor(t)
+		defer cleanup()
+
+		body := strings.Repeat("The page reloads. ", 20)
+		message := "From: jane.doe@example.com\r\nSubject: Login\r\n\r\n" + body + "\r\n"
+
+		_, err := processor.Process(strings.NewReader(message))
+		assert.Nil(t, err)
+		assert.Len(t, []rune(storage.created[0].Description), maxDescriptionLength)
+		assert.Len(t, storage.attachments, 1, "the full text is attached")
+		assert.Equal(t, fullTextFilename, storage.attachments[0].Filename)
+	})
+
+	t.Run("AutoSubmitted", func(t *testing.T) {
+		processor, storage, cleanup := newProcessor(t)
+		defer cleanup()
+
+		message := "From: mailer-daemon@example.com\r\nAuto-Submitted: auto-replied\r\nSubject: Out of office\r\n\r\nAway\r\n"
+
+		issueID, err := processor.Process(strings.NewReader(message))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), issueID)
+		assert.Empty(t, storage.created, "automatic emails are ignored")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		processor, _, cleanup := newProcessor(t)
+		defer cleanup()
+
+		_, err := processor.Process(strings.NewReader("not an email"))
+		assert.True(t, Permanent(err))
+	})
+}
+
+func TestMaildirPoller_Poll(t *testing.T) {
+	processor, storage, cleanup := newProcessor(t)
+	defer cleanup()
+
+	dir, err := ioutil.TempDir("", "yaits-maildir")
+	if err != nil {
+		t.Fatalf("couldn't create maildir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	poller, err := NewMaildirPoller(dir, processor, zap.NewNop().Sugar(), 0)
+	assert.Nil(t, err)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "new", "1.host"), []byte(newThread), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "new", "2.host"), []byte("not an email"), 0600))
+
+	filed, err := poller.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, filed)
+	assert.Len(t, storage.created, 1)
+
+	remaining, _ := ioutil.ReadDir(filepath.Join(dir, "new"))
+	assert.Empty(t, remaining)
+	_, err = os.Stat(filepath.Join(dir, "cur", "1.host"+seenFlag))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "cur", "2.host"+trashedFlag))
+	assert.Nil(t, err, "invalid messages are trashed")
+}
+
+func TestSMTPServer(t *testing.T) {
+	processor, storage, cleanup := newProcessor(t)
+	defer cleanup()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("couldn't listen: %s", err)
+	}
+
+	server := NewSMTPServer(processor, zap.NewNop().Sugar(), "localhost")
+	server.Recipients = []string{"support@example.com"}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	address := listener.Addr().String()
+
+	err = smtp.SendMail(address, nil, "jane.doe@example.com", []string{"support@example.com"}, []byte(newThread))
+	assert.Nil(t, err)
+	assert.Len(t, storage.created, 1)
+	assert.Len(t, storage.attachments, 1)
+
+	err = smtp.SendMail(address, nil, "jane.doe@example.com", []string{"sales@example.com"}, []byte(newThread))
+	assert.NotNil(t, err, "unknown recipients are refused")
+
+	err = smtp.SendMail(address, nil, "jane.doe@example.com", []string{"support@example.com"}, []byte("not an email"))
+	assert.NotNil(t, err, "invalid messages are rejected")
+}
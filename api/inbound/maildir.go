@@ -0,0 +1,106 @@
+package inbound
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Maildir info suffixes of processed messages, marked as seen, and of the ones that could not be processed, marked as
+// trashed for an operator to look into
+const (
+	seenFlag    = ":2,S"
+	trashedFlag = ":2,T"
+)
+
+// MaildirPoller periodically files the new messages of a maildir, such as one a mail server delivers support@ to,
+// moving them to cur once processed
+type MaildirPoller struct {
+	dir       string
+	processor *Processor
+	logger    *zap.SugaredLogger
+	interval  time.Duration
+}
+
+// NewMaildirPoller creates a MaildirPoller of the maildir at dir, creating its directories if needed
+func NewMaildirPoller(dir string, processor *Processor, logger *zap.SugaredLogger, interval time.Duration) (*MaildirPoller, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	return &MaildirPoller{
+		dir:       dir,
+		processor: processor,
+		logger:    logger.With("worker", "maildir-poller", "maildir", dir),
+		interval:  interval,
+	}, nil
+}
+
+// Run polls the maildir every interval until the context is done
+func (mp *MaildirPoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(mp.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := mp.Poll(); err != nil {
+			mp.logger.Errorf("couldn't poll maildir: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll files the new messages in delivery order, returning how many were filed. It stops at the first transient
+// failure for the message to be retried on the next poll
+func (mp *MaildirPoller) Poll() (int, error) {
+	files, err := ioutil.ReadDir(filepath.Join(mp.dir, "new"))
+	if err != nil {
+		return 0, err
+	}
+
+	// maildir names start with the delivery time
+	filed := 0
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(mp.dir, "new", file.Name())
+		l := mp.logger.With("file", file.Name())
+
+		f, err := os.Open(path)
+		if err != nil {
+			return filed, err
+		}
+		_, err = mp.processor.Process(f)
+		f.Close()
+
+		flag := seenFlag
+		if err != nil {
+			if !Permanent(err) {
+				return filed, err
+			}
+			l.Warnf("couldn't process message: %s", err.Error())
+			flag = trashedFlag
+		} else {
+			filed++
+		}
+
+		if err = os.Rename(path, filepath.Join(mp.dir, "cur", file.Name()+flag)); err != nil {
+			return filed, err
+		}
+	}
+
+	return filed, nil
+}
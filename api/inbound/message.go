@@ -0,0 +1,231 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// maxPartDepth bounds the nesting of multipart bodies
+const maxPartDepth = 10
+
+// ErrNoSender is returned when a message has no usable From address
+var ErrNoSender = errors.New("message has no sender")
+
+var (
+	headerDecoder = mime.WordDecoder{}
+
+	tagPattern     = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]*>`)
+	blankPattern   = regexp.MustCompile(`\n{3,}`)
+	messageIDToken = regexp.MustCompile(`<[^<>]+>`)
+)
+
+// Message is an inbound email reduced to what an issue is made of
+type Message struct {
+	MessageID  string
+	References []string
+	From       string
+	FromName   string
+	Subject    string
+	Text       string
+	// AutoSubmitted tells the message was generated by a machine, such as an auto-reply or a notification
+	AutoSubmitted bool
+	Attachments   []Attachment
+}
+
+// Attachment is a file attached to an inbound email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Parse reads a MIME message, keeping its plain text body, or its HTML one stripped of markup when it has none, and
+// its attachments
+func Parse(r io.Reader) (*Message, error) {
+	raw, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := raw.Header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return nil, ErrNoSender
+	}
+
+	subject, err := headerDecoder.DecodeHeader(raw.Header.Get("Subject"))
+	if err != nil {
+		subject = raw.Header.Get("Subject")
+	}
+
+	message := &Message{
+		MessageID:     strings.Trim(strings.TrimSpace(raw.Header.Get("Message-Id")), "<>"),
+		From:          strings.ToLower(from[0].Address),
+		FromName:      from[0].Name,
+		Subject:       strings.TrimSpace(subject),
+		AutoSubmitted: autoSubmitted(raw.Header),
+	}
+
+	for _, header := range []string{"In-Reply-To", "References"} {
+		for _, id := range messageIDToken.FindAllString(raw.Header.Get(header), -1) {
+			message.References = append(message.References, strings.Trim(id, "<>"))
+		}
+	}
+
+	var text, htmlText string
+	err = walkPart(message, &text, &htmlText, raw.Header, raw.Body, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if text == "" && htmlText != "" {
+		text = stripHTML(htmlText)
+	}
+	message.Text = strings.TrimSpace(strings.Replace(text, "\r\n", "\n", -1))
+
+	return message, nil
+}
+
+// partHeader is the subset of a MIME header the parts are decoded with
+type partHeader interface {
+	Get(key string) string
+}
+
+// walkPart decodes a part, descending into multipart ones, the first plain text and HTML bodies being kept and any
+// part with a filename or an attachment disposition becoming an attachment
+func walkPart(message *Message, text, htmlText *string, header partHeader, body io.Reader, depth int) error {
+	if depth > maxPartDepth {
+		return errors.New("message parts are nested too deeply")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if err = walkPart(message, text, htmlText, part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := ioutil.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if decoded, err := headerDecoder.DecodeHeader(filename); err == nil {
+		filename = decoded
+	}
+
+	switch {
+	case disposition == "attachment" || filename != "":
+		if filename == "" {
+			filename = "attachment"
+		}
+		message.Attachments = append(message.Attachments, Attachment{
+			Filename: filename, ContentType: mediaType, Content: content,
+		})
+	case mediaType == "text/plain" && *text == "":
+		*text = string(content)
+	case mediaType == "text/html" && *htmlText == "":
+		*htmlText = string(content)
+	}
+
+	return nil
+}
+
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, newlineStripper{body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// newlineStripper drops the line breaks base64 bodies are wrapped with
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (ns newlineStripper) Read(p []byte) (int, error) {
+	n, err := ns.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+			p[kept] = b
+			kept++
+		}
+	}
+
+	return kept, err
+}
+
+// autoSubmitted tells whether a message was sent by a machine, replying to which could start a mail loop
+func autoSubmitted(header mail.Header) bool {
+	if value := strings.ToLower(header.Get("Auto-Submitted")); value != "" && value != "no" {
+		return true
+	}
+
+	precedence := strings.ToLower(header.Get("Precedence"))
+	return precedence == "bulk" || precedence == "junk" || precedence == "list"
+}
+
+func stripHTML(body string) string {
+	body = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n\n", "</div>", "\n").
+		Replace(body)
+	body = html.UnescapeString(tagPattern.ReplaceAllString(body, ""))
+
+	lines := strings.Split(body, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	return blankPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+}
+
+// StripQuoted removes the quoted text a reply carries, from the attribution line such as "On ... wrote:" or the
+// first quoted line on, as well as the signature
+func StripQuoted(text string) string {
+	var kept bytes.Buffer
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") || line == "-- " || trimmed == "-----Original Message-----" ||
+			(strings.HasPrefix(trimmed, "On ") && strings.HasSuffix(trimmed, "wrote:")) {
+			break
+		}
+		kept.WriteString(line)
+		kept.WriteByte('\n')
+	}
+
+	return strings.TrimSpace(kept.String())
+}
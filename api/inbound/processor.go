@@ -0,0 +1,267 @@
+package inbound
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+)
+
+// Column sizes the texts taken from an email are cut to, the full text being attached when it does not fit
+const (
+	maxSummaryLength     = 64
+	maxDescriptionLength = 256
+	maxCommentLength     = 1024
+
+	fullTextFilename = "message.txt"
+	noSubject        = "(no subject)"
+)
+
+// issueKeyPattern matches the issue key the subjects of notification emails carry, such as "Re: [YAITS #42] ..."
+var issueKeyPattern = regexp.MustCompile(`(?i)\[(?:YAITS )?#(\d+)\]`)
+
+// permanentError is an error processing a message again would not fix
+type permanentError struct {
+	err error
+}
+
+func (pe permanentError) Error() string {
+	return pe.err.Error()
+}
+
+// Permanent tells whether a message failed to be processed for good, such as when it is not a valid email, rather
+// than because of a transient failure it should be retried after
+func Permanent(err error) bool {
+	_, ok := err.(permanentError)
+	return ok
+}
+
+// Processor turns inbound emails into issues: a new thread creates an issue while a reply, recognized by the issue key
+// of its subject or by the emails it refers to, comments on the issue. Senders are mapped to users by the addresses
+// of their email preferences, unknown senders being recorded by their address
+type Processor struct {
+	storage persistence.Storage
+	store   blob.Store
+	logger  *zap.SugaredLogger
+
+	// Project and IssueType are given to the issues created from emails
+	Project   string
+	IssueType string
+	// Users maps lower case sender addresses to users ahead of email preferences
+	Users             map[string]string
+	MaxAttachmentSize int64
+	AllowedTypes      []string
+}
+
+// NewProcessor creates a Processor storing the attached files in the given blob store
+func NewProcessor(storage persistence.Storage, store blob.Store, logger *zap.SugaredLogger) *Processor {
+	return &Processor{
+		storage: storage,
+		store:   store,
+		logger:  logger.With("worker", "inbound-email"),
+		Users:   map[string]string{},
+	}
+}
+
+// Process files a MIME message, returning the issue it was filed on. Emails sent by machines are ignored and a
+// message already processed is not filed twice
+func (p *Processor) Process(r io.Reader) (int64, error) {
+	message, err := Parse(r)
+	if err != nil {
+		return 0, permanentError{err}
+	}
+
+	l := p.logger.With("messageID", message.MessageID, "from", message.From)
+
+	if message.AutoSubmitted {
+		l.Infof("ignoring automatic email %q", message.Subject)
+		return 0, nil
+	}
+
+	if message.MessageID != "" {
+		issueID, err := p.storage.RetrieveIssueByMessageID(message.MessageID)
+		if err == nil {
+			l.Debugf("email already filed on issue %d", issueID)
+			return issueID, nil
+		}
+		if err != sql.ErrNoRows {
+			return 0, err
+		}
+	}
+
+	user, err := p.sender(message.From)
+	if err != nil {
+		return 0, err
+	}
+
+	issueID, err := p.thread(message)
+	if err != nil {
+		return 0, err
+	}
+
+	// the text that did not fit the issue or the comment is attached in full
+	text, limit := StripQuoted(message.Text), maxCommentLength
+	if issueID != 0 {
+		err = p.comment(message, text, user, issueID)
+		if err == sql.ErrNoRows {
+			// the issue the reply refers to was deleted, filing the email as a new one
+			issueID = 0
+		} else if err != nil {
+			return 0, err
+		}
+	}
+
+	if issueID == 0 {
+		text, limit = message.Text, maxDescriptionLength
+		if issueID, err = p.create(message, user); err != nil {
+			return 0, err
+		}
+	}
+
+	l = l.With("issueID", issueID)
+
+	if message.MessageID != "" {
+		if err = p.storage.RecordInboundMessage(message.MessageID, issueID); err != nil {
+			l.Errorf("couldn't record email: %s", err.Error())
+		}
+	}
+
+	if user != message.From {
+		if err = p.storage.WatchIssue(issueID, user); err != nil {
+			l.Errorf("couldn't make %s watch issue: %s", user, err.Error())
+		}
+	}
+
+	attachments := message.Attachments
+	if len([]rune(text)) > limit {
+		attachments = append(attachments, Attachment{Filename: fullTextFilename, ContentType: "text/plain",
+			Content: []byte(text)})
+	}
+	for _, attachment := range attachments {
+		if err = p.attach(issueID, attachment); err != nil {
+			l.Errorf("couldn't attach %s: %s", attachment.Filename, err.Error())
+		}
+	}
+
+	l.Infof("filed email %q", message.Subject)
+	return issueID, nil
+}
+
+// sender returns the user an address belongs to, the address itself when it belongs to no one
+func (p *Processor) sender(address string) (string, error) {
+	if user, ok := p.Users[address]; ok {
+		return user, nil
+	}
+
+	user, err := p.storage.RetrieveUserByEmail(address)
+	if err == sql.ErrNoRows {
+		return address, nil
+	}
+
+	return user, err
+}
+
+// thread returns the issue a reply belongs to, 0 when the message starts a new thread
+func (p *Processor) thread(message *Message) (int64, error) {
+	if match := issueKeyPattern.FindStringSubmatch(message.Subject); match != nil {
+		return strconv.ParseInt(match[1], 10, 64)
+	}
+
+	if len(message.References) == 0 {
+		return 0, nil
+	}
+
+	issueID, err := p.storage.RetrieveIssueByMessageID(message.References...)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return issueID, err
+}
+
+func (p *Processor) create(message *Message, user string) (int64, error) {
+	summary := strings.Join(strings.Fields(issueKeyPattern.ReplaceAllString(message.Subject, "")), " ")
+	if summary == "" {
+		summary = noSubject
+	}
+
+	issue := models.NewIssueRequest{
+		Summary:     truncate(summary, maxSummaryLength),
+		Description: truncate(message.Text, maxDescriptionLength),
+		Reporter:    user,
+		Project:     p.Project,
+		Type:        p.IssueType,
+	}
+
+	return p.storage.CreateIssue(issue, models.IssueEvent{Event: events.IssueCreated, Actor: user})
+}
+
+func (p *Processor) comment(message *Message, comment, user string, issueID int64) error {
+	if comment == "" && len(message.Attachments) == 0 {
+		return nil
+	}
+	if comment == "" {
+		filenames := make([]string, 0, len(message.Attachments))
+		for _, attachment := range message.Attachments {
+			filenames = append(filenames, attachment.Filename)
+		}
+		comment = fmt.Sprintf("Attached %s", strings.Join(filenames, ", "))
+	}
+	comment = truncate(comment, maxCommentLength)
+
+	update := models.UpdateIssueRequest{Comment: comment}
+	_, err := p.storage.UpdateIssue(update, issueID,
+		models.IssueEvent{Event: events.IssueCommented, Actor: user, Comment: comment})
+
+	return err
+}
+
+// attach stores an attached file the way uploaded attachments are, files over the size limit or of a type not
+// allowed being skipped
+func (p *Processor) attach(issueID int64, file Attachment) error {
+	size := int64(len(file.Content))
+	if p.MaxAttachmentSize > 0 && size > p.MaxAttachmentSize {
+		return fmt.Errorf("attachments are limited to %d bytes", p.MaxAttachmentSize)
+	}
+
+	contentType := blob.DetectContentType(file.ContentType, file.Content)
+	if !blob.AllowedType(contentType, p.AllowedTypes) {
+		return fmt.Errorf("attachments of type %s are not allowed", contentType)
+	}
+
+	sum := sha256.Sum256(file.Content)
+	attachment := models.Attachment{
+		IssueID:     issueID,
+		Filename:    file.Filename,
+		ContentType: contentType,
+		Size:        size,
+		Hash:        hex.EncodeToString(sum[:]),
+	}
+
+	_, err := p.storage.CreateAttachment(attachment, func() error {
+		return p.store.Put(attachment.Hash, bytes.NewReader(file.Content), size, contentType)
+	})
+	return err
+}
+
+// truncate cuts a text to at most max characters
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+
+	return string(runes[:max-1]) + "…"
+}
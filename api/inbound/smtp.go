@@ -0,0 +1,255 @@
+package inbound
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxRecipients bounds the recipients of a single message
+const maxRecipients = 100
+
+// ErrServerClosed is returned by Serve once the server is closed
+var ErrServerClosed = errors.New("smtp: server closed")
+
+// SMTPServer is a minimal SMTP listener filing the messages it receives, meant to sit behind the mail server
+// relaying support@ rather than to face the internet: it neither authenticates clients nor offers TLS
+type SMTPServer struct {
+	processor *Processor
+	logger    *zap.SugaredLogger
+
+	// Domain is the name the server greets clients with
+	Domain string
+	// Recipients are the addresses accepted, empty accepting any
+	Recipients []string
+	MaxSize    int64
+	Timeout    time.Duration
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+// NewSMTPServer creates an SMTPServer filing messages of up to 25MB
+func NewSMTPServer(processor *Processor, logger *zap.SugaredLogger, domain string) *SMTPServer {
+	return &SMTPServer{
+		processor: processor,
+		logger:    logger.With("worker", "smtp-server"),
+		Domain:    domain,
+		MaxSize:   25 << 20,
+		Timeout:   5 * time.Minute,
+	}
+}
+
+// ListenAndServe listens on the TCP address and serves SMTP sessions until the server is closed
+func (s *SMTPServer) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve serves SMTP sessions on a listener until the server is closed
+func (s *SMTPServer) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		go s.session(conn)
+	}
+}
+
+// Close stops accepting connections, the sessions in progress being left to finish
+func (s *SMTPServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Close()
+}
+
+// session speaks the subset of SMTP mail servers relay messages with
+func (s *SMTPServer) session(conn net.Conn) {
+	defer conn.Close()
+
+	l := s.logger.With("remote", conn.RemoteAddr().String())
+	text := textproto.NewConn(conn)
+
+	reply := func(format string, args ...interface{}) error {
+		if s.Timeout > 0 {
+			_ = conn.SetDeadline(time.Now().Add(s.Timeout))
+		}
+		return text.PrintfLine(format, args...)
+	}
+
+	// bounces have an empty sender, whether MAIL was given being tracked apart
+	var mail bool
+	var recipients []string
+
+	if reply("220 %s ESMTP YAITS", s.Domain) != nil {
+		return
+	}
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			verb, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			err = reply("250 %s", s.Domain)
+		case "EHLO":
+			err = reply("250-%s\r\n250-SIZE %d\r\n250 8BITMIME", s.Domain, s.MaxSize)
+		case "MAIL":
+			if _, ok := pathArgument(arg, "FROM:"); !ok {
+				err = reply("501 syntax: MAIL FROM:<address>")
+				break
+			}
+			mail, recipients = true, nil
+			err = reply("250 ok")
+		case "RCPT":
+			address, ok := pathArgument(arg, "TO:")
+			switch {
+			case !ok:
+				err = reply("501 syntax: RCPT TO:<address>")
+			case !mail:
+				err = reply("503 need MAIL first")
+			case len(recipients) >= maxRecipients:
+				err = reply("452 too many recipients")
+			case !s.accepts(address):
+				err = reply("550 no such mailbox")
+			default:
+				recipients = append(recipients, address)
+				err = reply("250 ok")
+			}
+		case "DATA":
+			if len(recipients) == 0 {
+				err = reply("503 need RCPT first")
+				break
+			}
+			if err = reply("354 end data with <CR><LF>.<CR><LF>"); err != nil {
+				return
+			}
+
+			err = s.receive(text, l)
+			mail, recipients = false, nil
+		case "RSET":
+			mail, recipients = false, nil
+			err = reply("250 ok")
+		case "NOOP":
+			err = reply("250 ok")
+		case "VRFY":
+			err = reply("252 cannot verify")
+		case "QUIT":
+			_ = reply("221 bye")
+			return
+		default:
+			err = reply("502 command not implemented")
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// receive reads a message and files it, replying whether it was accepted
+func (s *SMTPServer) receive(text *textproto.Conn, l *zap.SugaredLogger) error {
+	dot := text.DotReader()
+	body, err := ioutil.ReadAll(io.LimitReader(dot, s.MaxSize+1))
+	if err != nil {
+		return err
+	}
+
+	if int64(len(body)) > s.MaxSize {
+		// drain the rest of the message for the session to go on
+		if _, err = io.Copy(ioutil.Discard, dot); err != nil {
+			return err
+		}
+		return text.PrintfLine("552 message exceeds %d bytes", s.MaxSize)
+	}
+
+	issueID, err := s.processor.Process(bytes.NewReader(body))
+	if err != nil && Permanent(err) {
+		l.Warnf("rejected message: %s", err.Error())
+		return text.PrintfLine("554 %s", err.Error())
+	}
+	if err != nil {
+		l.Errorf("couldn't file message: %s", err.Error())
+		return text.PrintfLine("451 try again later")
+	}
+
+	return text.PrintfLine("250 filed as issue %d", issueID)
+}
+
+func (s *SMTPServer) accepts(address string) bool {
+	if len(s.Recipients) == 0 {
+		return true
+	}
+
+	for _, recipient := range s.Recipients {
+		if strings.EqualFold(recipient, address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pathArgument extracts the address of a MAIL FROM:<address> or RCPT TO:<address> argument, parameters such as
+// SIZE=1024 being ignored
+func pathArgument(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	path := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(path, "<") {
+		return "", false
+	}
+
+	end := strings.IndexByte(path, '>')
+	if end < 0 {
+		return "", false
+	}
+
+	return path[1:end], true
+}
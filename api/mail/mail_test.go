@@ -0,0 +1,253 @@
+package mail
+
+import (
+	"bufio"
+	"database/sql"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	mock "github.com/YAITS/api/persistence/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer accepts a single plain SMTP session and sends the received message over the returned channel
+func fakeSMTPServer(t *testing.T) (int, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("couldn't listen: %s", err)
+	}
+
+	received := make(chan string, 1)
+	go func() {
+		defer listener.Close()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 fake ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(command, "DATA"):
+				reply("354 go ahead")
+
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				reply("250 queued")
+			case strings.HasPrefix(command, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+
+	sender := NewSMTPSender(Config{Host: "127.0.0.1", Port: port, From: "yaits@example.com"})
+	err := sender.Send(Message{
+		To:      "john.doe@example.com",
+		Subject: "[YAITS #1] Évènement",
+		Text:    "Hello John Doe",
+		HTML:    "<p>Hello John Doe</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<http://localhost/api/unsubscribe?token=abc>"},
+	})
+	assert.Nil(t, err)
+
+	message, err := mail.ReadMessage(strings.NewReader(<-received))
+	if err != nil {
+		t.Fatalf("couldn't parse the sent message: %s", err)
+	}
+
+	subject, _ := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	assert.Equal(t, "[YAITS #1] Évènement", subject)
+	assert.Equal(t, "john.doe@example.com", message.Header.Get("To"))
+	assert.Equal(t, "<http://localhost/api/unsubscribe?token=abc>", message.Header.Get("List-Unsubscribe"))
+	assert.Contains(t, message.Header.Get("Content-Type"), "multipart/alternative")
+
+	body, _ := ioutil.ReadAll(message.Body)
+	assert.Contains(t, string(body), "Content-Type: text/plain; charset=utf-8")
+	assert.Contains(t, string(body), "Content-Type: text/html; charset=utf-8")
+	assert.Contains(t, string(body), "<p>Hello John Doe</p>")
+}
+
+// emailStorage keeps email preferences, watchers and queued emails in memory
+type emailStorage struct {
+	*mock.Storage
+	preferences map[string]models.EmailPreferences
+	watchers    []string
+	queued      []models.Email
+	sent        []int64
+}
+
+func (storage *emailStorage) RetrieveEmailPreferences(user string) (models.EmailPreferences, error) {
+	preferences, ok := storage.preferences[user]
+	if !ok {
+		return preferences, sql.ErrNoRows
+	}
+
+	return preferences, nil
+}
+
+func (storage *emailStorage) RetrieveWatchers(_ int64) ([]string, error) {
+	return storage.watchers, nil
+}
+
+func (storage *emailStorage) QueueEmail(email models.Email) error {
+	email.ID = int64(len(storage.queued) + 1)
+	storage.queued = append(storage.queued, email)
+	return nil
+}
+
+func (storage *emailStorage) RetrievePendingEmails(digest bool, _ int64) ([]models.Email, error) {
+	pending := make([]models.Email, 0)
+	sent := make(map[int64]bool)
+	for _, id := range storage.sent {
+		sent[id] = true
+	}
+
+	for _, email := range storage.queued {
+		if email.Digest == digest && !sent[email.ID] {
+			pending = append(pending, email)
+		}
+	}
+
+	return pending, nil
+}
+
+func (storage *emailStorage) MarkEmailsSent(emailIDs ...int64) error {
+	storage.sent = append(storage.sent, emailIDs...)
+	return nil
+}
+
+type fakeSender struct {
+	messages []Message
+}
+
+func (sender *fakeSender) Send(message Message) error {
+	sender.messages = append(sender.messages, message)
+	return nil
+}
+
+func newEmailStorage() *emailStorage {
+	all := models.EmailPreferences{Assigned: true, StatusChanged: true, Commented: true, Mentioned: true}
+
+	johndoe, janedoe, bob := all, all, all
+	johndoe.User, johndoe.Email, johndoe.UnsubscribeToken = "johndoe", "john.doe@example.com", "abc"
+	janedoe.User, janedoe.Email, janedoe.Digest = "janedoe", "jane.doe@example.com", true
+	bob.User, bob.Email, bob.Commented = "bob", "bob@example.com", false
+
+	return &emailStorage{
+		preferences: map[string]models.EmailPreferences{"johndoe": johndoe, "janedoe": janedoe, "bob": bob},
+		watchers:    []string{"johndoe", "janedoe", "bob", "alice"},
+	}
+}
+
+func TestMentions(t *testing.T) {
+	assert.Equal(t, []string{"johndoe", "jane.doe"}, Mentions("@johndoe could you ask @jane.doe. Thanks @johndoe"))
+	assert.Empty(t, Mentions("write to support@example.com"), "email addresses are not mentions")
+}
+
+func TestNotifier_Consumer(t *testing.T) {
+	issue := &models.IssueResponse{ID: 1, Summary: "Login <fails>", Status: "closed", Assignee: "bob"}
+
+	t.Run("Commented", func(t *testing.T) {
+		storage := newEmailStorage()
+		notifier := NewNotifier(storage, &fakeSender{}, zap.NewNop().Sugar(), time.Minute, "http://localhost:8080/")
+
+		err := notifier.Consumer().Consume(models.IssueEvent{ID: 7, Event: events.IssueCommented, IssueID: 1,
+			Actor: "johndoe", Comment: "@bob please have a look", Issue: issue})
+		assert.Nil(t, err)
+
+		kinds := make(map[string]string)
+		for _, email := range storage.queued {
+			kinds[email.User] = email.Kind
+			assert.Equal(t, int64(7), email.EventID)
+			assert.Equal(t, "[YAITS #1] Login <fails>", email.Subject)
+		}
+
+		assert.Equal(t, map[string]string{"bob": KindMentioned, "janedoe": KindCommented}, kinds,
+			"the actor and users without preferences are not emailed, mentions come first")
+		assert.Contains(t, storage.queued[0].Text, "http://localhost:8080/api/issue/1")
+		assert.Contains(t, storage.queued[0].HTML, "Login &lt;fails&gt;", "html is escaped")
+	})
+
+	t.Run("Updated", func(t *testing.T) {
+		storage := newEmailStorage()
+		notifier := NewNotifier(storage, &fakeSender{}, zap.NewNop().Sugar(), time.Minute, "http://localhost:8080")
+
+		err := notifier.Consumer().Consume(models.IssueEvent{ID: 8, Event: events.IssueUpdated, IssueID: 1,
+			Actor: "janedoe", Changed: []string{"status", "assignee"}, Issue: issue})
+		assert.Nil(t, err)
+
+		kinds := make(map[string]string)
+		for _, email := range storage.queued {
+			kinds[email.User] = email.Kind
+		}
+
+		assert.Equal(t, map[string]string{"bob": KindAssigned, "johndoe": KindStatusChanged}, kinds)
+	})
+}
+
+func TestNotifier_Send(t *testing.T) {
+	storage := newEmailStorage()
+	sender := &fakeSender{}
+	notifier := NewNotifier(storage, sender, zap.NewNop().Sugar(), time.Minute, "http://localhost:8080")
+	issue := &models.IssueResponse{ID: 1, Summary: "Login fails"}
+
+	for id := int64(1); id <= 2; id++ {
+		err := notifier.Consumer().Consume(models.IssueEvent{ID: id, Event: events.IssueCommented, IssueID: 1,
+			Actor: "bob", Comment: "This is a comment", Issue: issue})
+		assert.Nil(t, err)
+	}
+
+	now := time.Now()
+	assert.Nil(t, notifier.Send(now))
+
+	assert.Len(t, sender.messages, 2, "emails are sent on their own")
+	assert.Equal(t, "john.doe@example.com", sender.messages[0].To)
+	assert.Contains(t, sender.messages[0].Text, "Unsubscribe: http://localhost:8080/api/unsubscribe?token=abc")
+	assert.Contains(t, sender.messages[0].HTML, `href="http://localhost:8080/api/unsubscribe?token=abc"`)
+
+	assert.Nil(t, notifier.Send(now.Add(notifier.DigestInterval)))
+
+	assert.Len(t, sender.messages, 3, "digests are sent once due")
+	digest := sender.messages[2]
+	assert.Equal(t, "jane.doe@example.com", digest.To)
+	assert.Equal(t, "[YAITS] 2 updates on your issues", digest.Subject)
+	assert.Equal(t, 2, strings.Count(digest.Text, "This is a comment"))
+}
@@ -0,0 +1,352 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/outbox"
+	"github.com/YAITS/api/persistence"
+)
+
+// Kinds of email notifications, a user receiving at most one email per event
+const (
+	KindAssigned      = "assigned"
+	KindStatusChanged = "status"
+	KindCommented     = "commented"
+	KindMentioned     = "mentioned"
+)
+
+// maxDigestEmails bounds the emails batched into the digests of a run
+const maxDigestEmails = 10000
+
+// mentionPattern matches the users mentioned in a comment as @username
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// Notifier emails users of the events of the issues they are assigned, watch or are mentioned on. It consumes the
+// outbox to queue emails according to user preferences, then periodically sends them, the ones of users wanting a
+// digest being batched every DigestInterval
+type Notifier struct {
+	storage  persistence.Storage
+	sender   Sender
+	logger   *zap.SugaredLogger
+	interval time.Duration
+	baseURL  string
+
+	DigestInterval time.Duration
+	BatchSize      int64
+
+	lastDigest time.Time
+}
+
+// NewNotifier creates a Notifier sending the queued emails every interval, baseURL being the public URL of the API
+// used in links
+func NewNotifier(storage persistence.Storage, sender Sender, logger *zap.SugaredLogger, interval time.Duration,
+	baseURL string) *Notifier {
+	return &Notifier{
+		storage:        storage,
+		sender:         sender,
+		logger:         logger.With("worker", "email-notifier"),
+		interval:       interval,
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		DigestInterval: 24 * time.Hour,
+		BatchSize:      100,
+		lastDigest:     time.Now(),
+	}
+}
+
+// Consumer returns the outbox consumer queuing the emails of the events
+func (n *Notifier) Consumer() outbox.Consumer {
+	return outbox.Consumer{ID: "email", Consume: n.queue}
+}
+
+// Run sends the queued emails until the context is cancelled
+func (n *Notifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := n.Send(time.Now()); err != nil {
+			n.logger.Errorf("error sending emails: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Send sends the queued emails, and the digests when they are due at the given time
+func (n *Notifier) Send(now time.Time) error {
+	emails, err := n.storage.RetrievePendingEmails(false, n.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, email := range emails {
+		n.send(email.User, email.Subject, []models.Email{email})
+	}
+
+	if now.Sub(n.lastDigest) < n.DigestInterval {
+		return nil
+	}
+
+	emails, err = n.storage.RetrievePendingEmails(true, maxDigestEmails)
+	if err != nil {
+		return err
+	}
+
+	users := make([]string, 0)
+	digests := make(map[string][]models.Email)
+	for _, email := range emails {
+		if _, ok := digests[email.User]; !ok {
+			users = append(users, email.User)
+		}
+		digests[email.User] = append(digests[email.User], email)
+	}
+
+	for _, user := range users {
+		n.send(user, fmt.Sprintf("[YAITS] %d updates on your issues", len(digests[user])), digests[user])
+	}
+
+	n.lastDigest = now
+	return nil
+}
+
+// send emails the given items to a user, failures being logged and retried on the next run
+func (n *Notifier) send(user, subject string, items []models.Email) {
+	l := n.logger.With("user", user)
+
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	preferences, err := n.storage.RetrieveEmailPreferences(user)
+	if err != nil && err != sql.ErrNoRows {
+		l.Errorf("couldn't retrieve email preferences: %s", err.Error())
+		return
+	}
+
+	// the user removed their address since the emails were queued
+	if preferences.Email != "" {
+		message, err := n.message(preferences, subject, items)
+		if err == nil {
+			err = n.sender.Send(message)
+		}
+		if err != nil {
+			l.Errorf("couldn't send email: %s", err.Error())
+			return
+		}
+	}
+
+	if err = n.storage.MarkEmailsSent(ids...); err != nil {
+		l.Errorf("couldn't mark emails as sent: %s", err.Error())
+		return
+	}
+
+	l.Debugw("email sent", "items", len(items))
+}
+
+func (n *Notifier) message(preferences models.EmailPreferences, subject string, items []models.Email) (Message, error) {
+	unsubscribeURL := n.baseURL + "/api/unsubscribe?token=" + url.QueryEscape(preferences.UnsubscribeToken)
+
+	texts := make([]string, 0, len(items))
+	htmls := make([]htmltemplate.HTML, 0, len(items))
+	for _, item := range items {
+		texts = append(texts, item.Text)
+		// item bodies were escaped by their own template when queued
+		htmls = append(htmls, htmltemplate.HTML(item.HTML))
+	}
+
+	var text, html bytes.Buffer
+	err := layoutTemplate.Execute(&text, map[string]interface{}{
+		"User": preferences.User, "Items": texts, "UnsubscribeURL": unsubscribeURL,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	err = layoutHTMLTemplate.Execute(&html, map[string]interface{}{
+		"User": preferences.User, "Items": htmls, "UnsubscribeURL": unsubscribeURL,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      preferences.Email,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{"List-Unsubscribe": "<" + unsubscribeURL + ">", "Auto-Submitted": "auto-generated"},
+	}, nil
+}
+
+// queue queues the emails of an event for the users wanting them
+func (n *Notifier) queue(event models.IssueEvent) error {
+	if event.Issue == nil {
+		return nil
+	}
+
+	recipients, err := n.recipients(event)
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		preferences, err := n.storage.RetrieveEmailPreferences(recipient.user)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if preferences.Email == "" || !wants(preferences, recipient.kind) {
+			continue
+		}
+
+		email, err := n.render(event, recipient.kind)
+		if err != nil {
+			return err
+		}
+
+		email.User = recipient.user
+		email.Digest = preferences.Digest
+		if err = n.storage.QueueEmail(email); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type recipient struct {
+	user string
+	kind string
+}
+
+// recipients returns who should be emailed of an event, the actor excepted. A user is emailed once per event, of the
+// first of: being mentioned, being assigned, a status change, a comment
+func (n *Notifier) recipients(event models.IssueEvent) ([]recipient, error) {
+	recipients := make([]recipient, 0)
+	seen := map[string]bool{event.Actor: true, "": true}
+
+	add := func(kind string, users ...string) {
+		for _, user := range users {
+			if !seen[user] {
+				seen[user] = true
+				recipients = append(recipients, recipient{user: user, kind: kind})
+			}
+		}
+	}
+
+	switch event.Event {
+	case events.IssueUpdated:
+		if changed(event, "assignee") {
+			add(KindAssigned, event.Issue.Assignee)
+		}
+		if changed(event, "status") {
+			watchers, err := n.storage.RetrieveWatchers(event.IssueID)
+			if err != nil {
+				return nil, err
+			}
+			add(KindStatusChanged, watchers...)
+		}
+	case events.IssueCommented:
+		add(KindMentioned, Mentions(event.Comment)...)
+
+		watchers, err := n.storage.RetrieveWatchers(event.IssueID)
+		if err != nil {
+			return nil, err
+		}
+		add(KindCommented, watchers...)
+	}
+
+	return recipients, nil
+}
+
+func (n *Notifier) render(event models.IssueEvent, kind string) (models.Email, error) {
+	actor := event.Actor
+	if actor == "" {
+		actor = "Someone"
+	}
+
+	data := map[string]interface{}{
+		"Actor":   actor,
+		"Issue":   event.Issue,
+		"Comment": event.Comment,
+		"URL":     fmt.Sprintf("%s/api/issue/%d", n.baseURL, event.IssueID),
+	}
+
+	var text, html bytes.Buffer
+	if err := itemTemplates[kind].Execute(&text, data); err != nil {
+		return models.Email{}, err
+	}
+	if err := itemHTMLTemplates[kind].Execute(&html, data); err != nil {
+		return models.Email{}, err
+	}
+
+	return models.Email{
+		EventID: event.ID,
+		Kind:    kind,
+		IssueID: event.IssueID,
+		Subject: fmt.Sprintf("[YAITS #%d] %s", event.IssueID, event.Issue.Summary),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// Mentions returns the users mentioned in a text as @username, once each
+func Mentions(text string) []string {
+	users := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		user := strings.TrimRight(match[1], ".-")
+		if !seen[user] {
+			seen[user] = true
+			users = append(users, user)
+		}
+	}
+
+	return users
+}
+
+func wants(preferences models.EmailPreferences, kind string) bool {
+	switch kind {
+	case KindAssigned:
+		return preferences.Assigned
+	case KindStatusChanged:
+		return preferences.StatusChanged
+	case KindCommented:
+		return preferences.Commented
+	case KindMentioned:
+		return preferences.Mentioned
+	}
+
+	return false
+}
+
+func changed(event models.IssueEvent, field string) bool {
+	for _, name := range event.Changed {
+		if name == field {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,196 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TLS modes of the SMTP connection: none, STARTTLS upgrade of a plain connection or implicit TLS
+const (
+	TLSNone     = "none"
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+)
+
+// Config is the SMTP server emails are sent through
+type Config struct {
+	Host     string
+	Port     int
+	TLS      string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// Message is an email with both a plain text and an HTML body
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
+
+// Sender sends emails
+type Sender interface {
+	Send(message Message) error
+}
+
+// SMTPSender sends emails through an SMTP server
+type SMTPSender struct {
+	config Config
+}
+
+// NewSMTPSender creates an SMTPSender, the connection being plain when no TLS mode is configured
+func NewSMTPSender(config Config) *SMTPSender {
+	if config.TLS == "" {
+		config.TLS = TLSNone
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &SMTPSender{config: config}
+}
+
+// Send delivers a message over a new SMTP connection
+func (sender *SMTPSender) Send(message Message) error {
+	body, err := buildMessage(sender.config.From, message)
+	if err != nil {
+		return err
+	}
+
+	client, err := sender.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if sender.config.Username != "" {
+		auth := smtp.PlainAuth("", sender.config.Username, sender.config.Password, sender.config.Host)
+		if err = client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(sender.config.From); err != nil {
+		return err
+	}
+	if err = client.Rcpt(message.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (sender *SMTPSender) dial() (*smtp.Client, error) {
+	config := sender.config
+	address := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	tlsConfig := &tls.Config{ServerName: config.Host}
+	dialer := &net.Dialer{Timeout: config.Timeout}
+
+	var conn net.Conn
+	var err error
+	switch config.TLS {
+	case TLSImplicit:
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	case TLSNone, TLSStartTLS:
+		conn, err = dialer.Dial("tcp", address)
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", config.TLS)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if config.TLS == TLSStartTLS {
+		if err = client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+// buildMessage formats a message as a multipart/alternative MIME email
+func buildMessage(from string, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + from,
+		"To: " + message.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + parts.Boundary(),
+	}
+	names := make([]string, 0, len(message.Headers))
+	for name := range message.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		headers = append(headers, name+": "+message.Headers[name])
+	}
+	for _, header := range headers {
+		buf.WriteString(header + "\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := quotedprintable.NewWriter(w)
+		if _, err = encoder.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err = encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package mail
+
+import (
+	htmltemplate "html/template"
+	"text/template"
+)
+
+// itemTemplates render the part of an email describing an event, by kind of email
+var itemTemplates = map[string]*template.Template{
+	KindAssigned: template.Must(template.New(KindAssigned).Parse(
+		`{{.Actor}} assigned you issue #{{.Issue.ID}}: {{.Issue.Summary}}
+{{.URL}}`)),
+	KindStatusChanged: template.Must(template.New(KindStatusChanged).Parse(
+		`{{.Actor}} changed the status of issue #{{.Issue.ID}} ({{.Issue.Summary}}) to {{.Issue.Status}}
+{{.URL}}`)),
+	KindCommented: template.Must(template.New(KindCommented).Parse(
+		`{{.Actor}} commented on issue #{{.Issue.ID}} ({{.Issue.Summary}}):
+
+{{.Comment}}
+
+{{.URL}}`)),
+	KindMentioned: template.Must(template.New(KindMentioned).Parse(
+		`{{.Actor}} mentioned you on issue #{{.Issue.ID}} ({{.Issue.Summary}}):
+
+{{.Comment}}
+
+{{.URL}}`)),
+}
+
+// itemHTMLTemplates are the HTML counterparts of itemTemplates
+var itemHTMLTemplates = map[string]*htmltemplate.Template{
+	KindAssigned: htmltemplate.Must(htmltemplate.New(KindAssigned).Parse(
+		`<p>{{.Actor}} assigned you <a href="{{.URL}}">issue #{{.Issue.ID}}</a>: {{.Issue.Summary}}</p>`)),
+	KindStatusChanged: htmltemplate.Must(htmltemplate.New(KindStatusChanged).Parse(
+		`<p>{{.Actor}} changed the status of <a href="{{.URL}}">issue #{{.Issue.ID}}</a> ({{.Issue.Summary}}) to <strong>{{.Issue.Status}}</strong></p>`)),
+	KindCommented: htmltemplate.Must(htmltemplate.New(KindCommented).Parse(
+		`<p>{{.Actor}} commented on <a href="{{.URL}}">issue #{{.Issue.ID}}</a> ({{.Issue.Summary}}):</p><blockquote>{{.Comment}}</blockquote>`)),
+	KindMentioned: htmltemplate.Must(htmltemplate.New(KindMentioned).Parse(
+		`<p>{{.Actor}} mentioned you on <a href="{{.URL}}">issue #{{.Issue.ID}}</a> ({{.Issue.Summary}}):</p><blockquote>{{.Comment}}</blockquote>`)),
+}
+
+// layoutTemplate wraps the items of an email, a single one or a digest, with a greeting and an unsubscribe link
+var layoutTemplate = template.Must(template.New("layout").Parse(`Hello {{.User}},
+{{range .Items}}
+{{.}}
+{{end}}
+--
+You receive these emails because of your YAITS email preferences.
+Unsubscribe: {{.UnsubscribeURL}}
+`))
+
+var layoutHTMLTemplate = htmltemplate.Must(htmltemplate.New("layout").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<p>Hello {{.User}},</p>
+{{range .Items}}{{.}}
+{{end}}<hr>
+<p style="font-size: small">You receive these emails because of your YAITS email preferences.
+<a href="{{.UnsubscribeURL}}">Unsubscribe</a></p>
+</body>
+</html>
+`))
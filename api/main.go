@@ -1,17 +1,30 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"strings"
 	
 	"go.uber.org/zap"
 
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/inbound"
+	"github.com/YAITS/api/mail"
+	"github.com/YAITS/api/outbox"
 	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/rpc"
 	"github.com/YAITS/api/server"
+	"github.com/YAITS/api/server/handlers"
+	"github.com/YAITS/api/sla"
+	"github.com/YAITS/api/webhook"
 	"github.com/go-sql-driver/mysql"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/viper"
 )
 
@@ -30,14 +43,18 @@ var logger *zap.SugaredLogger
 
 // @BasePath /api
 func main() {
-	err := readConfig(defaultConfigPath)
-	logger := GetLogger()
-
-	if err != nil {
-		logger.Errorf("read config error: %s", err)
-		return
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"serve"}
 	}
 
+	os.Exit(runCommand(args))
+}
+
+// serve runs the API along with its background workers, the config being read
+func serve() {
+	logger := GetLogger()
+
 	ginPort := fmt.Sprintf(":%d", viper.GetInt64("server.port"))
 
 	storage, err := initDB()
@@ -46,7 +63,77 @@ func main() {
 		os.Exit(1)
 	}
 
-	apiServer := server.NewServer(ginPort, logger, storage)
+	if failed, err := storage.FailInterruptedBulkJobs(); err != nil {
+		logger.Errorf("error failing interrupted bulk jobs: %s", err.Error())
+		os.Exit(1)
+	} else if failed > 0 {
+		logger.Warnf("%d bulk jobs interrupted by the last shutdown failed", failed)
+	}
+
+	attachments, err := initAttachments()
+	if err != nil {
+		logger.Errorf("error initializing attachment store: %s", err.Error())
+		os.Exit(1)
+	}
+
+	hub := events.NewHub(viper.GetInt("events.historySize"))
+	integrations := handlers.Integrations{
+		Slack: initSlack(),
+		VCS:   handlers.VCS{Secret: viper.GetString("vcs.secret")},
+	}
+	config := server.Config{
+		RequireToken:           viper.GetBool("auth.requireToken"),
+		IdempotencyTTL:         viper.GetDuration("idempotency.ttl"),
+		IdempotencyMaxBodySize: viper.GetInt64("idempotency.maxBodySize"),
+	}
+	apiServer := server.NewServer(ginPort, logger, storage, attachments, hub, integrations, config)
+
+	sla.AtRiskRatio = viper.GetFloat64("sla.atRiskRatio")
+	evaluator := sla.NewEvaluator(storage, logger, viper.GetDuration("sla.evaluationInterval"))
+	go evaluator.Run(context.Background())
+
+	dispatcher := webhook.NewDispatcher(storage, logger, viper.GetDuration("webhooks.dispatchInterval"))
+	dispatcher.MaxAttempts = viper.GetInt64("webhooks.maxAttempts")
+	dispatcher.Backoff = viper.GetDuration("webhooks.backoff")
+	dispatcher.MaxBackoff = viper.GetDuration("webhooks.maxBackoff")
+	dispatcher.Client.Timeout = viper.GetDuration("webhooks.timeout")
+	dispatcher.BaseURL = viper.GetString("server.baseURL")
+	dispatcher.AllowPrivateNetworks = viper.GetBool("webhooks.allowPrivateNetworks")
+	go dispatcher.Run(context.Background())
+
+	consumers := []outbox.Consumer{
+		outbox.StreamConsumer(hub), outbox.WebhookConsumer(storage), outbox.NotificationConsumer(storage),
+	}
+
+	if viper.GetBool("email.enabled") {
+		notifier := initEmail(storage)
+		consumers = append(consumers, notifier.Consumer())
+		go notifier.Run(context.Background())
+	}
+
+	if viper.GetBool("inbound.enabled") {
+		if err := initInbound(storage, attachments); err != nil {
+			logger.Errorf("error initializing inbound email: %s", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if viper.GetBool("grpc.enabled") {
+		grpcServer := rpc.NewServer(logger, storage, attachments, hub, config)
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", viper.GetInt64("grpc.port")))
+		if err != nil {
+			logger.Errorf("error listening for grpc: %s", err.Error())
+			os.Exit(1)
+		}
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Errorf("grpc server stopped: %s", err.Error())
+			}
+		}()
+	}
+
+	relay := outbox.NewRelay(storage, logger, viper.GetDuration("events.relayInterval"), consumers...)
+	go relay.Run(context.Background())
 
 	// start server
 	if err := apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -75,22 +162,42 @@ func readConfig(filePath string) error {
 
 	viper.SetDefault("server.host", "127.0.0.1")
 	viper.SetDefault("server.port", "8080")
+	viper.SetDefault("server.baseURL", "http://localhost:8080")
 	viper.SetDefault("db.host", "localhost")
+	viper.SetDefault("sla.evaluationInterval", "1m")
+	viper.SetDefault("sla.atRiskRatio", 0.2)
+	viper.SetDefault("attachments.store", "local")
+	viper.SetDefault("attachments.path", "./data/attachments")
+	viper.SetDefault("attachments.maxSize", 10<<20)
+	viper.SetDefault("events.historySize", 1000)
+	viper.SetDefault("events.relayInterval", "1s")
+	viper.SetDefault("email.port", 25)
+	viper.SetDefault("email.tls", mail.TLSNone)
+	viper.SetDefault("email.from", "yaits@localhost")
+	viper.SetDefault("email.sendInterval", "30s")
+	viper.SetDefault("email.digestInterval", "24h")
+	viper.SetDefault("inbound.pollInterval", "30s")
+	viper.SetDefault("inbound.domain", "localhost")
+	viper.SetDefault("webhooks.dispatchInterval", "10s")
+	viper.SetDefault("webhooks.maxAttempts", 8)
+	viper.SetDefault("webhooks.backoff", "30s")
+	viper.SetDefault("webhooks.maxBackoff", "6h")
+	viper.SetDefault("webhooks.timeout", "10s")
+	viper.SetDefault("grpc.port", "9090")
+	viper.SetDefault("auth.requireToken", false)
+	viper.SetDefault("idempotency.ttl", "24h")
+	viper.SetDefault("idempotency.maxBodySize", 16<<20)
 	return viper.ReadConfig(f)
 }
 
-func initDB() (persistence.Storage, error) {
-	host := viper.GetString("db.host")
-	password := viper.GetString("db.password")
-	user := viper.GetString("db.user")
-	dbName := viper.GetString("db.database")
-
+// openDB opens the database of the config, a variable for the tests to open a stub instead
+var openDB = func() (*sql.DB, error) {
 	conConfig := mysql.Config{
-		User:                 user,
-		Passwd:               password,
+		User:                 viper.GetString("db.user"),
+		Passwd:               viper.GetString("db.password"),
 		Net:                  "tcp",
-		Addr:                 host,
-		DBName:               dbName,
+		Addr:                 viper.GetString("db.host"),
+		DBName:               viper.GetString("db.database"),
 		MaxAllowedPacket:     0,
 		AllowNativePasswords: true,
 		ParseTime:            true,
@@ -108,7 +215,144 @@ func initDB() (persistence.Storage, error) {
 		return nil, err
 	}
 
+	return db, nil
+}
+
+func initDB() (*persistence.MysqlStorage, error) {
+	db, err := openDB()
+	if err != nil {
+		return nil, err
+	}
+
 	dbStorage := persistence.NewMysqlStorage(db)
 
 	return dbStorage, nil
 }
+
+func initAttachments() (handlers.Attachments, error) {
+	attachments := handlers.Attachments{
+		MaxSize:      viper.GetInt64("attachments.maxSize"),
+		AllowedTypes: viper.GetStringSlice("attachments.allowedTypes"),
+	}
+
+	switch store := viper.GetString("attachments.store"); store {
+	case "local":
+		localStore, err := blob.NewLocalStore(viper.GetString("attachments.path"))
+		if err != nil {
+			return attachments, err
+		}
+		attachments.Store = localStore
+	case "s3":
+		attachments.Store = blob.NewS3Store(blob.S3Config{
+			Endpoint:  viper.GetString("attachments.s3.endpoint"),
+			Bucket:    viper.GetString("attachments.s3.bucket"),
+			Region:    viper.GetString("attachments.s3.region"),
+			AccessKey: viper.GetString("attachments.s3.accessKey"),
+			SecretKey: viper.GetString("attachments.s3.secretKey"),
+		})
+	default:
+		return attachments, fmt.Errorf("unknown attachment store %q", store)
+	}
+
+	return attachments, nil
+}
+
+// baseURL returns the public URL of the API the links sent by an integration point to, server.baseURL unless the
+// section of the integration sets its own
+func baseURL(section string) string {
+	if url := viper.GetString(section + ".baseURL"); url != "" {
+		return url
+	}
+
+	return viper.GetString("server.baseURL")
+}
+
+func initEmail(storage persistence.Storage) *mail.Notifier {
+	sender := mail.NewSMTPSender(mail.Config{
+		Host:     viper.GetString("email.host"),
+		Port:     viper.GetInt("email.port"),
+		TLS:      viper.GetString("email.tls"),
+		Username: viper.GetString("email.username"),
+		Password: viper.GetString("email.password"),
+		From:     viper.GetString("email.from"),
+	})
+
+	notifier := mail.NewNotifier(storage, sender, GetLogger(), viper.GetDuration("email.sendInterval"),
+		baseURL("email"))
+	notifier.DigestInterval = viper.GetDuration("email.digestInterval")
+
+	return notifier
+}
+
+// initSlack reads the Slack app slash commands come from, users being mapped as slackUserID=user
+func initSlack() handlers.Slack {
+	slack := handlers.Slack{
+		SigningSecret: viper.GetString("slack.signingSecret"),
+		BaseURL:       baseURL("slack"),
+		Users:         map[string]string{},
+	}
+
+	for _, mapping := range viper.GetStringSlice("slack.users") {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) == 2 {
+			slack.Users[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return slack
+}
+
+// initInbound files the emails of the configured maildir and SMTP listener, as attachments are uploaded
+func initInbound(storage persistence.Storage, attachments handlers.Attachments) error {
+	processor := inbound.NewProcessor(storage, attachments.Store, GetLogger())
+	processor.Project = viper.GetString("inbound.project")
+	processor.IssueType = viper.GetString("inbound.issueType")
+	processor.MaxAttachmentSize = attachments.MaxSize
+	processor.AllowedTypes = attachments.AllowedTypes
+	users, err := inboundUsers()
+	if err != nil {
+		return err
+	}
+	for address, user := range users {
+		processor.Users[address] = user
+	}
+
+	if dir := viper.GetString("inbound.maildir"); dir != "" {
+		poller, err := inbound.NewMaildirPoller(dir, processor, GetLogger(), viper.GetDuration("inbound.pollInterval"))
+		if err != nil {
+			return err
+		}
+		go poller.Run(context.Background())
+	}
+
+	if address := viper.GetString("inbound.smtpAddress"); address != "" {
+		smtpServer := inbound.NewSMTPServer(processor, GetLogger(), viper.GetString("inbound.domain"))
+		smtpServer.Recipients = viper.GetStringSlice("inbound.recipients")
+
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := smtpServer.Serve(listener); err != nil && err != inbound.ErrServerClosed {
+				GetLogger().Errorf("smtp server stopped: %s", err.Error())
+			}
+		}()
+	}
+
+	return nil
+}
+
+// inboundUsers reads the senders mapped to users as address=user
+func inboundUsers() (map[string]string, error) {
+	users := map[string]string{}
+	for _, mapping := range viper.GetStringSlice("inbound.users") {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid inbound user mapping %q, expecting address=user", mapping)
+		}
+		users[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	}
+
+	return users, nil
+}
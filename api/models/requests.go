@@ -1,21 +1,121 @@
 package models
 
-// NewIssueRequest is the incoming request to create a new issue
+import "time"
+
+// NewIssueRequest is the incoming request to create a new issue, OriginalEstimate being in minutes
 type NewIssueRequest struct {
-	Description string `json:"description" binding:"required"`
-	Summary     string `json:"summary" binding:"required"`
-	Priority    int64  `json:"priority" binding:"required"`
-	Assignee    string `json:"assignee"`
+	Description      string            `json:"description"`
+	Summary          string            `json:"summary" binding:"required"`
+	Priority         int64             `json:"priority"`
+	Assignee         string            `json:"assignee"`
+	Reporter         string            `json:"reporter"`
+	Project          string            `json:"project"`
+	Type             string            `json:"type"`
+	Fields           map[string]string `json:"fields"`
+	DueDate          *time.Time        `json:"dueDate"`
+	OriginalEstimate int64             `json:"originalEstimate"`
 }
 
-// UpdateIssueRequest is the incoming request to update an existing issue
+// UpdateIssueRequest is the incoming request to update an existing issue, estimates being in minutes. ExpectedVersion,
+// read from the If-Match header, restricts the update to that version of the issue when set. Empty fields are left
+// unchanged, except those listed in Changed by patches, which are cleared
 type UpdateIssueRequest struct {
-	Description string `json:"description"`
-	Summary     string `json:"summary"`
-	Priority    int64  `json:"priority"`
-	Assignee    string `json:"assignee"`
-	Status      string `json:"status"`
-	Comment     string `json:"comment"`
+	Description       string     `json:"description"`
+	Summary           string     `json:"summary"`
+	Priority          int64      `json:"priority"`
+	Assignee          string     `json:"assignee"`
+	Status            string     `json:"status"`
+	Comment           string     `json:"comment"`
+	DueDate           *time.Time `json:"dueDate"`
+	OriginalEstimate  *int64     `json:"originalEstimate"`
+	RemainingEstimate *int64     `json:"remainingEstimate"`
+	ExpectedVersion   int64      `json:"-"`
+	Changed           []string   `json:"-"`
+}
+
+// Sets tells whether an update sets a field, even to an empty value, its JSON name being listed in Changed
+func (req UpdateIssueRequest) Sets(field string) bool {
+	for _, changed := range req.Changed {
+		if changed == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewCommentRequest is the incoming request to comment on an issue
+type NewCommentRequest struct {
+	Comment string `json:"comment" binding:"required"`
+}
+
+// NewIssueTypeRequest is the incoming request to configure an issue type for a project
+type NewIssueTypeRequest struct {
+	Project             string   `json:"project"`
+	Name                string   `json:"name" binding:"required"`
+	Workflow            string   `json:"workflow"`
+	RequiredFields      []string `json:"requiredFields"`
+	DefaultPriority     int64    `json:"defaultPriority"`
+	DescriptionTemplate string   `json:"descriptionTemplate"`
+}
+
+// NewSLAPolicyRequest is the incoming request to create an SLA policy
+type NewSLAPolicyRequest struct {
+	Name              string `json:"name" binding:"required"`
+	Project           string `json:"project"`
+	Priority          int64  `json:"priority"`
+	ResponseMinutes   int64  `json:"responseMinutes"`
+	ResolutionMinutes int64  `json:"resolutionMinutes"`
+	CalendarID        int64  `json:"calendarID"`
+}
+
+// NewBusinessCalendarRequest is the incoming request to create a business-hours calendar
+type NewBusinessCalendarRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Timezone  string `json:"timezone"`
+	Workdays  []int  `json:"workdays"`
+	StartHour int    `json:"startHour"`
+	EndHour   int    `json:"endHour" binding:"required"`
+}
+
+// SLAQueryParam is the query header parameter to filter issues by SLA state
+type SLAQueryParam struct {
+	State string `form:"state"`
+}
+
+// NewWorklogRequest is the incoming request to log work on an issue, AdjustEstimate being either auto (the default)
+// to reduce the remaining estimate by the time spent or leave to keep it unchanged
+type NewWorklogRequest struct {
+	User           string `json:"user" binding:"required"`
+	Minutes        int64  `json:"minutes" binding:"required,min=1"`
+	WorkDate       string `json:"workDate"`
+	Note           string `json:"note"`
+	AdjustEstimate string `json:"adjustEstimate"`
+}
+
+// WorklogQueryParam is the query header parameter to aggregate logged work over a date range
+type WorklogQueryParam struct {
+	From    string `form:"from"`
+	To      string `form:"to"`
+	GroupBy string `form:"groupBy"`
+	User    string `form:"user"`
+	Project string `form:"project"`
+	IssueID int64  `form:"issueID"`
+}
+
+// NotificationQueryParam is the query header parameter to filter the notifications of a user
+type NotificationQueryParam struct {
+	Unread  bool   `form:"unread"`
+	IssueID int64  `form:"issueID"`
+	Kind    string `form:"kind"`
+	Limit   int64  `form:"limit"`
+	Offset  int64  `form:"offset"`
+}
+
+// IssueFilterQueryParam is the query header parameter to filter issue listings by project and type
+type IssueFilterQueryParam struct {
+	Project string `form:"project"`
+	Type    string `form:"type"`
 }
 
 // StatusQueryParam is the query header parameter to filter issues by statuses
@@ -28,3 +128,121 @@ type PriorityQueryParam struct {
 	PriorityStart int64 `form:"start"`
 	PriorityEnd   int64 `form:"end"`
 }
+
+// ExportQueryParam is the query header parameter of an export, filtering issues the way the listing endpoints do.
+// Columns is a comma separated list of the columns to export, every column by default
+type ExportQueryParam struct {
+	IssueFilterQueryParam
+	Format        string `form:"format"`
+	Status        string `form:"status"`
+	PriorityStart int64  `form:"start"`
+	PriorityEnd   int64  `form:"end"`
+	Columns       string `form:"columns"`
+	Comments      bool   `form:"comments"`
+}
+
+// IssueSearchQuery filters and pages an issue listing, a zero value leaving its criterion out. Issues are sorted by
+// id, Limit being the most issues returned
+type IssueSearchQuery struct {
+	IssueFilterQueryParam
+	Status        string
+	Assignee      string
+	Reporter      string
+	PriorityStart int64
+	PriorityEnd   int64
+	Limit         int64
+	Offset        int64
+}
+
+// GraphQLRequest is the incoming GraphQL query or mutation along with its variables
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewWebhookRequest is the incoming request to subscribe a URL to issue events, optionally restricted to a project.
+// Format is json (the default) or slack, the secret being only required by json webhooks
+type NewWebhookRequest struct {
+	URL     string   `json:"url" binding:"required,url"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events" binding:"required,min=1"`
+	Project string   `json:"project"`
+	Format  string   `json:"format"`
+}
+
+// WebhookDeliveryQueryParam is the query header parameter to filter the delivery log of a webhook
+type WebhookDeliveryQueryParam struct {
+	Status string `form:"status"`
+	Limit  int64  `form:"limit"`
+	Offset int64  `form:"offset"`
+}
+
+// EmailPreferencesRequest is the incoming request to set the email notifications of the current user
+type EmailPreferencesRequest struct {
+	Email         string `json:"email" binding:"omitempty,email"`
+	Assigned      bool   `json:"assigned"`
+	StatusChanged bool   `json:"statusChanged"`
+	Commented     bool   `json:"commented"`
+	Mentioned     bool   `json:"mentioned"`
+	Digest        bool   `json:"digest"`
+}
+
+// ImportOptions is the incoming request describing an import file. Columns maps issue fields, or fields.<name> for
+// custom fields, to the columns of a csv file while Users, Statuses and Priorities map the values found in the file to
+// users, statuses and priorities, the format providing defaults for the values of GitHub and Jira exports
+type ImportOptions struct {
+	Format          string            `json:"format" binding:"required"`
+	Project         string            `json:"project"`
+	Type            string            `json:"type"`
+	Columns         map[string]string `json:"columns"`
+	Users           map[string]string `json:"users"`
+	Statuses        map[string]string `json:"statuses"`
+	Priorities      map[string]int64  `json:"priorities"`
+	DefaultPriority int64             `json:"defaultPriority"`
+}
+
+// ImportIssue is an issue read from an import file, Row being its line, or its position in JSON arrays
+type ImportIssue struct {
+	NewIssueRequest
+	Status string `json:"status"`
+	Row    int64  `json:"-"`
+}
+
+// BulkIssueRequest is the incoming request to apply an operation to several issues, selected either by id or by a
+// filter. Operation is one of setStatus, assign, setPriority, addLabel, addComment or delete, taking its value from
+// Status, Assignee (empty unassigning the issues), Priority, Label or Comment. AllOrNothing applies the operation to
+// all the issues or none of them, and Async runs it in the background as a job
+type BulkIssueRequest struct {
+	IDs          []int64          `json:"ids"`
+	Filter       *BulkIssueFilter `json:"filter"`
+	Operation    string           `json:"operation" binding:"required"`
+	Status       string           `json:"status"`
+	Assignee     string           `json:"assignee"`
+	Priority     int64            `json:"priority"`
+	Label        string           `json:"label"`
+	Comment      string           `json:"comment"`
+	AllOrNothing bool             `json:"allOrNothing"`
+	Async        bool             `json:"async"`
+}
+
+// BulkIssueFilter selects the issues of a bulk operation the way issue searches do, a zero value leaving its
+// criterion out
+type BulkIssueFilter struct {
+	Project       string `json:"project"`
+	Type          string `json:"type"`
+	Status        string `json:"status"`
+	Assignee      string `json:"assignee"`
+	Reporter      string `json:"reporter"`
+	PriorityStart int64  `json:"priorityStart"`
+	PriorityEnd   int64  `json:"priorityEnd"`
+}
+
+// BulkChange is the change a bulk operation makes to each of its issues, along with the events it records: an update,
+// a label added to the labels custom field, or the deletion of the issue
+type BulkChange struct {
+	Update UpdateIssueRequest
+	Label  string
+	Delete bool
+	Events []IssueEvent
+}
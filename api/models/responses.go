@@ -3,18 +3,32 @@ package models
 import (
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"time"
 )
 
-// IssueResponse contains all information about an issue
+// IssueResponse contains all information about an issue, RespondedDate being when it first left the open status
+// and ResolvedDate when it was closed. Estimates and time spent are in minutes
 type IssueResponse struct {
-	ID          int64     `json:"id"`
-	Description string    `json:"description"`
-	Summary     string    `json:"summary"`
-	Status      string    `json:"status"`
-	Assignee    string    `json:"assignee"`
-	CreateDate  string    `json:"createDate"`
-	Priority    int64     `json:"priority"`
-	Comments    []Comment `json:"comments"`
+	ID                int64             `json:"id"`
+	Description       string            `json:"description"`
+	Summary           string            `json:"summary"`
+	Status            string            `json:"status"`
+	Assignee          string            `json:"assignee"`
+	Reporter          string            `json:"reporter,omitempty"`
+	CreateDate        string            `json:"createDate"`
+	Priority          int64             `json:"priority"`
+	Project           string            `json:"project"`
+	Type              string            `json:"type"`
+	Fields            map[string]string `json:"fields,omitempty"`
+	DueDate           string            `json:"dueDate,omitempty"`
+	RespondedDate     string            `json:"respondedDate,omitempty"`
+	ResolvedDate      string            `json:"resolvedDate,omitempty"`
+	OriginalEstimate  int64             `json:"originalEstimate"`
+	RemainingEstimate int64             `json:"remainingEstimate"`
+	TimeSpent         int64             `json:"timeSpent"`
+	Version           int64             `json:"version"`
+	SLA               *SLAStatus        `json:"sla,omitempty"`
+	Comments          []Comment         `json:"comments"`
 }
 
 // IssueIDResponse is returned when a new issue is created
@@ -22,21 +36,231 @@ type IssueIDResponse struct {
 	ID int64 `json:"id"`
 }
 
+// IssueType is a configurable kind of issue (bug, feature, task...) with its own defaults and required fields
+type IssueType struct {
+	ID                  int64    `json:"id"`
+	Project             string   `json:"project"`
+	Name                string   `json:"name"`
+	Workflow            string   `json:"workflow"`
+	RequiredFields      []string `json:"requiredFields"`
+	DefaultPriority     int64    `json:"defaultPriority"`
+	DescriptionTemplate string   `json:"descriptionTemplate"`
+}
+
+// SLAPolicy sets the response and resolution targets of the issues matching its project and priority
+type SLAPolicy struct {
+	ID                int64             `json:"id"`
+	Name              string            `json:"name"`
+	Project           string            `json:"project"`
+	Priority          int64             `json:"priority"`
+	ResponseMinutes   int64             `json:"responseMinutes"`
+	ResolutionMinutes int64             `json:"resolutionMinutes"`
+	Calendar          *BusinessCalendar `json:"calendar,omitempty"`
+}
+
+// BusinessCalendar describes the working hours during which SLA time is counted
+type BusinessCalendar struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Timezone  string `json:"timezone"`
+	Workdays  []int  `json:"workdays"`
+	StartHour int    `json:"startHour"`
+	EndHour   int    `json:"endHour"`
+}
+
+// SLAStatus is the computed state of the SLA targets of an issue (ok, at risk, breached or met)
+type SLAStatus struct {
+	Policy          string `json:"policy"`
+	ResponseDue     string `json:"responseDue,omitempty"`
+	ResponseState   string `json:"responseState,omitempty"`
+	ResolutionDue   string `json:"resolutionDue,omitempty"`
+	ResolutionState string `json:"resolutionState,omitempty"`
+}
+
+// SLABreach records the moment an issue missed one of its SLA targets
+type SLABreach struct {
+	IssueID    int64  `json:"issueID"`
+	Target     string `json:"target"`
+	Policy     string `json:"policy"`
+	BreachDate string `json:"breachDate"`
+}
+
+// Worklog is an amount of work, in minutes, logged by a user on an issue
+type Worklog struct {
+	ID         int64  `json:"id"`
+	IssueID    int64  `json:"issueID"`
+	User       string `json:"user"`
+	Minutes    int64  `json:"minutes"`
+	WorkDate   string `json:"workDate"`
+	Note       string `json:"note"`
+	CreateDate string `json:"createDate"`
+}
+
+// WorklogTotal is the work logged for an issue, a user or a project
+type WorklogTotal struct {
+	Key     string `json:"key"`
+	Minutes int64  `json:"minutes"`
+}
+
+// Timesheet is the work logged by a user over a week, from monday to sunday
+type Timesheet struct {
+	User         string         `json:"user"`
+	WeekStart    string         `json:"weekStart"`
+	WeekEnd      string         `json:"weekEnd"`
+	TotalMinutes int64          `json:"totalMinutes"`
+	Days         []TimesheetDay `json:"days"`
+}
+
+// TimesheetDay is the work logged by a user on a given day
+type TimesheetDay struct {
+	Date     string    `json:"date"`
+	Minutes  int64     `json:"minutes"`
+	Worklogs []Worklog `json:"worklogs"`
+}
+
 // Comment is the struct that contains an issue comment as well as the date when it was commented
 type Comment struct {
+	ID      int64  `json:"id,omitempty"`
 	Comment string `json:"comment"`
 }
 
+// Notification tells a watcher that an issue was changed, commented or deleted by another user, EventID being the
+// event it originates from
+type Notification struct {
+	ID         int64  `json:"id"`
+	EventID    int64  `json:"-"`
+	User       string `json:"user"`
+	IssueID    int64  `json:"issueID"`
+	Kind       string `json:"kind"`
+	Actor      string `json:"actor"`
+	Message    string `json:"message"`
+	Read       bool   `json:"read"`
+	CreateDate string `json:"createDate"`
+}
+
+// NotificationsResponse is the inbox of a user
+type NotificationsResponse struct {
+	UnreadCount   int64          `json:"unreadCount"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// Attachment describes a file attached to an issue or one of its comments, its content being stored by hash
+type Attachment struct {
+	ID          int64  `json:"id"`
+	IssueID     int64  `json:"issueID"`
+	CommentID   int64  `json:"commentID,omitempty"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Hash        string `json:"hash"`
+	CreateDate  string `json:"createDate"`
+}
+
+// Webhook is a subscription of a URL to issue events, its secret signing every delivery. Format is json for the
+// events to be delivered as is, or slack for them to be posted as messages to a Slack incoming webhook
+type Webhook struct {
+	ID         int64    `json:"id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"-"`
+	Events     []string `json:"events"`
+	Project    string   `json:"project"`
+	Format     string   `json:"format"`
+	CreateDate string   `json:"createDate"`
+}
+
+// IssueEvent is something that happened to an issue, as delivered to webhooks and event streams, Issue being the
+// state of the issue after the event, Changes summarizing an update and Changed listing the fields it changed. ID
+// identifies the event
+type IssueEvent struct {
+	ID        int64          `json:"id,omitempty"`
+	Event     string         `json:"event"`
+	IssueID   int64          `json:"issueID"`
+	Project   string         `json:"project"`
+	Actor     string         `json:"actor,omitempty"`
+	Comment   string         `json:"comment,omitempty"`
+	Changes   string         `json:"changes,omitempty"`
+	Changed   []string       `json:"changed,omitempty"`
+	Issue     *IssueResponse `json:"issue,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// WebhookDelivery is a queued delivery of an event to a webhook along with the outcome of its last attempt, URL,
+// Secret and Format being those of the webhook when being delivered
+type WebhookDelivery struct {
+	ID             int64  `json:"id"`
+	WebhookID      int64  `json:"webhookID"`
+	Event          string `json:"event"`
+	Payload        string `json:"payload"`
+	Status         string `json:"status"`
+	Attempts       int64  `json:"attempts"`
+	NextAttempt    string `json:"nextAttempt,omitempty"`
+	ResponseStatus int64  `json:"responseStatus,omitempty"`
+	LastError      string `json:"lastError,omitempty"`
+	CreateDate     string `json:"createDate"`
+	DeliveredDate  string `json:"deliveredDate,omitempty"`
+	URL            string `json:"-"`
+	Secret         string `json:"-"`
+	Format         string `json:"-"`
+}
+
+// EmailPreferences are the email notifications a user wants: when being assigned an issue, when a watched issue
+// changes status or is commented, and when being mentioned in a comment. Digest batches them into periodic emails
+type EmailPreferences struct {
+	User             string `json:"user"`
+	Email            string `json:"email"`
+	Assigned         bool   `json:"assigned"`
+	StatusChanged    bool   `json:"statusChanged"`
+	Commented        bool   `json:"commented"`
+	Mentioned        bool   `json:"mentioned"`
+	Digest           bool   `json:"digest"`
+	UnsubscribeToken string `json:"-"`
+}
+
+// Email is an email notification of an issue event, queued until sent on its own or within a digest
+type Email struct {
+	ID         int64  `json:"id"`
+	EventID    int64  `json:"eventID"`
+	User       string `json:"user"`
+	Kind       string `json:"kind"`
+	IssueID    int64  `json:"issueID"`
+	Subject    string `json:"subject"`
+	Text       string `json:"text"`
+	HTML       string `json:"html"`
+	Digest     bool   `json:"digest"`
+	CreateDate string `json:"createDate"`
+}
+
+// IdempotentResponse is the response to a write sent with an Idempotency-Key, replayed to the retries of the request
+// until it expires. Fingerprint identifies the request the key was first used for, and Status is 0 while that request
+// is in progress
+type IdempotentResponse struct {
+	User        string            `json:"user"`
+	Key         string            `json:"key"`
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status"`
+	Header      map[string]string `json:"header"`
+	Body        []byte            `json:"body"`
+	ExpireDate  time.Time         `json:"expireDate"`
+}
+
 // ErrorWrapper provides a general template for the response
 type ErrorWrapper struct {
 	Errors []StandardError `json:"errors"`
 }
 
-// StandardError is the normal error to be returned
+// StandardError is the normal error to be returned. Errors about a field of the request name it in Field, along with
+// the machine-readable Reason of the violation, such as required or out_of_range. Code stays the HTTP status for the
+// errors of every route to keep their shape, which is why the machine-readable code is named reason
 type StandardError struct {
+	// HTTP status of the response, the machine-readable code of a violation being its reason
 	Code        int    `json:"code"`
 	Title       string `json:"title"`
 	Description string `json:"description,omitempty"`
+	// JSON name of the field in violation, absent when the error is about the whole request
+	Field string `json:"field,omitempty"`
+	// Machine-readable code of the violation: required, too_long, out_of_range, invalid, invalid_type, malformed,
+	// duplicate or not_found
+	Reason string `json:"reason,omitempty"`
 }
 
 // NewErrorWrapper returns an ErrorWrapper with the appropriate parameters
@@ -48,3 +272,115 @@ func NewErrorWrapper(code int, description string) ErrorWrapper {
 func SetErrorStatusJSON(c *gin.Context, status int, description string) {
 	c.JSON(status, NewErrorWrapper(status, description))
 }
+
+// IssueLink is a commit or a pull request referring to an issue, Ref being the commit hash or the pull request number
+type IssueLink struct {
+	ID         int64  `json:"id"`
+	IssueID    int64  `json:"issueID"`
+	Kind       string `json:"kind"`
+	Repository string `json:"repository"`
+	Ref        string `json:"ref"`
+	URL        string `json:"url"`
+	Title      string `json:"title"`
+	Author     string `json:"author"`
+	CreateDate string `json:"createDate"`
+}
+
+// VCSWebhookResponse tells how many issues a push or pull request webhook linked and closed
+type VCSWebhookResponse struct {
+	Linked int64 `json:"linked"`
+	Closed int64 `json:"closed"`
+}
+
+// ImportRowError lists what prevents a row of an import file from being imported
+type ImportRowError struct {
+	Row    int64    `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// ImportReport is the outcome of the dry run of an import
+type ImportReport struct {
+	Total  int64            `json:"total"`
+	Valid  int64            `json:"valid"`
+	Errors []ImportRowError `json:"errors"`
+}
+
+// ImportJob is an import running in the background along with its progress, Errors listing the rows that were not
+// imported
+type ImportJob struct {
+	ID         int64            `json:"id"`
+	Format     string           `json:"format"`
+	Status     string           `json:"status"`
+	CreatedBy  string           `json:"createdBy"`
+	Total      int64            `json:"total"`
+	Processed  int64            `json:"processed"`
+	Created    int64            `json:"created"`
+	Failed     int64            `json:"failed"`
+	Errors     []ImportRowError `json:"errors"`
+	CreateDate string           `json:"createDate"`
+	UpdateDate string           `json:"updateDate"`
+}
+
+// BulkItemResult is the outcome of a bulk operation for one of its issues
+type BulkItemResult struct {
+	IssueID int64  `json:"issueID"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkJob is a bulk operation along with its progress and the result of every issue processed, the operation running
+// in the background when it has an id
+type BulkJob struct {
+	ID           int64            `json:"id,omitempty"`
+	Operation    string           `json:"operation"`
+	Status       string           `json:"status"`
+	CreatedBy    string           `json:"createdBy"`
+	AllOrNothing bool             `json:"allOrNothing"`
+	Total        int64            `json:"total"`
+	Processed    int64            `json:"processed"`
+	Succeeded    int64            `json:"succeeded"`
+	Failed       int64            `json:"failed"`
+	Results      []BulkItemResult `json:"results"`
+	CreateDate   string           `json:"createDate,omitempty"`
+	UpdateDate   string           `json:"updateDate,omitempty"`
+}
+
+// User is someone issues are assigned to or reported by, along with the number of those issues
+type User struct {
+	Name     string `json:"name"`
+	Assigned int64  `json:"assigned"`
+	Reported int64  `json:"reported"`
+}
+
+// Project is a project key issues are filed under, along with the number of its issues
+type Project struct {
+	Key        string `json:"key"`
+	Issues     int64  `json:"issues"`
+	OpenIssues int64  `json:"openIssues"`
+}
+
+// GraphQLResponse is the result of a GraphQL query or mutation, Data holding what could be resolved despite the errors
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLError is an error of a GraphQL query, located in the query and along the path of the field it concerns
+type GraphQLError struct {
+	Message    string                  `json:"message"`
+	Locations  []GraphQLErrorLocation  `json:"locations,omitempty"`
+	Path       []interface{}           `json:"path,omitempty"`
+	Extensions *GraphQLErrorExtensions `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorExtensions are the details of an error of a mutation rejecting its input, one error per field in
+// violation as the REST routes respond them
+type GraphQLErrorExtensions struct {
+	Errors []StandardError `json:"errors"`
+}
+
+// GraphQLErrorLocation is the line and column of a GraphQL query an error refers to
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+)
+
+// Notification kinds, one per issue event watchers are notified of
+const (
+	NotificationUpdated   = "updated"
+	NotificationCommented = "commented"
+	NotificationDeleted   = "deleted"
+)
+
+// StreamConsumer publishes the events to the event streams, the hub ignoring the events it already published
+func StreamConsumer(hub *events.Hub) Consumer {
+	return Consumer{
+		ID: "stream",
+		Consume: func(event models.IssueEvent) error {
+			hub.Publish(event)
+			return nil
+		},
+	}
+}
+
+// WebhookConsumer queues the delivery of the events to the subscribed webhooks, once per event and webhook
+func WebhookConsumer(storage persistence.Storage) Consumer {
+	return Consumer{
+		ID: "webhooks",
+		Consume: func(event models.IssueEvent) error {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+
+			_, err = storage.EnqueueWebhookDeliveries(event.ID, event.Event, event.Project, payload)
+			return err
+		},
+	}
+}
+
+// NotificationConsumer notifies the watchers of an issue of its updates, comments and deletion, once per event and
+// watcher. Watchers of a deleted issue are forgotten once notified
+func NotificationConsumer(storage persistence.Storage) Consumer {
+	return Consumer{
+		ID: "notifications",
+		Consume: func(event models.IssueEvent) error {
+			notification := models.Notification{EventID: event.ID, IssueID: event.IssueID, Actor: event.Actor}
+
+			switch event.Event {
+			case events.IssueUpdated:
+				notification.Kind = NotificationUpdated
+				notification.Message = event.Changes
+				if event.Issue != nil {
+					notification.Message = fmt.Sprintf("%s: %s", event.Issue.Summary, event.Changes)
+				}
+			case events.IssueCommented:
+				notification.Kind = NotificationCommented
+				notification.Message = event.Comment
+			case events.IssueDeleted:
+				notification.Kind = NotificationDeleted
+				notification.Message = fmt.Sprintf("issue #%d was deleted", event.IssueID)
+			default:
+				return nil
+			}
+
+			if _, err := storage.NotifyWatchers(notification); err != nil {
+				return err
+			}
+
+			if event.Event == events.IssueDeleted {
+				return storage.DeleteWatchers(event.IssueID)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+)
+
+// Consumer processes the events of the outbox. An event is consumed at least once, ID identifying the consumer so
+// that its progress is tracked separately: Consume must therefore be idempotent, the event ID being stable across
+// retries
+type Consumer struct {
+	ID      string
+	Consume func(event models.IssueEvent) error
+}
+
+// Relay periodically drains the outbox, handing every event to each consumer in order. A consumer failing on an
+// event is retried from that event on the next run, without holding back the other consumers
+type Relay struct {
+	storage   persistence.Storage
+	logger    *zap.SugaredLogger
+	interval  time.Duration
+	consumers []Consumer
+
+	BatchSize int64
+}
+
+// NewRelay creates a Relay running every interval
+func NewRelay(storage persistence.Storage, logger *zap.SugaredLogger, interval time.Duration, consumers ...Consumer) *Relay {
+	return &Relay{
+		storage:   storage,
+		logger:    logger.With("worker", "outbox-relay"),
+		interval:  interval,
+		consumers: consumers,
+		BatchSize: 100,
+	}
+}
+
+// Run drains the outbox until the context is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Drain(); err != nil {
+			r.logger.Errorf("error draining outbox: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain hands the pending events to every consumer, then prunes the events consumed by all of them
+func (r *Relay) Drain() error {
+	var failed error
+	for _, consumer := range r.consumers {
+		if err := r.drain(consumer); err != nil {
+			r.logger.Errorw("outbox consumer failed", "consumer", consumer.ID, "error", err.Error())
+			failed = err
+		}
+	}
+
+	if failed != nil {
+		return failed
+	}
+
+	consumerIDs := make([]string, 0, len(r.consumers))
+	for _, consumer := range r.consumers {
+		consumerIDs = append(consumerIDs, consumer.ID)
+	}
+
+	pruned, err := r.storage.PruneOutbox(consumerIDs)
+	if err != nil {
+		return err
+	}
+
+	if pruned > 0 {
+		r.logger.Debugw("outbox pruned", "events", pruned)
+	}
+	return nil
+}
+
+func (r *Relay) drain(consumer Consumer) error {
+	for {
+		events, err := r.storage.RetrieveOutboxEvents(consumer.ID, r.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err = consumer.Consume(event); err != nil {
+				return fmt.Errorf("event %d: %w", event.ID, err)
+			}
+
+			if err = r.storage.MarkOutboxEventConsumed(consumer.ID, event.ID); err != nil {
+				return err
+			}
+		}
+
+		if int64(len(events)) < r.BatchSize {
+			return nil
+		}
+	}
+}
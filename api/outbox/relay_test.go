@@ -0,0 +1,199 @@
+package outbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	mock "github.com/YAITS/api/persistence/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// outboxStorage keeps the outbox and the consumed events in memory
+type outboxStorage struct {
+	*mock.Storage
+	outbox        []models.IssueEvent
+	consumed      map[string]map[int64]bool
+	notifications []models.Notification
+	unwatched     []int64
+}
+
+func newOutboxStorage(outbox ...models.IssueEvent) *outboxStorage {
+	return &outboxStorage{outbox: outbox, consumed: make(map[string]map[int64]bool)}
+}
+
+func (storage *outboxStorage) RetrieveOutboxEvents(consumer string, limit int64) ([]models.IssueEvent, error) {
+	pending := make([]models.IssueEvent, 0)
+	for _, event := range storage.outbox {
+		if !storage.consumed[consumer][event.ID] && int64(len(pending)) < limit {
+			pending = append(pending, event)
+		}
+	}
+
+	return pending, nil
+}
+
+func (storage *outboxStorage) MarkOutboxEventConsumed(consumer string, eventID int64) error {
+	if storage.consumed[consumer] == nil {
+		storage.consumed[consumer] = make(map[int64]bool)
+	}
+	storage.consumed[consumer][eventID] = true
+	return nil
+}
+
+func (storage *outboxStorage) PruneOutbox(consumers []string) (int64, error) {
+	kept := make([]models.IssueEvent, 0)
+	for _, event := range storage.outbox {
+		for _, consumer := range consumers {
+			if !storage.consumed[consumer][event.ID] {
+				kept = append(kept, event)
+				break
+			}
+		}
+	}
+
+	pruned := int64(len(storage.outbox) - len(kept))
+	storage.outbox = kept
+	return pruned, nil
+}
+
+func (storage *outboxStorage) NotifyWatchers(notification models.Notification) (int64, error) {
+	storage.notifications = append(storage.notifications, notification)
+	return 1, nil
+}
+
+func (storage *outboxStorage) DeleteWatchers(issueID int64) error {
+	storage.unwatched = append(storage.unwatched, issueID)
+	return nil
+}
+
+func newRelay(storage *outboxStorage, consumers ...Consumer) *Relay {
+	relay := NewRelay(storage, zap.NewNop().Sugar(), time.Second, consumers...)
+	relay.BatchSize = 2
+	return relay
+}
+
+func TestRelay_Drain(t *testing.T) {
+	t.Run("InOrder", func(t *testing.T) {
+		storage := newOutboxStorage(
+			models.IssueEvent{ID: 1, Event: events.IssueCreated, IssueID: 1},
+			models.IssueEvent{ID: 2, Event: events.IssueUpdated, IssueID: 1},
+			models.IssueEvent{ID: 5, Event: events.IssueDeleted, IssueID: 1},
+		)
+
+		received := make([]int64, 0)
+		consumer := Consumer{ID: "test", Consume: func(event models.IssueEvent) error {
+			received = append(received, event.ID)
+			return nil
+		}}
+
+		err := newRelay(storage, consumer).Drain()
+		assert.Nil(t, err)
+
+		assert.Equal(t, []int64{1, 2, 5}, received, "events are consumed in order, over several batches")
+		assert.Empty(t, storage.outbox, "consumed events are pruned")
+	})
+
+	t.Run("Retried", func(t *testing.T) {
+		storage := newOutboxStorage(
+			models.IssueEvent{ID: 1, Event: events.IssueCreated, IssueID: 1},
+			models.IssueEvent{ID: 2, Event: events.IssueUpdated, IssueID: 1},
+		)
+
+		healthy := make([]int64, 0)
+		failing := make([]int64, 0)
+		fail := true
+		relay := newRelay(storage,
+			Consumer{ID: "healthy", Consume: func(event models.IssueEvent) error {
+				healthy = append(healthy, event.ID)
+				return nil
+			}},
+			Consumer{ID: "failing", Consume: func(event models.IssueEvent) error {
+				failing = append(failing, event.ID)
+				if fail && event.ID == 2 {
+					return errors.New("unavailable")
+				}
+				return nil
+			}},
+		)
+
+		err := relay.Drain()
+		assert.NotNil(t, err)
+		assert.Equal(t, []int64{1, 2}, healthy, "a failing consumer doesn't hold back the others")
+		assert.Len(t, storage.outbox, 2, "events not consumed by every consumer are kept")
+
+		fail = false
+		err = relay.Drain()
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1, 2}, healthy, "consumed events are not consumed again")
+		assert.Equal(t, []int64{1, 2, 2}, failing, "the failed event is retried")
+		assert.Empty(t, storage.outbox)
+	})
+
+	t.Run("ConsumersChanged", func(t *testing.T) {
+		storage := newOutboxStorage(models.IssueEvent{ID: 1, Event: events.IssueCreated, IssueID: 1})
+		storage.consumed["email"] = map[int64]bool{1: true}
+		storage.consumed["webhooks"] = map[int64]bool{1: true}
+
+		received := make([]int64, 0)
+		relay := newRelay(storage,
+			Consumer{ID: "webhooks", Consume: func(event models.IssueEvent) error { return nil }},
+			Consumer{ID: "notifications", Consume: func(event models.IssueEvent) error {
+				received = append(received, event.ID)
+				return errors.New("unavailable")
+			}},
+		)
+
+		err := relay.Drain()
+		assert.NotNil(t, err)
+		_, err = storage.PruneOutbox([]string{"webhooks", "notifications"})
+		assert.Nil(t, err)
+		assert.Len(t, storage.outbox, 1, "events consumed by a removed consumer are kept for the new ones")
+
+		relay.consumers[1].Consume = func(event models.IssueEvent) error {
+			received = append(received, event.ID)
+			return nil
+		}
+		err = relay.Drain()
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1, 1}, received, "the event reaches the consumer added")
+		assert.Empty(t, storage.outbox)
+	})
+}
+
+func TestNotificationConsumer(t *testing.T) {
+	storage := newOutboxStorage()
+	consumer := NotificationConsumer(storage)
+
+	issue := models.IssueResponse{ID: 1, Summary: "This is a summary"}
+	for _, event := range []models.IssueEvent{
+		{ID: 1, Event: events.IssueCreated, IssueID: 1, Issue: &issue},
+		{ID: 2, Event: events.IssueUpdated, IssueID: 1, Actor: "janedoe", Changes: "status set to closed", Issue: &issue},
+		{ID: 3, Event: events.IssueDeleted, IssueID: 1, Actor: "janedoe", Issue: &issue},
+	} {
+		assert.Nil(t, consumer.Consume(event))
+	}
+
+	assert.Equal(t, []models.Notification{
+		{EventID: 2, IssueID: 1, Kind: NotificationUpdated, Actor: "janedoe", Message: "This is a summary: status set to closed"},
+		{EventID: 3, IssueID: 1, Kind: NotificationDeleted, Actor: "janedoe", Message: "issue #1 was deleted"},
+	}, storage.notifications, "creations are not notified")
+	assert.Equal(t, []int64{1}, storage.unwatched, "watchers of deleted issues are forgotten")
+}
+
+func TestStreamConsumer(t *testing.T) {
+	hub := events.NewHub(10)
+	subscription := hub.Subscribe(0, 0)
+	consumer := StreamConsumer(hub)
+
+	event := models.IssueEvent{ID: 3, Event: events.IssueCreated, IssueID: 1}
+	assert.Nil(t, consumer.Consume(event))
+	assert.Nil(t, consumer.Consume(event))
+
+	assert.Equal(t, event, <-subscription.Events)
+	assert.Len(t, subscription.Events, 0, "events consumed again are not streamed twice")
+}
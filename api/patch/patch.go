@@ -0,0 +1,417 @@
+// Package patch applies JSON Merge Patches (RFC 7396) and JSON Patches (RFC 6902) to JSON documents.
+//
+// Numbers are kept as written, patched documents holding them unchanged
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MergePatchType is the media type of JSON Merge Patches
+	MergePatchType = "application/merge-patch+json"
+
+	// JSONPatchType is the media type of JSON Patches
+	JSONPatchType = "application/json-patch+json"
+)
+
+// InvalidError is returned for a patch or document that is not valid, such as malformed JSON or an unknown operation
+type InvalidError struct {
+	Message string
+}
+
+func (err *InvalidError) Error() string {
+	return err.Message
+}
+
+// ConflictError is returned for a valid patch that can't be applied to the document, such as a failing test operation
+// or a path that does not exist
+type ConflictError struct {
+	Message string
+}
+
+func (err *ConflictError) Error() string {
+	return err.Message
+}
+
+// Merge applies a JSON Merge Patch to a document: members of the patch replace those of the document, objects being
+// merged recursively, and null members remove them
+func Merge(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc, "document")
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(patch, "patch")
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergeValue(targetObject[name], value)
+		}
+	}
+
+	return targetObject
+}
+
+// errNotFound is returned for paths that do not exist, reported as a ConflictError naming the path of the operation
+var errNotFound = errors.New("not found")
+
+// operation is an operation of a JSON Patch, the value being kept raw to tell a null value from a missing one
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies the operations of a JSON Patch to a document in order, the document being left unchanged when any of
+// them fails
+func Apply(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc, "document")
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []operation
+	if err = json.Unmarshal(patch, &operations); err != nil {
+		return nil, &InvalidError{Message: "invalid patch, expecting an array of operations: " + err.Error()}
+	}
+
+	for i, op := range operations {
+		if target, err = apply(target, op); err != nil {
+			switch e := err.(type) {
+			case *InvalidError:
+				e.Message = fmt.Sprintf("operation %d: %s", i, e.Message)
+			case *ConflictError:
+				e.Message = fmt.Sprintf("operation %d: %s", i, e.Message)
+			}
+			return nil, err
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func apply(doc interface{}, op operation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, &InvalidError{Message: fmt.Sprintf("%s is missing its path", op.Op)}
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, &InvalidError{Message: fmt.Sprintf("%s is missing its value", op.Op)}
+		}
+		if value, err = decode(op.Value, "value"); err != nil {
+			return nil, err
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return nil, &InvalidError{Message: fmt.Sprintf("%s is missing its from", op.Op)}
+		}
+	}
+
+	doc, err = applyAt(doc, op, path, value)
+	if err == errNotFound {
+		err = &ConflictError{Message: fmt.Sprintf("path %s does not exist", *op.Path)}
+	}
+
+	return doc, err
+}
+
+func applyAt(doc interface{}, op operation, path []string, value interface{}) (interface{}, error) {
+	var err error
+	switch op.Op {
+	case "add":
+		return add(doc, path, value)
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "test":
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, &ConflictError{Message: fmt.Sprintf("test failed, %s does not hold the value tested", *op.Path)}
+		}
+		return doc, nil
+	case "move", "copy":
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = get(doc, from); err == errNotFound {
+			return nil, &ConflictError{Message: fmt.Sprintf("path %s does not exist", *op.From)}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			value = deepCopy(value)
+		} else {
+			if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+				return nil, &ConflictError{Message: fmt.Sprintf("can't move %s into one of its children", *op.From)}
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		}
+		return add(doc, path, value)
+	}
+
+	return nil, &InvalidError{Message: fmt.Sprintf("unknown operation %q", op.Op)}
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens, the empty pointer referencing the whole
+// document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &InvalidError{Message: fmt.Sprintf("invalid path %q, expecting a JSON pointer", pointer)}
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, errNotFound
+			}
+			node = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, errNotFound
+		}
+	}
+
+	return node, nil
+}
+
+// add adds a value at a path, returning the node holding it, arrays being reallocated by inserts
+func add(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, last := path[0], len(path) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if last {
+			n[token] = value
+			return n, nil
+		}
+
+		child, ok := n[token]
+		if !ok {
+			return nil, errNotFound
+		}
+		child, err := add(child, path[1:], value)
+		n[token] = child
+		return n, err
+	case []interface{}:
+		if last {
+			index := len(n)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+			return n, nil
+		}
+
+		index, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		n[index], err = add(n[index], path[1:], value)
+		return n, err
+	}
+
+	return nil, errNotFound
+}
+
+// remove removes the value at a path, returning the node that held it
+func remove(node interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, &ConflictError{Message: "can't remove the whole document"}
+	}
+
+	token, last := path[0], len(path) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, errNotFound
+		}
+		if last {
+			delete(n, token)
+			return n, nil
+		}
+
+		child, err := remove(child, path[1:])
+		n[token] = child
+		return n, err
+	case []interface{}:
+		index, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			return append(n[:index], n[index+1:]...), nil
+		}
+
+		n[index], err = remove(n[index], path[1:])
+		return n, err
+	}
+
+	return nil, errNotFound
+}
+
+// arrayIndex parses the index of an array element, up to max
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, &InvalidError{Message: fmt.Sprintf("invalid array index %q", token)}
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, errNotFound
+	}
+
+	return index, nil
+}
+
+// equal compares JSON values, numbers being equal when their values are
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, value := range x {
+			other, ok := y[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for name, child := range v {
+			c[name] = deepCopy(child)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, child := range v {
+			c[i] = deepCopy(child)
+		}
+		return c
+	}
+
+	return value
+}
+
+// decode decodes a JSON value, numbers as json.Number
+func decode(data []byte, what string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, &InvalidError{Message: fmt.Sprintf("invalid %s: %s", what, err)}
+	}
+	if decoder.More() {
+		return nil, &InvalidError{Message: fmt.Sprintf("invalid %s: unexpected data after the JSON value", what)}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,101 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	// the examples of RFC 7396, appendix A
+	tests := []struct {
+		doc, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		merged, err := Merge([]byte(test.doc), []byte(test.patch))
+		assert.Nil(t, err)
+		assert.JSONEq(t, test.expected, string(merged), "merging %s into %s", test.patch, test.doc)
+	}
+
+	_, err := Merge([]byte(`{"a":"b"}`), []byte(`{"a":`))
+	assert.IsType(t, &InvalidError{}, err)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, expected string
+	}{
+		{"AddMember", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"AddElement", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			`{"foo":["bar","qux","baz"]}`},
+		{"AppendElement", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`},
+		{"AddNull", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":null}]`, `{"foo":"bar","baz":null}`},
+		{"RemoveMember", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"RemoveElement", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"Replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			`{"baz":"boo","foo":"bar"}`},
+		{"Move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"MoveElement", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`},
+		{"Copy", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"add","path":"/baz/qux","value":2}]`,
+			`{"foo":{"bar":1},"baz":{"bar":1,"qux":2}}`},
+		{"Test", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{"EscapedPath", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`,
+			`{"~1":10}`},
+		{"ReplaceDocument", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patched, err := Apply([]byte(test.doc), []byte(test.patch))
+			assert.Nil(t, err)
+			assert.JSONEq(t, test.expected, string(patched))
+		})
+	}
+
+	failures := []struct {
+		name, doc, patch string
+		expected         interface{}
+	}{
+		{"TestFails", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, &ConflictError{}},
+		{"MissingMember", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, &ConflictError{}},
+		{"MissingParent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, &ConflictError{}},
+		{"OutOfBounds", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`, &ConflictError{}},
+		{"MoveIntoChild", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, &ConflictError{}},
+		{"UnknownOperation", `{}`, `[{"op":"merge","path":"/foo","value":1}]`, &InvalidError{}},
+		{"MissingValue", `{}`, `[{"op":"add","path":"/foo"}]`, &InvalidError{}},
+		{"InvalidPointer", `{}`, `[{"op":"add","path":"foo","value":1}]`, &InvalidError{}},
+		{"InvalidIndex", `{"foo":["bar"]}`, `[{"op":"remove","path":"/foo/01"}]`, &InvalidError{}},
+		{"NotAnArray", `{}`, `{"op":"add","path":"/foo","value":1}`, &InvalidError{}},
+	}
+
+	for _, test := range failures {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Apply([]byte(test.doc), []byte(test.patch))
+			assert.NotNil(t, err)
+			assert.IsType(t, test.expected, err)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"database/sql"
+
+	"github.com/YAITS/api/models"
+)
+
+const attachmentColumns = `id, issueID, commentID, filename, contentType, size, hash, createDate`
+
+// CreateAttachment records an attachment, its content being stored with put unless other attachments already share
+// it, the comment when given having to belong to the issue. The content of the hash is locked until the attachment
+// is recorded, so that releasing the last attachment sharing it can't delete it in between
+func (mysqlSt *MysqlStorage) CreateAttachment(attachment models.Attachment, put func() error) (int64, error) {
+	tx, err := mysqlSt.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := createAttachment(tx, attachment, put)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func createAttachment(tx *sql.Tx, attachment models.Attachment, put func() error) (int64, error) {
+	var commentID interface{}
+
+	if attachment.CommentID != 0 {
+		var found int
+		query := `SELECT 1 FROM comments WHERE commentID = ? AND issueID = ?`
+		if err := tx.QueryRow(query, attachment.CommentID, attachment.IssueID).Scan(&found); err != nil {
+			return 0, err
+		}
+		commentID = attachment.CommentID
+	}
+
+	shared, err := lockContent(tx, attachment.Hash)
+	if err != nil {
+		return 0, err
+	}
+
+	if shared == 0 {
+		if err = put(); err != nil {
+			return 0, err
+		}
+	}
+
+	insertQuery := "INSERT INTO attachments(issueID, commentID, filename, contentType, size, hash) VALUES(?, ?, ?, ?, ?, ?)"
+
+	result, err := tx.Exec(insertQuery, attachment.IssueID, commentID, attachment.Filename,
+		attachment.ContentType, attachment.Size, attachment.Hash)
+	if err != nil {
+		return 0, err
+	}
+
+	id, _ := result.LastInsertId()
+	return id, nil
+}
+
+// RetrieveAttachments returns the attachments of an issue and of its comments
+func (mysqlSt *MysqlStorage) RetrieveAttachments(issueID int64) ([]models.Attachment, error) {
+	resp := make([]models.Attachment, 0)
+
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE issueID = ? ORDER BY id`
+
+	rows, err := mysqlSt.db.Query(query, issueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, attachment)
+	}
+
+	return resp, rows.Err()
+}
+
+// RetrieveAttachmentByID returns an attachment filtered by its id
+func (mysqlSt *MysqlStorage) RetrieveAttachmentByID(attachmentID int64) (models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = ?`
+
+	return scanAttachment(mysqlSt.db.QueryRow(query, attachmentID))
+}
+
+// DeleteAttachmentByID deletes an attachment record, its content being left to the caller
+func (mysqlSt *MysqlStorage) DeleteAttachmentByID(attachmentID int64) error {
+	query := `DELETE FROM attachments WHERE id = ?`
+
+	_, err := mysqlSt.db.Exec(query, attachmentID)
+
+	return err
+}
+
+// ReleaseAttachmentContent deletes the content with the given hash with remove once no attachment refers to it
+// anymore, under the lock CreateAttachment takes on it
+func (mysqlSt *MysqlStorage) ReleaseAttachmentContent(hash string, remove func() error) error {
+	tx, err := mysqlSt.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	shared, err := lockContent(tx, hash)
+	if err == nil && shared == 0 {
+		if err = remove(); err == nil {
+			_, err = tx.Exec(`DELETE FROM attachment_contents WHERE hash = ?`, hash)
+		}
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockContent locks the content with the given hash until the end of the transaction, returning how many
+// attachments share it
+func lockContent(tx *sql.Tx, hash string) (int64, error) {
+	query := `INSERT INTO attachment_contents (hash) VALUES (?) ON DUPLICATE KEY UPDATE hash = hash`
+	if _, err := tx.Exec(query, hash); err != nil {
+		return 0, err
+	}
+
+	// a locking read, for attachments committed while waiting for the lock to be counted
+	var count int64
+	query = `SELECT COUNT(*) FROM attachments WHERE hash = ? LOCK IN SHARE MODE`
+	err := tx.QueryRow(query, hash).Scan(&count)
+
+	return count, err
+}
+
+func scanAttachment(row rowScanner) (models.Attachment, error) {
+	var attachment models.Attachment
+	var commentID sql.NullInt64
+
+	err := row.Scan(&attachment.ID, &attachment.IssueID, &commentID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.Hash, &attachment.CreateDate)
+	attachment.CommentID = commentID.Int64
+
+	return attachment, err
+}
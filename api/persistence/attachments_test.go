@@ -0,0 +1,177 @@
+package persistence
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_CreateAttachment(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+	attachment := models.Attachment{IssueID: IssueID, Filename: "steps.txt", ContentType: "text/plain", Size: 4, Hash: "hash"}
+
+	var puts int
+	put := func() error {
+		puts++
+		return nil
+	}
+
+	t.Run("OnIssue", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectContentLock(mock, "hash", 0)
+		mock.ExpectExec("INSERT INTO attachments").
+			WithArgs(IssueID, nil, "steps.txt", "text/plain", 4, "hash").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		if _, err = testingStorage.CreateAttachment(attachment, put); err != nil {
+			t.Errorf("Error should not have occurred while creating attachment: %s", err)
+		}
+		assert.Equal(t, 1, puts, "a new content is stored")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("OnComment", func(t *testing.T) {
+		onComment := attachment
+		onComment.CommentID = 2
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT 1 FROM comments").
+			WithArgs(2, IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		expectContentLock(mock, "hash", 1)
+		mock.ExpectExec("INSERT INTO attachments").
+			WithArgs(IssueID, 2, "steps.txt", "text/plain", 4, "hash").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		if _, err = testingStorage.CreateAttachment(onComment, put); err != nil {
+			t.Errorf("Error should not have occurred while creating attachment: %s", err)
+		}
+		assert.Equal(t, 1, puts, "a shared content is stored once")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("CommentOfAnotherIssue", func(t *testing.T) {
+		onComment := attachment
+		onComment.CommentID = 3
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT 1 FROM comments").
+			WithArgs(3, IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}))
+		mock.ExpectRollback()
+
+		_, err = testingStorage.CreateAttachment(onComment, put)
+		assert.Equal(t, sql.ErrNoRows, err, "comment must belong to the issue")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+func TestMysqlStorage_ReleaseAttachmentContent(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	var removes int
+	remove := func() error {
+		removes++
+		return nil
+	}
+
+	t.Run("Shared", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectContentLock(mock, "hash", 2)
+		mock.ExpectCommit()
+
+		if err := testingStorage.ReleaseAttachmentContent("hash", remove); err != nil {
+			t.Errorf("Error should not have occurred while releasing content: %s", err)
+		}
+		assert.Equal(t, 0, removes, "a content still shared is kept")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("Unused", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectContentLock(mock, "hash", 0)
+		mock.ExpectExec("DELETE FROM attachment_contents").
+			WithArgs("hash").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := testingStorage.ReleaseAttachmentContent("hash", remove); err != nil {
+			t.Errorf("Error should not have occurred while releasing content: %s", err)
+		}
+		assert.Equal(t, 1, removes, "a content no attachment refers to is removed")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("RemoveFailed", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectContentLock(mock, "hash", 0)
+		mock.ExpectRollback()
+
+		err := testingStorage.ReleaseAttachmentContent("hash", func() error {
+			return sql.ErrConnDone
+		})
+		assert.Equal(t, sql.ErrConnDone, err)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+// expectContentLock expects the content of a hash to be locked, shared by the given number of attachments
+func expectContentLock(mock sqlmock.Sqlmock, hash string, shared int64) {
+	mock.ExpectExec("INSERT INTO attachment_contents").
+		WithArgs(hash).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM attachments (.+) LOCK IN SHARE MODE").
+		WithArgs(hash).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(shared))
+}
@@ -0,0 +1,270 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupTables are the tables backups hold, in an order their rows can be loaded in without breaking foreign keys.
+// The schema migrations are left out, backups being loaded into a schema migrated to their version, and so are the
+// idempotency keys, which expire within hours, along with the bulk jobs, whose results are only polled while they
+// run, and the attachment contents, which only lock the contents attachments share
+var BackupTables = []string{
+	"issues", "comments", "issue_types", "issue_fields", "business_calendars", "sla_policies", "sla_breaches",
+	"worklogs", "attachments", "watchers", "notifications", "webhooks", "webhook_deliveries", "outbox",
+	"outbox_consumed", "email_preferences", "emails", "issue_links", "import_jobs", "inbound_messages", "api_tokens",
+}
+
+// backupTables returns the backup tables that exist at a schema version, in order, all of them for a database never
+// migrated
+func backupTables(schemaVersion int64) []string {
+	if schemaVersion < 1 || schemaVersion > int64(len(Migrations)) {
+		return BackupTables
+	}
+
+	schema := schemaTables(Migrations[:schemaVersion])
+	tables := make([]string, 0, len(BackupTables))
+	for _, table := range BackupTables {
+		if schema[table] != nil {
+			tables = append(tables, table)
+		}
+	}
+
+	return tables
+}
+
+// seededTables are the tables migrations seed, their rows being replaced by those of the backup loaded
+var seededTables = map[string]bool{"issue_types": true}
+
+// backupTimeFormat is the format dates are read from and written to backups in, as UTC
+const backupTimeFormat = "2006-01-02 15:04:05.999999"
+
+// NotEmptyError is returned loading a backup into a database already holding data
+type NotEmptyError struct {
+	Table string
+}
+
+func (err *NotEmptyError) Error() string {
+	return fmt.Sprintf("table %s is not empty, backups are only loaded into an empty database", err.Table)
+}
+
+// SnapshotTables reads the rows of the backup tables in one consistent snapshot, returning the schema version. Values
+// are read as int64, float64, string or nil, dates as UTC strings, for rows to load the same into any database
+func (mysqlSt *MysqlStorage) SnapshotTables(each func(table string, row map[string]interface{}) error) (int64, error) {
+	if err := mysqlSt.createMigrationTable(); err != nil {
+		return 0, err
+	}
+
+	tx, err := mysqlSt.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var version sql.NullInt64
+	if err = tx.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	for _, table := range backupTables(version.Int64) {
+		if err = snapshotTable(tx, table, each); err != nil {
+			return 0, err
+		}
+	}
+
+	return version.Int64, tx.Commit()
+}
+
+func snapshotTable(tx *sql.Tx, table string, each func(table string, row map[string]interface{}) error) error {
+	rows, err := tx.Query(`SELECT * FROM ` + table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if row[column.Name()], err = backupValue(values[i], column.DatabaseTypeName()); err != nil {
+				return fmt.Errorf("couldn't read %s.%s: %s", table, column.Name(), err)
+			}
+		}
+
+		if err = each(table, row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// backupValue converts a value scanned from MySQL, which reads numbers and text as bytes
+func backupValue(value interface{}, databaseType string) (interface{}, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(backupTimeFormat), nil
+	case []byte:
+		switch {
+		case strings.Contains(databaseType, "INT"):
+			return strconv.ParseInt(string(v), 10, 64)
+		case databaseType == "DECIMAL" || databaseType == "FLOAT" || databaseType == "DOUBLE":
+			return strconv.ParseFloat(string(v), 64)
+		}
+		return string(v), nil
+	}
+
+	return value, nil
+}
+
+// LoadTables loads the rows of a backup of a schema version into an empty database in one transaction, calling load
+// for every backup table in order with the function inserting its rows. The rows of the tables migrations seed are
+// replaced, the database being empty otherwise
+func (mysqlSt *MysqlStorage) LoadTables(schemaVersion int64, load func(table string, insert func(row map[string]interface{}) error) error) error {
+	version, err := mysqlSt.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version != schemaVersion {
+		return versionMismatch(schemaVersion, version)
+	}
+
+	return loadTables(mysqlSt.db, schemaVersion, load)
+}
+
+func versionMismatch(backupVersion, version int64) error {
+	return fmt.Errorf("the backup is of schema version %d and the database of version %d, backups being loaded into "+
+		"a database of their version", backupVersion, version)
+}
+
+// loadTables loads the rows of a backup of a schema version into empty tables in one transaction, replacing the rows
+// migrations seed
+func loadTables(db *sql.DB, schemaVersion int64, load func(table string, insert func(row map[string]interface{}) error) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tables := backupTables(schemaVersion)
+	for _, table := range tables {
+		if seededTables[table] {
+			_, err = tx.Exec(`DELETE FROM ` + table)
+		} else {
+			err = checkEmpty(tx, table)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, table := range tables {
+		columns, err := tableColumns(tx, table)
+		if err != nil {
+			return err
+		}
+
+		statements := map[string]*sql.Stmt{}
+		err = load(table, func(row map[string]interface{}) error {
+			return insertRow(tx, table, columns, statements, row)
+		})
+		for _, stmt := range statements {
+			stmt.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't load %s: %s", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func checkEmpty(tx *sql.Tx, table string) error {
+	var one int64
+	err := tx.QueryRow(`SELECT 1 FROM ` + table + ` LIMIT 1`).Scan(&one)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return &NotEmptyError{Table: table}
+}
+
+// tableColumns returns the columns of a table, the columns of the rows loaded being checked against them
+func tableColumns(tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query(`SELECT * FROM ` + table + ` LIMIT 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+
+	return columns, nil
+}
+
+// insertRow inserts a row, the statements being prepared once per set of columns
+func insertRow(tx *sql.Tx, table string, columns map[string]bool, statements map[string]*sql.Stmt, row map[string]interface{}) error {
+	if len(row) == 0 {
+		return errors.New("empty row")
+	}
+
+	names := make([]string, 0, len(row))
+	for name := range row {
+		if !columns[name] {
+			return fmt.Errorf("unknown column %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := strings.Join(names, ",")
+	stmt, ok := statements[key]
+	if !ok {
+		var err error
+		query := fmt.Sprintf("INSERT INTO %s (`%s`) VALUES (?%s)", table, strings.Join(names, "`, `"),
+			strings.Repeat(", ?", len(names)-1))
+		if stmt, err = tx.Prepare(query); err != nil {
+			return err
+		}
+		statements[key] = stmt
+	}
+
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = row[name]
+	}
+
+	_, err := stmt.Exec(args...)
+	return err
+}
@@ -0,0 +1,141 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_SnapshotTables(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tables := BackupTables
+	BackupTables = []string{"issues", "watchers"}
+	defer func() {
+		BackupTables = tables
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+	latest := Migrations[len(Migrations)-1].Version
+
+	createDate := time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)
+	issueRows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", int64(0)),
+		sqlmock.NewColumn("summary").OfType("VARCHAR", ""),
+		sqlmock.NewColumn("createDate").OfType("TIMESTAMP", time.Time{}),
+		sqlmock.NewColumn("dueDate").OfType("TIMESTAMP", time.Time{}).Nullable(true),
+	).AddRow([]byte("1"), []byte("Login fails"), createDate, nil)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT MAX\\(version\\) FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(latest))
+	mock.ExpectQuery("SELECT \\* FROM issues").WillReturnRows(issueRows)
+	mock.ExpectQuery("SELECT \\* FROM watchers").
+		WillReturnRows(sqlmock.NewRows([]string{"issueID", "username"}))
+	mock.ExpectCommit()
+
+	// run the code
+	var rows []map[string]interface{}
+	version, err := testingStorage.SnapshotTables(func(table string, row map[string]interface{}) error {
+		assert.Equal(t, "issues", table)
+		rows = append(rows, row)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, latest, version)
+	assert.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "summary": "Login fails", "createDate": "2020-07-01 10:00:00", "dueDate": nil},
+	}, rows)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMysqlStorage_LoadTables(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tables := BackupTables
+	BackupTables = []string{"issues", "issue_types"}
+	defer func() {
+		BackupTables = tables
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	expectVersion := func(version int64) {
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT MAX\\(version\\) FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+	}
+
+	expectVersion(2)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT 1 FROM issues LIMIT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	mock.ExpectExec("DELETE FROM issue_types").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery("SELECT \\* FROM issues LIMIT 0").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "priority"}))
+	mock.ExpectPrepare("INSERT INTO issues \\(`id`, `priority`, `summary`\\) VALUES \\(\\?, \\?, \\?\\)").
+		ExpectExec().
+		WithArgs(int64(1), int64(2), "Login fails").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT \\* FROM issue_types LIMIT 0").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectRollback()
+
+	// run the code
+	err = testingStorage.LoadTables(2, func(table string, insert func(row map[string]interface{}) error) error {
+		switch table {
+		case "issues":
+			return insert(map[string]interface{}{"id": int64(1), "summary": "Login fails", "priority": int64(2)})
+		default:
+			return insert(map[string]interface{}{"id": int64(1), "name; DROP TABLE issues": "Bug"})
+		}
+	})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown column")
+
+	t.Run("NotEmpty", func(t *testing.T) {
+		expectVersion(2)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT 1 FROM issues LIMIT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := testingStorage.LoadTables(2, func(string, func(map[string]interface{}) error) error { return nil })
+		assert.Equal(t, &NotEmptyError{Table: "issues"}, err)
+	})
+
+	t.Run("SchemaVersion", func(t *testing.T) {
+		expectVersion(3)
+
+		err := testingStorage.LoadTables(2, func(string, func(map[string]interface{}) error) error { return nil })
+		assert.NotNil(t, err)
+	})
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
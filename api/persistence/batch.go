@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"strings"
+
+	"github.com/YAITS/api/models"
+)
+
+// RetrieveComments returns the comments of several issues in a single query, by issue id. Issues without comments
+// are left out
+func (mysqlSt *MysqlStorage) RetrieveComments(issueIDs ...int64) (map[int64][]models.Comment, error) {
+	comments := make(map[int64][]models.Comment)
+	if len(issueIDs) == 0 {
+		return comments, nil
+	}
+
+	args := make([]interface{}, 0, len(issueIDs))
+	for _, id := range issueIDs {
+		args = append(args, id)
+	}
+
+	query := `SELECT issueID, commentID, comment FROM comments WHERE issueID IN (` + placeholders(len(args)) +
+		`) ORDER BY issueID, commentID`
+
+	rows, err := mysqlSt.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var issueID int64
+		var comment models.Comment
+		if err = rows.Scan(&issueID, &comment.ID, &comment.Comment); err != nil {
+			return nil, err
+		}
+		comments[issueID] = append(comments[issueID], comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// RetrieveFields returns the custom fields of several issues in a single query, by issue id. Issues without custom
+// fields are left out
+func (mysqlSt *MysqlStorage) RetrieveFields(issueIDs ...int64) (map[int64]map[string]string, error) {
+	fields := make(map[int64]map[string]string)
+	if len(issueIDs) == 0 {
+		return fields, nil
+	}
+
+	args := make([]interface{}, 0, len(issueIDs))
+	for _, id := range issueIDs {
+		args = append(args, id)
+	}
+
+	query := `SELECT issueID, name, value FROM issue_fields WHERE issueID IN (` + placeholders(len(args)) + `)`
+
+	rows, err := mysqlSt.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var issueID int64
+		var name, value string
+		if err = rows.Scan(&issueID, &name, &value); err != nil {
+			return nil, err
+		}
+		if fields[issueID] == nil {
+			fields[issueID] = make(map[string]string)
+		}
+		fields[issueID][name] = value
+	}
+
+	return fields, rows.Err()
+}
+
+// RetrieveUsers returns the users issues are assigned to or reported by, in name order, restricted to the given
+// names if any
+func (mysqlSt *MysqlStorage) RetrieveUsers(names ...string) ([]models.User, error) {
+	query := `SELECT name, SUM(assigned), SUM(reported) FROM (
+		SELECT assignee AS name, 1 AS assigned, 0 AS reported FROM issues WHERE assignee <> ''
+		UNION ALL SELECT reporter AS name, 0 AS assigned, 1 AS reported FROM issues WHERE reporter <> ''
+	) AS users`
+	args := make([]interface{}, 0, len(names))
+	if len(names) > 0 {
+		for _, name := range names {
+			args = append(args, name)
+		}
+		query += ` WHERE name IN (` + placeholders(len(args)) + `)`
+	}
+	query += ` GROUP BY name ORDER BY name`
+
+	rows, err := mysqlSt.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err = rows.Scan(&user.Name, &user.Assigned, &user.Reported); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// RetrieveProjects returns the projects issues are filed under, in key order, restricted to the given keys if any
+func (mysqlSt *MysqlStorage) RetrieveProjects(keys ...string) ([]models.Project, error) {
+	query := `SELECT project, COUNT(*), SUM(CASE WHEN status = 'closed' THEN 0 ELSE 1 END) FROM issues`
+	args := make([]interface{}, 0, len(keys))
+	if len(keys) > 0 {
+		for _, key := range keys {
+			args = append(args, key)
+		}
+		query += ` WHERE project IN (` + placeholders(len(args)) + `)`
+	}
+	query += ` GROUP BY project ORDER BY project`
+
+	rows, err := mysqlSt.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := make([]models.Project, 0)
+	for rows.Next() {
+		var project models.Project
+		if err = rows.Scan(&project.Key, &project.Issues, &project.OpenIssues); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+// placeholders returns the ?, ?, ... parameters of an IN clause of n values
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,161 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_RetrieveComments(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT issueID, commentID, comment FROM comments WHERE issueID IN \\(\\?, \\?, \\?\\)").
+		WithArgs(int64(1), int64(2), int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"issueID", "commentID", "comment"}).
+			AddRow(1, 1, Comment).
+			AddRow(1, 2, "Another comment").
+			AddRow(3, 3, Comment))
+
+	// run the code
+	comments, err := testingStorage.RetrieveComments(1, 2, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64][]models.Comment{
+		1: {{ID: 1, Comment: Comment}, {ID: 2, Comment: "Another comment"}},
+		3: {{ID: 3, Comment: Comment}},
+	}, comments)
+
+	// no issue means no query
+	comments, err = testingStorage.RetrieveComments()
+	assert.Nil(t, err)
+	assert.Empty(t, comments)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveFields(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT issueID, name, value FROM issue_fields WHERE issueID IN \\(\\?, \\?\\)").
+		WithArgs(int64(1), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"issueID", "name", "value"}).
+			AddRow(2, "browser", "Firefox").
+			AddRow(2, "os", "Linux"))
+
+	// run the code
+	fields, err := testingStorage.RetrieveFields(1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]map[string]string{2: {"browser": "Firefox", "os": "Linux"}}, fields)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveUsers(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	t.Run("All", func(t *testing.T) {
+		mock.ExpectQuery("SELECT name, SUM\\(assigned\\), SUM\\(reported\\) FROM (.+) AS users GROUP BY name").
+			WithArgs().
+			WillReturnRows(sqlmock.NewRows([]string{"name", "assigned", "reported"}).
+				AddRow(Assignee, 2, 1).
+				AddRow("Jane Doe", 0, 3))
+
+		// run the code
+		users, err := testingStorage.RetrieveUsers()
+		assert.Nil(t, err)
+		assert.Equal(t, []models.User{{Name: Assignee, Assigned: 2, Reported: 1}, {Name: "Jane Doe", Reported: 3}}, users)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("ByName", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) AS users WHERE name IN \\(\\?\\) GROUP BY name").
+			WithArgs(Assignee).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "assigned", "reported"}).
+				AddRow(Assignee, 2, 1))
+
+		// run the code
+		users, err := testingStorage.RetrieveUsers(Assignee)
+		assert.Nil(t, err)
+		assert.Equal(t, []models.User{{Name: Assignee, Assigned: 2, Reported: 1}}, users)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+func TestMysqlStorage_RetrieveProjects(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT project, COUNT\\(\\*\\), (.+) FROM issues WHERE project IN \\(\\?, \\?\\) GROUP BY project").
+		WithArgs(Project, "WEB").
+		WillReturnRows(sqlmock.NewRows([]string{"project", "issues", "openIssues"}).
+			AddRow(Project, 5, 2).
+			AddRow("WEB", 1, 1))
+
+	// run the code
+	projects, err := testingStorage.RetrieveProjects(Project, "WEB")
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Project{{Key: Project, Issues: 5, OpenIssues: 2}, {Key: "WEB", Issues: 1, OpenIssues: 1}}, projects)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
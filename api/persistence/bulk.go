@@ -0,0 +1,238 @@
+package persistence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/YAITS/api/models"
+)
+
+// Bulk job statuses, the same as the import job ones
+const (
+	BulkJobQueued    = ImportJobQueued
+	BulkJobRunning   = ImportJobRunning
+	BulkJobCompleted = ImportJobCompleted
+	BulkJobFailed    = ImportJobFailed
+)
+
+const (
+	// LabelsField is the custom field holding the labels of an issue, separated by commas
+	LabelsField = "labels"
+
+	// errBulkRolledBack is the error of the issues of an all-or-nothing bulk change rolled back or never changed
+	// because another issue failed
+	errBulkRolledBack = "not applied, another issue failed"
+)
+
+// BulkChangeIssues applies a change to issues in a single transaction, returning the result of each of them in
+// order. An issue failing only rolls its own change back, unless allOrNothing is set, in which case the whole
+// transaction is rolled back at the first failure and the other issues are reported as not applied
+func (mysqlSt *MysqlStorage) BulkChangeIssues(change models.BulkChange, issueIDs []int64, allOrNothing bool) ([]models.BulkItemResult, error) {
+	results := make([]models.BulkItemResult, 0, len(issueIDs))
+
+	tx, err := mysqlSt.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, issueID := range issueIDs {
+		if !allOrNothing {
+			if _, err = tx.Exec(`SAVEPOINT bulk_item`); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		itemErr := bulkChangeIssue(tx, change, issueID)
+		if itemErr == nil {
+			results = append(results, models.BulkItemResult{IssueID: issueID, Success: true})
+			continue
+		}
+
+		message := itemErr.Error()
+		if itemErr == sql.ErrNoRows {
+			message = "could not find issue"
+		} else if constraint, ok := AsConstraintError(itemErr); ok {
+			message = constraint.Message
+		}
+
+		if allOrNothing {
+			tx.Rollback()
+			return rolledBack(issueIDs, i, message), nil
+		}
+
+		if _, err = tx.Exec(`ROLLBACK TO SAVEPOINT bulk_item`); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		results = append(results, models.BulkItemResult{IssueID: issueID, Error: message})
+	}
+
+	return results, tx.Commit()
+}
+
+// bulkChangeIssue applies a bulk change to a single issue, sql.ErrNoRows when there is no such issue
+func bulkChangeIssue(tx *sql.Tx, change models.BulkChange, issueID int64) error {
+	if change.Delete {
+		return deleteIssue(tx, issueID, change.Events)
+	}
+
+	issue, err := scanIssue(tx.QueryRow(`SELECT `+issueColumns+` FROM issues WHERE id = ? FOR UPDATE`, issueID))
+	if err != nil {
+		return err
+	}
+
+	if change.Label != "" {
+		if issue.Fields, err = issueFields(tx, issueID); err != nil {
+			return err
+		}
+
+		labels := splitLabels(issue.Fields[LabelsField])
+		for _, label := range labels {
+			if label == change.Label {
+				// the issue already has the label, nothing changes
+				return nil
+			}
+		}
+		if issue.Fields == nil {
+			issue.Fields = make(map[string]string)
+		}
+		issue.Fields[LabelsField] = strings.Join(append(labels, change.Label), ",")
+
+		upsertQuery := `INSERT INTO issue_fields (issueID, name, value) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE value = VALUES(value)`
+
+		if _, err = tx.Exec(upsertQuery, issueID, LabelsField, issue.Fields[LabelsField]); err != nil {
+			return err
+		}
+	}
+
+	return updateIssue(tx, &issue, change.Update, change.Events)
+}
+
+// rolledBack returns the results of an all-or-nothing bulk change rolled back because of the failure of an issue
+func rolledBack(issueIDs []int64, failed int, message string) []models.BulkItemResult {
+	results := make([]models.BulkItemResult, 0, len(issueIDs))
+	for i, issueID := range issueIDs {
+		result := models.BulkItemResult{IssueID: issueID, Error: errBulkRolledBack}
+		if i == failed {
+			result.Error = message
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// issueFields returns the custom fields of an issue within a transaction
+func issueFields(tx *sql.Tx, issueID int64) (map[string]string, error) {
+	rows, err := tx.Query(`SELECT name, value FROM issue_fields WHERE issueID = ?`, issueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields map[string]string
+	for rows.Next() {
+		var name, value string
+		if err = rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[name] = value
+	}
+
+	return fields, rows.Err()
+}
+
+// splitLabels returns the labels of the labels custom field
+func splitLabels(value string) []string {
+	labels := make([]string, 0)
+	for _, label := range strings.Split(value, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	return labels
+}
+
+// CreateBulkJob records a bulk operation about to run in the background
+func (mysqlSt *MysqlStorage) CreateBulkJob(job models.BulkJob) (int64, error) {
+	results, err := encodeBulkResults(job.Results)
+	if err != nil {
+		return 0, err
+	}
+
+	insertQuery := `INSERT INTO bulk_jobs(operation, status, createdBy, allOrNothing, total, processed, succeeded, failed,
+		results) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := mysqlSt.db.Exec(insertQuery, job.Operation, job.Status, job.CreatedBy, job.AllOrNothing, job.Total,
+		job.Processed, job.Succeeded, job.Failed, string(results))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateBulkJob records the status, the progress and the results of a bulk job
+func (mysqlSt *MysqlStorage) UpdateBulkJob(job models.BulkJob) error {
+	results, err := encodeBulkResults(job.Results)
+	if err != nil {
+		return err
+	}
+
+	updateQuery := `UPDATE bulk_jobs SET status = ?, processed = ?, succeeded = ?, failed = ?, results = ? WHERE id = ?`
+
+	_, err = mysqlSt.db.Exec(updateQuery, job.Status, job.Processed, job.Succeeded, job.Failed, string(results), job.ID)
+
+	return err
+}
+
+// FailInterruptedBulkJobs fails the bulk jobs left queued or running by a server that stopped, their progress being
+// kept, returning how many were. It is meant to run at startup, before any job is started
+func (mysqlSt *MysqlStorage) FailInterruptedBulkJobs() (int64, error) {
+	updateQuery := `UPDATE bulk_jobs SET status = ? WHERE status IN (?, ?)`
+
+	result, err := mysqlSt.db.Exec(updateQuery, BulkJobFailed, BulkJobQueued, BulkJobRunning)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// RetrieveBulkJob returns a bulk job along with its progress and the results of the issues processed
+func (mysqlSt *MysqlStorage) RetrieveBulkJob(jobID int64) (models.BulkJob, error) {
+	var job models.BulkJob
+	var results sql.NullString
+
+	query := `SELECT id, operation, status, createdBy, allOrNothing, total, processed, succeeded, failed, results,
+		createDate, updateDate FROM bulk_jobs WHERE id = ?`
+
+	err := mysqlSt.db.QueryRow(query, jobID).Scan(&job.ID, &job.Operation, &job.Status, &job.CreatedBy,
+		&job.AllOrNothing, &job.Total, &job.Processed, &job.Succeeded, &job.Failed, &results, &job.CreateDate,
+		&job.UpdateDate)
+	if err != nil {
+		return job, err
+	}
+
+	job.Results = make([]models.BulkItemResult, 0)
+	if results.Valid && results.String != "" {
+		err = json.Unmarshal([]byte(results.String), &job.Results)
+	}
+
+	return job, err
+}
+
+func encodeBulkResults(results []models.BulkItemResult) ([]byte, error) {
+	if results == nil {
+		results = make([]models.BulkItemResult, 0)
+	}
+
+	return json.Marshal(results)
+}
@@ -0,0 +1,244 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_BulkChangeIssues(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	issueColumnNames := []string{"id", "summary", "description", "priority", "status", "assignee", "reporter",
+		"createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate",
+		"remainingEstimate", "timeSpent", "version"}
+	issueRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(issueColumnNames).
+			AddRow(IssueID, Summary, Description, Priority, "open", Assignee, Assignee, CreateDate, Project, Type, nil,
+				nil, nil, 0, 0, 0, 1)
+	}
+	change := models.BulkChange{
+		Update: models.UpdateIssueRequest{Priority: 5},
+		Events: []models.IssueEvent{{Event: "issue.updated", Changes: "priority set to 5"}},
+	}
+
+	t.Run("ItemRolledBack", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT bulk_item").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT (.+) FROM issues WHERE id = \\? FOR UPDATE").
+			WithArgs(IssueID).
+			WillReturnRows(issueRows())
+		mock.ExpectExec("UPDATE issues SET").
+			WithArgs(Summary, Description, Assignee, "open", 5, nil, nil, nil, 0, 0, IssueID, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO outbox").
+			WithArgs("issue.updated", IssueID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("SAVEPOINT bulk_item").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT (.+) FROM issues WHERE id = \\? FOR UPDATE").
+			WithArgs(IssueID + 1).
+			WillReturnRows(sqlmock.NewRows(issueColumnNames))
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_item").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		// run the code
+		results, err := testingStorage.BulkChangeIssues(change, []int64{IssueID, IssueID + 1}, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []models.BulkItemResult{
+			{IssueID: IssueID, Success: true},
+			{IssueID: IssueID + 1, Error: "could not find issue"},
+		}, results)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("AllOrNothing", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM issues WHERE id = \\? FOR UPDATE").
+			WithArgs(IssueID).
+			WillReturnRows(issueRows())
+		mock.ExpectExec("UPDATE issues SET").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO outbox").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("SELECT (.+) FROM issues WHERE id = \\? FOR UPDATE").
+			WithArgs(IssueID + 1).
+			WillReturnRows(sqlmock.NewRows(issueColumnNames))
+		mock.ExpectRollback()
+
+		// run the code
+		results, err := testingStorage.BulkChangeIssues(change, []int64{IssueID, IssueID + 1, IssueID + 2}, true)
+		assert.Nil(t, err)
+		assert.Equal(t, []models.BulkItemResult{
+			{IssueID: IssueID, Error: errBulkRolledBack},
+			{IssueID: IssueID + 1, Error: "could not find issue"},
+			{IssueID: IssueID + 2, Error: errBulkRolledBack},
+		}, results)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("AddLabel", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT bulk_item").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT (.+) FROM issues WHERE id = \\? FOR UPDATE").
+			WithArgs(IssueID).
+			WillReturnRows(issueRows())
+		mock.ExpectQuery("SELECT (.+) FROM issue_fields").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).AddRow(LabelsField, "backend, urgent"))
+		mock.ExpectExec("INSERT INTO issue_fields (.+) ON DUPLICATE KEY UPDATE").
+			WithArgs(IssueID, LabelsField, "backend,urgent,login").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("UPDATE issues SET").
+			WithArgs(Summary, Description, Assignee, "open", Priority, nil, nil, nil, 0, 0, IssueID, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("SAVEPOINT bulk_item").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT (.+) FROM issues WHERE id = \\? FOR UPDATE").
+			WithArgs(IssueID + 1).
+			WillReturnRows(issueRows())
+		mock.ExpectQuery("SELECT (.+) FROM issue_fields").
+			WithArgs(IssueID + 1).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).AddRow(LabelsField, "login"))
+		mock.ExpectCommit()
+
+		// run the code, the second issue having the label already
+		results, err := testingStorage.BulkChangeIssues(models.BulkChange{Label: "login"},
+			[]int64{IssueID, IssueID + 1}, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []models.BulkItemResult{
+			{IssueID: IssueID, Success: true},
+			{IssueID: IssueID + 1, Success: true},
+		}, results)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT bulk_item").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM issues").
+			WithArgs(IssueID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// run the code
+		results, err := testingStorage.BulkChangeIssues(models.BulkChange{Delete: true}, []int64{IssueID}, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []models.BulkItemResult{{IssueID: IssueID, Success: true}}, results)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+func TestMysqlStorage_BulkJob(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	results := []models.BulkItemResult{{IssueID: IssueID, Success: true}, {IssueID: 2, Error: "could not find issue"}}
+	encodedResults := `[{"issueID":1,"success":true},{"issueID":2,"success":false,"error":"could not find issue"}]`
+
+	mock.ExpectExec("INSERT INTO bulk_jobs").
+		WithArgs("setStatus", BulkJobQueued, Assignee, false, 2, 0, 0, 0, "[]").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE bulk_jobs").
+		WithArgs(BulkJobCompleted, 2, 1, 1, encodedResults, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM bulk_jobs").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "operation", "status", "createdBy", "allOrNothing", "total",
+			"processed", "succeeded", "failed", "results", "createDate", "updateDate"}).
+			AddRow(1, "setStatus", BulkJobCompleted, Assignee, false, 2, 2, 1, 1, encodedResults, CreateDate, CreateDate))
+
+	// run the code
+	job := models.BulkJob{Operation: "setStatus", Status: BulkJobQueued, CreatedBy: Assignee, Total: 2}
+	job.ID, err = testingStorage.CreateBulkJob(job)
+	assert.Nil(t, err)
+
+	job.Status, job.Processed, job.Succeeded, job.Failed, job.Results = BulkJobCompleted, 2, 1, 1, results
+	assert.Nil(t, testingStorage.UpdateBulkJob(job))
+
+	retrieved, err := testingStorage.RetrieveBulkJob(1)
+	assert.Nil(t, err)
+	assert.Equal(t, results, retrieved.Results)
+	assert.Equal(t, int64(1), retrieved.Succeeded)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_FailInterruptedBulkJobs(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("UPDATE bulk_jobs SET status = (.+) WHERE status IN").
+		WithArgs(BulkJobFailed, BulkJobQueued, BulkJobRunning).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// run the code
+	failed, err := testingStorage.FailInterruptedBulkJobs()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), failed)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
@@ -0,0 +1,95 @@
+package persistence
+
+import (
+	"regexp"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Reasons of constraint errors, the same as the validation codes
+const (
+	ConstraintOutOfRange = "out_of_range"
+	ConstraintTooLong    = "too_long"
+	ConstraintInvalid    = "invalid"
+	ConstraintRequired   = "required"
+	ConstraintDuplicate  = "duplicate"
+	ConstraintNotFound   = "not_found"
+)
+
+// ConstraintError is a write the schema rejected, such as a value out of the range of a check constraint or too long
+// for its column. Field is the JSON name of the column, empty when it isn't known
+type ConstraintError struct {
+	Field   string
+	Reason  string
+	Message string
+}
+
+func (err *ConstraintError) Error() string {
+	return err.Message
+}
+
+// MySQL error numbers of the constraints a write may violate
+const (
+	mysqlDuplicateEntry       = 1062
+	mysqlBadNull              = 1048
+	mysqlOutOfRange           = 1264
+	mysqlDataTruncated        = 1265
+	mysqlIncorrectValue       = 1366
+	mysqlDataTooLong          = 1406
+	mysqlNoReferencedRow      = 1452
+	mysqlCheckConstraintFails = 3819
+)
+
+var (
+	columnPattern     = regexp.MustCompile("column '([^']+)'")
+	constraintPattern = regexp.MustCompile("constraint '([^']+)'")
+	foreignKeyPattern = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`\\)")
+	keyPattern        = regexp.MustCompile("for key '(?:[^.']+\\.)?([^']+)'")
+)
+
+// constraintFields are the JSON names of the columns and constraints that differ from them
+var constraintFields = map[string]string{
+	"issueType":                "type",
+	"priority_range":           "priority",
+	"default_priority_range":   "defaultPriority",
+	"issue_types_project_name": "name",
+}
+
+// AsConstraintError returns the constraint of the schema a MySQL error is about, false when the error is not a
+// constraint violation
+func AsConstraintError(err error) (*ConstraintError, bool) {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	if !ok {
+		return nil, false
+	}
+
+	constraint := &ConstraintError{Message: mysqlErr.Message}
+	pattern := columnPattern
+	switch mysqlErr.Number {
+	case mysqlCheckConstraintFails:
+		constraint.Reason, pattern = ConstraintOutOfRange, constraintPattern
+	case mysqlOutOfRange:
+		constraint.Reason = ConstraintOutOfRange
+	case mysqlDataTooLong:
+		constraint.Reason = ConstraintTooLong
+	case mysqlDataTruncated, mysqlIncorrectValue:
+		constraint.Reason = ConstraintInvalid
+	case mysqlBadNull:
+		constraint.Reason = ConstraintRequired
+	case mysqlDuplicateEntry:
+		constraint.Reason, pattern = ConstraintDuplicate, keyPattern
+	case mysqlNoReferencedRow:
+		constraint.Reason, pattern = ConstraintNotFound, foreignKeyPattern
+	default:
+		return nil, false
+	}
+
+	if match := pattern.FindStringSubmatch(mysqlErr.Message); match != nil {
+		constraint.Field = match[1]
+		if field, ok := constraintFields[match[1]]; ok {
+			constraint.Field = field
+		}
+	}
+
+	return constraint, true
+}
@@ -0,0 +1,45 @@
+package persistence
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsConstraintError(t *testing.T) {
+	tests := []struct {
+		err      *mysql.MySQLError
+		expected *ConstraintError
+	}{
+		{&mysql.MySQLError{Number: 3819, Message: "Check constraint 'priority_range' is violated."},
+			&ConstraintError{Field: "priority", Reason: ConstraintOutOfRange,
+				Message: "Check constraint 'priority_range' is violated."}},
+		{&mysql.MySQLError{Number: 1406, Message: "Data too long for column 'summary' at row 1"},
+			&ConstraintError{Field: "summary", Reason: ConstraintTooLong,
+				Message: "Data too long for column 'summary' at row 1"}},
+		{&mysql.MySQLError{Number: 1265, Message: "Data truncated for column 'status' at row 1"},
+			&ConstraintError{Field: "status", Reason: ConstraintInvalid,
+				Message: "Data truncated for column 'status' at row 1"}},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'API-Bug' for key 'issue_types.issue_types_project_name'"},
+			&ConstraintError{Field: "name", Reason: ConstraintDuplicate,
+				Message: "Duplicate entry 'API-Bug' for key 'issue_types.issue_types_project_name'"}},
+		{&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+			"(`yaits`.`worklogs`, CONSTRAINT `worklogs_fk_1` FOREIGN KEY (`issueID`) REFERENCES `issues` (`id`))"},
+			&ConstraintError{Field: "issueID", Reason: ConstraintNotFound,
+				Message: "Cannot add or update a child row: a foreign key constraint fails " +
+					"(`yaits`.`worklogs`, CONSTRAINT `worklogs_fk_1` FOREIGN KEY (`issueID`) REFERENCES `issues` (`id`))"}},
+	}
+
+	for _, test := range tests {
+		constraint, ok := AsConstraintError(test.err)
+		assert.True(t, ok)
+		assert.Equal(t, test.expected, constraint)
+	}
+
+	_, ok := AsConstraintError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+	assert.False(t, ok)
+	_, ok = AsConstraintError(errors.New("connection refused"))
+	assert.False(t, ok)
+}
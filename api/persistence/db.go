@@ -1,22 +1,121 @@
 package persistence
 
 import (
-	"context"
 	"database/sql"
+	"errors"
+	"time"
+
 	"github.com/YAITS/api/models"
 )
 
 // Storage is an interface to query and insert into some data storage
 type Storage interface {
-	CreateIssue(summary, description, assignee string, priority int64) (int64, error)
-	UpdateIssue(summary, description, assignee, status, comment string, priority, issueID int64) (*models.IssueResponse, error)
+	CreateIssue(issue models.NewIssueRequest, events ...models.IssueEvent) (int64, error)
+	UpdateIssue(update models.UpdateIssueRequest, issueID int64, events ...models.IssueEvent) (*models.IssueResponse, error)
 	RetrieveIssueByID(issueID int64) (models.IssueResponse, error)
-	RetrieveIssues() ([]models.IssueResponse, error)
-	RetrieveIssueByStatus(statusFilter string) ([]models.IssueResponse, error)
-	RetrieveIssueByPriority(priorityStart, priorityEnd int64) ([]models.IssueResponse, error)
-	DeleteIssueByID(issueID int64) error
+	RetrieveIssues(filter models.IssueFilterQueryParam) ([]models.IssueResponse, error)
+	RetrieveIssueByStatus(statusFilter string, filter models.IssueFilterQueryParam) ([]models.IssueResponse, error)
+	RetrieveIssueByPriority(priorityStart, priorityEnd int64, filter models.IssueFilterQueryParam) ([]models.IssueResponse, error)
+	DeleteIssueByID(issueID int64, events ...models.IssueEvent) error
+	ExportIssues(query models.ExportQueryParam, each func(models.IssueResponse) error) error
+	SearchIssues(search models.IssueSearchQuery) ([]models.IssueResponse, error)
+	RetrieveComments(issueIDs ...int64) (map[int64][]models.Comment, error)
+	RetrieveFields(issueIDs ...int64) (map[int64]map[string]string, error)
+	RetrieveUsers(names ...string) ([]models.User, error)
+	RetrieveProjects(keys ...string) ([]models.Project, error)
+
+	CreateIssueType(issueType models.NewIssueTypeRequest) (int64, error)
+	RetrieveIssueType(project, name string) (models.IssueType, error)
+	RetrieveIssueTypes(project string) ([]models.IssueType, error)
+	DeleteIssueTypeByID(issueTypeID int64) error
+
+	CreateSLAPolicy(policy models.NewSLAPolicyRequest) (int64, error)
+	RetrieveSLAPolicies() ([]models.SLAPolicy, error)
+	DeleteSLAPolicyByID(policyID int64) error
+	CreateBusinessCalendar(calendar models.NewBusinessCalendarRequest) (int64, error)
+	RetrieveBusinessCalendars() ([]models.BusinessCalendar, error)
+	RetrieveSLADueIssues(responseCreatedBefore, resolutionCreatedBefore, doneSince time.Time) ([]models.IssueResponse, error)
+	RecordSLABreach(breach models.SLABreach) (bool, error)
+	RetrieveSLABreaches(issueID int64) ([]models.SLABreach, error)
+
+	CreateWorklog(worklog models.NewWorklogRequest, issueID int64) (int64, error)
+	RetrieveWorklogs(issueID int64) ([]models.Worklog, error)
+	DeleteWorklog(issueID, worklogID int64) error
+	RetrieveWorklogTotals(query models.WorklogQueryParam) ([]models.WorklogTotal, error)
+	RetrieveUserWorklogs(user, from, to string) ([]models.Worklog, error)
+
+	CreateAttachment(attachment models.Attachment, put func() error) (int64, error)
+	RetrieveAttachments(issueID int64) ([]models.Attachment, error)
+	RetrieveAttachmentByID(attachmentID int64) (models.Attachment, error)
+	DeleteAttachmentByID(attachmentID int64) error
+	ReleaseAttachmentContent(hash string, remove func() error) error
+
+	WatchIssue(issueID int64, user string) error
+	UnwatchIssue(issueID int64, user string) error
+	RetrieveWatchers(issueID int64) ([]string, error)
+	DeleteWatchers(issueID int64) error
+	NotifyWatchers(notification models.Notification) (int64, error)
+	RetrieveNotifications(user string, query models.NotificationQueryParam) ([]models.Notification, error)
+	CountUnreadNotifications(user string) (int64, error)
+	MarkNotificationsRead(user string, notificationID, issueID int64) (int64, error)
+
+	CreateWebhook(webhook models.NewWebhookRequest) (int64, error)
+	RetrieveWebhooks() ([]models.Webhook, error)
+	DeleteWebhookByID(webhookID int64) error
+	EnqueueWebhookDeliveries(eventID int64, event, project string, payload []byte) (int64, error)
+	RetrieveDueWebhookDeliveries(now time.Time, limit int64) ([]models.WebhookDelivery, error)
+	UpdateWebhookDelivery(delivery models.WebhookDelivery, nextAttempt time.Time) error
+	RetrieveWebhookDeliveries(webhookID int64, query models.WebhookDeliveryQueryParam) ([]models.WebhookDelivery, error)
+	RedeliverWebhookDelivery(webhookID, deliveryID int64) error
+
+	RetrieveOutboxEvents(consumer string, limit int64) ([]models.IssueEvent, error)
+	MarkOutboxEventConsumed(consumer string, eventID int64) error
+	PruneOutbox(consumers []string) (int64, error)
+
+	RetrieveEmailPreferences(user string) (models.EmailPreferences, error)
+	SaveEmailPreferences(preferences models.EmailPreferences) error
+	RetrieveUserByEmail(email string) (string, error)
+	UnsubscribeEmails(token string) (string, error)
+	QueueEmail(email models.Email) error
+	RetrievePendingEmails(digest bool, limit int64) ([]models.Email, error)
+	MarkEmailsSent(emailIDs ...int64) error
+
+	CreateIssueLink(link models.IssueLink) (bool, error)
+	RetrieveIssueLinks(issueID int64) ([]models.IssueLink, error)
+
+	ImportIssues(issues []models.ImportIssue) ([]int64, error)
+	CreateImportJob(job models.ImportJob) (int64, error)
+	UpdateImportJob(job models.ImportJob) error
+	RetrieveImportJob(jobID int64) (models.ImportJob, error)
+
+	BulkChangeIssues(change models.BulkChange, issueIDs []int64, allOrNothing bool) ([]models.BulkItemResult, error)
+	CreateBulkJob(job models.BulkJob) (int64, error)
+	UpdateBulkJob(job models.BulkJob) error
+	RetrieveBulkJob(jobID int64) (models.BulkJob, error)
+
+	RetrieveIssueByMessageID(messageIDs ...string) (int64, error)
+	RecordInboundMessage(messageID string, issueID int64) error
+
+	CreateAPIToken(user, token string, admin bool) error
+	RotateAPIToken(user, token string) error
+	RetrieveTokenUser(token string) (string, bool, error)
+
+	ReserveIdempotencyKey(reservation models.IdempotentResponse) (models.IdempotentResponse, bool, error)
+	SaveIdempotentResponse(response models.IdempotentResponse) error
+	ReleaseIdempotencyKey(user, key string) error
 }
 
+const (
+	statusOpen   = "open"
+	statusClosed = "closed"
+)
+
+// Unassigned is the assignee of issues assigned to nobody, the default of the column
+const Unassigned = "unassigned"
+
+// ErrVersionConflict is returned updating an issue that was updated since it was read
+var ErrVersionConflict = errors.New("the issue was updated in the meantime")
+
 //MysqlStorage - Hold sql database pointer
 type MysqlStorage struct {
 	db *sql.DB
@@ -38,254 +137,459 @@ type IssueEntry struct {
 	Priority    int
 }
 
-// CreateIssue creates a new issue
-func (mysqlSt *MysqlStorage) CreateIssue(summary, description, assignee string, priority int64) (int64, error) {
-	var err error
-	var result sql.Result
-	if assignee == "" {
-		insertQuery := "INSERT INTO issues(summary, description, priority) VALUES(?, ?, ?)"
-		result, err = mysqlSt.db.Exec(insertQuery, summary, description, priority)
-	} else {
-		insertQuery := "INSERT INTO issues(summary, description, priority, assignee) VALUES(?, ?, ?, ?)"
-		result, err = mysqlSt.db.Exec(insertQuery, summary, description, priority, assignee)
-	}
-
+// CreateIssue creates a new issue along with its custom fields, recording the given events in the outbox
+func (mysqlSt *MysqlStorage) CreateIssue(issue models.NewIssueRequest, events ...models.IssueEvent) (int64, error) {
+	tx, err := mysqlSt.db.Begin()
 	if err != nil {
 		return 0, err
 	}
 
-	id, _ := result.LastInsertId()
-	return id, nil
-}
-
-// UpdateIssue edits an existing issue
-func (mysqlSt *MysqlStorage) UpdateIssue(summary, description, assignee, status, comment string, priority, issueID int64) (*models.IssueResponse, error) {
-	var err error
-
-	issue, err := mysqlSt.RetrieveIssueByID(issueID)
+	id, err := insertIssue(tx, issue, "")
 	if err != nil {
-		return nil, err
+		tx.Rollback()
+		return 0, err
 	}
 
-	if summary != "" {
-		issue.Summary = summary
-	}
-	if description != "" {
-		issue.Description = description
+	if len(events) > 0 {
+		// read the issue back for the database defaults to be part of the events
+		created, err := scanIssue(tx.QueryRow(`SELECT `+issueColumns+` FROM issues WHERE id = ?`, id))
+		if err == nil {
+			created.Fields = issue.Fields
+			err = writeEvents(tx, created, events)
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
 	}
-	if assignee != "" {
-		issue.Assignee = assignee
+
+	return id, tx.Commit()
+}
+
+// insertIssue inserts an issue along with its custom fields, the database defaulting its status when none is given
+func insertIssue(tx *sql.Tx, issue models.NewIssueRequest, status string) (int64, error) {
+	columns := "summary, description, priority, project, issueType, dueDate, originalEstimate, remainingEstimate"
+	placeholders := "?, ?, ?, ?, ?, ?, ?, ?"
+	args := []interface{}{issue.Summary, issue.Description, issue.Priority, issue.Project, issue.Type, issue.DueDate,
+		issue.OriginalEstimate, issue.OriginalEstimate}
+
+	// let the database default the assignee when none is given
+	if issue.Assignee != "" {
+		columns += ", assignee"
+		placeholders += ", ?"
+		args = append(args, issue.Assignee)
 	}
-	if status != "" {
-		issue.Status = status
+	if issue.Reporter != "" {
+		columns += ", reporter"
+		placeholders += ", ?"
+		args = append(args, issue.Reporter)
 	}
-	if priority != 0 {
-		issue.Priority = priority
+	if status != "" && status != statusOpen {
+		// issues out of the open status have been responded to, and resolved once closed
+		now := time.Now().UTC()
+		columns += ", status, respondedDate"
+		placeholders += ", ?, ?"
+		args = append(args, status, now)
+		if status == statusClosed {
+			columns += ", resolvedDate"
+			placeholders += ", ?"
+			args = append(args, now)
+		}
 	}
-	if comment != "" {
-		issue.Comments = append(issue.Comments, models.Comment{Comment: comment})
+
+	insertQuery := "INSERT INTO issues(" + columns + ") VALUES(" + placeholders + ")"
+	result, err := tx.Exec(insertQuery, args...)
+	if err != nil {
+		return 0, err
 	}
 
-	ctx := context.Background()
-	tx, _ := mysqlSt.db.BeginTx(ctx, nil)
+	id, _ := result.LastInsertId()
 
-	updateQuery := "UPDATE issues SET summary = ?, description = ?, assignee = ?, status = ?, priority = ? WHERE id = ?"
+	insertFieldQuery := "INSERT INTO issue_fields (issueID, name, value) VALUES (?, ?, ?)"
+	for name, value := range issue.Fields {
+		if _, err = tx.Exec(insertFieldQuery, id, name, value); err != nil {
+			return 0, err
+		}
+	}
 
-	_, err = mysqlSt.db.ExecContext(ctx, updateQuery, issue.Summary, issue.Description, issue.Assignee, issue.Status, issue.Priority, issueID)
+	return id, nil
+}
+
+// UpdateIssue edits an existing issue, keeping track of when it was first responded to and when it was resolved, and
+// records the given events in the outbox. The issue is only updated at the version it was read at, or expected at,
+// ErrVersionConflict being returned when it was updated in the meantime
+func (mysqlSt *MysqlStorage) UpdateIssue(update models.UpdateIssueRequest, issueID int64, events ...models.IssueEvent) (*models.IssueResponse, error) {
+	issue, err := mysqlSt.RetrieveIssueByID(issueID)
 	if err != nil {
-		// if error in the query execution, rollback the transaction
-		tx.Rollback()
 		return nil, err
 	}
+	if update.ExpectedVersion != 0 && update.ExpectedVersion != issue.Version {
+		return nil, ErrVersionConflict
+	}
 
-	insertCommentQuery := "INSERT INTO comments (comment, issueID) values (?, ?)"
-
-	_, err = mysqlSt.db.ExecContext(ctx, insertCommentQuery, comment, issueID)
+	tx, err := mysqlSt.db.Begin()
 	if err != nil {
+		return nil, err
+	}
+
+	if err = updateIssue(tx, &issue, update, events); err != nil {
+		// if error in the query execution, rollback the transaction
 		tx.Rollback()
 		return nil, err
 	}
 
-	return &issue, nil
+	return &issue, tx.Commit()
 }
 
-// RetrieveIssues returns all existing issues
-func (mysqlSt *MysqlStorage) RetrieveIssues() ([]models.IssueResponse, error) {
-	resp := make([]models.IssueResponse, 0)
-	var summary, status, description, assignee, createDate string
-	var id, priority int64
+// updateIssue applies an update to an issue read at its current version within a transaction, recording the events
+func updateIssue(tx *sql.Tx, issue *models.IssueResponse, update models.UpdateIssueRequest, events []models.IssueEvent) error {
+	if update.Summary != "" {
+		issue.Summary = update.Summary
+	}
+	if update.Description != "" || update.Sets("description") {
+		issue.Description = update.Description
+	}
+	if update.Assignee != "" {
+		issue.Assignee = update.Assignee
+	} else if update.Sets("assignee") {
+		issue.Assignee = Unassigned
+	}
+	if update.Status != "" {
+		issue.Status = update.Status
+	}
+	if update.Priority != 0 {
+		issue.Priority = update.Priority
+	}
+	if update.DueDate != nil {
+		issue.DueDate = update.DueDate.UTC().Format(time.RFC3339)
+	} else if update.Sets("dueDate") {
+		issue.DueDate = ""
+	}
+	if update.OriginalEstimate != nil || update.Sets("originalEstimate") {
+		issue.OriginalEstimate = Int64Value(update.OriginalEstimate)
+		// the remaining estimate follows the original one until work gets logged
+		if update.RemainingEstimate == nil && !update.Sets("remainingEstimate") && issue.TimeSpent == 0 {
+			issue.RemainingEstimate = issue.OriginalEstimate
+		}
+	}
+	if update.RemainingEstimate != nil || update.Sets("remainingEstimate") {
+		issue.RemainingEstimate = Int64Value(update.RemainingEstimate)
+	}
+	if update.Comment != "" {
+		issue.Comments = append(issue.Comments, models.Comment{Comment: update.Comment})
+	}
 
-	query := `SELECT id, summary, description, priority, status, assignee, createDate FROM issues`
+	now := time.Now().UTC().Format(time.RFC3339)
+	if issue.Status != statusOpen && issue.RespondedDate == "" {
+		issue.RespondedDate = now
+	}
+	if issue.Status != statusClosed {
+		issue.ResolvedDate = ""
+	} else if issue.ResolvedDate == "" {
+		issue.ResolvedDate = now
+	}
 
-	rows, err := mysqlSt.db.Query(query)
+	updateQuery := "UPDATE issues SET summary = ?, description = ?, assignee = ?, status = ?, priority = ?, dueDate = ?, respondedDate = ?, resolvedDate = ?, originalEstimate = ?, remainingEstimate = ?, version = version + 1 WHERE id = ? AND version = ?"
+
+	result, err := tx.Exec(updateQuery, issue.Summary, issue.Description, issue.Assignee, issue.Status, issue.Priority,
+		nullTime(issue.DueDate), nullTime(issue.RespondedDate), nullTime(issue.ResolvedDate),
+		issue.OriginalEstimate, issue.RemainingEstimate, issue.ID, issue.Version)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	for rows.Next() {
-		err = rows.Scan(&id, &summary, &description, &priority, &status, &assignee, &createDate)
-		if err != nil {
-			return nil, err
-		}
+	// the version being incremented, no row is affected only when the issue was updated since it was read
+	affected, err := result.RowsAffected()
+	if err == nil && affected == 0 {
+		err = ErrVersionConflict
+	}
+	if err != nil {
+		return err
+	}
+	issue.Version++
 
-		comments, err := mysqlSt.getComments(id)
-		if err != nil {
-			return resp, err
-		}
+	if update.Comment != "" {
+		insertCommentQuery := "INSERT INTO comments (comment, issueID) values (?, ?)"
 
-		resp = append(resp, models.IssueResponse{
-			ID:          int64(id),
-			Summary:     summary,
-			Description: description,
-			Priority:    int64(priority),
-			Status:      status,
-			Assignee:    assignee,
-			CreateDate:  createDate,
-			Comments:    comments,
-		})
+		if _, err = tx.Exec(insertCommentQuery, update.Comment, issue.ID); err != nil {
+			return err
+		}
 	}
 
-	return resp, nil
+	return writeEvents(tx, *issue, events)
 }
 
-// RetrieveIssueByID returns an issue filtered by the issue id
-func (mysqlSt *MysqlStorage) RetrieveIssueByID(issueID int64) (models.IssueResponse, error) {
-	var resp models.IssueResponse
-	var summary, description, status, assignee, createDate string
-	var id, priority int
+// RetrieveIssues returns all existing issues
+func (mysqlSt *MysqlStorage) RetrieveIssues(filter models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
+	query := `SELECT ` + issueColumns + ` FROM issues WHERE 1 = 1`
+	filterClause, args := issueFilterClause(filter)
 
-	query := `SELECT id, summary, description, priority, status, assignee, createDate FROM issues WHERE id = ?`
+	return mysqlSt.queryIssues(query+filterClause, args...)
+}
 
-	err := mysqlSt.db.QueryRow(query, issueID).Scan(&id, &summary, &description, &priority, &status, &assignee, &createDate)
+// RetrieveIssueByID returns an issue filtered by the issue id
+func (mysqlSt *MysqlStorage) RetrieveIssueByID(issueID int64) (models.IssueResponse, error) {
+	query := `SELECT ` + issueColumns + ` FROM issues WHERE id = ?`
 
+	resp, err := scanIssue(mysqlSt.db.QueryRow(query, issueID))
 	if err != nil {
 		return resp, err
 	}
 
-	comments, err := mysqlSt.getComments(issueID)
+	resp.Comments, err = mysqlSt.getComments(issueID)
 	if err != nil {
 		return resp, err
 	}
 
-	resp = models.IssueResponse{
-		ID:          int64(id),
-		Summary:     summary,
-		Description: description,
-		Priority:    int64(priority),
-		Status:      status,
-		Assignee:    assignee,
-		CreateDate:  createDate,
-		Comments:    comments,
+	resp.Fields, err = mysqlSt.getFields(issueID)
+	if err != nil {
+		return resp, err
 	}
 
 	return resp, nil
 }
 
 // RetrieveIssueByStatus returns an issue filtered by the status (open, closed, in progress)
-func (mysqlSt *MysqlStorage) RetrieveIssueByStatus(statusFilter string) ([]models.IssueResponse, error) {
-	resp := make([]models.IssueResponse, 0)
-	var summary, status, description, assignee, createDate string
-	var id, priority int64
+func (mysqlSt *MysqlStorage) RetrieveIssueByStatus(statusFilter string, filter models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
+	query := `SELECT ` + issueColumns + ` FROM issues WHERE status = ?`
+	filterClause, args := issueFilterClause(filter)
+
+	return mysqlSt.queryIssues(query+filterClause, append([]interface{}{statusFilter}, args...)...)
+}
 
-	query := `SELECT id, summary, description, priority, status, assignee, createDate FROM issues WHERE status = ?`
+// RetrieveIssueByPriority returns an issue filtered by the priority
+func (mysqlSt *MysqlStorage) RetrieveIssueByPriority(priorityStart, priorityEnd int64, filter models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
+	query := `SELECT ` + issueColumns + ` FROM issues WHERE priority >= ?`
+	args := []interface{}{priorityStart}
+
+	if priorityEnd != 0 {
+		query += ` AND priority <= ?`
+		args = append(args, priorityEnd)
+	}
+
+	filterClause, filterArgs := issueFilterClause(filter)
 
-	rows, err := mysqlSt.db.Query(query, statusFilter)
+	return mysqlSt.queryIssues(query+filterClause, append(args, filterArgs...)...)
+}
+
+// SearchIssues returns a page of the issues matching a search in id order, without their comments and custom fields
+// which are retrieved for a whole page at once with RetrieveComments and RetrieveFields
+func (mysqlSt *MysqlStorage) SearchIssues(search models.IssueSearchQuery) ([]models.IssueResponse, error) {
+	query := `SELECT ` + issueColumns + ` FROM issues WHERE 1 = 1`
+	args := make([]interface{}, 0)
+
+	if search.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, search.Status)
+	}
+	if search.Assignee != "" {
+		query += ` AND assignee = ?`
+		args = append(args, search.Assignee)
+	}
+	if search.Reporter != "" {
+		query += ` AND reporter = ?`
+		args = append(args, search.Reporter)
+	}
+	if search.PriorityStart != 0 {
+		query += ` AND priority >= ?`
+		args = append(args, search.PriorityStart)
+	}
+	if search.PriorityEnd != 0 {
+		query += ` AND priority <= ?`
+		args = append(args, search.PriorityEnd)
+	}
+	filterClause, filterArgs := issueFilterClause(search.IssueFilterQueryParam)
+	query += filterClause + ` ORDER BY id LIMIT ? OFFSET ?`
+	args = append(append(args, filterArgs...), search.Limit, search.Offset)
+
+	rows, err := mysqlSt.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
+	resp := make([]models.IssueResponse, 0)
 	for rows.Next() {
-		err = rows.Scan(&id, &summary, &description, &priority, &status, &assignee, &createDate)
+		issue, err := scanIssue(rows)
 		if err != nil {
 			return nil, err
 		}
+		resp = append(resp, issue)
+	}
+
+	return resp, rows.Err()
+}
+
+// DeleteIssueByID deletes an issue filtered by the issue id, recording the given events in the outbox
+func (mysqlSt *MysqlStorage) DeleteIssueByID(issueID int64, events ...models.IssueEvent) error {
+	tx, err := mysqlSt.db.Begin()
+	if err != nil {
+		return err
+	}
 
-		comments, err := mysqlSt.getComments(id)
+	if err = deleteIssue(tx, issueID, events); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteIssue deletes an issue within a transaction, recording the events, sql.ErrNoRows when there is no such issue
+func deleteIssue(tx *sql.Tx, issueID int64, events []models.IssueEvent) error {
+	if len(events) > 0 {
+		// the events carry the issue as it was before its deletion
+		issue, err := scanIssue(tx.QueryRow(`SELECT `+issueColumns+` FROM issues WHERE id = ?`, issueID))
+		if err == nil {
+			err = writeEvents(tx, issue, events)
+		}
 		if err != nil {
-			return resp, err
+			return err
 		}
+	}
 
-		resp = append(resp, models.IssueResponse{
-			ID:          int64(id),
-			Summary:     summary,
-			Description: description,
-			Priority:    int64(priority),
-			Status:      status,
-			Assignee:    assignee,
-			CreateDate:  createDate,
-			Comments:    comments,
-		})
+	query := `DELETE FROM issues WHERE id = ?`
+
+	result, err := tx.Exec(query, issueID)
+	if err != nil {
+		return err
 	}
 
-	return resp, nil
+	affected, err := result.RowsAffected()
+	if err == nil && affected == 0 {
+		err = sql.ErrNoRows
+	}
+
+	return err
 }
 
-// RetrieveIssueByPriority returns an issue filtered by the priority
-func (mysqlSt *MysqlStorage) RetrieveIssueByPriority(priorityStart, priorityEnd int64) ([]models.IssueResponse, error) {
-	resp := make([]models.IssueResponse, 0)
-	var summary, status, description, assignee, createDate string
-	var id, priority int64
-	var rows *sql.Rows
-	var err error
+// issueColumns lists the issue columns in the order expected by scanIssue
+const issueColumns = `id, summary, description, priority, status, assignee, reporter, createDate, project, issueType, dueDate, respondedDate, resolvedDate, originalEstimate, remainingEstimate, timeSpent, version`
 
-	query := `SELECT id, summary, description, priority, status, assignee, createDate FROM issues WHERE priority >= ?`
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-	if priorityEnd != 0 {
-		query += ` AND priority <= ?`
-		rows, err = mysqlSt.db.Query(query, priorityStart, priorityEnd)
-	} else {
-		rows, err = mysqlSt.db.Query(query, priorityStart)
+func scanIssue(row rowScanner) (models.IssueResponse, error) {
+	var issue models.IssueResponse
+	var reporter sql.NullString
+	var dueDate, respondedDate, resolvedDate sql.NullTime
+
+	err := row.Scan(&issue.ID, &issue.Summary, &issue.Description, &issue.Priority, &issue.Status,
+		&issue.Assignee, &reporter, &issue.CreateDate, &issue.Project, &issue.Type, &dueDate, &respondedDate, &resolvedDate,
+		&issue.OriginalEstimate, &issue.RemainingEstimate, &issue.TimeSpent, &issue.Version)
+
+	issue.Reporter = reporter.String
+	issue.DueDate = formatNullTime(dueDate)
+	issue.RespondedDate = formatNullTime(respondedDate)
+	issue.ResolvedDate = formatNullTime(resolvedDate)
+
+	return issue, err
+}
+
+func formatNullTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// nullTime converts an RFC3339 date back into a query parameter, the empty string being NULL
+func nullTime(date string) interface{} {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil
+	}
+
+	return t
+}
+
+// Int64Value dereferences an optional value, nil being 0
+func Int64Value(value *int64) int64 {
+	if value == nil {
+		return 0
+	}
+
+	return *value
+}
+
+// issueFilterClause builds the AND conditions restricting a listing to a project and/or an issue type
+func issueFilterClause(filter models.IssueFilterQueryParam) (string, []interface{}) {
+	clause := ""
+	args := make([]interface{}, 0)
+
+	if filter.Project != "" {
+		clause += ` AND project = ?`
+		args = append(args, filter.Project)
+	}
+	if filter.Type != "" {
+		clause += ` AND issueType = ?`
+		args = append(args, filter.Type)
 	}
 
+	return clause, args
+}
+
+func (mysqlSt *MysqlStorage) queryIssues(query string, args ...interface{}) ([]models.IssueResponse, error) {
+	resp := make([]models.IssueResponse, 0)
+
+	rows, err := mysqlSt.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		err = rows.Scan(&id, &summary, &description, &priority, &status, &assignee, &createDate)
+		issue, err := scanIssue(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		comments, err := mysqlSt.getComments(id)
+		issue.Comments, err = mysqlSt.getComments(issue.ID)
 		if err != nil {
 			return resp, err
 		}
 
-		resp = append(resp, models.IssueResponse{
-			ID:          int64(id),
-			Summary:     summary,
-			Description: description,
-			Priority:    int64(priority),
-			Status:      status,
-			Assignee:    assignee,
-			CreateDate:  createDate,
-			Comments:    comments,
-		})
+		resp = append(resp, issue)
 	}
 
-	return resp, nil
+	return resp, rows.Err()
 }
 
-// DeleteIssueByID deletes an issue filtered by the issue id
-func (mysqlSt *MysqlStorage) DeleteIssueByID(issueID int64) error {
-
-	query := `DELETE FROM issues WHERE id = ?`
+func (mysqlSt *MysqlStorage) getFields(issueID int64) (map[string]string, error) {
+	fields := make(map[string]string)
+	var name, value string
 
-	_, err := mysqlSt.db.Exec(query, issueID)
+	query := `SELECT name, value FROM issue_fields WHERE issueID = ?`
 
+	rows, err := mysqlSt.db.Query(query, issueID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
 	}
 
-	return nil
+	return fields, nil
 }
 
 func (mysqlSt *MysqlStorage) getComments(issueID int64) ([]models.Comment, error) {
 	comments := make([]models.Comment, 0)
 	var comment string
+	var commentID int64
 
-	query := `SELECT comment FROM comments WHERE issueID = ?`
+	query := `SELECT commentID, comment FROM comments WHERE issueID = ?`
 
 	rows, err := mysqlSt.db.Query(query, issueID)
 
@@ -294,8 +598,9 @@ func (mysqlSt *MysqlStorage) getComments(issueID int64) ([]models.Comment, error
 	}
 
 	for rows.Next() {
-		err = rows.Scan(&comment)
+		err = rows.Scan(&commentID, &comment)
 		comments = append(comments, models.Comment{
+			ID:      commentID,
 			Comment: comment,
 		})
 	}
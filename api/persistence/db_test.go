@@ -3,8 +3,10 @@ package persistence
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
 	_ "github.com/stretchr/testify/assert"
 	_ "github.com/stretchr/testify/require"
 )
@@ -17,7 +19,9 @@ const (
 	Status      = "Open"
 	Priority    = int64(1)
 	CreateDate  = "some date"
-	Comment 	= "This is a comment"
+	Comment     = "This is a comment"
+	Project     = "API"
+	Type        = "Bug"
 )
 
 func TestMysqlStorage_RetrieveIssues(t *testing.T) {
@@ -35,16 +39,16 @@ func TestMysqlStorage_RetrieveIssues(t *testing.T) {
 	testingStorage := NewMysqlStorage(db)
 
 	mock.ExpectQuery("SELECT (.+) FROM issues").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "createDate"}).
-			AddRow(IssueID, Summary, Description, Priority, Status, Assignee, CreateDate))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+			AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
 
 	mock.ExpectQuery("SELECT (.+) FROM comments").
 		WithArgs(IssueID).
-		WillReturnRows(sqlmock.NewRows([]string{"comment"}).
-			AddRow(Comment))
+		WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}).
+			AddRow(1, Comment))
 
 	// run the code
-	if _, err = testingStorage.RetrieveIssues(); err != nil {
+	if _, err = testingStorage.RetrieveIssues(models.IssueFilterQueryParam{}); err != nil {
 		t.Errorf("Error should not have occurred while getting all issues: %s", err)
 	}
 
@@ -70,13 +74,18 @@ func TestMysqlStorage_RetrieveIssueByID(t *testing.T) {
 
 	mock.ExpectQuery("SELECT (.+) FROM issues").
 		WithArgs(IssueID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "createDate"}).
-			AddRow(IssueID, Summary, Description, Priority, Status, Assignee, CreateDate))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+			AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
 
 	mock.ExpectQuery("SELECT (.+) FROM comments").
 		WithArgs(IssueID).
-		WillReturnRows(sqlmock.NewRows([]string{"comment"}).
-			AddRow(Comment))
+		WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}).
+			AddRow(1, Comment))
+
+	mock.ExpectQuery("SELECT (.+) FROM issue_fields").
+		WithArgs(IssueID).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).
+			AddRow("steps to reproduce", Description))
 
 	// run the code
 	if _, err = testingStorage.RetrieveIssueByID(IssueID); err != nil {
@@ -105,16 +114,16 @@ func TestMysqlStorage_RetrieveIssueByStatus(t *testing.T) {
 
 	mock.ExpectQuery("SELECT (.+) FROM issues WHERE status").
 		WithArgs(Status).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "createDate"}).
-			AddRow(IssueID, Summary, Description, Priority, Status, Assignee, CreateDate))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+			AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
 
 	mock.ExpectQuery("SELECT (.+) FROM comments").
 		WithArgs(IssueID).
-		WillReturnRows(sqlmock.NewRows([]string{"comment"}).
-			AddRow(Comment))
+		WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}).
+			AddRow(1, Comment))
 
 	// run the code
-	if _, err = testingStorage.RetrieveIssueByStatus(Status); err != nil {
+	if _, err = testingStorage.RetrieveIssueByStatus(Status, models.IssueFilterQueryParam{}); err != nil {
 		t.Errorf("Error should not have occurred while retrieving issue: %s", err)
 	}
 
@@ -141,26 +150,136 @@ func TestMysqlStorage_UpdateIssue(t *testing.T) {
 	t.Run("NoError", func(t *testing.T) {
 		mock.ExpectQuery("SELECT (.+) FROM issues").
 			WithArgs(IssueID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "createDate"}).
-				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, CreateDate))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
 
 		// set expectations
 		mock.ExpectQuery("SELECT (.+) FROM comments").
 			WithArgs(IssueID).
-			WillReturnRows(sqlmock.NewRows([]string{"comment"}).
-				AddRow(Comment))
+			WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}).
+				AddRow(1, Comment))
 
+		mock.ExpectQuery("SELECT (.+) FROM issue_fields").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).
+				AddRow("steps to reproduce", Description))
+
+		mock.ExpectBegin()
 		mock.ExpectExec("UPDATE issues SET").
-			WithArgs(Summary, Description, Assignee, Status, Priority, IssueID).
+			WithArgs(Summary, Description, Assignee, Status, Priority, nil, sqlmock.AnyArg(), nil, 0, 0, IssueID, 1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectExec("INSERT INTO comments").
 			WithArgs(Comment, IssueID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
+		mock.ExpectExec("INSERT INTO outbox").
+			WithArgs("issue.commented", IssueID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
 		// run the code
-		if _, err = testingStorage.UpdateIssue(Summary, Description, Assignee, Status, Comment, Priority, IssueID); err != nil {
+		update := models.UpdateIssueRequest{
+			Summary:     Summary,
+			Description: Description,
+			Assignee:    Assignee,
+			Status:      Status,
+			Comment:     Comment,
+			Priority:    Priority,
+		}
+		event := models.IssueEvent{Event: "issue.commented", Actor: Assignee, Comment: Comment}
+		issue, err := testingStorage.UpdateIssue(update, IssueID, event)
+		if err != nil {
 			t.Errorf("Error should not have occurred while updating issue: %s", err)
+		} else if issue.Version != 2 {
+			t.Errorf("Version should have been incremented to 2, got %d", issue.Version)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("ConcurrentUpdate", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM issues").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
+		mock.ExpectQuery("SELECT (.+) FROM comments").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}))
+		mock.ExpectQuery("SELECT (.+) FROM issue_fields").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "value"}))
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE issues SET (.+) WHERE id = \\? AND version = \\?").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		// run the code
+		if _, err = testingStorage.UpdateIssue(models.UpdateIssueRequest{Status: Status}, IssueID); err != ErrVersionConflict {
+			t.Errorf("ErrVersionConflict should have been returned, got %v", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("ExpectedVersion", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM issues").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 3))
+		mock.ExpectQuery("SELECT (.+) FROM comments").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}))
+		mock.ExpectQuery("SELECT (.+) FROM issue_fields").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "value"}))
+
+		// run the code
+		update := models.UpdateIssueRequest{Status: Status, ExpectedVersion: 2}
+		if _, err = testingStorage.UpdateIssue(update, IssueID); err != ErrVersionConflict {
+			t.Errorf("ErrVersionConflict should have been returned, got %v", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("ClearFields", func(t *testing.T) {
+		dueDate := time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery("SELECT (.+) FROM issues").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, dueDate, nil, nil, 60, 45, 15, 1))
+		mock.ExpectQuery("SELECT (.+) FROM comments").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}))
+		mock.ExpectQuery("SELECT (.+) FROM issue_fields").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "value"}))
+
+		// cleared fields are written empty, no comment being inserted
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE issues SET").
+			WithArgs(Summary, "", "unassigned", Status, Priority, nil, sqlmock.AnyArg(), nil, 60, 0, IssueID, 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		// run the code
+		update := models.UpdateIssueRequest{Changed: []string{"description", "assignee", "dueDate", "remainingEstimate"}}
+		issue, err := testingStorage.UpdateIssue(update, IssueID)
+		if err != nil {
+			t.Errorf("Error should not have occurred while clearing fields: %s", err)
+		} else if issue.Assignee != "unassigned" || issue.DueDate != "" {
+			t.Errorf("Assignee and due date should have been cleared, got %q and %q", issue.Assignee, issue.DueDate)
 		}
 
 		//check expectations are met
@@ -186,9 +305,11 @@ func TestMysqlStorage_DeleteIssueByID(t *testing.T) {
 
 	t.Run("NoError", func(t *testing.T) {
 		// set expectations
+		mock.ExpectBegin()
 		mock.ExpectExec("DELETE FROM issues").
 			WithArgs(IssueID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		// run the code
 		if err = testingStorage.DeleteIssueByID(IssueID); err != nil {
@@ -201,11 +322,39 @@ func TestMysqlStorage_DeleteIssueByID(t *testing.T) {
 		}
 	})
 
+	t.Run("WithEvent", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM issues").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
+		mock.ExpectExec("INSERT INTO outbox").
+			WithArgs("issue.deleted", IssueID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM issues").
+			WithArgs(IssueID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		// run the code
+		if err = testingStorage.DeleteIssueByID(IssueID, models.IssueEvent{Event: "issue.deleted"}); err != nil {
+			t.Errorf("Error should not have occurred while deleting issue: %s", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
 	t.Run("Error", func(t *testing.T) {
 		// set expectations
+		mock.ExpectBegin()
 		mock.ExpectExec("DELETE FROM issues").
 			WithArgs(IssueID).
 			WillReturnError(errors.New("err"))
+		mock.ExpectRollback()
 
 		// run the code
 		if err = testingStorage.DeleteIssueByID(IssueID); err == nil {
@@ -240,16 +389,16 @@ func TestMysqlStorage_RetrieveIssueByPriority(t *testing.T) {
 		// set expectations
 		mock.ExpectQuery("SELECT (.+) FROM issues").
 			WithArgs(priorityStart).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "createDate"}).
-				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, CreateDate))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
 
 		mock.ExpectQuery("SELECT (.+) FROM comments").
 			WithArgs(IssueID).
-			WillReturnRows(sqlmock.NewRows([]string{"comment"}).
-				AddRow(Comment))
+			WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}).
+				AddRow(1, Comment))
 
 		// run the code
-		if _, err = testingStorage.RetrieveIssueByPriority(priorityStart, 0); err != nil {
+		if _, err = testingStorage.RetrieveIssueByPriority(priorityStart, 0, models.IssueFilterQueryParam{}); err != nil {
 			t.Errorf("Error should not have occurred while retrieving issue: %s", err)
 		}
 
@@ -263,16 +412,16 @@ func TestMysqlStorage_RetrieveIssueByPriority(t *testing.T) {
 		// set expectations
 		mock.ExpectQuery("SELECT (.+) FROM issues").
 			WithArgs(priorityStart, priorityEnd).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "createDate"}).
-				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, CreateDate))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
 
 		mock.ExpectQuery("SELECT (.+) FROM comments").
 			WithArgs(IssueID).
-			WillReturnRows(sqlmock.NewRows([]string{"comment"}).
-				AddRow(Comment))
+			WillReturnRows(sqlmock.NewRows([]string{"commentID", "comment"}).
+				AddRow(1, Comment))
 
 		// run the code
-		if _, err = testingStorage.RetrieveIssueByPriority(priorityStart, priorityEnd); err != nil {
+		if _, err = testingStorage.RetrieveIssueByPriority(priorityStart, priorityEnd, models.IssueFilterQueryParam{}); err != nil {
 			t.Errorf("Error should not have occurred while retrieving issue: %s", err)
 		}
 
@@ -282,3 +431,107 @@ func TestMysqlStorage_RetrieveIssueByPriority(t *testing.T) {
 		}
 	})
 }
+
+func TestMysqlStorage_SearchIssues(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	search := models.IssueSearchQuery{IssueFilterQueryParam: models.IssueFilterQueryParam{Project: Project},
+		Status: "open", Assignee: Assignee, PriorityStart: 1, PriorityEnd: 5, Limit: 20, Offset: 40}
+
+	// comments are not queried, only the page of issues
+	mock.ExpectQuery("SELECT (.+) FROM issues WHERE 1 = 1 AND status = \\? AND assignee = \\? AND priority >= \\? AND priority <= \\? AND project = \\? ORDER BY id LIMIT \\? OFFSET \\?").
+		WithArgs("open", Assignee, int64(1), int64(5), Project, int64(20), int64(40)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+			AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
+
+	// run the code
+	issues, err := testingStorage.SearchIssues(search)
+	if err != nil {
+		t.Errorf("Error should not have occurred while searching issues: %s", err)
+	}
+	if len(issues) != 1 || issues[0].ID != IssueID || issues[0].Comments != nil {
+		t.Errorf("Unexpected issues: %v", issues)
+	}
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_CreateIssue(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	t.Run("NoError", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO issues").
+			WithArgs(Summary, Description, Priority, Project, Type, nil, 0, 0, Assignee).
+			WillReturnResult(sqlmock.NewResult(IssueID, 1))
+		mock.ExpectExec("INSERT INTO issue_fields").
+			WithArgs(IssueID, "steps to reproduce", Comment).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		// run the code
+		issue := models.NewIssueRequest{
+			Summary:     Summary,
+			Description: Description,
+			Priority:    Priority,
+			Assignee:    Assignee,
+			Project:     Project,
+			Type:        Type,
+			Fields:      map[string]string{"steps to reproduce": Comment},
+		}
+		if _, err = testingStorage.CreateIssue(issue); err != nil {
+			t.Errorf("Error should not have occurred while creating issue: %s", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO issues").
+			WithArgs(Summary, Description, Priority, "", "", nil, 0, 0).
+			WillReturnError(errors.New("err"))
+		mock.ExpectRollback()
+
+		// run the code
+		issue := models.NewIssueRequest{Summary: Summary, Description: Description, Priority: Priority}
+		if _, err = testingStorage.CreateIssue(issue); err == nil {
+			t.Errorf("Error should have occured while creating issue: %s", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
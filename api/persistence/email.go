@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"strings"
+
+	"github.com/YAITS/api/models"
+)
+
+// RetrieveEmailPreferences returns the email preferences of a user, sql.ErrNoRows when they never set any
+func (mysqlSt *MysqlStorage) RetrieveEmailPreferences(user string) (models.EmailPreferences, error) {
+	var preferences models.EmailPreferences
+
+	query := `SELECT username, email, assigned, statusChanged, commented, mentioned, digest, unsubscribeToken
+		FROM email_preferences WHERE username = ?`
+
+	err := mysqlSt.db.QueryRow(query, user).Scan(&preferences.User, &preferences.Email, &preferences.Assigned,
+		&preferences.StatusChanged, &preferences.Commented, &preferences.Mentioned, &preferences.Digest,
+		&preferences.UnsubscribeToken)
+
+	return preferences, err
+}
+
+// RetrieveUserByEmail returns the user whose email preferences hold an address, sql.ErrNoRows when none does
+func (mysqlSt *MysqlStorage) RetrieveUserByEmail(email string) (string, error) {
+	var user string
+
+	query := `SELECT username FROM email_preferences WHERE email = ? ORDER BY username LIMIT 1`
+	err := mysqlSt.db.QueryRow(query, email).Scan(&user)
+
+	return user, err
+}
+
+// SaveEmailPreferences creates or replaces the email preferences of a user, the unsubscribe token of existing
+// preferences being kept
+func (mysqlSt *MysqlStorage) SaveEmailPreferences(preferences models.EmailPreferences) error {
+	upsertQuery := `INSERT INTO email_preferences(username, email, assigned, statusChanged, commented, mentioned, digest,
+		unsubscribeToken) VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE email = VALUES(email), assigned = VALUES(assigned),
+		statusChanged = VALUES(statusChanged), commented = VALUES(commented), mentioned = VALUES(mentioned),
+		digest = VALUES(digest)`
+
+	_, err := mysqlSt.db.Exec(upsertQuery, preferences.User, preferences.Email, preferences.Assigned,
+		preferences.StatusChanged, preferences.Commented, preferences.Mentioned, preferences.Digest,
+		preferences.UnsubscribeToken)
+
+	return err
+}
+
+// UnsubscribeEmails turns off every email notification of the user owning an unsubscribe token, returning the user
+func (mysqlSt *MysqlStorage) UnsubscribeEmails(token string) (string, error) {
+	var user string
+
+	query := `SELECT username FROM email_preferences WHERE unsubscribeToken = ?`
+	if err := mysqlSt.db.QueryRow(query, token).Scan(&user); err != nil {
+		return "", err
+	}
+
+	updateQuery := `UPDATE email_preferences SET assigned = FALSE, statusChanged = FALSE, commented = FALSE,
+		mentioned = FALSE WHERE username = ?`
+
+	_, err := mysqlSt.db.Exec(updateQuery, user)
+
+	return user, err
+}
+
+// QueueEmail queues an email notification, queuing the same kind of email of an event twice for a user being a no-op
+func (mysqlSt *MysqlStorage) QueueEmail(email models.Email) error {
+	insertQuery := `INSERT IGNORE INTO emails(eventID, username, kind, issueID, subject, textBody, htmlBody, digest)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := mysqlSt.db.Exec(insertQuery, email.EventID, email.User, email.Kind, email.IssueID, email.Subject,
+		email.Text, email.HTML, email.Digest)
+
+	return err
+}
+
+// RetrievePendingEmails returns the oldest emails yet to be sent, either on their own or within a digest
+func (mysqlSt *MysqlStorage) RetrievePendingEmails(digest bool, limit int64) ([]models.Email, error) {
+	resp := make([]models.Email, 0)
+
+	query := `SELECT id, eventID, username, kind, issueID, subject, textBody, htmlBody, digest, createDate FROM emails
+		WHERE sentDate IS NULL AND digest = ? ORDER BY id LIMIT ?`
+
+	rows, err := mysqlSt.db.Query(query, digest, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var email models.Email
+
+		err = rows.Scan(&email.ID, &email.EventID, &email.User, &email.Kind, &email.IssueID, &email.Subject,
+			&email.Text, &email.HTML, &email.Digest, &email.CreateDate)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, email)
+	}
+
+	return resp, rows.Err()
+}
+
+// MarkEmailsSent records that emails were sent
+func (mysqlSt *MysqlStorage) MarkEmailsSent(emailIDs ...int64) error {
+	if len(emailIDs) == 0 {
+		return nil
+	}
+
+	updateQuery := `UPDATE emails SET sentDate = NOW() WHERE id IN (?` + strings.Repeat(", ?", len(emailIDs)-1) + `)`
+
+	args := make([]interface{}, 0, len(emailIDs))
+	for _, id := range emailIDs {
+		args = append(args, id)
+	}
+
+	_, err := mysqlSt.db.Exec(updateQuery, args...)
+
+	return err
+}
@@ -0,0 +1,152 @@
+package persistence
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_SaveEmailPreferences(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("INSERT INTO email_preferences(.+) ON DUPLICATE KEY UPDATE").
+		WithArgs(Assignee, "john.doe@example.com", true, false, true, true, true, "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// run the code
+	err = testingStorage.SaveEmailPreferences(models.EmailPreferences{User: Assignee, Email: "john.doe@example.com",
+		Assigned: true, Commented: true, Mentioned: true, Digest: true, UnsubscribeToken: "abc"})
+	assert.Nil(t, err)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveUserByEmail(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT username FROM email_preferences WHERE email = \\?").
+		WithArgs("john.doe@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"username"}).AddRow(Assignee))
+
+	// run the code
+	user, err := testingStorage.RetrieveUserByEmail("john.doe@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, Assignee, user)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_UnsubscribeEmails(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	t.Run("Known", func(t *testing.T) {
+		mock.ExpectQuery("SELECT username FROM email_preferences").
+			WithArgs("abc").
+			WillReturnRows(sqlmock.NewRows([]string{"username"}).AddRow(Assignee))
+		mock.ExpectExec("UPDATE email_preferences SET assigned = FALSE").
+			WithArgs(Assignee).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		user, err := testingStorage.UnsubscribeEmails("abc")
+		assert.Nil(t, err)
+		assert.Equal(t, Assignee, user)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		mock.ExpectQuery("SELECT username FROM email_preferences").
+			WithArgs("xyz").
+			WillReturnRows(sqlmock.NewRows([]string{"username"}))
+
+		_, err := testingStorage.UnsubscribeEmails("xyz")
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_QueueEmail(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("INSERT IGNORE INTO emails").
+		WithArgs(7, Assignee, "commented", IssueID, "[YAITS #1] This is a summary", Comment, "<p>"+Comment+"</p>", false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT (.+) FROM emails WHERE sentDate IS NULL AND digest = \\?").
+		WithArgs(false, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "eventID", "username", "kind", "issueID", "subject", "textBody", "htmlBody", "digest", "createDate"}).
+			AddRow(1, 7, Assignee, "commented", IssueID, "[YAITS #1] This is a summary", Comment, "<p>"+Comment+"</p>", false, CreateDate))
+	mock.ExpectExec("UPDATE emails SET sentDate = NOW\\(\\) WHERE id IN \\(\\?, \\?\\)").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// run the code
+	email := models.Email{EventID: 7, User: Assignee, Kind: "commented", IssueID: IssueID,
+		Subject: "[YAITS #1] This is a summary", Text: Comment, HTML: "<p>" + Comment + "</p>"}
+	assert.Nil(t, testingStorage.QueueEmail(email))
+
+	emails, err := testingStorage.RetrievePendingEmails(false, 10)
+	assert.Nil(t, err)
+	assert.Len(t, emails, 1)
+
+	assert.Nil(t, testingStorage.MarkEmailsSent(1, 2))
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
@@ -0,0 +1,100 @@
+package persistence
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/YAITS/api/models"
+)
+
+// ExportIssues streams the issues matching an export query in id order, calling each for every issue as rows are
+// read from the cursor rather than loading them all. Comments are joined in when asked for, the rows of an issue
+// following each other
+func (mysqlSt *MysqlStorage) ExportIssues(query models.ExportQueryParam, each func(models.IssueResponse) error) error {
+	columns := "issues." + strings.Replace(issueColumns, ", ", ", issues.", -1)
+	from := ` FROM issues`
+	if query.Comments {
+		columns += ", comments.commentID, comments.comment"
+		from += ` LEFT JOIN comments ON comments.issueID = issues.id`
+	}
+
+	where := ` WHERE 1 = 1`
+	args := make([]interface{}, 0)
+	if query.Status != "" {
+		where += ` AND status = ?`
+		args = append(args, query.Status)
+	}
+	if query.PriorityStart != 0 {
+		where += ` AND priority >= ?`
+		args = append(args, query.PriorityStart)
+	}
+	if query.PriorityEnd != 0 {
+		where += ` AND priority <= ?`
+		args = append(args, query.PriorityEnd)
+	}
+	filterClause, filterArgs := issueFilterClause(query.IssueFilterQueryParam)
+
+	order := ` ORDER BY issues.id`
+	if query.Comments {
+		order += `, comments.commentID`
+	}
+
+	rows, err := mysqlSt.db.Query(`SELECT `+columns+from+where+filterClause+order, append(args, filterArgs...)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current *models.IssueResponse
+	for rows.Next() {
+		var issue models.IssueResponse
+		var commentID sql.NullInt64
+		var comment sql.NullString
+
+		if query.Comments {
+			issue, err = scanIssue(scannerWith(rows, &commentID, &comment))
+		} else {
+			issue, err = scanIssue(rows)
+		}
+		if err != nil {
+			return err
+		}
+
+		if current == nil || current.ID != issue.ID {
+			if current != nil {
+				if err = each(*current); err != nil {
+					return err
+				}
+			}
+			issue.Comments = make([]models.Comment, 0)
+			current = &issue
+		}
+
+		if commentID.Valid {
+			current.Comments = append(current.Comments, models.Comment{ID: commentID.Int64, Comment: comment.String})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if current != nil {
+		return each(*current)
+	}
+
+	return nil
+}
+
+// extraScanner scans the columns following the issue ones into extra destinations
+type extraScanner struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func scannerWith(row rowScanner, extra ...interface{}) rowScanner {
+	return extraScanner{row: row, extra: extra}
+}
+
+func (scanner extraScanner) Scan(dest ...interface{}) error {
+	return scanner.row.Scan(append(dest, scanner.extra...)...)
+}
@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_ExportIssues(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	t.Run("WithComments", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM issues LEFT JOIN comments (.+) WHERE 1 = 1 AND status = \? AND priority >= \? AND project = \? ORDER BY issues.id, comments.commentID`).
+			WithArgs("open", 2, Project).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version", "commentID", "comment"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1, 1, Comment).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1, 2, "Another comment").
+				AddRow(IssueID+1, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1, nil, nil))
+
+		// run the code
+		issues := make([]models.IssueResponse, 0)
+		query := models.ExportQueryParam{IssueFilterQueryParam: models.IssueFilterQueryParam{Project: Project},
+			Status: "open", PriorityStart: 2, Comments: true}
+		err := testingStorage.ExportIssues(query, func(issue models.IssueResponse) error {
+			issues = append(issues, issue)
+			return nil
+		})
+		assert.Nil(t, err)
+
+		assert.Len(t, issues, 2)
+		assert.Equal(t, []models.Comment{{ID: 1, Comment: Comment}, {ID: 2, Comment: "Another comment"}},
+			issues[0].Comments, "the rows of an issue are grouped")
+		assert.Equal(t, []models.Comment{}, issues[1].Comments)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("StopsOnWriteError", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM issues WHERE 1 = 1 ORDER BY issues.id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1).
+				AddRow(IssueID+1, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
+
+		// run the code
+		calls := 0
+		err := testingStorage.ExportIssues(models.ExportQueryParam{}, func(issue models.IssueResponse) error {
+			calls++
+			return sqlmock.ErrCancelled
+		})
+		assert.Equal(t, sqlmock.ErrCancelled, err)
+		assert.Equal(t, 1, calls)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
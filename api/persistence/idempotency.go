@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// ReserveIdempotencyKey reserves the Idempotency-Key of a user for a request, returning true when it was free. When
+// it was used already, the response stored for it is returned instead, with a Status of 0 while its request is in
+// progress. Expired keys are deleted first, their keys being free again
+func (mysqlSt *MysqlStorage) ReserveIdempotencyKey(reservation models.IdempotentResponse) (models.IdempotentResponse, bool, error) {
+	now := time.Now().UTC()
+	if _, err := mysqlSt.db.Exec(`DELETE FROM idempotency_keys WHERE expireDate <= ?`, now); err != nil {
+		return reservation, false, err
+	}
+
+	insertQuery := `INSERT IGNORE INTO idempotency_keys(username, idempotencyKey, fingerprint, status, expireDate)
+		VALUES(?, ?, ?, 0, ?)`
+
+	result, err := mysqlSt.db.Exec(insertQuery, reservation.User, reservation.Key, reservation.Fingerprint,
+		reservation.ExpireDate.UTC())
+	if err != nil {
+		return reservation, false, err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil || rows == 1 {
+		return reservation, err == nil, err
+	}
+
+	stored := models.IdempotentResponse{User: reservation.User, Key: reservation.Key}
+	var header sql.NullString
+	query := `SELECT fingerprint, status, header, body, expireDate FROM idempotency_keys
+		WHERE username = ? AND idempotencyKey = ?`
+
+	err = mysqlSt.db.QueryRow(query, reservation.User, reservation.Key).
+		Scan(&stored.Fingerprint, &stored.Status, &header, &stored.Body, &stored.ExpireDate)
+	if err != nil {
+		return stored, false, err
+	}
+
+	if header.Valid {
+		err = json.Unmarshal([]byte(header.String), &stored.Header)
+	}
+
+	return stored, false, err
+}
+
+// SaveIdempotentResponse stores the response to the request an Idempotency-Key was reserved for
+func (mysqlSt *MysqlStorage) SaveIdempotentResponse(response models.IdempotentResponse) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	updateQuery := `UPDATE idempotency_keys SET status = ?, header = ?, body = ? WHERE username = ? AND idempotencyKey = ?`
+
+	_, err = mysqlSt.db.Exec(updateQuery, response.Status, string(header), response.Body, response.User, response.Key)
+
+	return err
+}
+
+// ReleaseIdempotencyKey deletes the Idempotency-Key of a user, such as one reserved for a request that failed, for
+// the request to be retried
+func (mysqlSt *MysqlStorage) ReleaseIdempotencyKey(user, key string) error {
+	_, err := mysqlSt.db.Exec(`DELETE FROM idempotency_keys WHERE username = ? AND idempotencyKey = ?`, user, key)
+
+	return err
+}
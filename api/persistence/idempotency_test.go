@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/YAITS/api/models"
+)
+
+func TestMysqlStorage_IdempotencyKeys(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	expireDate := time.Date(2020, 7, 2, 10, 0, 0, 0, time.UTC)
+	reservation := models.IdempotentResponse{User: Assignee, Key: "create-1", Fingerprint: "abc", ExpireDate: expireDate}
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE expireDate <= \\?").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT IGNORE INTO idempotency_keys").
+		WithArgs(Assignee, "create-1", "abc", expireDate).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec("UPDATE idempotency_keys SET status = \\?, header = \\?, body = \\?").
+		WithArgs(201, `{"Content-Type":"application/json"}`, []byte(`{"id":1}`), Assignee, "create-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE expireDate <= \\?").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT IGNORE INTO idempotency_keys").
+		WithArgs(Assignee, "create-1", "abc", expireDate).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT fingerprint, status, header, body, expireDate FROM idempotency_keys").
+		WithArgs(Assignee, "create-1").
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "status", "header", "body", "expireDate"}).
+			AddRow("abc", 201, `{"Content-Type":"application/json"}`, []byte(`{"id":1}`), expireDate))
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE username = \\? AND idempotencyKey = \\?").
+		WithArgs(Assignee, "create-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// run the code
+	_, reserved, err := testingStorage.ReserveIdempotencyKey(reservation)
+	assert.Nil(t, err)
+	assert.True(t, reserved)
+
+	response := reservation
+	response.Status = 201
+	response.Header = map[string]string{"Content-Type": "application/json"}
+	response.Body = []byte(`{"id":1}`)
+	assert.Nil(t, testingStorage.SaveIdempotentResponse(response))
+
+	stored, reserved, err := testingStorage.ReserveIdempotencyKey(reservation)
+	assert.Nil(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, response, stored)
+
+	assert.Nil(t, testingStorage.ReleaseIdempotencyKey(Assignee, "create-1"))
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
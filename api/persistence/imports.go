@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/YAITS/api/models"
+)
+
+// Import job statuses, a job failing when none of its rows could be imported
+const (
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// ImportIssues creates a chunk of imported issues in a single transaction, their reporters and assignees watching
+// them, returning their ids in order. Imported issues are not published as events not to flood the subscribers
+func (mysqlSt *MysqlStorage) ImportIssues(issues []models.ImportIssue) ([]int64, error) {
+	ids := make([]int64, 0, len(issues))
+
+	tx, err := mysqlSt.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	watchQuery := "INSERT IGNORE INTO watchers(issueID, username) VALUES(?, ?)"
+	for _, issue := range issues {
+		id, err := insertIssue(tx, issue.NewIssueRequest, issue.Status)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		for _, user := range []string{issue.Reporter, issue.Assignee} {
+			if user == "" {
+				continue
+			}
+			if _, err = tx.Exec(watchQuery, id, user); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, tx.Commit()
+}
+
+// CreateImportJob records an import about to run in the background
+func (mysqlSt *MysqlStorage) CreateImportJob(job models.ImportJob) (int64, error) {
+	errors, err := encodeImportErrors(job.Errors)
+	if err != nil {
+		return 0, err
+	}
+
+	insertQuery := `INSERT INTO import_jobs(format, status, createdBy, total, processed, created, failed, errors)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := mysqlSt.db.Exec(insertQuery, job.Format, job.Status, job.CreatedBy, job.Total, job.Processed,
+		job.Created, job.Failed, string(errors))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateImportJob records the status and the progress of an import job
+func (mysqlSt *MysqlStorage) UpdateImportJob(job models.ImportJob) error {
+	errors, err := encodeImportErrors(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	updateQuery := `UPDATE import_jobs SET status = ?, processed = ?, created = ?, failed = ?, errors = ? WHERE id = ?`
+
+	_, err = mysqlSt.db.Exec(updateQuery, job.Status, job.Processed, job.Created, job.Failed, string(errors), job.ID)
+
+	return err
+}
+
+// RetrieveImportJob returns an import job along with its progress
+func (mysqlSt *MysqlStorage) RetrieveImportJob(jobID int64) (models.ImportJob, error) {
+	var job models.ImportJob
+	var errors sql.NullString
+
+	query := `SELECT id, format, status, createdBy, total, processed, created, failed, errors, createDate, updateDate
+		FROM import_jobs WHERE id = ?`
+
+	err := mysqlSt.db.QueryRow(query, jobID).Scan(&job.ID, &job.Format, &job.Status, &job.CreatedBy, &job.Total,
+		&job.Processed, &job.Created, &job.Failed, &errors, &job.CreateDate, &job.UpdateDate)
+	if err != nil {
+		return job, err
+	}
+
+	job.Errors = make([]models.ImportRowError, 0)
+	if errors.Valid && errors.String != "" {
+		err = json.Unmarshal([]byte(errors.String), &job.Errors)
+	}
+
+	return job, err
+}
+
+func encodeImportErrors(rowErrors []models.ImportRowError) ([]byte, error) {
+	if rowErrors == nil {
+		rowErrors = make([]models.ImportRowError, 0)
+	}
+
+	return json.Marshal(rowErrors)
+}
@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_ImportIssues(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	issues := []models.ImportIssue{
+		{NewIssueRequest: models.NewIssueRequest{Summary: Summary, Description: Description, Priority: Priority,
+			Project: Project, Reporter: Assignee}, Row: 2},
+		{NewIssueRequest: models.NewIssueRequest{Summary: Summary, Description: Description, Priority: Priority,
+			Project: Project}, Status: "closed", Row: 3},
+	}
+
+	t.Run("NoError", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO issues").
+			WithArgs(Summary, Description, Priority, Project, "", nil, 0, 0, Assignee).
+			WillReturnResult(sqlmock.NewResult(IssueID, 1))
+		mock.ExpectExec("INSERT IGNORE INTO watchers").
+			WithArgs(IssueID, Assignee).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO issues\(.*status, respondedDate, resolvedDate\)`).
+			WithArgs(Summary, Description, Priority, Project, "", nil, 0, 0, "closed", sqlmock.AnyArg(),
+				sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(IssueID+1, 1))
+		mock.ExpectCommit()
+
+		// run the code
+		ids, err := testingStorage.ImportIssues(issues)
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{IssueID, IssueID + 1}, ids)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("ChunkRolledBack", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO issues").
+			WillReturnResult(sqlmock.NewResult(IssueID, 1))
+		mock.ExpectExec("INSERT IGNORE INTO watchers").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO issues").
+			WillReturnError(sqlmock.ErrCancelled)
+		mock.ExpectRollback()
+
+		// run the code
+		_, err := testingStorage.ImportIssues(issues)
+		assert.Equal(t, sqlmock.ErrCancelled, err)
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+func TestMysqlStorage_ImportJob(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	rowErrors := []models.ImportRowError{{Row: 3, Errors: []string{"summary is required"}}}
+	encodedErrors := `[{"row":3,"errors":["summary is required"]}]`
+
+	mock.ExpectExec("INSERT INTO import_jobs").
+		WithArgs("csv", ImportJobQueued, Assignee, 2, 0, 0, 0, "[]").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE import_jobs").
+		WithArgs(ImportJobCompleted, 2, 1, 1, encodedErrors, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM import_jobs").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "format", "status", "createdBy", "total", "processed", "created",
+			"failed", "errors", "createDate", "updateDate"}).
+			AddRow(1, "csv", ImportJobCompleted, Assignee, 2, 2, 1, 1, encodedErrors, CreateDate, CreateDate))
+
+	// run the code
+	job := models.ImportJob{Format: "csv", Status: ImportJobQueued, CreatedBy: Assignee, Total: 2}
+	job.ID, err = testingStorage.CreateImportJob(job)
+	assert.Nil(t, err)
+
+	job.Status, job.Processed, job.Created, job.Failed, job.Errors = ImportJobCompleted, 2, 1, 1, rowErrors
+	assert.Nil(t, testingStorage.UpdateImportJob(job))
+
+	retrieved, err := testingStorage.RetrieveImportJob(1)
+	assert.Nil(t, err)
+	assert.Equal(t, rowErrors, retrieved.Errors)
+	assert.Equal(t, int64(1), retrieved.Created)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
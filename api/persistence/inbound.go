@@ -0,0 +1,31 @@
+package persistence
+
+import (
+	"strings"
+)
+
+// RetrieveIssueByMessageID returns the issue an inbound email with one of the given message ids was filed on,
+// sql.ErrNoRows when none was
+func (mysqlSt *MysqlStorage) RetrieveIssueByMessageID(messageIDs ...string) (int64, error) {
+	var issueID int64
+
+	args := make([]interface{}, 0, len(messageIDs))
+	for _, messageID := range messageIDs {
+		args = append(args, messageID)
+	}
+
+	query := `SELECT issueID FROM inbound_messages WHERE messageID IN (?` + strings.Repeat(", ?", len(messageIDs)-1) +
+		`) ORDER BY createDate DESC LIMIT 1`
+	err := mysqlSt.db.QueryRow(query, args...).Scan(&issueID)
+
+	return issueID, err
+}
+
+// RecordInboundMessage remembers the issue an inbound email was filed on, recording a message twice being a no-op
+func (mysqlSt *MysqlStorage) RecordInboundMessage(messageID string, issueID int64) error {
+	insertQuery := `INSERT IGNORE INTO inbound_messages(messageID, issueID) VALUES(?, ?)`
+
+	_, err := mysqlSt.db.Exec(insertQuery, messageID, issueID)
+
+	return err
+}
@@ -0,0 +1,42 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_InboundMessages(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("INSERT IGNORE INTO inbound_messages").
+		WithArgs("thread-1@example.com", IssueID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT issueID FROM inbound_messages WHERE messageID IN \\(\\?, \\?\\)").
+		WithArgs("reply-1@example.com", "thread-1@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"issueID"}).AddRow(IssueID))
+
+	// run the code
+	assert.Nil(t, testingStorage.RecordInboundMessage("thread-1@example.com", IssueID))
+
+	issueID, err := testingStorage.RetrieveIssueByMessageID("reply-1@example.com", "thread-1@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, IssueID, issueID)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
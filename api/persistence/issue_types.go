@@ -0,0 +1,81 @@
+package persistence
+
+import (
+	"strings"
+
+	"github.com/YAITS/api/models"
+)
+
+const issueTypeColumns = `id, project, name, workflow, requiredFields, defaultPriority, descriptionTemplate`
+
+// CreateIssueType configures a new issue type, the empty project being shared by all projects
+func (mysqlSt *MysqlStorage) CreateIssueType(issueType models.NewIssueTypeRequest) (int64, error) {
+	insertQuery := "INSERT INTO issue_types(project, name, workflow, requiredFields, defaultPriority, descriptionTemplate) VALUES(?, ?, ?, ?, ?, ?)"
+
+	result, err := mysqlSt.db.Exec(insertQuery, issueType.Project, issueType.Name, issueType.Workflow,
+		strings.Join(issueType.RequiredFields, ","), issueType.DefaultPriority, issueType.DescriptionTemplate)
+	if err != nil {
+		return 0, err
+	}
+
+	id, _ := result.LastInsertId()
+	return id, nil
+}
+
+// RetrieveIssueType returns the issue type with the given name, preferring the project's own definition
+// over the shared one
+func (mysqlSt *MysqlStorage) RetrieveIssueType(project, name string) (models.IssueType, error) {
+	query := `SELECT ` + issueTypeColumns + ` FROM issue_types WHERE name = ? AND project IN (?, '') ORDER BY project DESC LIMIT 1`
+
+	return scanIssueType(mysqlSt.db.QueryRow(query, name, project))
+}
+
+// RetrieveIssueTypes returns the issue types available to a project
+func (mysqlSt *MysqlStorage) RetrieveIssueTypes(project string) ([]models.IssueType, error) {
+	resp := make([]models.IssueType, 0)
+
+	query := `SELECT ` + issueTypeColumns + ` FROM issue_types WHERE project IN (?, '') ORDER BY name, project`
+
+	rows, err := mysqlSt.db.Query(query, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		issueType, err := scanIssueType(rows)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, issueType)
+	}
+
+	return resp, rows.Err()
+}
+
+// DeleteIssueTypeByID deletes an issue type, existing issues keep their type name
+func (mysqlSt *MysqlStorage) DeleteIssueTypeByID(issueTypeID int64) error {
+	query := `DELETE FROM issue_types WHERE id = ?`
+
+	_, err := mysqlSt.db.Exec(query, issueTypeID)
+
+	return err
+}
+
+func scanIssueType(row rowScanner) (models.IssueType, error) {
+	var issueType models.IssueType
+	var requiredFields string
+
+	err := row.Scan(&issueType.ID, &issueType.Project, &issueType.Name, &issueType.Workflow,
+		&requiredFields, &issueType.DefaultPriority, &issueType.DescriptionTemplate)
+	if err != nil {
+		return issueType, err
+	}
+
+	issueType.RequiredFields = make([]string, 0)
+	if requiredFields != "" {
+		issueType.RequiredFields = strings.Split(requiredFields, ",")
+	}
+
+	return issueType, nil
+}
@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_CreateIssueType(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("INSERT INTO issue_types").
+		WithArgs(Project, Type, "default", "steps to reproduce,environment", Priority, Description).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// run the code
+	issueType := models.NewIssueTypeRequest{
+		Project:             Project,
+		Name:                Type,
+		Workflow:            "default",
+		RequiredFields:      []string{"steps to reproduce", "environment"},
+		DefaultPriority:     Priority,
+		DescriptionTemplate: Description,
+	}
+	if _, err = testingStorage.CreateIssueType(issueType); err != nil {
+		t.Errorf("Error should not have occurred while creating issue type: %s", err)
+	}
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveIssueType(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM issue_types WHERE name").
+		WithArgs(Type, Project).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project", "name", "workflow", "requiredFields", "defaultPriority", "descriptionTemplate"}).
+			AddRow(1, Project, Type, "default", "steps to reproduce,environment", Priority, Description))
+
+	// run the code
+	issueType, err := testingStorage.RetrieveIssueType(Project, Type)
+	if err != nil {
+		t.Errorf("Error should not have occurred while retrieving issue type: %s", err)
+	}
+
+	assert.Equal(t, []string{"steps to reproduce", "environment"}, issueType.RequiredFields, "required fields are split")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveIssueTypes(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM issue_types WHERE project").
+		WithArgs(Project).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project", "name", "workflow", "requiredFields", "defaultPriority", "descriptionTemplate"}).
+			AddRow(1, Project, Type, "default", "", Priority, Description).
+			AddRow(2, "", "Task", "default", "", Priority, ""))
+
+	// run the code
+	issueTypes, err := testingStorage.RetrieveIssueTypes(Project)
+	if err != nil {
+		t.Errorf("Error should not have occurred while retrieving issue types: %s", err)
+	}
+
+	assert.Len(t, issueTypes, 2, "project and shared issue types are returned")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
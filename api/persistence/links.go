@@ -0,0 +1,49 @@
+package persistence
+
+import (
+	"github.com/YAITS/api/models"
+)
+
+// CreateIssueLink links a commit or a pull request to an issue, telling whether the link is new: linking the same
+// change twice is a no-op
+func (mysqlSt *MysqlStorage) CreateIssueLink(link models.IssueLink) (bool, error) {
+	insertQuery := `INSERT IGNORE INTO issue_links(issueID, kind, repository, ref, url, title, author)
+		VALUES(?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := mysqlSt.db.Exec(insertQuery, link.IssueID, link.Kind, link.Repository, link.Ref, link.URL,
+		link.Title, link.Author)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+
+	return affected > 0, err
+}
+
+// RetrieveIssueLinks returns the commits and pull requests linked to an issue, oldest first
+func (mysqlSt *MysqlStorage) RetrieveIssueLinks(issueID int64) ([]models.IssueLink, error) {
+	resp := make([]models.IssueLink, 0)
+
+	query := `SELECT id, issueID, kind, repository, ref, url, title, author, createDate FROM issue_links
+		WHERE issueID = ? ORDER BY id`
+
+	rows, err := mysqlSt.db.Query(query, issueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link models.IssueLink
+
+		err = rows.Scan(&link.ID, &link.IssueID, &link.Kind, &link.Repository, &link.Ref, &link.URL, &link.Title,
+			&link.Author, &link.CreateDate)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, link)
+	}
+
+	return resp, rows.Err()
+}
@@ -0,0 +1,81 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_CreateIssueLink(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	link := models.IssueLink{IssueID: IssueID, Kind: "commit", Repository: "yaits/api", Ref: "0d1a26e",
+		URL: "https://github.com/yaits/api/commit/0d1a26e", Title: "Fix login redirect", Author: "johndoe"}
+
+	mock.ExpectExec("INSERT IGNORE INTO issue_links").
+		WithArgs(IssueID, "commit", "yaits/api", "0d1a26e", link.URL, link.Title, link.Author).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT IGNORE INTO issue_links").
+		WithArgs(IssueID, "commit", "yaits/api", "0d1a26e", link.URL, link.Title, link.Author).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// run the code
+	created, err := testingStorage.CreateIssueLink(link)
+	assert.Nil(t, err)
+	assert.True(t, created)
+
+	created, err = testingStorage.CreateIssueLink(link)
+	assert.Nil(t, err)
+	assert.False(t, created, "linking a change twice is a no-op")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveIssueLinks(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM issue_links").
+		WithArgs(IssueID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "issueID", "kind", "repository", "ref", "url", "title", "author", "createDate"}).
+			AddRow(1, IssueID, "pull_request", "yaits/api", "42", "https://github.com/yaits/api/pull/42", "Fix login", "johndoe", CreateDate))
+
+	// run the code
+	links, err := testingStorage.RetrieveIssueLinks(IssueID)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []models.IssueLink{{ID: 1, IssueID: IssueID, Kind: "pull_request", Repository: "yaits/api",
+		Ref: "42", URL: "https://github.com/yaits/api/pull/42", Title: "Fix login", Author: "johndoe",
+		CreateDate: CreateDate}}, links)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
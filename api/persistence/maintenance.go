@@ -0,0 +1,34 @@
+package persistence
+
+import (
+	"fmt"
+	"strings"
+)
+
+// searchTables are the tables issue listings and searches filter on
+var searchTables = []string{"issues", "comments", "issue_fields", "issue_links", "watchers"}
+
+// ReindexSearch refreshes the statistics of the indexes issue listings and searches rely on, for MySQL to keep
+// picking them after bulk changes such as imports and restores
+func (mysqlSt *MysqlStorage) ReindexSearch() ([]string, error) {
+	rows, err := mysqlSt.db.Query(`ANALYZE TABLE ` + strings.Join(searchTables, ", "))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0, len(searchTables))
+	for rows.Next() {
+		var table, op, msgType, msgText string
+		if err = rows.Scan(&table, &op, &msgType, &msgText); err != nil {
+			return nil, err
+		}
+
+		if msgType == "error" {
+			return nil, fmt.Errorf("couldn't analyze %s: %s", table, msgText)
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
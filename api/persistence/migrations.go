@@ -0,0 +1,557 @@
+package persistence
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+)
+
+// Migration is a versioned change of the schema
+type Migration struct {
+	Version    int64
+	Name       string
+	Statements []string
+}
+
+// Migrations are the changes Migrate applies in order, the first one creating the schema of the first
+// mysql/seed.sql and every later one the tables and columns of a feature. The columns, keys and foreign keys a
+// database already has are skipped, so databases created from any older mysql/seed.sql, before migrations existed,
+// are taken to the current schema as well
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS issues (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			summary varchar (64),
+			description varchar(256),
+			priority int not null default 1,
+			status ENUM('open', 'in progress', 'closed') not null default 'open',
+			assignee varchar(64) not null default 'unassigned',
+			reporter varchar(64),
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			constraint priority_range check (priority > 0 and priority < 11)
+			)`,
+			`CREATE TABLE IF NOT EXISTS comments (
+			commentID int(10) unsigned NOT NULL AUTO_INCREMENT,
+			issueID int(10) unsigned NOT NULL,
+			comment varchar(1024),
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (commentID),
+			CONSTRAINT comments_fk_1 FOREIGN KEY (issueID) REFERENCES issues (id) ON DELETE CASCADE
+			)`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "issue types",
+		Statements: []string{
+			`ALTER TABLE issues ADD COLUMN project varchar(16) not null default ''`,
+			`ALTER TABLE issues ADD COLUMN issueType varchar(32) not null default ''`,
+			`ALTER TABLE issues ADD KEY issues_project_type (project, issueType)`,
+			`CREATE TABLE IF NOT EXISTS issue_types (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			project varchar(16) not null default '',
+			name varchar(32) not null,
+			workflow varchar(64) not null default 'default',
+			requiredFields varchar(256) not null default '',
+			defaultPriority int not null default 1,
+			descriptionTemplate varchar(256) not null default '',
+			PRIMARY KEY (id),
+			UNIQUE KEY issue_types_project_name (project, name),
+			constraint default_priority_range check (defaultPriority > 0 and defaultPriority < 11)
+			)`,
+			`CREATE TABLE IF NOT EXISTS issue_fields (
+			issueID int(10) unsigned NOT NULL,
+			name varchar(64) not null,
+			value varchar(1024) not null default '',
+			PRIMARY KEY (issueID, name),
+			CONSTRAINT issue_fields_fk_1 FOREIGN KEY (issueID) REFERENCES issues (id) ON DELETE CASCADE
+			)`,
+			`INSERT IGNORE INTO issue_types (name, requiredFields, defaultPriority, descriptionTemplate) values
+			('Bug', 'steps to reproduce', 3, 'Expected behaviour:\n\nActual behaviour:'),
+			('Feature', '', 5, 'As a ... I want ... so that ...'),
+			('Task', '', 5, '')`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "sla policies",
+		Statements: []string{
+			`ALTER TABLE issues ADD COLUMN dueDate timestamp NULL DEFAULT NULL`,
+			`ALTER TABLE issues ADD COLUMN respondedDate timestamp NULL DEFAULT NULL`,
+			`ALTER TABLE issues ADD COLUMN resolvedDate timestamp NULL DEFAULT NULL`,
+			`CREATE TABLE IF NOT EXISTS business_calendars (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			name varchar(64) not null,
+			timezone varchar(64) not null default 'UTC',
+			workdays varchar(16) not null default '1,2,3,4,5',
+			startHour int not null default 9,
+			endHour int not null default 17,
+			PRIMARY KEY (id),
+			constraint business_hours_range check (startHour >= 0 and endHour <= 24 and startHour < endHour)
+			)`,
+			`CREATE TABLE IF NOT EXISTS sla_policies (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			name varchar(64) not null,
+			project varchar(16) not null default '',
+			priority int not null default 0,
+			responseMinutes int not null default 0,
+			resolutionMinutes int not null default 0,
+			calendarID int(10) unsigned NULL,
+			PRIMARY KEY (id),
+			CONSTRAINT sla_policies_fk_1 FOREIGN KEY (calendarID) REFERENCES business_calendars (id) ON DELETE SET NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS sla_breaches (
+			issueID int(10) unsigned NOT NULL,
+			target ENUM('response', 'resolution') not null,
+			policy varchar(64) not null,
+			breachDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (issueID, target),
+			CONSTRAINT sla_breaches_fk_1 FOREIGN KEY (issueID) REFERENCES issues (id) ON DELETE CASCADE
+			)`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "time tracking",
+		Statements: []string{
+			`ALTER TABLE issues ADD COLUMN originalEstimate int not null default 0`,
+			`ALTER TABLE issues ADD COLUMN remainingEstimate int not null default 0`,
+			`ALTER TABLE issues ADD COLUMN timeSpent int not null default 0`,
+			`CREATE TABLE IF NOT EXISTS worklogs (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			issueID int(10) unsigned NOT NULL,
+			username varchar(64) not null,
+			minutes int not null,
+			workDate date not null,
+			note varchar(256) not null default '',
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY worklogs_user_date (username, workDate),
+			CONSTRAINT worklogs_fk_1 FOREIGN KEY (issueID) REFERENCES issues (id) ON DELETE CASCADE,
+			constraint worklog_minutes check (minutes > 0)
+			)`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "attachments",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS attachments (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			issueID int(10) unsigned NOT NULL,
+			commentID int(10) unsigned NULL,
+			filename varchar(256) not null,
+			contentType varchar(128) not null,
+			size bigint not null,
+			hash char(64) not null,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY attachments_hash (hash),
+			CONSTRAINT attachments_fk_1 FOREIGN KEY (issueID) REFERENCES issues (id) ON DELETE CASCADE,
+			CONSTRAINT attachments_fk_2 FOREIGN KEY (commentID) REFERENCES comments (commentID) ON DELETE CASCADE
+			)`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "notifications",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS watchers (
+			issueID int(10) unsigned NOT NULL,
+			username varchar(64) not null,
+			PRIMARY KEY (issueID, username),
+			CONSTRAINT watchers_fk_1 FOREIGN KEY (issueID) REFERENCES issues (id) ON DELETE CASCADE
+			)`,
+			`CREATE TABLE IF NOT EXISTS notifications (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			username varchar(64) not null,
+			issueID int(10) unsigned NOT NULL,
+			kind varchar(16) not null,
+			actor varchar(64) not null default '',
+			message varchar(1024) not null default '',
+			isRead boolean not null default false,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY notifications_user_read (username, isRead)
+			)`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "webhooks",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS webhooks (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			url varchar(2048) not null,
+			secret varchar(256) not null,
+			events varchar(256) not null,
+			project varchar(64) not null default '',
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			webhookID int(10) unsigned NOT NULL,
+			event varchar(32) not null,
+			payload mediumtext not null,
+			status varchar(16) not null default 'pending',
+			attempts int not null default 0,
+			nextAttempt timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			responseStatus int not null default 0,
+			lastError varchar(1024) not null default '',
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			deliveredDate timestamp NULL,
+			PRIMARY KEY (id),
+			KEY webhook_deliveries_due (status, nextAttempt),
+			CONSTRAINT webhook_deliveries_fk_1 FOREIGN KEY (webhookID) REFERENCES webhooks (id) ON DELETE CASCADE
+			)`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "outbox",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS outbox (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			event varchar(32) not null,
+			issueID int(10) unsigned NOT NULL,
+			payload mediumtext not null,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS outbox_consumed (
+			consumer varchar(32) not null,
+			eventID int(10) unsigned NOT NULL,
+			PRIMARY KEY (consumer, eventID),
+			KEY outbox_consumed_event (eventID),
+			CONSTRAINT outbox_consumed_fk_1 FOREIGN KEY (eventID) REFERENCES outbox (id) ON DELETE CASCADE
+			)`,
+			// the notifications and deliveries made before the outbox have no event
+			`ALTER TABLE notifications ADD COLUMN eventID int(10) unsigned NULL AFTER id`,
+			`ALTER TABLE notifications ADD UNIQUE KEY notifications_event (eventID, username)`,
+			`ALTER TABLE webhook_deliveries ADD COLUMN eventID int(10) unsigned NULL AFTER id`,
+			`ALTER TABLE webhook_deliveries ADD UNIQUE KEY webhook_deliveries_event (eventID, webhookID)`,
+			`ALTER TABLE watchers DROP FOREIGN KEY watchers_fk_1`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "email notifications",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS email_preferences (
+			username varchar(64) not null,
+			email varchar(256) not null default '',
+			assigned boolean not null default true,
+			statusChanged boolean not null default true,
+			commented boolean not null default true,
+			mentioned boolean not null default true,
+			digest boolean not null default false,
+			unsubscribeToken char(32) not null,
+			PRIMARY KEY (username),
+			UNIQUE KEY email_preferences_token (unsubscribeToken)
+			)`,
+			`CREATE TABLE IF NOT EXISTS emails (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			eventID int(10) unsigned NOT NULL,
+			username varchar(64) not null,
+			kind varchar(16) not null,
+			issueID int(10) unsigned NOT NULL,
+			subject varchar(512) not null,
+			textBody text not null,
+			htmlBody text not null,
+			digest boolean not null default false,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			sentDate timestamp NULL,
+			PRIMARY KEY (id),
+			UNIQUE KEY emails_event (eventID, username, kind),
+			KEY emails_pending (sentDate, digest)
+			)`,
+		},
+	},
+	{
+		Version: 10,
+		Name:    "inbound email",
+		Statements: []string{
+			`ALTER TABLE email_preferences ADD KEY email_preferences_email (email)`,
+			`CREATE TABLE IF NOT EXISTS inbound_messages (
+			messageID varchar(255) not null,
+			issueID int(10) unsigned NOT NULL,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (messageID),
+			KEY inbound_messages_issue (issueID)
+			)`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "webhook formats",
+		Statements: []string{
+			`ALTER TABLE webhooks MODIFY COLUMN secret varchar(256) not null default ''`,
+			`ALTER TABLE webhooks ADD COLUMN format varchar(16) not null default 'json'`,
+		},
+	},
+	{
+		Version: 12,
+		Name:    "issue links",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS issue_links (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			issueID int(10) unsigned NOT NULL,
+			kind varchar(16) not null,
+			repository varchar(256) not null,
+			ref varchar(64) not null,
+			url varchar(2048) not null default '',
+			title varchar(256) not null default '',
+			author varchar(64) not null default '',
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY issue_links_ref (issueID, kind, repository, ref),
+			CONSTRAINT issue_links_fk_1 FOREIGN KEY (issueID) REFERENCES issues (id) ON DELETE CASCADE
+			)`,
+		},
+	},
+	{
+		Version: 13,
+		Name:    "import jobs",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS import_jobs (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			format varchar(16) not null,
+			status ENUM('queued', 'running', 'completed', 'failed') not null default 'queued',
+			createdBy varchar(64) not null default '',
+			total int not null default 0,
+			processed int not null default 0,
+			created int not null default 0,
+			failed int not null default 0,
+			errors mediumtext,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			updateDate timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id)
+			)`,
+		},
+	},
+	{
+		Version: 14,
+		Name:    "api tokens",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS api_tokens (
+			username varchar(64) not null,
+			tokenHash char(64) not null,
+			admin boolean not null default false,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			rotateDate timestamp NULL DEFAULT NULL,
+			PRIMARY KEY (username),
+			UNIQUE KEY api_tokens_hash (tokenHash)
+			)`,
+		},
+	},
+	{
+		Version: 15,
+		Name:    "issue versions",
+		Statements: []string{
+			`ALTER TABLE issues ADD COLUMN version int unsigned not null default 1`,
+		},
+	},
+	{
+		Version: 16,
+		Name:    "idempotency keys",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS idempotency_keys (
+			username varchar(64) not null,
+			idempotencyKey varchar(255) not null,
+			fingerprint char(64) not null,
+			status int not null default 0,
+			header text,
+			body mediumblob,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			expireDate timestamp NOT NULL,
+			PRIMARY KEY (username, idempotencyKey),
+			KEY idempotency_keys_expire (expireDate)
+			)`,
+		},
+	},
+	{
+		Version: 17,
+		Name:    "bulk jobs",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS bulk_jobs (
+			id int(10) unsigned NOT NULL AUTO_INCREMENT,
+			operation varchar(16) not null,
+			status ENUM('queued', 'running', 'completed', 'failed') not null default 'queued',
+			createdBy varchar(64) not null default '',
+			allOrNothing boolean not null default false,
+			total int not null default 0,
+			processed int not null default 0,
+			succeeded int not null default 0,
+			failed int not null default 0,
+			results mediumtext,
+			createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			updateDate timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id)
+			)`,
+		},
+	},
+	{
+		Version: 18,
+		Name:    "attachment contents",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS attachment_contents (
+			hash char(64) not null,
+			PRIMARY KEY (hash)
+			)`,
+		},
+	},
+}
+
+// SchemaVersion returns the version of the last migration applied, 0 when the database was never migrated
+func (mysqlSt *MysqlStorage) SchemaVersion() (int64, error) {
+	if err := mysqlSt.createMigrationTable(); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err := mysqlSt.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+
+	return version.Int64, err
+}
+
+// Migrate applies the migrations newer than the schema version in order, returning those applied. MySQL committing
+// schema changes right away, a migration failing halfway is left applied up to the failing statement, and resumed
+// from there when migrating again
+func (mysqlSt *MysqlStorage) Migrate() ([]Migration, error) {
+	return mysqlSt.MigrateTo(Migrations[len(Migrations)-1].Version)
+}
+
+// MigrateTo applies the migrations newer than the schema version up to target, such as the version of a backup
+func (mysqlSt *MysqlStorage) MigrateTo(target int64) ([]Migration, error) {
+	version, err := mysqlSt.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]Migration, 0)
+	for _, migration := range Migrations {
+		if migration.Version <= version || migration.Version > target {
+			continue
+		}
+
+		for _, statement := range migration.Statements {
+			done, err := mysqlSt.statementApplied(statement)
+			if err == nil && !done {
+				_, err = mysqlSt.db.Exec(statement)
+			}
+			if err != nil {
+				return applied, err
+			}
+		}
+
+		insertQuery := `INSERT INTO schema_migrations(version, name) VALUES(?, ?)`
+		if _, err = mysqlSt.db.Exec(insertQuery, migration.Version, migration.Name); err != nil {
+			return applied, err
+		}
+
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// statementApplied tells whether the database already has the column or the key a statement adds, or no longer has
+// the foreign key it drops, the other statements being safe to run again
+func (mysqlSt *MysqlStorage) statementApplied(statement string) (bool, error) {
+	var query string
+	var match []string
+	if match = addColumnPattern.FindStringSubmatch(statement); match != nil {
+		query = `SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ?
+			AND column_name = ?`
+	} else if match = addKeyPattern.FindStringSubmatch(statement); match != nil {
+		query = `SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ?
+			AND index_name = ?`
+	} else if match = dropForeignKeyPattern.FindStringSubmatch(statement); match != nil {
+		query = `SELECT COUNT(*) FROM information_schema.table_constraints WHERE table_schema = DATABASE()
+			AND table_name = ? AND constraint_name = ? AND constraint_type = 'FOREIGN KEY'`
+	} else {
+		return false, nil
+	}
+
+	var count int64
+	if err := mysqlSt.db.QueryRow(query, match[1], match[2]).Scan(&count); err != nil {
+		return false, err
+	}
+
+	if dropForeignKeyPattern.MatchString(statement) {
+		return count == 0, nil
+	}
+	return count > 0, nil
+}
+
+func (mysqlSt *MysqlStorage) createMigrationTable() error {
+	_, err := mysqlSt.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version int not null,
+		name varchar(64) not null,
+		appliedDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (version)
+		)`)
+
+	return err
+}
+
+var (
+	createTablePattern    = regexp.MustCompile(`(?s)^CREATE TABLE IF NOT EXISTS (\w+) \((.*)\)$`)
+	addColumnPattern      = regexp.MustCompile(`^ALTER TABLE (\w+) ADD COLUMN (\w+) (\w+)`)
+	addKeyPattern         = regexp.MustCompile(`^ALTER TABLE (\w+) ADD (?:UNIQUE )?KEY (\w+)`)
+	dropForeignKeyPattern = regexp.MustCompile(`^ALTER TABLE (\w+) DROP FOREIGN KEY (\w+)`)
+	primaryKeyPattern     = regexp.MustCompile(`^PRIMARY KEY \(([^)]+)\)`)
+	// tableKeywords start the lines of a table definition that are not columns
+	tableKeywords = map[string]bool{"primary": true, "key": true, "unique": true, "constraint": true, "index": true,
+		"fulltext": true}
+)
+
+// tableSchema is a table as the migrations define it, types being the lowercase MySQL ones without their length
+type tableSchema struct {
+	columns    []string
+	types      []string
+	primaryKey string
+}
+
+// schemaTables returns the tables the CREATE TABLE and ALTER TABLE ADD COLUMN statements of migrations define
+func schemaTables(migrations []Migration) map[string]*tableSchema {
+	tables := make(map[string]*tableSchema)
+	for _, migration := range migrations {
+		for _, statement := range migration.Statements {
+			if match := addColumnPattern.FindStringSubmatch(statement); match != nil {
+				if table := tables[match[1]]; table != nil {
+					table.columns = append(table.columns, match[2])
+					table.types = append(table.types, strings.ToLower(match[3]))
+				}
+				continue
+			}
+
+			match := createTablePattern.FindStringSubmatch(statement)
+			if match == nil {
+				continue
+			}
+
+			table := &tableSchema{}
+			for _, line := range strings.Split(match[2], "\n") {
+				line = strings.TrimSpace(line)
+				words := strings.Fields(line)
+				switch {
+				case len(words) < 2:
+				case primaryKeyPattern.MatchString(line):
+					table.primaryKey = primaryKeyPattern.FindStringSubmatch(line)[1]
+				case !tableKeywords[strings.ToLower(words[0])]:
+					table.columns = append(table.columns, words[0])
+					table.types = append(table.types, strings.ToLower(strings.SplitN(words[1], "(", 2)[0]))
+				}
+			}
+			tables[match[1]] = table
+		}
+	}
+
+	return tables
+}
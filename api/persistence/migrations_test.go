@@ -0,0 +1,214 @@
+package persistence
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSchema is the columns, keys and foreign keys of the tables of a database, by table
+type testSchema struct {
+	columns     map[string]map[string]bool
+	keys        map[string]map[string]bool
+	foreignKeys map[string]map[string]bool
+}
+
+func newTestSchema() *testSchema {
+	return &testSchema{columns: map[string]map[string]bool{}, keys: map[string]map[string]bool{},
+		foreignKeys: map[string]map[string]bool{}}
+}
+
+// addTable records a table of a CREATE TABLE statement, as mysql/seed.sql or the migrations define it
+func (schema *testSchema) addTable(name, definition string) {
+	schema.columns[name], schema.keys[name], schema.foreignKeys[name] = map[string]bool{}, map[string]bool{},
+		map[string]bool{}
+	for _, line := range strings.Split(strings.Replace(definition, "`", "", -1), "\n") {
+		words := strings.Fields(strings.TrimSpace(line))
+		switch {
+		case len(words) < 2 || strings.EqualFold(words[0], "PRIMARY"):
+		case strings.EqualFold(words[0], "KEY"):
+			schema.keys[name][words[1]] = true
+		case strings.EqualFold(words[0], "UNIQUE"):
+			schema.keys[name][words[2]] = true
+		case strings.EqualFold(words[0], "CONSTRAINT"):
+			if strings.EqualFold(words[2], "FOREIGN") {
+				schema.foreignKeys[name][words[1]] = true
+			}
+		default:
+			schema.columns[name][words[0]] = true
+		}
+	}
+}
+
+// seedSchema returns the schema mysql/seed.sql creates
+func seedSchema(t *testing.T) *testSchema {
+	seed, err := ioutil.ReadFile("../../mysql/seed.sql")
+	if err != nil {
+		t.Fatalf("couldn't read the seed: %s", err)
+	}
+
+	schema := newTestSchema()
+	for _, match := range regexp.MustCompile("(?s)Create table `(\\w+)` \\((.*?)\\n\\);").FindAllStringSubmatch(string(seed), -1) {
+		if match[1] != "schema_migrations" {
+			schema.addTable(match[1], match[2])
+		}
+	}
+
+	return schema
+}
+
+// migrateSchema applies the statements of migrations to a schema, the columns and keys it has being skipped, calling
+// guard with the information_schema table looked up for a column or a key along with whether it is there, and run
+// with the statements run
+func migrateSchema(schema *testSchema, migrations []Migration, guard func(table string, args []string, exists bool),
+	run func(statement string), applied func(migration Migration)) {
+	for _, migration := range migrations {
+		for _, statement := range migration.Statements {
+			runs := true
+			if match := addColumnPattern.FindStringSubmatch(statement); match != nil {
+				runs = !schema.columns[match[1]][match[2]]
+				guard("columns", match[1:3], !runs)
+				schema.columns[match[1]][match[2]] = true
+			} else if match := addKeyPattern.FindStringSubmatch(statement); match != nil {
+				runs = !schema.keys[match[1]][match[2]]
+				guard("statistics", match[1:3], !runs)
+				schema.keys[match[1]][match[2]] = true
+			} else if match := dropForeignKeyPattern.FindStringSubmatch(statement); match != nil {
+				runs = schema.foreignKeys[match[1]][match[2]]
+				guard("table_constraints", match[1:3], runs)
+				delete(schema.foreignKeys[match[1]], match[2])
+			} else if match := createTablePattern.FindStringSubmatch(statement); match != nil {
+				if schema.columns[match[1]] == nil {
+					schema.addTable(match[1], match[2])
+				}
+			}
+
+			if runs {
+				run(statement)
+			}
+		}
+		applied(migration)
+	}
+}
+
+// migratedSchema returns the schema of a database created by migrations
+func migratedSchema(migrations []Migration) *testSchema {
+	schema := newTestSchema()
+	migrateSchema(schema, migrations, func(string, []string, bool) {}, func(string) {}, func(Migration) {})
+
+	return schema
+}
+
+// expectMigrations expects the statements of migrations to run against a database of a schema, the columns and keys
+// it has being skipped, and applies them to the schema
+func expectMigrations(mock sqlmock.Sqlmock, schema *testSchema, migrations []Migration) {
+	migrateSchema(schema, migrations, func(table string, args []string, exists bool) {
+		count := sqlmock.NewRows([]string{"count"}).AddRow(0)
+		if exists {
+			count = sqlmock.NewRows([]string{"count"}).AddRow(1)
+		}
+		mock.ExpectQuery("FROM information_schema."+table).WithArgs(args[0], args[1]).WillReturnRows(count)
+	}, func(statement string) {
+		mock.ExpectExec(regexp.QuoteMeta(statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+	}, func(migration Migration) {
+		mock.ExpectExec("INSERT INTO schema_migrations").
+			WithArgs(migration.Version, migration.Name).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	})
+}
+
+func TestMysqlStorage_Migrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int64
+		schema  *testSchema
+	}{
+		// a database predating migrations, as the first mysql/seed.sql created it
+		{name: "Baseline", schema: migratedSchema(Migrations[:1])},
+		// databases predating migrations created from a later mysql/seed.sql, with some or all of the features
+		{name: "OlderSeed", schema: migratedSchema(Migrations[:8])},
+		{name: "Seed", schema: seedSchema(t)},
+		{name: "Migrated", version: 8, schema: migratedSchema(Migrations[:8])},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+
+			// force db closure at end of test
+			defer func() {
+				_ = db.Close()
+			}()
+
+			testingStorage := NewMysqlStorage(db)
+			schema := tt.schema
+
+			version := sqlmock.NewRows([]string{"version"})
+			if tt.version > 0 {
+				version.AddRow(tt.version)
+			} else {
+				version.AddRow(nil)
+			}
+			mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery("SELECT MAX\\(version\\) FROM schema_migrations").WillReturnRows(version)
+			expectMigrations(mock, schema, Migrations[tt.version:])
+
+			// run the code
+			applied, err := testingStorage.Migrate()
+			assert.Nil(t, err)
+			assert.Equal(t, Migrations[tt.version:], applied)
+			assert.Equal(t, seedSchema(t), schema, "migrating leads to the schema of mysql/seed.sql")
+
+			//check expectations are met
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Expectations not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	for i, migration := range Migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "migrations must be numbered in order")
+		assert.NotEmpty(t, migration.Statements)
+	}
+}
+
+func TestMysqlStorage_ReindexSearch(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	rows := sqlmock.NewRows([]string{"Table", "Op", "Msg_type", "Msg_text"})
+	for _, table := range searchTables {
+		rows.AddRow("yaits."+table, "analyze", "status", "OK")
+	}
+	mock.ExpectQuery("ANALYZE TABLE issues, comments").WillReturnRows(rows)
+
+	// run the code
+	tables, err := testingStorage.ReindexSearch()
+	assert.Nil(t, err)
+	assert.Len(t, tables, len(searchTables))
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
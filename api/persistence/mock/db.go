@@ -1,6 +1,13 @@
 package persistence
 
-import "github.com/YAITS/api/models"
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/YAITS/api/models"
+	base "github.com/YAITS/api/persistence"
+)
 
 type Storage struct{}
 
@@ -12,6 +19,8 @@ const (
 	Status      = "Open"
 	Priority    = int64(1)
 	CreateDate  = "some date"
+	Token       = "mock-token"
+	Version     = int64(1)
 )
 
 var MockIssueResponse = models.IssueResponse{
@@ -22,17 +31,148 @@ var MockIssueResponse = models.IssueResponse{
 	CreateDate:  CreateDate,
 	Priority:    Priority,
 	Status:      Status,
+	Version:     Version,
+}
+
+var MockComment = models.Comment{
+	ID:      1,
+	Comment: "This is a comment",
+}
+
+var MockIssueType = models.IssueType{
+	ID:                  1,
+	Name:                "Bug",
+	Workflow:            "default",
+	RequiredFields:      []string{"steps to reproduce"},
+	DefaultPriority:     Priority,
+	DescriptionTemplate: "What happened?",
+}
+
+var MockSLAPolicy = models.SLAPolicy{
+	ID:                1,
+	Name:              "P1",
+	Priority:          Priority,
+	ResponseMinutes:   60,
+	ResolutionMinutes: 24 * 60,
+	Calendar: &models.BusinessCalendar{
+		ID:        1,
+		Name:      "Office hours",
+		Timezone:  "UTC",
+		Workdays:  []int{1, 2, 3, 4, 5},
+		StartHour: 9,
+		EndHour:   17,
+	},
+}
+
+var MockWorklog = models.Worklog{
+	ID:       1,
+	IssueID:  IssueID,
+	User:     Assignee,
+	Minutes:  90,
+	WorkDate: "2020-09-08",
+	Note:     "investigation",
+}
+
+var MockAttachment = models.Attachment{
+	ID:          1,
+	IssueID:     IssueID,
+	Filename:    "steps.txt",
+	ContentType: "text/plain",
+	Size:        int64(len(Description)),
+	Hash:        "0dd10219cd79342198085cbe6f737bd54efe119b24c84cbc053023ed6b7da4c8",
+}
+
+var MockNotification = models.Notification{
+	ID:      1,
+	User:    Assignee,
+	IssueID: IssueID,
+	Kind:    "commented",
+	Actor:   "Jane Doe",
+	Message: "This is a comment",
 }
 
-func (storage *Storage) CreateIssue(_, _, _ string, _ int64) (int64, error) {
+var MockWebhook = models.Webhook{
+	ID:      1,
+	URL:     "http://127.0.0.1/hooks/yaits",
+	Secret:  "s3cr3t",
+	Events:  []string{"issue.created", "issue.updated"},
+	Project: "YAITS",
+	Format:  "json",
+}
+
+var MockWebhookDelivery = models.WebhookDelivery{
+	ID:             1,
+	WebhookID:      1,
+	Event:          "issue.created",
+	Payload:        `{"event":"issue.created","issueID":1}`,
+	Status:         "delivered",
+	Attempts:       1,
+	ResponseStatus: 200,
+}
+
+var MockIssueLink = models.IssueLink{
+	ID:         1,
+	IssueID:    IssueID,
+	Kind:       "commit",
+	Repository: "yaits/api",
+	Ref:        "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+	URL:        "https://github.com/yaits/api/commit/0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+	Title:      "Fix login redirect",
+	Author:     "johndoe",
+	CreateDate: CreateDate,
+}
+
+var MockBulkJob = models.BulkJob{
+	ID:         1,
+	Operation:  "setStatus",
+	Status:     "completed",
+	CreatedBy:  Assignee,
+	Total:      2,
+	Processed:  2,
+	Succeeded:  1,
+	Failed:     1,
+	Results:    []models.BulkItemResult{{IssueID: IssueID, Success: true}, {IssueID: 2, Error: "could not find issue"}},
+	CreateDate: CreateDate,
+	UpdateDate: CreateDate,
+}
+
+var MockImportJob = models.ImportJob{
+	ID:         1,
+	Format:     "csv",
+	Status:     "completed",
+	CreatedBy:  Assignee,
+	Total:      2,
+	Processed:  2,
+	Created:    1,
+	Failed:     1,
+	Errors:     []models.ImportRowError{{Row: 3, Errors: []string{"summary is required"}}},
+	CreateDate: CreateDate,
+	UpdateDate: CreateDate,
+}
+
+var MockEmailPreferences = models.EmailPreferences{
+	User:             Assignee,
+	Email:            "john.doe@example.com",
+	Assigned:         true,
+	StatusChanged:    true,
+	Commented:        true,
+	Mentioned:        true,
+	UnsubscribeToken: "0123456789abcdef",
+}
+
+func (storage *Storage) CreateIssue(_ models.NewIssueRequest, _ ...models.IssueEvent) (int64, error) {
 	return 1, nil
 }
 
-func (storage *Storage) UpdateIssue(_, _, _, _, _ string, _, _ int64) (*models.IssueResponse, error) {
+func (storage *Storage) UpdateIssue(update models.UpdateIssueRequest, _ int64, _ ...models.IssueEvent) (*models.IssueResponse, error) {
+	if update.ExpectedVersion != 0 && update.ExpectedVersion != Version {
+		return nil, base.ErrVersionConflict
+	}
+
 	return &MockIssueResponse, nil
 }
 
-func (storage *Storage) RetrieveIssues() ([]models.IssueResponse, error) {
+func (storage *Storage) RetrieveIssues(_ models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
 	return []models.IssueResponse{MockIssueResponse}, nil
 }
 
@@ -40,15 +180,356 @@ func (storage *Storage) RetrieveIssueByID(_ int64) (models.IssueResponse, error)
 	return MockIssueResponse, nil
 }
 
-func (storage *Storage) RetrieveIssueByStatus(_ string) ([]models.IssueResponse, error) {
+func (storage *Storage) RetrieveIssueByStatus(_ string, _ models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
 	return []models.IssueResponse{MockIssueResponse}, nil
 }
 
-func (storage *Storage) RetrieveIssueByPriority(_, _ int64) ([]models.IssueResponse, error) {
+func (storage *Storage) RetrieveIssueByPriority(_, _ int64, _ models.IssueFilterQueryParam) ([]models.IssueResponse, error) {
 	return []models.IssueResponse{MockIssueResponse}, nil
 }
 
-func (storage *Storage) DeleteIssueByID(_ int64) error {
+func (storage *Storage) ExportIssues(_ models.ExportQueryParam, each func(models.IssueResponse) error) error {
+	return each(MockIssueResponse)
+}
+
+func (storage *Storage) SearchIssues(_ models.IssueSearchQuery) ([]models.IssueResponse, error) {
+	return []models.IssueResponse{MockIssueResponse}, nil
+}
+
+func (storage *Storage) RetrieveComments(issueIDs ...int64) (map[int64][]models.Comment, error) {
+	comments := make(map[int64][]models.Comment)
+	for _, id := range issueIDs {
+		comments[id] = []models.Comment{MockComment}
+	}
+	return comments, nil
+}
+
+func (storage *Storage) RetrieveFields(_ ...int64) (map[int64]map[string]string, error) {
+	return map[int64]map[string]string{}, nil
+}
+
+func (storage *Storage) RetrieveUsers(_ ...string) ([]models.User, error) {
+	return []models.User{{Name: Assignee, Assigned: 1}}, nil
+}
+
+func (storage *Storage) RetrieveProjects(_ ...string) ([]models.Project, error) {
+	return []models.Project{{Key: "", Issues: 1, OpenIssues: 1}}, nil
+}
+
+func (storage *Storage) DeleteIssueByID(_ int64, _ ...models.IssueEvent) error {
+	return nil
+}
+
+func (storage *Storage) CreateIssueType(_ models.NewIssueTypeRequest) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) RetrieveIssueType(_, _ string) (models.IssueType, error) {
+	return MockIssueType, nil
+}
+
+func (storage *Storage) RetrieveIssueTypes(_ string) ([]models.IssueType, error) {
+	return []models.IssueType{MockIssueType}, nil
+}
+
+func (storage *Storage) DeleteIssueTypeByID(_ int64) error {
+	return nil
+}
+
+func (storage *Storage) CreateSLAPolicy(_ models.NewSLAPolicyRequest) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) RetrieveSLAPolicies() ([]models.SLAPolicy, error) {
+	return []models.SLAPolicy{MockSLAPolicy}, nil
+}
+
+func (storage *Storage) DeleteSLAPolicyByID(_ int64) error {
+	return nil
+}
+
+func (storage *Storage) CreateBusinessCalendar(_ models.NewBusinessCalendarRequest) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) RetrieveBusinessCalendars() ([]models.BusinessCalendar, error) {
+	return []models.BusinessCalendar{*MockSLAPolicy.Calendar}, nil
+}
+
+func (storage *Storage) RetrieveSLADueIssues(_, _, _ time.Time) ([]models.IssueResponse, error) {
+	return []models.IssueResponse{MockIssueResponse}, nil
+}
+
+func (storage *Storage) RecordSLABreach(_ models.SLABreach) (bool, error) {
+	return true, nil
+}
+
+func (storage *Storage) RetrieveSLABreaches(_ int64) ([]models.SLABreach, error) {
+	return []models.SLABreach{}, nil
+}
+
+func (storage *Storage) CreateWorklog(_ models.NewWorklogRequest, _ int64) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) RetrieveWorklogs(_ int64) ([]models.Worklog, error) {
+	return []models.Worklog{MockWorklog}, nil
+}
+
+func (storage *Storage) DeleteWorklog(_, _ int64) error {
+	return nil
+}
+
+func (storage *Storage) RetrieveWorklogTotals(_ models.WorklogQueryParam) ([]models.WorklogTotal, error) {
+	return []models.WorklogTotal{{Key: "1", Minutes: MockWorklog.Minutes}}, nil
+}
+
+func (storage *Storage) RetrieveUserWorklogs(_, _, _ string) ([]models.Worklog, error) {
+	return []models.Worklog{MockWorklog}, nil
+}
+
+func (storage *Storage) CreateAttachment(_ models.Attachment, put func() error) (int64, error) {
+	return 1, put()
+}
+
+func (storage *Storage) RetrieveAttachments(_ int64) ([]models.Attachment, error) {
+	return []models.Attachment{MockAttachment}, nil
+}
+
+func (storage *Storage) RetrieveAttachmentByID(_ int64) (models.Attachment, error) {
+	return MockAttachment, nil
+}
+
+func (storage *Storage) DeleteAttachmentByID(_ int64) error {
+	return nil
+}
+
+func (storage *Storage) ReleaseAttachmentContent(_ string, remove func() error) error {
+	return remove()
+}
+
+func (storage *Storage) WatchIssue(_ int64, _ string) error {
+	return nil
+}
+
+func (storage *Storage) UnwatchIssue(_ int64, _ string) error {
+	return nil
+}
+
+func (storage *Storage) RetrieveWatchers(_ int64) ([]string, error) {
+	return []string{Assignee}, nil
+}
+
+func (storage *Storage) DeleteWatchers(_ int64) error {
+	return nil
+}
+
+func (storage *Storage) NotifyWatchers(_ models.Notification) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) RetrieveNotifications(_ string, _ models.NotificationQueryParam) ([]models.Notification, error) {
+	return []models.Notification{MockNotification}, nil
+}
+
+func (storage *Storage) CountUnreadNotifications(_ string) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) MarkNotificationsRead(_ string, _, _ int64) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) CreateWebhook(_ models.NewWebhookRequest) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) RetrieveWebhooks() ([]models.Webhook, error) {
+	return []models.Webhook{MockWebhook}, nil
+}
+
+func (storage *Storage) DeleteWebhookByID(_ int64) error {
+	return nil
+}
+
+func (storage *Storage) EnqueueWebhookDeliveries(_ int64, _, _ string, _ []byte) (int64, error) {
+	return 1, nil
+}
+
+func (storage *Storage) RetrieveDueWebhookDeliveries(_ time.Time, _ int64) ([]models.WebhookDelivery, error) {
+	return []models.WebhookDelivery{}, nil
+}
+
+func (storage *Storage) UpdateWebhookDelivery(_ models.WebhookDelivery, _ time.Time) error {
+	return nil
+}
+
+func (storage *Storage) RetrieveWebhookDeliveries(_ int64, _ models.WebhookDeliveryQueryParam) ([]models.WebhookDelivery, error) {
+	return []models.WebhookDelivery{MockWebhookDelivery}, nil
+}
+
+func (storage *Storage) RedeliverWebhookDelivery(_, _ int64) error {
+	return nil
+}
+
+func (storage *Storage) RetrieveOutboxEvents(_ string, _ int64) ([]models.IssueEvent, error) {
+	return []models.IssueEvent{}, nil
+}
+
+func (storage *Storage) MarkOutboxEventConsumed(_ string, _ int64) error {
+	return nil
+}
+
+func (storage *Storage) PruneOutbox(_ []string) (int64, error) {
+	return 0, nil
+}
+
+func (storage *Storage) RetrieveEmailPreferences(_ string) (models.EmailPreferences, error) {
+	return MockEmailPreferences, nil
+}
+
+func (storage *Storage) SaveEmailPreferences(_ models.EmailPreferences) error {
+	return nil
+}
+
+func (storage *Storage) RetrieveUserByEmail(_ string) (string, error) {
+	return Assignee, nil
+}
+
+func (storage *Storage) UnsubscribeEmails(_ string) (string, error) {
+	return Assignee, nil
+}
+
+func (storage *Storage) QueueEmail(_ models.Email) error {
+	return nil
+}
+
+func (storage *Storage) RetrievePendingEmails(_ bool, _ int64) ([]models.Email, error) {
+	return []models.Email{}, nil
+}
+
+func (storage *Storage) MarkEmailsSent(_ ...int64) error {
+	return nil
+}
+
+func (storage *Storage) CreateIssueLink(_ models.IssueLink) (bool, error) {
+	return true, nil
+}
+
+func (storage *Storage) RetrieveIssueLinks(_ int64) ([]models.IssueLink, error) {
+	return []models.IssueLink{MockIssueLink}, nil
+}
+
+func (storage *Storage) ImportIssues(issues []models.ImportIssue) ([]int64, error) {
+	ids := make([]int64, 0, len(issues))
+	for range issues {
+		ids = append(ids, IssueID)
+	}
+	return ids, nil
+}
+
+func (storage *Storage) CreateImportJob(_ models.ImportJob) (int64, error) {
+	return MockImportJob.ID, nil
+}
+
+func (storage *Storage) UpdateImportJob(_ models.ImportJob) error {
+	return nil
+}
+
+func (storage *Storage) RetrieveImportJob(_ int64) (models.ImportJob, error) {
+	return MockImportJob, nil
+}
+
+// BulkChangeIssues changes the mock issue, the other issues not being found
+func (storage *Storage) BulkChangeIssues(_ models.BulkChange, issueIDs []int64, allOrNothing bool) ([]models.BulkItemResult, error) {
+	results := make([]models.BulkItemResult, 0, len(issueIDs))
+	failed := false
+	for _, id := range issueIDs {
+		result := models.BulkItemResult{IssueID: id, Success: id == IssueID}
+		if !result.Success {
+			result.Error = "could not find issue"
+			failed = true
+		}
+		results = append(results, result)
+	}
+
+	if allOrNothing && failed {
+		for i := range results {
+			if results[i].Success {
+				results[i] = models.BulkItemResult{IssueID: results[i].IssueID, Error: "not applied, another issue failed"}
+			}
+		}
+	}
+	return results, nil
+}
+
+func (storage *Storage) CreateBulkJob(_ models.BulkJob) (int64, error) {
+	return MockBulkJob.ID, nil
+}
+
+func (storage *Storage) UpdateBulkJob(_ models.BulkJob) error {
+	return nil
+}
+
+func (storage *Storage) RetrieveBulkJob(_ int64) (models.BulkJob, error) {
+	return MockBulkJob, nil
+}
+
+func (storage *Storage) RetrieveIssueByMessageID(_ ...string) (int64, error) {
+	return IssueID, nil
+}
+
+func (storage *Storage) RecordInboundMessage(_ string, _ int64) error {
+	return nil
+}
+
+func (storage *Storage) CreateAPIToken(_, _ string, _ bool) error {
+	return nil
+}
+
+func (storage *Storage) RotateAPIToken(_, _ string) error {
+	return nil
+}
+
+func (storage *Storage) RetrieveTokenUser(token string) (string, bool, error) {
+	if token != Token {
+		return "", false, sql.ErrNoRows
+	}
+	return Assignee, true, nil
+}
+
+// idempotentResponses are the Idempotency-Keys reserved, kept across requests for retries to be replayed
+var idempotentResponses = struct {
+	sync.Mutex
+	responses map[string]models.IdempotentResponse
+}{responses: map[string]models.IdempotentResponse{}}
+
+func (storage *Storage) ReserveIdempotencyKey(reservation models.IdempotentResponse) (models.IdempotentResponse, bool, error) {
+	idempotentResponses.Lock()
+	defer idempotentResponses.Unlock()
+
+	key := reservation.User + "/" + reservation.Key
+	if stored, ok := idempotentResponses.responses[key]; ok {
+		return stored, false, nil
+	}
+
+	idempotentResponses.responses[key] = reservation
+	return reservation, true, nil
+}
+
+func (storage *Storage) SaveIdempotentResponse(response models.IdempotentResponse) error {
+	idempotentResponses.Lock()
+	defer idempotentResponses.Unlock()
+
+	idempotentResponses.responses[response.User+"/"+response.Key] = response
+	return nil
+}
+
+func (storage *Storage) ReleaseIdempotencyKey(user, key string) error {
+	idempotentResponses.Lock()
+	defer idempotentResponses.Unlock()
+
+	delete(idempotentResponses.responses, user+"/"+key)
 	return nil
 }
 
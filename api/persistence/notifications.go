@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"github.com/YAITS/api/models"
+)
+
+const defaultNotificationLimit = 50
+
+// WatchIssue makes a user watch an issue, watching it twice being a no-op
+func (mysqlSt *MysqlStorage) WatchIssue(issueID int64, user string) error {
+	insertQuery := "INSERT IGNORE INTO watchers(issueID, username) VALUES(?, ?)"
+
+	_, err := mysqlSt.db.Exec(insertQuery, issueID, user)
+
+	return err
+}
+
+// UnwatchIssue stops a user from watching an issue
+func (mysqlSt *MysqlStorage) UnwatchIssue(issueID int64, user string) error {
+	query := `DELETE FROM watchers WHERE issueID = ? AND username = ?`
+
+	_, err := mysqlSt.db.Exec(query, issueID, user)
+
+	return err
+}
+
+// RetrieveWatchers returns the users watching an issue
+func (mysqlSt *MysqlStorage) RetrieveWatchers(issueID int64) ([]string, error) {
+	watchers := make([]string, 0)
+	var user string
+
+	query := `SELECT username FROM watchers WHERE issueID = ? ORDER BY username`
+
+	rows, err := mysqlSt.db.Query(query, issueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = rows.Scan(&user); err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, user)
+	}
+
+	return watchers, rows.Err()
+}
+
+// DeleteWatchers stops every user from watching an issue
+func (mysqlSt *MysqlStorage) DeleteWatchers(issueID int64) error {
+	query := `DELETE FROM watchers WHERE issueID = ?`
+
+	_, err := mysqlSt.db.Exec(query, issueID)
+
+	return err
+}
+
+// NotifyWatchers creates a copy of the notification for every watcher of the issue but its actor, returning how
+// many were created. Notifying watchers twice of the same event is a no-op
+func (mysqlSt *MysqlStorage) NotifyWatchers(notification models.Notification) (int64, error) {
+	insertQuery := `INSERT IGNORE INTO notifications(eventID, username, issueID, kind, actor, message)
+		SELECT ?, username, issueID, ?, ?, ? FROM watchers WHERE issueID = ? AND username <> ?`
+
+	result, err := mysqlSt.db.Exec(insertQuery, notification.EventID, notification.Kind, notification.Actor,
+		notification.Message, notification.IssueID, notification.Actor)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// RetrieveNotifications returns the notifications of a user, most recent first
+func (mysqlSt *MysqlStorage) RetrieveNotifications(user string, query models.NotificationQueryParam) ([]models.Notification, error) {
+	resp := make([]models.Notification, 0)
+
+	notificationsQuery := `SELECT id, username, issueID, kind, actor, message, isRead, createDate FROM notifications WHERE username = ?`
+	args := []interface{}{user}
+
+	if query.Unread {
+		notificationsQuery += ` AND isRead = FALSE`
+	}
+	if query.IssueID != 0 {
+		notificationsQuery += ` AND issueID = ?`
+		args = append(args, query.IssueID)
+	}
+	if query.Kind != "" {
+		notificationsQuery += ` AND kind = ?`
+		args = append(args, query.Kind)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	notificationsQuery += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, query.Offset)
+
+	rows, err := mysqlSt.db.Query(notificationsQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notification models.Notification
+
+		err = rows.Scan(&notification.ID, &notification.User, &notification.IssueID, &notification.Kind,
+			&notification.Actor, &notification.Message, &notification.Read, &notification.CreateDate)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, notification)
+	}
+
+	return resp, rows.Err()
+}
+
+// CountUnreadNotifications returns how many notifications of a user are unread
+func (mysqlSt *MysqlStorage) CountUnreadNotifications(user string) (int64, error) {
+	var count int64
+
+	query := `SELECT COUNT(*) FROM notifications WHERE username = ? AND isRead = FALSE`
+	err := mysqlSt.db.QueryRow(query, user).Scan(&count)
+
+	return count, err
+}
+
+// MarkNotificationsRead marks a notification of a user as read, or all of them (those of an issue when given)
+// when no notification id is given, returning how many were marked
+func (mysqlSt *MysqlStorage) MarkNotificationsRead(user string, notificationID, issueID int64) (int64, error) {
+	updateQuery := `UPDATE notifications SET isRead = TRUE WHERE username = ? AND isRead = FALSE`
+	args := []interface{}{user}
+
+	if notificationID != 0 {
+		updateQuery += ` AND id = ?`
+		args = append(args, notificationID)
+	}
+	if issueID != 0 {
+		updateQuery += ` AND issueID = ?`
+		args = append(args, issueID)
+	}
+
+	result, err := mysqlSt.db.Exec(updateQuery, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,121 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_NotifyWatchers(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("INSERT IGNORE INTO notifications(.+) SELECT (.+) FROM watchers").
+		WithArgs(5, "commented", Assignee, Comment, IssueID, Assignee).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// run the code
+	notification := models.Notification{EventID: 5, IssueID: IssueID, Kind: "commented", Actor: Assignee, Message: Comment}
+	count, err := testingStorage.NotifyWatchers(notification)
+	if err != nil {
+		t.Errorf("Error should not have occurred while notifying watchers: %s", err)
+	}
+
+	assert.Equal(t, int64(2), count, "every other watcher is notified")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveNotifications(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM notifications WHERE username = \\? AND isRead = FALSE AND issueID = \\? ORDER BY id DESC LIMIT").
+		WithArgs(Assignee, IssueID, 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "issueID", "kind", "actor", "message", "isRead", "createDate"}).
+			AddRow(1, Assignee, IssueID, "commented", "Jane Doe", Comment, false, CreateDate))
+
+	// run the code
+	query := models.NotificationQueryParam{Unread: true, IssueID: IssueID}
+	notifications, err := testingStorage.RetrieveNotifications(Assignee, query)
+	if err != nil {
+		t.Errorf("Error should not have occurred while retrieving notifications: %s", err)
+	}
+
+	assert.Len(t, notifications, 1)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_MarkNotificationsRead(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	t.Run("One", func(t *testing.T) {
+		mock.ExpectExec("UPDATE notifications SET isRead = TRUE WHERE username = \\? AND isRead = FALSE AND id = \\?").
+			WithArgs(Assignee, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if _, err = testingStorage.MarkNotificationsRead(Assignee, 3, 0); err != nil {
+			t.Errorf("Error should not have occurred while marking notifications: %s", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("All", func(t *testing.T) {
+		mock.ExpectExec("UPDATE notifications SET isRead = TRUE WHERE username = \\? AND isRead = FALSE$").
+			WithArgs(Assignee).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+
+		if _, err = testingStorage.MarkNotificationsRead(Assignee, 0, 0); err != nil {
+			t.Errorf("Error should not have occurred while marking notifications: %s", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// writeEvents records issue events in the outbox within the transaction changing the issue, so that they are
+// published if and only if the change is committed
+func writeEvents(tx *sql.Tx, issue models.IssueResponse, events []models.IssueEvent) error {
+	insertQuery := "INSERT INTO outbox(event, issueID, payload) VALUES(?, ?, ?)"
+	now := time.Now().UTC()
+
+	for _, event := range events {
+		event.IssueID = issue.ID
+		event.Project = issue.Project
+		event.Issue = &issue
+		event.Timestamp = now
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(insertQuery, event.Event, issue.ID, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RetrieveOutboxEvents returns the oldest events a consumer has yet to consume, in order, their ID being the
+// outbox one
+func (mysqlSt *MysqlStorage) RetrieveOutboxEvents(consumer string, limit int64) ([]models.IssueEvent, error) {
+	resp := make([]models.IssueEvent, 0)
+
+	query := `SELECT o.id, o.payload FROM outbox o
+		LEFT JOIN outbox_consumed c ON c.eventID = o.id AND c.consumer = ?
+		WHERE c.eventID IS NULL ORDER BY o.id LIMIT ?`
+
+	rows, err := mysqlSt.db.Query(query, consumer, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.IssueEvent
+		var id int64
+		var payload []byte
+
+		if err = rows.Scan(&id, &payload); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+
+		event.ID = id
+		resp = append(resp, event)
+	}
+
+	return resp, rows.Err()
+}
+
+// MarkOutboxEventConsumed records that a consumer consumed an event, marking it twice being a no-op
+func (mysqlSt *MysqlStorage) MarkOutboxEventConsumed(consumer string, eventID int64) error {
+	insertQuery := "INSERT IGNORE INTO outbox_consumed(consumer, eventID) VALUES(?, ?)"
+
+	_, err := mysqlSt.db.Exec(insertQuery, consumer, eventID)
+
+	return err
+}
+
+// PruneOutbox deletes the events consumed by every one of the given consumers, returning how many were deleted.
+// Events consumed by consumers no longer running don't count, so that changing the consumers never loses an event
+func (mysqlSt *MysqlStorage) PruneOutbox(consumers []string) (int64, error) {
+	if len(consumers) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, 0, len(consumers)+1)
+	for _, consumer := range consumers {
+		args = append(args, consumer)
+	}
+	args = append(args, len(consumers))
+
+	query := `DELETE FROM outbox WHERE id IN (
+		SELECT eventID FROM (SELECT eventID FROM outbox_consumed WHERE consumer IN (` + placeholders(len(consumers)) + `)
+		GROUP BY eventID HAVING COUNT(DISTINCT consumer) = ?) consumed)`
+
+	result, err := mysqlSt.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,144 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_CreateIssueWithEvent(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	t.Run("Committed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO issues").
+			WillReturnResult(sqlmock.NewResult(IssueID, 1))
+		mock.ExpectQuery("SELECT (.+) FROM issues").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
+		mock.ExpectExec("INSERT INTO outbox").
+			WithArgs("issue.created", IssueID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		// run the code
+		issue := models.NewIssueRequest{Summary: Summary, Description: Description, Priority: Priority, Project: Project}
+		if _, err = testingStorage.CreateIssue(issue, models.IssueEvent{Event: "issue.created"}); err != nil {
+			t.Errorf("Error should not have occurred while creating issue: %s", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("RolledBack", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO issues").
+			WillReturnResult(sqlmock.NewResult(IssueID, 1))
+		mock.ExpectQuery("SELECT (.+) FROM issues").
+			WithArgs(IssueID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+				AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
+		mock.ExpectExec("INSERT INTO outbox").
+			WillReturnError(sqlmock.ErrCancelled)
+		mock.ExpectRollback()
+
+		// run the code
+		issue := models.NewIssueRequest{Summary: Summary, Description: Description, Priority: Priority, Project: Project}
+		_, err = testingStorage.CreateIssue(issue, models.IssueEvent{Event: "issue.created"})
+		assert.NotNil(t, err, "the issue is not created when its event can't be recorded")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+func TestMysqlStorage_RetrieveOutboxEvents(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT o.id, o.payload FROM outbox o LEFT JOIN outbox_consumed c").
+		WithArgs("webhooks", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}).
+			AddRow(4, `{"event":"issue.commented","issueID":1,"project":"API","comment":"This is a comment"}`).
+			AddRow(6, `{"event":"issue.deleted","issueID":1,"project":"API"}`))
+
+	// run the code
+	events, err := testingStorage.RetrieveOutboxEvents("webhooks", 10)
+	if err != nil {
+		t.Errorf("Error should not have occurred while retrieving events: %s", err)
+	}
+
+	assert.Equal(t, []models.IssueEvent{
+		{ID: 4, Event: "issue.commented", IssueID: IssueID, Project: Project, Comment: Comment},
+		{ID: 6, Event: "issue.deleted", IssueID: IssueID, Project: Project},
+	}, events, "events are identified by their outbox id")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_MarkOutboxEventConsumed(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("INSERT IGNORE INTO outbox_consumed").
+		WithArgs("webhooks", 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM outbox WHERE id IN (.+) WHERE consumer IN \\(\\?, \\?\\) (.+) COUNT\\(DISTINCT consumer\\) = \\?").
+		WithArgs("webhooks", "email", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// run the code
+	err = testingStorage.MarkOutboxEventConsumed("webhooks", 4)
+	assert.Nil(t, err)
+
+	pruned, err := testingStorage.PruneOutbox([]string{"webhooks", "email"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), pruned)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
@@ -0,0 +1,208 @@
+package persistence
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// CreateSLAPolicy creates a new SLA policy, a zero calendar id meaning the targets run around the clock
+func (mysqlSt *MysqlStorage) CreateSLAPolicy(policy models.NewSLAPolicyRequest) (int64, error) {
+	var calendarID interface{}
+	if policy.CalendarID != 0 {
+		calendarID = policy.CalendarID
+	}
+
+	insertQuery := "INSERT INTO sla_policies(name, project, priority, responseMinutes, resolutionMinutes, calendarID) VALUES(?, ?, ?, ?, ?, ?)"
+
+	result, err := mysqlSt.db.Exec(insertQuery, policy.Name, policy.Project, policy.Priority,
+		policy.ResponseMinutes, policy.ResolutionMinutes, calendarID)
+	if err != nil {
+		return 0, err
+	}
+
+	id, _ := result.LastInsertId()
+	return id, nil
+}
+
+// RetrieveSLAPolicies returns every SLA policy along with its business-hours calendar
+func (mysqlSt *MysqlStorage) RetrieveSLAPolicies() ([]models.SLAPolicy, error) {
+	resp := make([]models.SLAPolicy, 0)
+
+	query := `SELECT p.id, p.name, p.project, p.priority, p.responseMinutes, p.resolutionMinutes,
+		c.id, c.name, c.timezone, c.workdays, c.startHour, c.endHour
+		FROM sla_policies p LEFT JOIN business_calendars c ON c.id = p.calendarID`
+
+	rows, err := mysqlSt.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var policy models.SLAPolicy
+		var calendarID, startHour, endHour sql.NullInt64
+		var calendarName, timezone, workdays sql.NullString
+
+		err = rows.Scan(&policy.ID, &policy.Name, &policy.Project, &policy.Priority, &policy.ResponseMinutes,
+			&policy.ResolutionMinutes, &calendarID, &calendarName, &timezone, &workdays, &startHour, &endHour)
+		if err != nil {
+			return nil, err
+		}
+
+		if calendarID.Valid {
+			policy.Calendar = &models.BusinessCalendar{
+				ID:        calendarID.Int64,
+				Name:      calendarName.String,
+				Timezone:  timezone.String,
+				Workdays:  parseWorkdays(workdays.String),
+				StartHour: int(startHour.Int64),
+				EndHour:   int(endHour.Int64),
+			}
+		}
+
+		resp = append(resp, policy)
+	}
+
+	return resp, rows.Err()
+}
+
+// DeleteSLAPolicyByID deletes an SLA policy
+func (mysqlSt *MysqlStorage) DeleteSLAPolicyByID(policyID int64) error {
+	query := `DELETE FROM sla_policies WHERE id = ?`
+
+	_, err := mysqlSt.db.Exec(query, policyID)
+
+	return err
+}
+
+// CreateBusinessCalendar creates a new business-hours calendar
+func (mysqlSt *MysqlStorage) CreateBusinessCalendar(calendar models.NewBusinessCalendarRequest) (int64, error) {
+	workdays := make([]string, 0, len(calendar.Workdays))
+	for _, day := range calendar.Workdays {
+		workdays = append(workdays, strconv.Itoa(day))
+	}
+
+	insertQuery := "INSERT INTO business_calendars(name, timezone, workdays, startHour, endHour) VALUES(?, ?, ?, ?, ?)"
+
+	result, err := mysqlSt.db.Exec(insertQuery, calendar.Name, calendar.Timezone, strings.Join(workdays, ","),
+		calendar.StartHour, calendar.EndHour)
+	if err != nil {
+		return 0, err
+	}
+
+	id, _ := result.LastInsertId()
+	return id, nil
+}
+
+// RetrieveBusinessCalendars returns every business-hours calendar
+func (mysqlSt *MysqlStorage) RetrieveBusinessCalendars() ([]models.BusinessCalendar, error) {
+	resp := make([]models.BusinessCalendar, 0)
+
+	query := `SELECT id, name, timezone, workdays, startHour, endHour FROM business_calendars`
+
+	rows, err := mysqlSt.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var calendar models.BusinessCalendar
+		var workdays string
+
+		err = rows.Scan(&calendar.ID, &calendar.Name, &calendar.Timezone, &workdays, &calendar.StartHour, &calendar.EndHour)
+		if err != nil {
+			return nil, err
+		}
+
+		calendar.Workdays = parseWorkdays(workdays)
+		resp = append(resp, calendar)
+	}
+
+	return resp, rows.Err()
+}
+
+// RecordSLABreach stores a breach event, returning false when the breach of that target was already recorded
+func (mysqlSt *MysqlStorage) RecordSLABreach(breach models.SLABreach) (bool, error) {
+	insertQuery := "INSERT IGNORE INTO sla_breaches(issueID, target, policy) VALUES(?, ?, ?)"
+
+	result, err := mysqlSt.db.Exec(insertQuery, breach.IssueID, breach.Target, breach.Policy)
+	if err != nil {
+		return false, err
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// RetrieveSLADueIssues returns the issues whose SLA targets may have been missed without their breach being recorded,
+// their comments being left out. A target is checked when the issue was created before the given date, by which its
+// shortest target has passed, and is still running or was done since doneSince, for the breaches of targets done
+// late between two evaluations not to be missed
+func (mysqlSt *MysqlStorage) RetrieveSLADueIssues(responseCreatedBefore, resolutionCreatedBefore,
+	doneSince time.Time) ([]models.IssueResponse, error) {
+	resp := make([]models.IssueResponse, 0)
+
+	query := `SELECT ` + issueColumns + ` FROM issues i WHERE
+		(i.createDate <= ? AND (i.respondedDate IS NULL OR i.respondedDate >= ?)
+			AND NOT EXISTS (SELECT 1 FROM sla_breaches b WHERE b.issueID = i.id AND b.target = 'response'))
+		OR (i.createDate <= ? AND (i.resolvedDate IS NULL OR i.resolvedDate >= ?)
+			AND NOT EXISTS (SELECT 1 FROM sla_breaches b WHERE b.issueID = i.id AND b.target = 'resolution'))
+		ORDER BY i.id`
+
+	rows, err := mysqlSt.db.Query(query, responseCreatedBefore, doneSince, resolutionCreatedBefore, doneSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, issue)
+	}
+
+	return resp, rows.Err()
+}
+
+// RetrieveSLABreaches returns the breach events recorded for an issue
+func (mysqlSt *MysqlStorage) RetrieveSLABreaches(issueID int64) ([]models.SLABreach, error) {
+	resp := make([]models.SLABreach, 0)
+
+	query := `SELECT issueID, target, policy, breachDate FROM sla_breaches WHERE issueID = ? ORDER BY breachDate`
+
+	rows, err := mysqlSt.db.Query(query, issueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var breach models.SLABreach
+
+		if err = rows.Scan(&breach.IssueID, &breach.Target, &breach.Policy, &breach.BreachDate); err != nil {
+			return nil, err
+		}
+		resp = append(resp, breach)
+	}
+
+	return resp, rows.Err()
+}
+
+func parseWorkdays(workdays string) []int {
+	days := make([]int, 0)
+
+	for _, day := range strings.Split(workdays, ",") {
+		if d, err := strconv.Atoi(strings.TrimSpace(day)); err == nil {
+			days = append(days, d)
+		}
+	}
+
+	return days
+}
@@ -0,0 +1,131 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_RetrieveSLAPolicies(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM sla_policies p LEFT JOIN business_calendars").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "project", "priority", "responseMinutes", "resolutionMinutes",
+			"id", "name", "timezone", "workdays", "startHour", "endHour"}).
+			AddRow(1, "P1", Project, Priority, 60, 1440, 1, "Office hours", "UTC", "1,2,3,4,5", 9, 17).
+			AddRow(2, "Default", "", 0, 0, 4320, nil, nil, nil, nil, nil, nil))
+
+	// run the code
+	policies, err := testingStorage.RetrieveSLAPolicies()
+	if err != nil {
+		t.Errorf("Error should not have occurred while retrieving sla policies: %s", err)
+	}
+
+	assert.Len(t, policies, 2)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, policies[0].Calendar.Workdays, "calendar is joined")
+	assert.Nil(t, policies[1].Calendar, "policy without calendar runs around the clock")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RecordSLABreach(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+	breach := models.SLABreach{IssueID: IssueID, Target: "response", Policy: "P1"}
+
+	t.Run("NewBreach", func(t *testing.T) {
+		mock.ExpectExec("INSERT IGNORE INTO sla_breaches").
+			WithArgs(IssueID, "response", "P1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		recorded, err := testingStorage.RecordSLABreach(breach)
+		if err != nil {
+			t.Errorf("Error should not have occurred while recording sla breach: %s", err)
+		}
+		assert.True(t, recorded, "breach is recorded")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("AlreadyRecorded", func(t *testing.T) {
+		mock.ExpectExec("INSERT IGNORE INTO sla_breaches").
+			WithArgs(IssueID, "response", "P1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		recorded, err := testingStorage.RecordSLABreach(breach)
+		if err != nil {
+			t.Errorf("Error should not have occurred while recording sla breach: %s", err)
+		}
+		assert.False(t, recorded, "breach is only recorded once")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+func TestMysqlStorage_RetrieveSLADueIssues(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+	now := time.Date(2020, 9, 8, 12, 0, 0, 0, time.UTC)
+	evaluated := now.Add(-time.Minute)
+
+	// a single query, the comments being left out
+	mock.ExpectQuery("SELECT (.+) FROM issues i WHERE (.+) NOT EXISTS \\(SELECT 1 FROM sla_breaches (.+) ORDER BY i.id").
+		WithArgs(now.Add(-time.Hour), evaluated, now.Add(-8*time.Hour), evaluated).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "description", "priority", "status", "assignee", "reporter", "createDate", "project", "issueType", "dueDate", "respondedDate", "resolvedDate", "originalEstimate", "remainingEstimate", "timeSpent", "version"}).
+			AddRow(IssueID, Summary, Description, Priority, Status, Assignee, Assignee, CreateDate, Project, Type, nil, nil, nil, 0, 0, 0, 1))
+
+	// run the code
+	issues, err := testingStorage.RetrieveSLADueIssues(now.Add(-time.Hour), now.Add(-8*time.Hour), evaluated)
+	if err != nil {
+		t.Errorf("Error should not have occurred while retrieving due issues: %s", err)
+	}
+	assert.Len(t, issues, 1)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
@@ -0,0 +1,137 @@
+package persistence
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SQLiteStorage is a SQLite database backups are restored into and taken from, for a copy of the data that needs no
+// MySQL server. Its tables are the backup tables with the columns of the schema version of the backup, without the
+// indexes and constraints of MySQL, and it is not served by the API
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage creates a SQLiteStorage of a database opened with the sqlite3 driver
+func NewSQLiteStorage(db *sql.DB) *SQLiteStorage {
+	return &SQLiteStorage{db: db}
+}
+
+// SchemaVersion returns the schema version of the backup loaded, 0 when none was
+func (sqliteSt *SQLiteStorage) SchemaVersion() (int64, error) {
+	_, err := sqliteSt.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		appliedDate TEXT DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err = sqliteSt.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+
+	return version.Int64, err
+}
+
+// SnapshotTables reads the rows of the backup tables in one transaction, returning the schema version
+func (sqliteSt *SQLiteStorage) SnapshotTables(each func(table string, row map[string]interface{}) error) (int64, error) {
+	version, err := sqliteSt.SchemaVersion()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := sqliteSt.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, table := range backupTables(version) {
+		if err = snapshotTable(tx, table, each); err != nil {
+			return 0, err
+		}
+	}
+
+	return version, tx.Commit()
+}
+
+// LoadTables loads the rows of a backup into an empty database in one transaction, the tables of the schema version
+// of the backup being created first in a new database
+func (sqliteSt *SQLiteStorage) LoadTables(schemaVersion int64, load func(table string, insert func(row map[string]interface{}) error) error) error {
+	version, err := sqliteSt.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		err = sqliteSt.createTables(schemaVersion)
+	} else if version != schemaVersion {
+		err = versionMismatch(schemaVersion, version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return loadTables(sqliteSt.db, schemaVersion, load)
+}
+
+// createTables creates the backup tables as the migrations up to a schema version define them, recording the
+// migrations as applied
+func (sqliteSt *SQLiteStorage) createTables(schemaVersion int64) error {
+	if schemaVersion < 1 || schemaVersion > Migrations[len(Migrations)-1].Version {
+		return fmt.Errorf("unknown schema version %d", schemaVersion)
+	}
+
+	migrations := Migrations[:schemaVersion]
+	tables := schemaTables(migrations)
+
+	tx, err := sqliteSt.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, name := range backupTables(schemaVersion) {
+		if _, err = tx.Exec(sqliteTable(name, tables[name])); err != nil {
+			return err
+		}
+	}
+
+	for _, migration := range migrations {
+		insertQuery := `INSERT INTO schema_migrations(version, name) VALUES(?, ?)`
+		if _, err = tx.Exec(insertQuery, migration.Version, migration.Name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// sqliteTable returns the CREATE TABLE statement of a table, its columns having the affinity of their MySQL type.
+// Dates are kept as the text backups hold them
+func sqliteTable(name string, table *tableSchema) string {
+	definitions := make([]string, 0, len(table.columns)+1)
+	for i, column := range table.columns {
+		definitions = append(definitions, fmt.Sprintf("`%s` %s", column, sqliteType(table.types[i])))
+	}
+	if table.primaryKey != "" {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", table.primaryKey))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (%s)", name, strings.Join(definitions, ", "))
+}
+
+func sqliteType(mysqlType string) string {
+	switch {
+	case strings.Contains(mysqlType, "int") || mysqlType == "boolean":
+		return "INTEGER"
+	case mysqlType == "float" || mysqlType == "double" || mysqlType == "decimal":
+		return "REAL"
+	case strings.Contains(mysqlType, "blob"):
+		return "BLOB"
+	}
+
+	return "TEXT"
+}
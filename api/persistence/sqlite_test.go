@@ -0,0 +1,140 @@
+package persistence
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// backupRows are rows of a backup, as SnapshotTables reads them from MySQL
+var backupRows = map[string][]map[string]interface{}{
+	"issues": {
+		{"id": int64(1), "summary": "Login fails", "description": "Blank page", "priority": int64(2), "status": "open",
+			"assignee": "alice", "reporter": "bob", "createDate": "2020-07-01 10:00:00", "project": "WEB",
+			"issueType": "Bug", "dueDate": nil, "respondedDate": nil, "resolvedDate": nil, "originalEstimate": int64(60),
+			"remainingEstimate": int64(30), "timeSpent": int64(30), "version": int64(3)},
+	},
+	"comments": {
+		{"commentID": int64(1), "issueID": int64(1), "comment": "Reproduced \"again\"\non Firefox",
+			"createDate": "2020-07-01 10:05:00.5"},
+	},
+	"api_tokens": {
+		{"username": "alice", "tokenHash": "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8",
+			"admin": int64(1), "createDate": "2020-07-01 09:00:00", "rotateDate": nil},
+	},
+}
+
+func openSQLite(t *testing.T, dir string) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "yaits.db"))
+	if err != nil {
+		t.Fatalf("couldn't open the SQLite database: %s", err)
+	}
+
+	return db
+}
+
+func loadBackupRows(table string, insert func(row map[string]interface{}) error) error {
+	for _, row := range backupRows[table] {
+		if err := insert(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaits-sqlite")
+	if err != nil {
+		t.Fatalf("couldn't create the temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := openSQLite(t, dir)
+	defer db.Close()
+	testingStorage := NewSQLiteStorage(db)
+	latest := Migrations[len(Migrations)-1].Version
+
+	// run the code
+	err = testingStorage.LoadTables(latest, loadBackupRows)
+	assert.Nil(t, err)
+
+	version, err := testingStorage.SchemaVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, latest, version)
+
+	snapshot := map[string][]map[string]interface{}{}
+	version, err = testingStorage.SnapshotTables(func(table string, row map[string]interface{}) error {
+		snapshot[table] = append(snapshot[table], row)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, latest, version)
+	assert.Equal(t, backupRows, snapshot, "rows are read back as they were loaded")
+
+	t.Run("NotEmpty", func(t *testing.T) {
+		err := testingStorage.LoadTables(latest, loadBackupRows)
+		assert.Equal(t, &NotEmptyError{Table: "issues"}, err)
+	})
+
+	t.Run("SchemaVersion", func(t *testing.T) {
+		err := testingStorage.LoadTables(latest-1, loadBackupRows)
+		assert.EqualError(t, err, versionMismatch(latest-1, latest).Error())
+	})
+
+	t.Run("UnknownColumn", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "yaits-sqlite")
+		if err != nil {
+			t.Fatalf("couldn't create the temporary directory: %s", err)
+		}
+		defer os.RemoveAll(dir)
+
+		db := openSQLite(t, dir)
+		defer db.Close()
+
+		// issues have no version before the migration adding it
+		err = NewSQLiteStorage(db).LoadTables(migrationVersion(t, "issue versions")-1, loadBackupRows)
+		assert.EqualError(t, err, `couldn't load issues: unknown column "version"`)
+
+		var count int64
+		assert.Nil(t, db.QueryRow(`SELECT COUNT(*) FROM comments`).Scan(&count))
+		assert.Zero(t, count, "nothing is loaded when a row fails")
+	})
+}
+
+func TestBackupTables(t *testing.T) {
+	assert.Equal(t, []string{"issues", "comments"}, backupTables(1), "tables of later migrations are left out")
+	assert.Equal(t, BackupTables, backupTables(Migrations[len(Migrations)-1].Version))
+	assert.Equal(t, BackupTables, backupTables(0))
+}
+
+func migrationVersion(t *testing.T, name string) int64 {
+	for _, migration := range Migrations {
+		if migration.Name == name {
+			return migration.Version
+		}
+	}
+
+	t.Fatalf("no migration named %s", name)
+	return 0
+}
+
+func TestSchemaTables(t *testing.T) {
+	tables := schemaTables(Migrations)
+
+	issues := tables["issues"]
+	assert.Equal(t, "id", issues.primaryKey)
+	assert.Equal(t, "version", issues.columns[len(issues.columns)-1], "columns added later are included")
+	assert.Equal(t, "issueID, name", tables["issue_fields"].primaryKey)
+
+	assert.Equal(t, "CREATE TABLE watchers (`issueID` INTEGER, `username` TEXT, PRIMARY KEY (issueID, username))",
+		sqliteTable("watchers", tables["watchers"]))
+	assert.Equal(t, "TEXT", sqliteType("enum"))
+	assert.Equal(t, "TEXT", sqliteType("timestamp"), "dates are kept as text")
+	assert.Equal(t, "BLOB", sqliteType("mediumblob"))
+}
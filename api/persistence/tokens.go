@@ -0,0 +1,51 @@
+package persistence
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+)
+
+// CreateAPIToken gives a user an API token, replacing the token the user had. Tokens are stored as their SHA-256 hash
+func (mysqlSt *MysqlStorage) CreateAPIToken(user, token string, admin bool) error {
+	insertQuery := `INSERT INTO api_tokens(username, tokenHash, admin) VALUES(?, ?, ?)
+		ON DUPLICATE KEY UPDATE tokenHash = VALUES(tokenHash), admin = VALUES(admin), rotateDate = CURRENT_TIMESTAMP`
+
+	_, err := mysqlSt.db.Exec(insertQuery, user, hashToken(token), admin)
+
+	return err
+}
+
+// RotateAPIToken replaces the API token of a user, sql.ErrNoRows when the user has none
+func (mysqlSt *MysqlStorage) RotateAPIToken(user, token string) error {
+	updateQuery := `UPDATE api_tokens SET tokenHash = ?, rotateDate = CURRENT_TIMESTAMP WHERE username = ?`
+
+	result, err := mysqlSt.db.Exec(updateQuery, hashToken(token), user)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err == nil && rows == 0 {
+		err = sql.ErrNoRows
+	}
+
+	return err
+}
+
+// RetrieveTokenUser returns the user owning an API token and whether they are an administrator, sql.ErrNoRows for
+// unknown tokens
+func (mysqlSt *MysqlStorage) RetrieveTokenUser(token string) (string, bool, error) {
+	var user string
+	var admin bool
+
+	err := mysqlSt.db.QueryRow(`SELECT username, admin FROM api_tokens WHERE tokenHash = ?`, hashToken(token)).
+		Scan(&user, &admin)
+
+	return user, admin, err
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
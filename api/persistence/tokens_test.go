@@ -0,0 +1,53 @@
+package persistence
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_APITokens(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("INSERT INTO api_tokens").
+		WithArgs("admin", hashToken("secret"), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT username, admin FROM api_tokens WHERE tokenHash = \\?").
+		WithArgs(hashToken("secret")).
+		WillReturnRows(sqlmock.NewRows([]string{"username", "admin"}).AddRow("admin", true))
+	mock.ExpectExec("UPDATE api_tokens SET tokenHash = \\?").
+		WithArgs(hashToken("rotated"), "admin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE api_tokens SET tokenHash = \\?").
+		WithArgs(hashToken("rotated"), "nobody").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// run the code
+	assert.Nil(t, testingStorage.CreateAPIToken("admin", "secret", true))
+
+	user, admin, err := testingStorage.RetrieveTokenUser("secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", user)
+	assert.True(t, admin)
+
+	assert.Nil(t, testingStorage.RotateAPIToken("admin", "rotated"))
+	assert.Equal(t, sql.ErrNoRows, testingStorage.RotateAPIToken("nobody", "rotated"))
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
@@ -0,0 +1,188 @@
+package persistence
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// Webhook delivery statuses, a delivery being dead once it ran out of attempts
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+const defaultWebhookDeliveryLimit = 50
+
+// CreateWebhook subscribes a URL to issue events
+func (mysqlSt *MysqlStorage) CreateWebhook(webhook models.NewWebhookRequest) (int64, error) {
+	insertQuery := "INSERT INTO webhooks(url, secret, events, project, format) VALUES(?, ?, ?, ?, ?)"
+
+	result, err := mysqlSt.db.Exec(insertQuery, webhook.URL, webhook.Secret, strings.Join(webhook.Events, ","),
+		webhook.Project, webhook.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// RetrieveWebhooks returns every webhook subscription
+func (mysqlSt *MysqlStorage) RetrieveWebhooks() ([]models.Webhook, error) {
+	resp := make([]models.Webhook, 0)
+
+	query := `SELECT id, url, secret, events, project, format, createDate FROM webhooks ORDER BY id`
+
+	rows, err := mysqlSt.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var webhook models.Webhook
+		var events string
+
+		err = rows.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &events, &webhook.Project, &webhook.Format,
+			&webhook.CreateDate)
+		if err != nil {
+			return nil, err
+		}
+
+		webhook.Events = strings.Split(events, ",")
+		resp = append(resp, webhook)
+	}
+
+	return resp, rows.Err()
+}
+
+// DeleteWebhookByID removes a webhook subscription along with its deliveries
+func (mysqlSt *MysqlStorage) DeleteWebhookByID(webhookID int64) error {
+	query := `DELETE FROM webhooks WHERE id = ?`
+
+	_, err := mysqlSt.db.Exec(query, webhookID)
+
+	return err
+}
+
+// EnqueueWebhookDeliveries queues the delivery of an event payload to every webhook subscribed to the event and
+// the project, returning how many deliveries were queued. Queuing the same event twice is a no-op
+func (mysqlSt *MysqlStorage) EnqueueWebhookDeliveries(eventID int64, event, project string, payload []byte) (int64, error) {
+	insertQuery := `INSERT IGNORE INTO webhook_deliveries(eventID, webhookID, event, payload, status, nextAttempt)
+		SELECT ?, id, ?, ?, ?, NOW() FROM webhooks WHERE FIND_IN_SET(?, events) > 0 AND (project = '' OR project = ?)`
+
+	result, err := mysqlSt.db.Exec(insertQuery, eventID, event, string(payload), WebhookDeliveryPending, event, project)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// RetrieveDueWebhookDeliveries returns the pending deliveries whose next attempt is due, along with the URL, secret
+// and format of their webhook
+func (mysqlSt *MysqlStorage) RetrieveDueWebhookDeliveries(now time.Time, limit int64) ([]models.WebhookDelivery, error) {
+	resp := make([]models.WebhookDelivery, 0)
+
+	query := `SELECT d.id, d.webhookID, d.event, d.payload, d.attempts, w.url, w.secret, w.format
+		FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhookID
+		WHERE d.status = ? AND d.nextAttempt <= ? ORDER BY d.nextAttempt, d.id LIMIT ?`
+
+	rows, err := mysqlSt.db.Query(query, WebhookDeliveryPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery := models.WebhookDelivery{Status: WebhookDeliveryPending}
+
+		err = rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Attempts,
+			&delivery.URL, &delivery.Secret, &delivery.Format)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, delivery)
+	}
+
+	return resp, rows.Err()
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt, scheduling the next one at nextAttempt when the
+// delivery is still pending
+func (mysqlSt *MysqlStorage) UpdateWebhookDelivery(delivery models.WebhookDelivery, nextAttempt time.Time) error {
+	updateQuery := `UPDATE webhook_deliveries SET status = ?, attempts = ?, nextAttempt = ?, responseStatus = ?,
+		lastError = ?, deliveredDate = IF(status = ?, NOW(), deliveredDate) WHERE id = ?`
+
+	_, err := mysqlSt.db.Exec(updateQuery, delivery.Status, delivery.Attempts, nextAttempt, delivery.ResponseStatus,
+		delivery.LastError, WebhookDeliveryDelivered, delivery.ID)
+
+	return err
+}
+
+// RetrieveWebhookDeliveries returns the delivery log of a webhook, most recent first
+func (mysqlSt *MysqlStorage) RetrieveWebhookDeliveries(webhookID int64, query models.WebhookDeliveryQueryParam) ([]models.WebhookDelivery, error) {
+	resp := make([]models.WebhookDelivery, 0)
+
+	deliveriesQuery := `SELECT id, webhookID, event, payload, status, attempts, nextAttempt, responseStatus, lastError,
+		createDate, deliveredDate FROM webhook_deliveries WHERE webhookID = ?`
+	args := []interface{}{webhookID}
+
+	if query.Status != "" {
+		deliveriesQuery += ` AND status = ?`
+		args = append(args, query.Status)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryLimit
+	}
+	deliveriesQuery += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, query.Offset)
+
+	rows, err := mysqlSt.db.Query(deliveriesQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var nextAttempt, deliveredDate sql.NullTime
+
+		err = rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status,
+			&delivery.Attempts, &nextAttempt, &delivery.ResponseStatus, &delivery.LastError, &delivery.CreateDate,
+			&deliveredDate)
+		if err != nil {
+			return nil, err
+		}
+
+		if delivery.Status == WebhookDeliveryPending {
+			delivery.NextAttempt = formatNullTime(nextAttempt)
+		}
+		delivery.DeliveredDate = formatNullTime(deliveredDate)
+		resp = append(resp, delivery)
+	}
+
+	return resp, rows.Err()
+}
+
+// RedeliverWebhookDelivery queues a delivery of a webhook again, whatever its status, with a fresh set of attempts
+func (mysqlSt *MysqlStorage) RedeliverWebhookDelivery(webhookID, deliveryID int64) error {
+	updateQuery := `UPDATE webhook_deliveries SET status = ?, attempts = 0, nextAttempt = NOW(), deliveredDate = NULL
+		WHERE id = ? AND webhookID = ?`
+
+	result, err := mysqlSt.db.Exec(updateQuery, WebhookDeliveryPending, deliveryID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package persistence
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_CreateWebhook(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectExec("INSERT INTO webhooks").
+		WithArgs("http://ci.local/hook", "s3cr3t", "issue.created,issue.deleted", "YAITS", "json").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// run the code
+	id, err := testingStorage.CreateWebhook(models.NewWebhookRequest{URL: "http://ci.local/hook", Secret: "s3cr3t",
+		Events: []string{"issue.created", "issue.deleted"}, Project: "YAITS", Format: "json"})
+	if err != nil {
+		t.Errorf("Error should not have occurred while creating webhook: %s", err)
+	}
+
+	assert.Equal(t, int64(1), id)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_EnqueueWebhookDeliveries(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	payload := `{"event":"issue.created"}`
+	mock.ExpectExec("INSERT IGNORE INTO webhook_deliveries(.+) SELECT (.+) FROM webhooks WHERE FIND_IN_SET").
+		WithArgs(5, "issue.created", payload, WebhookDeliveryPending, "issue.created", "YAITS").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// run the code
+	count, err := testingStorage.EnqueueWebhookDeliveries(5, "issue.created", "YAITS", []byte(payload))
+	if err != nil {
+		t.Errorf("Error should not have occurred while queuing deliveries: %s", err)
+	}
+
+	assert.Equal(t, int64(2), count, "one delivery per subscribed webhook")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveDueWebhookDeliveries(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	now := time.Date(2020, 9, 8, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM webhook_deliveries d JOIN webhooks w").
+		WithArgs(WebhookDeliveryPending, now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhookID", "event", "payload", "attempts", "url", "secret", "format"}).
+			AddRow(3, 1, "issue.created", "{}", 2, "http://ci.local/hook", "s3cr3t", "slack"))
+
+	// run the code
+	deliveries, err := testingStorage.RetrieveDueWebhookDeliveries(now, 10)
+	if err != nil {
+		t.Errorf("Error should not have occurred while retrieving deliveries: %s", err)
+	}
+
+	assert.Equal(t, []models.WebhookDelivery{{ID: 3, WebhookID: 1, Event: "issue.created", Payload: "{}",
+		Status: WebhookDeliveryPending, Attempts: 2, URL: "http://ci.local/hook", Secret: "s3cr3t", Format: "slack"}}, deliveries)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_UpdateWebhookDelivery(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	next := time.Date(2020, 9, 8, 12, 1, 0, 0, time.UTC)
+	mock.ExpectExec("UPDATE webhook_deliveries SET status").
+		WithArgs(WebhookDeliveryPending, 3, next, 503, "unexpected status 503", WebhookDeliveryDelivered, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// run the code
+	delivery := models.WebhookDelivery{ID: 7, Status: WebhookDeliveryPending, Attempts: 3, ResponseStatus: 503,
+		LastError: "unexpected status 503"}
+	if err = testingStorage.UpdateWebhookDelivery(delivery, next); err != nil {
+		t.Errorf("Error should not have occurred while updating delivery: %s", err)
+	}
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RedeliverWebhookDelivery(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	t.Run("Queued", func(t *testing.T) {
+		mock.ExpectExec("UPDATE webhook_deliveries SET status = \\?, attempts = 0").
+			WithArgs(WebhookDeliveryPending, 7, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = testingStorage.RedeliverWebhookDelivery(1, 7)
+		assert.Nil(t, err)
+	})
+
+	t.Run("OtherWebhook", func(t *testing.T) {
+		mock.ExpectExec("UPDATE webhook_deliveries SET status = \\?, attempts = 0").
+			WithArgs(WebhookDeliveryPending, 7, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = testingStorage.RedeliverWebhookDelivery(2, 7)
+		assert.Equal(t, sql.ErrNoRows, err, "delivery must belong to the webhook")
+	})
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
@@ -0,0 +1,177 @@
+package persistence
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+const (
+	// AdjustEstimateLeave keeps the remaining estimate unchanged when work is logged
+	AdjustEstimateLeave = "leave"
+
+	worklogColumns = `id, issueID, username, minutes, workDate, note, createDate`
+	dateFormat     = "2006-01-02"
+)
+
+// CreateWorklog logs work on an issue, adding it to the time spent and, unless asked to leave it, deducting it
+// from the remaining estimate
+func (mysqlSt *MysqlStorage) CreateWorklog(worklog models.NewWorklogRequest, issueID int64) (int64, error) {
+	tx, err := mysqlSt.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	updateQuery := "UPDATE issues SET timeSpent = timeSpent + ?, remainingEstimate = GREATEST(remainingEstimate - ?, 0), version = version + 1 WHERE id = ?"
+	deducted := worklog.Minutes
+	if worklog.AdjustEstimate == AdjustEstimateLeave {
+		deducted = 0
+	}
+
+	result, err := tx.Exec(updateQuery, worklog.Minutes, deducted, issueID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return 0, sql.ErrNoRows
+	}
+
+	insertQuery := "INSERT INTO worklogs(issueID, username, minutes, workDate, note) VALUES(?, ?, ?, ?, ?)"
+
+	result, err = tx.Exec(insertQuery, issueID, worklog.User, worklog.Minutes, worklog.WorkDate, worklog.Note)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	id, _ := result.LastInsertId()
+	return id, tx.Commit()
+}
+
+// RetrieveWorklogs returns the work logged on an issue
+func (mysqlSt *MysqlStorage) RetrieveWorklogs(issueID int64) ([]models.Worklog, error) {
+	query := `SELECT ` + worklogColumns + ` FROM worklogs WHERE issueID = ? ORDER BY workDate, id`
+
+	return mysqlSt.queryWorklogs(query, issueID)
+}
+
+// DeleteWorklog deletes logged work, giving its time back to the remaining estimate
+func (mysqlSt *MysqlStorage) DeleteWorklog(issueID, worklogID int64) error {
+	var minutes int64
+
+	tx, err := mysqlSt.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT minutes FROM worklogs WHERE id = ? AND issueID = ? FOR UPDATE`
+	if err = tx.QueryRow(query, worklogID, issueID).Scan(&minutes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM worklogs WHERE id = ?`, worklogID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	updateQuery := "UPDATE issues SET timeSpent = GREATEST(timeSpent - ?, 0), remainingEstimate = remainingEstimate + ?, version = version + 1 WHERE id = ?"
+	if _, err = tx.Exec(updateQuery, minutes, minutes, issueID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RetrieveWorklogTotals sums the logged work per issue, user or project over an optional date range
+func (mysqlSt *MysqlStorage) RetrieveWorklogTotals(query models.WorklogQueryParam) ([]models.WorklogTotal, error) {
+	resp := make([]models.WorklogTotal, 0)
+
+	groupBy := "w.issueID"
+	switch query.GroupBy {
+	case "user":
+		groupBy = "w.username"
+	case "project":
+		groupBy = "i.project"
+	}
+
+	totalsQuery := `SELECT ` + groupBy + `, SUM(w.minutes) FROM worklogs w JOIN issues i ON i.id = w.issueID WHERE 1 = 1`
+	args := make([]interface{}, 0)
+
+	if query.From != "" {
+		totalsQuery += ` AND w.workDate >= ?`
+		args = append(args, query.From)
+	}
+	if query.To != "" {
+		totalsQuery += ` AND w.workDate <= ?`
+		args = append(args, query.To)
+	}
+	if query.User != "" {
+		totalsQuery += ` AND w.username = ?`
+		args = append(args, query.User)
+	}
+	if query.Project != "" {
+		totalsQuery += ` AND i.project = ?`
+		args = append(args, query.Project)
+	}
+	if query.IssueID != 0 {
+		totalsQuery += ` AND w.issueID = ?`
+		args = append(args, query.IssueID)
+	}
+
+	totalsQuery += ` GROUP BY ` + groupBy + ` ORDER BY ` + groupBy
+
+	rows, err := mysqlSt.db.Query(totalsQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total models.WorklogTotal
+
+		if err = rows.Scan(&total.Key, &total.Minutes); err != nil {
+			return nil, err
+		}
+		resp = append(resp, total)
+	}
+
+	return resp, rows.Err()
+}
+
+// RetrieveUserWorklogs returns the work logged by a user between two dates included
+func (mysqlSt *MysqlStorage) RetrieveUserWorklogs(user, from, to string) ([]models.Worklog, error) {
+	query := `SELECT ` + worklogColumns + ` FROM worklogs WHERE username = ? AND workDate >= ? AND workDate <= ? ORDER BY workDate, id`
+
+	return mysqlSt.queryWorklogs(query, user, from, to)
+}
+
+func (mysqlSt *MysqlStorage) queryWorklogs(query string, args ...interface{}) ([]models.Worklog, error) {
+	resp := make([]models.Worklog, 0)
+
+	rows, err := mysqlSt.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var worklog models.Worklog
+		var workDate time.Time
+
+		err = rows.Scan(&worklog.ID, &worklog.IssueID, &worklog.User, &worklog.Minutes, &workDate, &worklog.Note, &worklog.CreateDate)
+		if err != nil {
+			return nil, err
+		}
+
+		worklog.WorkDate = workDate.Format(dateFormat)
+		resp = append(resp, worklog)
+	}
+
+	return resp, rows.Err()
+}
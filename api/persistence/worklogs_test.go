@@ -0,0 +1,196 @@
+package persistence
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlStorage_CreateWorklog(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+	worklog := models.NewWorklogRequest{User: Assignee, Minutes: 90, WorkDate: "2020-09-08", Note: Comment}
+
+	t.Run("AutoAdjust", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE issues SET timeSpent").
+			WithArgs(90, 90, IssueID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO worklogs").
+			WithArgs(IssueID, Assignee, 90, "2020-09-08", Comment).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		// run the code
+		if _, err = testingStorage.CreateWorklog(worklog, IssueID); err != nil {
+			t.Errorf("Error should not have occurred while logging work: %s", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("LeaveEstimate", func(t *testing.T) {
+		leave := worklog
+		leave.AdjustEstimate = AdjustEstimateLeave
+
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE issues SET timeSpent").
+			WithArgs(90, 0, IssueID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO worklogs").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		// run the code
+		if _, err = testingStorage.CreateWorklog(leave, IssueID); err != nil {
+			t.Errorf("Error should not have occurred while logging work: %s", err)
+		}
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+
+	t.Run("IssueNotFound", func(t *testing.T) {
+		// set expectations
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE issues SET timeSpent").
+			WithArgs(90, 90, IssueID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		// run the code
+		_, err = testingStorage.CreateWorklog(worklog, IssueID)
+		assert.Equal(t, sql.ErrNoRows, err, "missing issue is reported")
+
+		//check expectations are met
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met: %s", err)
+		}
+	})
+}
+
+func TestMysqlStorage_DeleteWorklog(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	// set expectations
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT minutes FROM worklogs").
+		WithArgs(2, IssueID).
+		WillReturnRows(sqlmock.NewRows([]string{"minutes"}).AddRow(90))
+	mock.ExpectExec("DELETE FROM worklogs").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE issues SET timeSpent").
+		WithArgs(90, 90, IssueID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// run the code
+	if err = testingStorage.DeleteWorklog(IssueID, 2); err != nil {
+		t.Errorf("Error should not have occurred while deleting worklog: %s", err)
+	}
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveWorklogTotals(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT i.project, SUM\\(w.minutes\\) FROM worklogs w JOIN issues i (.+) GROUP BY i.project").
+		WithArgs("2020-09-01", "2020-09-30", Assignee).
+		WillReturnRows(sqlmock.NewRows([]string{"project", "minutes"}).
+			AddRow(Project, 150))
+
+	// run the code
+	query := models.WorklogQueryParam{From: "2020-09-01", To: "2020-09-30", GroupBy: "project", User: Assignee}
+	totals, err := testingStorage.RetrieveWorklogTotals(query)
+	if err != nil {
+		t.Errorf("Error should not have occurred while aggregating worklogs: %s", err)
+	}
+
+	assert.Equal(t, []models.WorklogTotal{{Key: Project, Minutes: 150}}, totals)
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
+
+func TestMysqlStorage_RetrieveUserWorklogs(t *testing.T) {
+	// setup
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// force db closure at end of test
+	defer func() {
+		_ = db.Close()
+	}()
+
+	testingStorage := NewMysqlStorage(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM worklogs WHERE username").
+		WithArgs(Assignee, "2020-09-07", "2020-09-13").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "issueID", "username", "minutes", "workDate", "note", "createDate"}).
+			AddRow(1, IssueID, Assignee, 90, time.Date(2020, 9, 8, 0, 0, 0, 0, time.UTC), Comment, CreateDate))
+
+	// run the code
+	worklogs, err := testingStorage.RetrieveUserWorklogs(Assignee, "2020-09-07", "2020-09-13")
+	if err != nil {
+		t.Errorf("Error should not have occurred while retrieving worklogs: %s", err)
+	}
+
+	assert.Equal(t, "2020-09-08", worklogs[0].WorkDate, "work date is formatted as a day")
+
+	//check expectations are met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met: %s", err)
+	}
+}
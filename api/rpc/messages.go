@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/rpc/pb"
+)
+
+func issueMessage(issue models.IssueResponse) *pb.Issue {
+	message := &pb.Issue{
+		Id:                issue.ID,
+		Summary:           issue.Summary,
+		Description:       issue.Description,
+		Status:            issue.Status,
+		Priority:          issue.Priority,
+		Assignee:          issue.Assignee,
+		Reporter:          issue.Reporter,
+		Project:           issue.Project,
+		Type:              issue.Type,
+		Fields:            issue.Fields,
+		CreateDate:        issue.CreateDate,
+		DueDate:           issue.DueDate,
+		RespondedDate:     issue.RespondedDate,
+		ResolvedDate:      issue.ResolvedDate,
+		OriginalEstimate:  issue.OriginalEstimate,
+		RemainingEstimate: issue.RemainingEstimate,
+		TimeSpent:         issue.TimeSpent,
+		Version:           issue.Version,
+		Comments:          make([]*pb.Comment, 0, len(issue.Comments)),
+	}
+
+	for _, comment := range issue.Comments {
+		message.Comments = append(message.Comments, &pb.Comment{Id: comment.ID, Comment: comment.Comment})
+	}
+
+	return message
+}
+
+func eventMessage(event models.IssueEvent) *pb.IssueEvent {
+	message := &pb.IssueEvent{
+		Id:        event.ID,
+		Event:     event.Event,
+		IssueId:   event.IssueID,
+		Project:   event.Project,
+		Actor:     event.Actor,
+		Comment:   event.Comment,
+		Changes:   event.Changes,
+		Changed:   event.Changed,
+		Timestamp: event.Timestamp.UTC().Format(time.RFC3339),
+	}
+
+	if event.Issue != nil {
+		message.Issue = issueMessage(*event.Issue)
+	}
+
+	return message
+}
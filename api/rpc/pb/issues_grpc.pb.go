@@ -0,0 +1,313 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// IssueServiceClient is the client API for IssueService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IssueServiceClient interface {
+	CreateIssue(ctx context.Context, in *CreateIssueRequest, opts ...grpc.CallOption) (*Issue, error)
+	GetIssue(ctx context.Context, in *GetIssueRequest, opts ...grpc.CallOption) (*Issue, error)
+	ListIssues(ctx context.Context, in *ListIssuesRequest, opts ...grpc.CallOption) (*ListIssuesResponse, error)
+	UpdateIssue(ctx context.Context, in *UpdateIssueRequest, opts ...grpc.CallOption) (*Issue, error)
+	DeleteIssue(ctx context.Context, in *DeleteIssueRequest, opts ...grpc.CallOption) (*DeleteIssueResponse, error)
+	// Watch streams the events of an issue, or of every issue when issue_id is 0, replaying the recent events that
+	// followed last_event_id
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (IssueService_WatchClient, error)
+}
+
+type issueServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIssueServiceClient(cc grpc.ClientConnInterface) IssueServiceClient {
+	return &issueServiceClient{cc}
+}
+
+func (c *issueServiceClient) CreateIssue(ctx context.Context, in *CreateIssueRequest, opts ...grpc.CallOption) (*Issue, error) {
+	out := new(Issue)
+	err := c.cc.Invoke(ctx, "/yaits.IssueService/CreateIssue", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueServiceClient) GetIssue(ctx context.Context, in *GetIssueRequest, opts ...grpc.CallOption) (*Issue, error) {
+	out := new(Issue)
+	err := c.cc.Invoke(ctx, "/yaits.IssueService/GetIssue", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueServiceClient) ListIssues(ctx context.Context, in *ListIssuesRequest, opts ...grpc.CallOption) (*ListIssuesResponse, error) {
+	out := new(ListIssuesResponse)
+	err := c.cc.Invoke(ctx, "/yaits.IssueService/ListIssues", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueServiceClient) UpdateIssue(ctx context.Context, in *UpdateIssueRequest, opts ...grpc.CallOption) (*Issue, error) {
+	out := new(Issue)
+	err := c.cc.Invoke(ctx, "/yaits.IssueService/UpdateIssue", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueServiceClient) DeleteIssue(ctx context.Context, in *DeleteIssueRequest, opts ...grpc.CallOption) (*DeleteIssueResponse, error) {
+	out := new(DeleteIssueResponse)
+	err := c.cc.Invoke(ctx, "/yaits.IssueService/DeleteIssue", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (IssueService_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &IssueService_ServiceDesc.Streams[0], "/yaits.IssueService/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &issueServiceWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type IssueService_WatchClient interface {
+	Recv() (*IssueEvent, error)
+	grpc.ClientStream
+}
+
+type issueServiceWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *issueServiceWatchClient) Recv() (*IssueEvent, error) {
+	m := new(IssueEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IssueServiceServer is the server API for IssueService service.
+// All implementations must embed UnimplementedIssueServiceServer
+// for forward compatibility
+type IssueServiceServer interface {
+	CreateIssue(context.Context, *CreateIssueRequest) (*Issue, error)
+	GetIssue(context.Context, *GetIssueRequest) (*Issue, error)
+	ListIssues(context.Context, *ListIssuesRequest) (*ListIssuesResponse, error)
+	UpdateIssue(context.Context, *UpdateIssueRequest) (*Issue, error)
+	DeleteIssue(context.Context, *DeleteIssueRequest) (*DeleteIssueResponse, error)
+	// Watch streams the events of an issue, or of every issue when issue_id is 0, replaying the recent events that
+	// followed last_event_id
+	Watch(*WatchRequest, IssueService_WatchServer) error
+	mustEmbedUnimplementedIssueServiceServer()
+}
+
+// UnimplementedIssueServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedIssueServiceServer struct {
+}
+
+func (UnimplementedIssueServiceServer) CreateIssue(context.Context, *CreateIssueRequest) (*Issue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateIssue not implemented")
+}
+func (UnimplementedIssueServiceServer) GetIssue(context.Context, *GetIssueRequest) (*Issue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIssue not implemented")
+}
+func (UnimplementedIssueServiceServer) ListIssues(context.Context, *ListIssuesRequest) (*ListIssuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListIssues not implemented")
+}
+func (UnimplementedIssueServiceServer) UpdateIssue(context.Context, *UpdateIssueRequest) (*Issue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateIssue not implemented")
+}
+func (UnimplementedIssueServiceServer) DeleteIssue(context.Context, *DeleteIssueRequest) (*DeleteIssueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteIssue not implemented")
+}
+func (UnimplementedIssueServiceServer) Watch(*WatchRequest, IssueService_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedIssueServiceServer) mustEmbedUnimplementedIssueServiceServer() {}
+
+// UnsafeIssueServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IssueServiceServer will
+// result in compilation errors.
+type UnsafeIssueServiceServer interface {
+	mustEmbedUnimplementedIssueServiceServer()
+}
+
+func RegisterIssueServiceServer(s grpc.ServiceRegistrar, srv IssueServiceServer) {
+	s.RegisterService(&IssueService_ServiceDesc, srv)
+}
+
+func _IssueService_CreateIssue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateIssueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueServiceServer).CreateIssue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/yaits.IssueService/CreateIssue",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueServiceServer).CreateIssue(ctx, req.(*CreateIssueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueService_GetIssue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIssueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueServiceServer).GetIssue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/yaits.IssueService/GetIssue",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueServiceServer).GetIssue(ctx, req.(*GetIssueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueService_ListIssues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListIssuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueServiceServer).ListIssues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/yaits.IssueService/ListIssues",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueServiceServer).ListIssues(ctx, req.(*ListIssuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueService_UpdateIssue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateIssueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueServiceServer).UpdateIssue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/yaits.IssueService/UpdateIssue",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueServiceServer).UpdateIssue(ctx, req.(*UpdateIssueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueService_DeleteIssue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteIssueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueServiceServer).DeleteIssue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/yaits.IssueService/DeleteIssue",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueServiceServer).DeleteIssue(ctx, req.(*DeleteIssueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IssueServiceServer).Watch(m, &issueServiceWatchServer{stream})
+}
+
+type IssueService_WatchServer interface {
+	Send(*IssueEvent) error
+	grpc.ServerStream
+}
+
+type issueServiceWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *issueServiceWatchServer) Send(m *IssueEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// IssueService_ServiceDesc is the grpc.ServiceDesc for IssueService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IssueService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "yaits.IssueService",
+	HandlerType: (*IssueServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateIssue",
+			Handler:    _IssueService_CreateIssue_Handler,
+		},
+		{
+			MethodName: "GetIssue",
+			Handler:    _IssueService_GetIssue_Handler,
+		},
+		{
+			MethodName: "ListIssues",
+			Handler:    _IssueService_ListIssues_Handler,
+		},
+		{
+			MethodName: "UpdateIssue",
+			Handler:    _IssueService_UpdateIssue_Handler,
+		},
+		{
+			MethodName: "DeleteIssue",
+			Handler:    _IssueService_DeleteIssue_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _IssueService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "issues.proto",
+}
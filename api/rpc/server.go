@@ -0,0 +1,376 @@
+package rpc
+
+//go:generate protoc -I pb --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative pb/issues.proto
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/rpc/pb"
+	"github.com/YAITS/api/server"
+	"github.com/YAITS/api/server/handlers"
+	"github.com/YAITS/api/validation"
+)
+
+// Page sizes of ListIssues
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// loggerKey is the context key of the request logger
+type loggerKey struct{}
+
+// userKey is the context key of the calling user
+type userKey struct{}
+
+// IssueService implements the gRPC IssueService over the storage and the validation of the REST API, Watch streaming
+// the events of the hub the event stream routes read from
+type IssueService struct {
+	pb.UnimplementedIssueServiceServer
+
+	storage     persistence.Storage
+	attachments handlers.Attachments
+	hub         *events.Hub
+}
+
+// NewServer creates a gRPC server serving the IssueService, requests being logged with their own request id like
+// those of the REST API and authenticated with the same bearer tokens
+func NewServer(logger *zap.SugaredLogger, storage persistence.Storage, attachments handlers.Attachments,
+	hub *events.Hub, config server.Config) *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := authenticate(withLogger(ctx, logger, info.FullMethod), storage, config.RequireToken)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+			ctx, err := authenticate(withLogger(stream.Context(), logger, info.FullMethod), storage,
+				config.RequireToken)
+			if err != nil {
+				return err
+			}
+			return handler(srv, &loggedStream{ServerStream: stream, ctx: ctx})
+		}),
+	)
+
+	pb.RegisterIssueServiceServer(grpcServer, &IssueService{storage: storage, attachments: attachments, hub: hub})
+
+	return grpcServer
+}
+
+// CreateIssue creates an issue the way POST /issue does
+func (s *IssueService) CreateIssue(ctx context.Context, req *pb.CreateIssueRequest) (*pb.Issue, error) {
+	l := requestLogger(ctx)
+	l.Debug("received issue creation request")
+
+	newIssue := models.NewIssueRequest{
+		Summary:          req.Summary,
+		Description:      req.Description,
+		Priority:         req.Priority,
+		Assignee:         req.Assignee,
+		Reporter:         req.Reporter,
+		Project:          req.Project,
+		Type:             req.Type,
+		Fields:           req.Fields,
+		OriginalEstimate: req.OriginalEstimate,
+	}
+
+	dueDate, err := parseDate(req.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	newIssue.DueDate = dueDate
+
+	issue, err := handlers.CreateIssue(s.storage, l, currentUser(ctx), newIssue)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return issueMessage(issue), nil
+}
+
+// GetIssue retrieves an issue along with its comments the way GET /issue/{id} does
+func (s *IssueService) GetIssue(ctx context.Context, req *pb.GetIssueRequest) (*pb.Issue, error) {
+	issue, err := s.storage.RetrieveIssueByID(req.Id)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			requestLogger(ctx).Errorf("error retrieving issue in db: %s", err.Error())
+		}
+		return nil, statusError(err)
+	}
+
+	return issueMessage(issue), nil
+}
+
+// ListIssues lists a page of the issues matching the filters of the request, their comments being retrieved for the
+// whole page at once when included
+func (s *IssueService) ListIssues(ctx context.Context, req *pb.ListIssuesRequest) (*pb.ListIssuesResponse, error) {
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize < 0 || pageSize > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page size must be between 1 and %d", maxPageSize)
+	}
+	if req.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset must not be negative")
+	}
+
+	search := models.IssueSearchQuery{
+		IssueFilterQueryParam: models.IssueFilterQueryParam{Project: req.Project, Type: req.Type},
+		Status:                req.Status,
+		Assignee:              req.Assignee,
+		Reporter:              req.Reporter,
+		PriorityStart:         req.PriorityStart,
+		PriorityEnd:           req.PriorityEnd,
+		Limit:                 pageSize,
+		Offset:                req.Offset,
+	}
+
+	issues, err := s.storage.SearchIssues(search)
+	if err == nil && req.IncludeComments {
+		err = s.attachComments(issues)
+	}
+	if err != nil {
+		requestLogger(ctx).Errorf("error retrieving issues in db: %s", err.Error())
+		return nil, statusError(err)
+	}
+
+	resp := &pb.ListIssuesResponse{Issues: make([]*pb.Issue, 0, len(issues))}
+	for _, issue := range issues {
+		resp.Issues = append(resp.Issues, issueMessage(issue))
+	}
+
+	return resp, nil
+}
+
+// UpdateIssue updates an issue the way PATCH /issue/{id} does
+func (s *IssueService) UpdateIssue(ctx context.Context, req *pb.UpdateIssueRequest) (*pb.Issue, error) {
+	l := requestLogger(ctx).With("issueID", req.Id)
+	l.Debug("received issue update request")
+
+	update := models.UpdateIssueRequest{
+		Summary:         req.Summary,
+		Description:     req.Description,
+		Priority:        req.Priority,
+		Assignee:        req.Assignee,
+		Status:          req.Status,
+		Comment:         req.Comment,
+		ExpectedVersion: req.ExpectedVersion,
+	}
+	if req.OriginalEstimate != nil {
+		update.OriginalEstimate = &req.OriginalEstimate.Value
+	}
+	if req.RemainingEstimate != nil {
+		update.RemainingEstimate = &req.RemainingEstimate.Value
+	}
+
+	dueDate, err := parseDate(req.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	update.DueDate = dueDate
+
+	issue, err := handlers.UpdateIssue(s.storage, l, currentUser(ctx), req.Id, update)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return issueMessage(issue), nil
+}
+
+// DeleteIssue deletes an issue along with its attachments the way DELETE /issue/{id} does
+func (s *IssueService) DeleteIssue(ctx context.Context, req *pb.DeleteIssueRequest) (*pb.DeleteIssueResponse, error) {
+	l := requestLogger(ctx).With("issueID", req.Id)
+	l.Debug("received issue deletion request")
+
+	if err := handlers.DeleteIssue(s.storage, s.attachments, l, currentUser(ctx), req.Id); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &pb.DeleteIssueResponse{}, nil
+}
+
+// Watch streams issue events until the client goes away, the stream ending when the client lags too far behind for
+// it to resume from its last event
+func (s *IssueService) Watch(req *pb.WatchRequest, stream pb.IssueService_WatchServer) error {
+	l := requestLogger(stream.Context()).With("issueID", req.IssueId, "lastEventID", req.LastEventId)
+	l.Debug("event stream opened")
+
+	subscription := s.hub.Subscribe(req.IssueId, req.LastEventId)
+	defer s.hub.Unsubscribe(subscription)
+
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				l.Debug("event stream lagged behind, closing")
+				return status.Error(codes.ResourceExhausted, "lagged behind, resume from the last event received")
+			}
+
+			if err := stream.Send(eventMessage(event)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			l.Debug("event stream closed")
+			return nil
+		}
+	}
+}
+
+func (s *IssueService) attachComments(issues []models.IssueResponse) error {
+	ids := make([]int64, 0, len(issues))
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+
+	comments, err := s.storage.RetrieveComments(ids...)
+	if err != nil {
+		return err
+	}
+
+	for i := range issues {
+		issues[i].Comments = comments[issues[i].ID]
+	}
+
+	return nil
+}
+
+// statusError turns the errors of the storage and of the shared issue logic into gRPC statuses
+func statusError(err error) error {
+	if err == sql.ErrNoRows || err == handlers.ErrIssueNotFound {
+		return status.Error(codes.NotFound, handlers.ErrIssueNotFound.Error())
+	}
+	if requestErr, ok := err.(*handlers.RequestError); ok {
+		return invalidArgument(requestErr.Message, requestErr.Errors)
+	}
+	if constraint, ok := persistence.AsConstraintError(err); ok {
+		return invalidArgument(constraint.Message, validation.Errors{{Field: constraint.Field, Code: constraint.Reason,
+			Message: constraint.Message}})
+	}
+	if err == persistence.ErrVersionConflict {
+		return status.Error(codes.Aborted, err.Error())
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
+
+// invalidArgument returns the InvalidArgument status of a rejected request, the fields in violation being its
+// BadRequest details
+func invalidArgument(message string, errs validation.Errors) error {
+	st := status.New(codes.InvalidArgument, message)
+	if len(errs) == 0 {
+		return st.Err()
+	}
+
+	badRequest := &errdetails.BadRequest{FieldViolations: make([]*errdetails.BadRequest_FieldViolation, 0, len(errs))}
+	for _, fieldErr := range errs {
+		badRequest.FieldViolations = append(badRequest.FieldViolations,
+			&errdetails.BadRequest_FieldViolation{Field: fieldErr.Field, Description: fieldErr.Message})
+	}
+
+	if detailed, err := st.WithDetails(badRequest); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+// parseDate reads an RFC 3339 date, the empty string being no date
+func parseDate(date string) (*time.Time, error) {
+	if date == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid date %q, expecting RFC 3339", date)
+	}
+
+	return &t, nil
+}
+
+// authenticate resolves the calling user the way the REST API does: the bearer token of the authorization metadata
+// is the user owning it, and without one the x-yaits-user metadata is trusted unless tokens are required
+func authenticate(ctx context.Context, storage persistence.Storage, requireToken bool) (context.Context, error) {
+	token := strings.TrimSpace(strings.TrimPrefix(metadataValue(ctx, "authorization"), "Bearer "))
+	if token == "" {
+		if requireToken {
+			return nil, status.Error(codes.Unauthenticated, "a bearer token is required")
+		}
+
+		return context.WithValue(ctx, userKey{}, metadataValue(ctx, strings.ToLower(handlers.UserHeader))), nil
+	}
+
+	user, _, err := storage.RetrieveTokenUser(token)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	if err != nil {
+		requestLogger(ctx).Errorf("error retrieving token in db: %s", err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return context.WithValue(ctx, userKey{}, user), nil
+}
+
+// currentUser returns the user authenticated for the request, anonymous requests having none
+func currentUser(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// metadataValue returns the first value of an incoming metadata key, the empty string if there is none
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func withLogger(ctx context.Context, logger *zap.SugaredLogger, method string) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger.With("request-id", uuid.New().String(), "rpc", method))
+}
+
+func requestLogger(ctx context.Context) *zap.SugaredLogger {
+	if l, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger); ok {
+		return l
+	}
+
+	return zap.NewNop().Sugar()
+}
+
+// loggedStream is a server stream carrying the request logger in its context
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *loggedStream) Context() context.Context {
+	return stream.ctx
+}
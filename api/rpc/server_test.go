@@ -0,0 +1,234 @@
+package rpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	persistence "github.com/YAITS/api/persistence/mock"
+	"github.com/YAITS/api/rpc/pb"
+	"github.com/YAITS/api/server"
+	"github.com/YAITS/api/server/handlers"
+	"github.com/YAITS/api/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueService(t *testing.T) {
+	hub := events.NewHub(10)
+	client, closeClient := getClient(t, hub, server.Config{})
+	defer closeClient()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-yaits-user", persistence.Assignee)
+
+	t.Run("CreateIssue", func(t *testing.T) {
+		issue, err := client.CreateIssue(ctx, &pb.CreateIssueRequest{Summary: "Login fails", Description: "Blank page",
+			Priority: 2, DueDate: "2020-07-01T00:00:00Z", Fields: map[string]string{"browser": "Firefox"}})
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.IssueID, issue.GetId())
+
+		t.Run("MissingPriority", func(t *testing.T) {
+			_, err := client.CreateIssue(ctx, &pb.CreateIssueRequest{Summary: "Login fails", Description: "Blank page"})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Equal(t, "priority is required", status.Convert(err).Message())
+		})
+
+		t.Run("InvalidDueDate", func(t *testing.T) {
+			_, err := client.CreateIssue(ctx, &pb.CreateIssueRequest{Summary: "Login fails", Priority: 2,
+				DueDate: "tomorrow"})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	})
+
+	t.Run("GetIssue", func(t *testing.T) {
+		issue, err := client.GetIssue(ctx, &pb.GetIssueRequest{Id: persistence.IssueID})
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.Summary, issue.GetSummary())
+		assert.Equal(t, persistence.Assignee, issue.GetAssignee())
+		assert.Equal(t, persistence.Version, issue.GetVersion())
+	})
+
+	t.Run("ListIssues", func(t *testing.T) {
+		resp, err := client.ListIssues(ctx, &pb.ListIssuesRequest{Status: "open", IncludeComments: true})
+		assert.Nil(t, err)
+		if assert.Len(t, resp.GetIssues(), 1) {
+			assert.Equal(t, []*pb.Comment{{Id: persistence.MockComment.ID, Comment: persistence.MockComment.Comment}},
+				resp.GetIssues()[0].GetComments())
+		}
+
+		t.Run("PageTooLarge", func(t *testing.T) {
+			_, err := client.ListIssues(ctx, &pb.ListIssuesRequest{PageSize: 1000})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	})
+
+	t.Run("UpdateIssue", func(t *testing.T) {
+		issue, err := client.UpdateIssue(ctx, &pb.UpdateIssueRequest{Id: persistence.IssueID, Status: "closed",
+			RemainingEstimate: wrapperspb.Int64(0)})
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.IssueID, issue.GetId())
+
+		t.Run("ExpectedVersion", func(t *testing.T) {
+			_, err := client.UpdateIssue(ctx, &pb.UpdateIssueRequest{Id: persistence.IssueID, Status: "closed",
+				ExpectedVersion: persistence.Version})
+			assert.Nil(t, err)
+		})
+
+		t.Run("VersionConflict", func(t *testing.T) {
+			_, err := client.UpdateIssue(ctx, &pb.UpdateIssueRequest{Id: persistence.IssueID, Status: "closed",
+				ExpectedVersion: persistence.Version + 1})
+			assert.Equal(t, codes.Aborted, status.Code(err))
+		})
+	})
+
+	t.Run("DeleteIssue", func(t *testing.T) {
+		_, err := client.DeleteIssue(ctx, &pb.DeleteIssueRequest{Id: persistence.IssueID})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		hub.Publish(models.IssueEvent{Event: events.IssueCreated, IssueID: 1})
+		hub.Publish(models.IssueEvent{Event: events.IssueUpdated, IssueID: 2, Changed: []string{"status"}})
+		hub.Publish(models.IssueEvent{Event: events.IssueCommented, IssueID: 1, Comment: "Fixed"})
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// resuming after the first event replays the events of the issue that followed it
+		stream, err := client.Watch(watchCtx, &pb.WatchRequest{IssueId: 1, LastEventId: 1})
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		event, err := stream.Recv()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), event.GetId())
+		assert.Equal(t, events.IssueCommented, event.GetEvent())
+		assert.Equal(t, "Fixed", event.GetComment())
+	})
+}
+
+func TestAuthentication(t *testing.T) {
+	client, closeClient := getClient(t, events.NewHub(10), server.Config{RequireToken: true})
+	defer closeClient()
+
+	get := &pb.GetIssueRequest{Id: persistence.IssueID}
+
+	t.Run("Token", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+persistence.Token)
+		_, err := client.GetIssue(ctx, get)
+		assert.Nil(t, err)
+	})
+
+	t.Run("MissingToken", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-yaits-user", persistence.Assignee)
+		_, err := client.GetIssue(ctx, get)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		stream, err := client.Watch(ctx, &pb.WatchRequest{})
+		if assert.Nil(t, err) {
+			_, err = stream.Recv()
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		}
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer unknown")
+		_, err := client.GetIssue(ctx, get)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	storage := persistence.NewMockStorage()
+
+	t.Run("Token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization",
+			"Bearer "+persistence.Token, "x-yaits-user", "mallory"))
+		ctx, err := authenticate(ctx, storage, false)
+		assert.Nil(t, err)
+		assert.Equal(t, persistence.Assignee, currentUser(ctx))
+	})
+
+	t.Run("UserMetadata", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-yaits-user", "mallory"))
+		ctx, err := authenticate(ctx, storage, false)
+		assert.Nil(t, err)
+		assert.Equal(t, "mallory", currentUser(ctx))
+	})
+}
+
+func TestStatusError(t *testing.T) {
+	assert.Equal(t, codes.NotFound, status.Code(statusError(handlers.ErrIssueNotFound)))
+	assert.Equal(t, codes.InvalidArgument, status.Code(statusError(&handlers.RequestError{Message: "unknown issue type"})))
+	assert.Equal(t, codes.Internal, status.Code(statusError(context.DeadlineExceeded)))
+
+	t.Run("Violations", func(t *testing.T) {
+		err := statusError(&handlers.RequestError{Message: "priority is required", Errors: validation.Errors{
+			{Field: "priority", Code: validation.CodeRequired, Message: "priority is required"}}})
+
+		st := status.Convert(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		if assert.Len(t, st.Details(), 1) {
+			assert.Equal(t, []*errdetails.BadRequest_FieldViolation{{Field: "priority",
+				Description: "priority is required"}}, st.Details()[0].(*errdetails.BadRequest).GetFieldViolations())
+		}
+	})
+
+	t.Run("Constraint", func(t *testing.T) {
+		err := statusError(&mysql.MySQLError{Number: 3819, Message: "Check constraint 'priority_range' is violated."})
+
+		st := status.Convert(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		if assert.Len(t, st.Details(), 1) {
+			violations := st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()
+			assert.Equal(t, "priority", violations[0].GetField())
+		}
+	})
+}
+
+func getClient(t *testing.T, hub *events.Hub, config server.Config) (pb.IssueServiceClient, func()) {
+	blobRoot, err := ioutil.TempDir("", "yaits-attachments")
+	if err != nil {
+		t.Fatalf("couldn't create the attachment store: %s", err)
+	}
+	store, err := blob.NewLocalStore(blobRoot)
+	if err != nil {
+		t.Fatalf("couldn't create the attachment store: %s", err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := NewServer(zap.NewNop().Sugar(), persistence.NewMockStorage(), handlers.Attachments{Store: store}, hub,
+		config)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+	if err != nil {
+		t.Fatalf("couldn't connect to the grpc server: %s", err)
+	}
+
+	return pb.NewIssueServiceClient(conn), func() {
+		_ = conn.Close()
+		grpcServer.Stop()
+		_ = os.RemoveAll(blobRoot)
+	}
+}
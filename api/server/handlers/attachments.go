@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is what an upload may add to the attachment size limit for its multipart boundaries, part
+// headers and other form fields
+const multipartOverhead = 64 << 10
+
+// Attachments holds the blob store keeping attachment contents and the limits enforced on uploads
+type Attachments struct {
+	Store   blob.Store
+	MaxSize int64
+	// AllowedTypes are media types such as application/pdf or wildcards such as image/*, empty allowing any type
+	AllowedTypes []string
+}
+
+//HandlePOSTAttachment - Route to attach a file to an issue or a comment
+// @summary Attach a file
+// @description Uploads a file attached to an issue, or to one of its comments when a comment id is given
+// @tags Attachments
+// @accept multipart/form-data
+// @produce json
+// @Param id path int true "ID of the issue"
+// @Param commentID path int false "ID of the comment"
+// @Param file formData file true "attached file"
+// @success 201 {object} models.Attachment
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 413 {object} models.ErrorWrapper
+// @failure 415 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/attachments [post]
+// @router /issue/{id}/comments/{commentID}/attachments [post]
+func HandlePOSTAttachment(storage persistence.Storage, attachments Attachments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] create-attachment")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		var commentID int64
+		if c.Param("commentID") != "" {
+			if commentID, err = strconv.ParseInt(c.Param("commentID"), 10, 64); err != nil {
+				models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid comment id format")
+				return
+			}
+		}
+
+		// the body is limited before being parsed, large files being otherwise spooled to disk in full
+		if attachments.MaxSize > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, attachments.MaxSize+multipartOverhead)
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil && isBodyTooLarge(err) {
+			models.SetErrorStatusJSON(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("attachments are limited to %d bytes", attachments.MaxSize))
+			return
+		}
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "a file is required")
+			return
+		}
+
+		l = l.With("issueID", issueID, "commentID", commentID, "filename", fileHeader.Filename)
+		l.Debug("received attachment upload")
+
+		if attachments.MaxSize > 0 && fileHeader.Size > attachments.MaxSize {
+			models.SetErrorStatusJSON(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("attachments are limited to %d bytes", attachments.MaxSize))
+			return
+		}
+
+		f, err := fileHeader.Open()
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		content, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		contentType := blob.DetectContentType(fileHeader.Header.Get("Content-Type"), content)
+		if !blob.AllowedType(contentType, attachments.AllowedTypes) {
+			models.SetErrorStatusJSON(c, http.StatusUnsupportedMediaType, fmt.Sprintf("attachments of type %s are not allowed", contentType))
+			return
+		}
+
+		_, err = storage.RetrieveIssueByID(issueID)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find issue")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("error retrieving issue in db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		sum := sha256.Sum256(content)
+		attachment := models.Attachment{
+			IssueID:     issueID,
+			CommentID:   commentID,
+			Filename:    fileHeader.Filename,
+			ContentType: contentType,
+			Size:        int64(len(content)),
+			Hash:        hex.EncodeToString(sum[:]),
+		}
+
+		// identical contents are only stored once
+		attachment.ID, err = storage.CreateAttachment(attachment, func() error {
+			return attachments.Store.Put(attachment.Hash, bytes.NewReader(content), attachment.Size, contentType)
+		})
+
+		if err != nil {
+			releaseBlob(storage, attachments.Store, attachment.Hash, l)
+		}
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find comment")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("couldn't store attachment: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("attachment stored")
+		c.JSON(http.StatusCreated, attachment)
+		return
+	}
+}
+
+//HandleGETAttachments - Route to list the attachments of an issue
+// @summary Retrieves the attachments of an issue
+// @description Retrieves the files attached to an issue and to its comments
+// @tags Attachments
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @success 200 {array} models.Attachment
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/attachments [get]
+func HandleGETAttachments(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-attachments")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		attachmentsResponse, err := storage.RetrieveAttachments(issueID)
+
+		if err != nil {
+			l.Errorf("error retrieving attachments in db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("attachments successfully retrieved")
+		c.JSON(http.StatusOK, attachmentsResponse)
+		return
+	}
+}
+
+//HandleGETAttachmentContent - Route to download an attachment
+// @summary Download an attachment
+// @description Downloads the content of an attachment
+// @tags Attachments
+// @produce octet-stream
+// @Param id path int true "ID of the attachment"
+// @success 200 {file} file
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /attachments/{id} [get]
+func HandleGETAttachmentContent(storage persistence.Storage, attachments Attachments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] download-attachment")
+
+		attachmentID, err := strconv.ParseInt(c.Param("attachmentID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid attachment id format")
+			return
+		}
+
+		attachment, err := storage.RetrieveAttachmentByID(attachmentID)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find attachment")
+			return
+		}
+
+		var content io.ReadCloser
+		if err == nil {
+			content, err = attachments.Store.Get(attachment.Hash)
+		}
+
+		if err != nil {
+			l.Errorf("error retrieving attachment: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+		defer content.Close()
+
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+		c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content,
+			map[string]string{"Content-Disposition": disposition})
+		return
+	}
+}
+
+//HandleDELETEAttachment - Route to delete an attachment
+// @summary Delete an attachment
+// @description Deletes an attachment, its content being removed once no other attachment shares it
+// @tags Attachments
+// @accept json
+// @produce json
+// @Param id path int true "ID of the attachment"
+// @success 204 {} No Content
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /attachments/{id} [delete]
+func HandleDELETEAttachment(storage persistence.Storage, attachments Attachments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[DELETE] delete-attachment")
+
+		attachmentID, err := strconv.ParseInt(c.Param("attachmentID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid attachment id format")
+			return
+		}
+
+		l = l.With("attachmentID", attachmentID)
+
+		attachment, err := storage.RetrieveAttachmentByID(attachmentID)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find attachment")
+			return
+		}
+
+		if err == nil {
+			err = storage.DeleteAttachmentByID(attachmentID)
+		}
+
+		if err != nil {
+			l.Errorf("couldn't delete: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		releaseBlob(storage, attachments.Store, attachment.Hash, l)
+
+		l.Debug("attachment deleted")
+		c.Status(http.StatusNoContent)
+		return
+	}
+}
+
+// isBodyTooLarge tells whether reading a request body failed on the limit of http.MaxBytesReader, whose error has
+// no type of its own to check against
+func isBodyTooLarge(err error) bool {
+	return strings.Contains(err.Error(), "http: request body too large")
+}
+
+// releaseBlob deletes the content with the given hash once no attachment refers to it anymore
+func releaseBlob(storage persistence.Storage, store blob.Store, hash string, l *zap.SugaredLogger) {
+	err := storage.ReleaseAttachmentContent(hash, func() error {
+		return store.Delete(hash)
+	})
+
+	if err != nil {
+		l.Errorf("couldn't release attachment content %s: %s", hash, err.Error())
+	}
+}
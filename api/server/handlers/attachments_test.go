@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/models"
+	persistence "github.com/YAITS/api/persistence/mock"
+)
+
+// unreachableStorage fails to retrieve issues as a database gone away does
+type unreachableStorage struct {
+	persistence.Storage
+}
+
+func (storage *unreachableStorage) RetrieveIssueByID(_ int64) (models.IssueResponse, error) {
+	return models.IssueResponse{}, errors.New("connection refused")
+}
+
+func TestHandlePOSTAttachment(t *testing.T) {
+	attachments, removeStore := newAttachments(t)
+	defer removeStore()
+
+	t.Run("StorageError", func(t *testing.T) {
+		recorder := uploadAttachment(HandlePOSTAttachment(&unreachableStorage{}, attachments))
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+		exists, _ := attachments.Store.Exists(persistence.MockAttachment.Hash)
+		assert.False(t, exists, "no content is stored for an issue that couldn't be checked")
+	})
+}
+
+// newAttachments creates Attachments storing contents in a temporary directory the returned function removes
+func newAttachments(t *testing.T) (Attachments, func()) {
+	root, err := ioutil.TempDir("", "yaits-attachments")
+	if err != nil {
+		t.Fatalf("couldn't create blob root: %s", err)
+	}
+
+	store, err := blob.NewLocalStore(root)
+	if err != nil {
+		t.Fatalf("couldn't create blob store: %s", err)
+	}
+
+	return Attachments{Store: store, MaxSize: 64}, func() {
+		_ = os.RemoveAll(root)
+	}
+}
+
+// uploadAttachment uploads the description of the mock issue to a handler as a file attached to issue 1
+func uploadAttachment(handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "steps.txt")
+	_, _ = part.Write([]byte(persistence.Description))
+	_ = writer.Close()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("logger", zap.NewNop().Sugar())
+	})
+	router.POST("/issue/:issueID/attachments", handler)
+
+	req := httptest.NewRequest("POST", "/issue/1/attachments", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	return recorder
+}
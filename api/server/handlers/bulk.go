@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// Bulk operations
+const (
+	BulkSetStatus   = "setStatus"
+	BulkAssign      = "assign"
+	BulkSetPriority = "setPriority"
+	BulkAddLabel    = "addLabel"
+	BulkAddComment  = "addComment"
+	BulkDelete      = "delete"
+)
+
+const (
+	// bulkBatchSize is the number of issues changed per transaction, all-or-nothing operations using a single one
+	bulkBatchSize = 100
+
+	// maxBulkIssues bounds the issues a bulk operation selects, and maxSyncBulkIssues those it changes within the
+	// request, larger selections running in the background
+	maxBulkIssues     = 10000
+	maxSyncBulkIssues = 1000
+
+	// bulkSearchPage is the number of issues a bulk filter retrieves per search
+	bulkSearchPage = 500
+
+	maxLabelLength = 64
+)
+
+//HandlePOSTBulk - Route to apply an operation to several issues
+// @summary Change several issues at once
+// @description Applies an operation to the issues of a list of ids or matching a filter: setStatus, assign (an empty
+// @description assignee unassigning the issues), setPriority, addLabel (added to the labels custom field),
+// @description addComment or delete. Issues are changed in batches, each issue failing on its own unless allOrNothing
+// @description is set, and the result of every issue is returned. Async operations, required above 1000 issues, run
+// @description in the background as a job whose progress is retrieved from /issues/bulk/{jobID}
+// @tags Update
+// @accept json
+// @produce json
+// @Param request body models.BulkIssueRequest true "Bulk operation"
+// @success 200 {object} models.BulkJob
+// @success 202 {object} models.BulkJob
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issues/bulk [post]
+func HandlePOSTBulk(storage persistence.Storage, attachments Attachments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] bulk-issues")
+
+		var req models.BulkIssueRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			setBindingError(c, err)
+			return
+		}
+
+		change, err := bulkChange(req, currentUser(c))
+		if err != nil {
+			setValidationError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		issueIDs, err := bulkSelection(storage, req)
+		if _, ok := err.(validation.Errors); ok {
+			setValidationError(c, http.StatusBadRequest, err)
+			return
+		}
+		if err != nil {
+			l.Errorf("error searching issues in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !req.Async && len(issueIDs) > maxSyncBulkIssues {
+			setValidationError(c, http.StatusBadRequest, validation.Errors{{Field: "async", Code: validation.CodeRequired,
+				Message: fmt.Sprintf("%d issues selected, operations on more than %d issues must be async",
+					len(issueIDs), maxSyncBulkIssues)}})
+			return
+		}
+
+		job := models.BulkJob{
+			Operation:    req.Operation,
+			Status:       persistence.BulkJobQueued,
+			CreatedBy:    currentUser(c),
+			AllOrNothing: req.AllOrNothing,
+			Total:        int64(len(issueIDs)),
+			Results:      make([]models.BulkItemResult, 0, len(issueIDs)),
+		}
+
+		l = l.With("operation", req.Operation, "issues", len(issueIDs), "async", req.Async)
+		l.Debug("received bulk operation")
+
+		if !req.Async {
+			job = runBulk(storage, attachments, l, job, change, issueIDs, nil)
+			c.JSON(http.StatusOK, job)
+			return
+		}
+
+		job.ID, err = storage.CreateBulkJob(job)
+		if err != nil {
+			l.Errorf("couldn't record bulk job: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l = l.With("job", job.ID)
+		go runBulkJob(storage, attachments, l, job, change, issueIDs)
+
+		l.Debug("bulk job started")
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+}
+
+//HandleGETBulkJob - Route to retrieve the progress of a bulk operation
+// @summary Retrieves a bulk job
+// @description Retrieves the status and the progress of an async bulk operation along with the result of every issue
+// @description processed so far
+// @tags Update
+// @accept json
+// @produce json
+// @Param jobID path int true "ID of the bulk job"
+// @success 200 {object} models.BulkJob
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issues/bulk/{jobID} [get]
+func HandleGETBulkJob(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-bulk-job")
+
+		jobID, err := strconv.ParseInt(c.Param("jobID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid bulk job id format")
+			return
+		}
+
+		job, err := storage.RetrieveBulkJob(jobID)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "bulk job not found")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("error retrieving bulk job in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("bulk job successfully retrieved")
+		c.JSON(http.StatusOK, job)
+		return
+	}
+}
+
+// bulkChange validates the argument of a bulk operation, returning the change it makes to every issue along with the
+// events recorded for them
+func bulkChange(req models.BulkIssueRequest, actor string) (models.BulkChange, error) {
+	var change models.BulkChange
+	var errs validation.Errors
+
+	switch req.Operation {
+	case BulkSetStatus:
+		errs.OneOf("status", req.Status, validation.Statuses)
+		change.Update.Status = req.Status
+	case BulkAssign:
+		errs.MaxLength("assignee", req.Assignee, validation.MaxUserLength)
+		change.Update.Assignee = req.Assignee
+		if req.Assignee == "" {
+			change.Update.Changed = []string{"assignee"}
+		}
+	case BulkSetPriority:
+		errs.Range("priority", req.Priority, validation.MinPriority, validation.MaxPriority)
+		change.Update.Priority = req.Priority
+	case BulkAddLabel:
+		label := strings.TrimSpace(req.Label)
+		errs.Required("label", label)
+		errs.MaxLength("label", label, maxLabelLength)
+		if strings.Contains(label, ",") {
+			errs.Add("label", validation.CodeInvalid, "label can't contain commas")
+		}
+		change.Label = label
+		change.Events = []models.IssueEvent{{Event: events.IssueUpdated, Actor: actor,
+			Changes: "label " + label + " added", Changed: []string{persistence.LabelsField}}}
+		return change, errs.Err()
+	case BulkAddComment:
+		if err := validation.Comment(req.Comment); err != nil {
+			return change, err
+		}
+		change.Update.Comment = req.Comment
+	case BulkDelete:
+		change.Delete = true
+		change.Events = []models.IssueEvent{{Event: events.IssueDeleted, Actor: actor}}
+		return change, nil
+	default:
+		errs.OneOf("operation", req.Operation, []string{BulkSetStatus, BulkAssign, BulkSetPriority, BulkAddLabel,
+			BulkAddComment, BulkDelete})
+	}
+	if len(errs) > 0 {
+		return change, errs
+	}
+
+	if changes := describeUpdate(change.Update); changes != "" {
+		change.Events = append(change.Events, models.IssueEvent{Event: events.IssueUpdated, Actor: actor,
+			Changes: changes, Changed: changedFields(change.Update)})
+	}
+	if change.Update.Comment != "" {
+		change.Events = append(change.Events, models.IssueEvent{Event: events.IssueCommented, Actor: actor,
+			Comment: change.Update.Comment})
+	}
+
+	return change, nil
+}
+
+// bulkSelection returns the ids of the issues a bulk operation applies to, in order and without duplicates, the
+// violations of the request when the selection is invalid
+func bulkSelection(storage persistence.Storage, req models.BulkIssueRequest) ([]int64, error) {
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		return nil, validation.Errors{{Field: "ids", Code: validation.CodeRequired,
+			Message: "either ids or a filter is required"}}
+	}
+
+	issueIDs := make([]int64, 0, len(req.IDs))
+	if req.Filter == nil {
+		seen := make(map[int64]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			if !seen[id] {
+				seen[id] = true
+				issueIDs = append(issueIDs, id)
+			}
+		}
+	} else {
+		filter := *req.Filter
+		if filter == (models.BulkIssueFilter{}) {
+			return nil, validation.Errors{{Field: "filter", Code: validation.CodeRequired,
+				Message: "the filter needs at least one criterion"}}
+		}
+
+		search := models.IssueSearchQuery{
+			IssueFilterQueryParam: models.IssueFilterQueryParam{Project: filter.Project, Type: filter.Type},
+			Status:                filter.Status,
+			Assignee:              filter.Assignee,
+			Reporter:              filter.Reporter,
+			PriorityStart:         filter.PriorityStart,
+			PriorityEnd:           filter.PriorityEnd,
+			Limit:                 bulkSearchPage,
+		}
+		for len(issueIDs) <= maxBulkIssues {
+			issues, err := storage.SearchIssues(search)
+			if err != nil {
+				return nil, err
+			}
+			for _, issue := range issues {
+				issueIDs = append(issueIDs, issue.ID)
+			}
+			if int64(len(issues)) < search.Limit {
+				break
+			}
+			search.Offset += search.Limit
+		}
+	}
+
+	if len(issueIDs) > maxBulkIssues {
+		return nil, validation.Errors{{Code: validation.CodeOutOfRange,
+			Message: fmt.Sprintf("bulk operations apply to %d issues at most", maxBulkIssues)}}
+	}
+
+	return issueIDs, nil
+}
+
+// runBulkJob runs an async bulk job, recording its progress. A panic fails the job, with the progress made so far,
+// instead of taking the server down
+func runBulkJob(storage persistence.Storage, attachments Attachments, l *zap.SugaredLogger, job models.BulkJob,
+	change models.BulkChange, issueIDs []int64) {
+	progress := func(update models.BulkJob) {
+		job = update
+		if err := storage.UpdateBulkJob(job); err != nil {
+			l.Errorf("couldn't record bulk progress: %s", err.Error())
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			l.Errorf("bulk job failed on a panic: %v", r)
+			job.Status = persistence.BulkJobFailed
+			progress(job)
+		}
+	}()
+
+	runBulk(storage, attachments, l, job, change, issueIDs, progress)
+}
+
+// runBulk applies a bulk change batch by batch, calling progress with the job after every batch when given. The issues
+// assigned are watched by their assignee and the attachments of the issues deleted are released once committed. The
+// job fails when none of its issues could be changed
+func runBulk(storage persistence.Storage, attachments Attachments, l *zap.SugaredLogger, job models.BulkJob,
+	change models.BulkChange, issueIDs []int64, progress func(models.BulkJob)) models.BulkJob {
+	job.Status = persistence.BulkJobRunning
+	if progress != nil {
+		progress(job)
+	}
+
+	batchSize := bulkBatchSize
+	if job.AllOrNothing {
+		batchSize = len(issueIDs)
+	}
+
+	for start := 0; start < len(issueIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(issueIDs) {
+			end = len(issueIDs)
+		}
+		batch := issueIDs[start:end]
+
+		// attachment records go away with the issues, so their contents are released afterwards
+		issueAttachments := make(map[int64][]models.Attachment)
+		var err error
+		if change.Delete {
+			for _, id := range batch {
+				if issueAttachments[id], err = storage.RetrieveAttachments(id); err != nil {
+					break
+				}
+			}
+		}
+
+		var results []models.BulkItemResult
+		if err == nil {
+			results, err = storage.BulkChangeIssues(change, batch, job.AllOrNothing)
+		}
+		if err != nil {
+			l.Errorf("couldn't change issues %d to %d: %s", batch[0], batch[len(batch)-1], err.Error())
+			results = make([]models.BulkItemResult, 0, len(batch))
+			for _, id := range batch {
+				results = append(results, models.BulkItemResult{IssueID: id, Error: err.Error()})
+			}
+		}
+
+		for _, result := range results {
+			if !result.Success {
+				job.Failed++
+				continue
+			}
+			job.Succeeded++
+
+			watchIssue(storage, l, result.IssueID, change.Update.Assignee)
+			for _, attachment := range issueAttachments[result.IssueID] {
+				releaseBlob(storage, attachments.Store, attachment.Hash, l)
+			}
+		}
+
+		job.Results = append(job.Results, results...)
+		job.Processed += int64(len(batch))
+		if progress != nil {
+			progress(job)
+		}
+	}
+
+	job.Status = persistence.BulkJobCompleted
+	if job.Succeeded == 0 && job.Failed > 0 {
+		job.Status = persistence.BulkJobFailed
+	}
+	if progress != nil {
+		progress(job)
+	}
+
+	l.Infof("bulk %s %s: %d issues changed, %d failed", job.Operation, job.Status, job.Succeeded, job.Failed)
+	return job
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	persistence "github.com/YAITS/api/persistence/mock"
+)
+
+// panickingStorage panics on the second batch of a bulk change, recording the updates of the job
+type panickingStorage struct {
+	persistence.Storage
+	batches int
+	jobs    []models.BulkJob
+}
+
+func (storage *panickingStorage) BulkChangeIssues(_ models.BulkChange, issueIDs []int64,
+	_ bool) ([]models.BulkItemResult, error) {
+	storage.batches++
+	if storage.batches > 1 {
+		panic("lost connection")
+	}
+
+	results := make([]models.BulkItemResult, 0, len(issueIDs))
+	for _, id := range issueIDs {
+		results = append(results, models.BulkItemResult{IssueID: id, Success: true})
+	}
+	return results, nil
+}
+
+func (storage *panickingStorage) UpdateBulkJob(job models.BulkJob) error {
+	storage.jobs = append(storage.jobs, job)
+	return nil
+}
+
+func TestRunBulkJob(t *testing.T) {
+	storage := &panickingStorage{}
+	issueIDs := make([]int64, bulkBatchSize+1)
+	for i := range issueIDs {
+		issueIDs[i] = int64(i + 1)
+	}
+
+	job := models.BulkJob{ID: 1, Operation: BulkSetPriority, Status: "queued", Total: int64(len(issueIDs))}
+	change := models.BulkChange{Update: models.UpdateIssueRequest{Priority: 2}}
+	assert.NotPanics(t, func() {
+		runBulkJob(storage, Attachments{}, zap.NewNop().Sugar(), job, change, issueIDs)
+	})
+
+	if assert.Len(t, storage.jobs, 3) {
+		failed := storage.jobs[2]
+		assert.Equal(t, "failed", failed.Status, "a panic fails the job")
+		assert.Equal(t, int64(bulkBatchSize), failed.Processed, "the progress made is kept")
+		assert.Equal(t, int64(bulkBatchSize), failed.Succeeded)
+	}
+}
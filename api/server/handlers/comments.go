@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/validation"
+)
+
+//HandlePOSTComment - Route to comment on an issue
+// @summary Comment on an issue
+// @description Adds a comment to an issue, notifying its watchers and the subscribed webhooks. Comments are posted
+// @description apart from updates, patches of the issue holding its fields only
+// @tags Update
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @param commentRequest body models.NewCommentRequest true "YAITS comment request"
+// @success 201 {object} models.IssueResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 422 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/comments [post]
+func HandlePOSTComment(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] create-comment")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		var req models.NewCommentRequest
+		err = c.ShouldBindJSON(&req)
+
+		l = l.With("request", req, "issueID", issueID)
+		l.Debug("received comment creation request")
+
+		if err != nil {
+			l.Errorf("couldn't bind to comment request: %s", err.Error())
+			setBindingError(c, err)
+			return
+		}
+		if err = validation.Comment(req.Comment); err != nil {
+			setValidationError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		issue, err := storage.UpdateIssue(models.UpdateIssueRequest{Comment: req.Comment}, issueID,
+			models.IssueEvent{Event: events.IssueCommented, Actor: currentUser(c), Comment: req.Comment})
+		if err == nil {
+			err = applyIssueSLA(storage, issue)
+		}
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find issue")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("couldn't insert into db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("insertion successful")
+		c.Header("ETag", issueETag(issue.Version))
+		c.JSON(http.StatusCreated, issue)
+		return
+	}
+}
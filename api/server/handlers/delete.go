@@ -14,7 +14,7 @@ import (
 
 //HandleDELETE - Route to delete an issue
 // @summary Delete an issue
-// @description Deletes an issue given an issue id
+// @description Deletes an issue given an issue id, along with its attachments, notifying its watchers and the subscribed webhooks
 // @tags Deletion
 // @accept json
 // @produce json
@@ -24,7 +24,7 @@ import (
 // @failure 404 {object} models.ErrorWrapper
 // @failure 500 {object} models.ErrorWrapper
 // @router /issue/{id} [delete]
-func HandleDELETE(storage persistence.Storage) gin.HandlerFunc {
+func HandleDELETE(storage persistence.Storage, attachments Attachments) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[DELETE] delete-issue")
 
@@ -38,16 +38,12 @@ func HandleDELETE(storage persistence.Storage) gin.HandlerFunc {
 		l = l.With( "issueID", issueID)
 		l.Debug("received issue deletion request")
 
-		err = storage.DeleteIssueByID(issueID)
-
-		if err != nil {
-			l.Errorf("couldn't update: %s", err.Error())
-			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+		if err = DeleteIssue(storage, attachments, l, currentUser(c), issueID); err != nil {
+			setIssueError(c, err)
 			return
 		}
 
-		l.Debug("issue deleted")
-		c.JSON(http.StatusNoContent,  "issue deleted")
+		c.Status(http.StatusNoContent)
 		return
 	}
 }
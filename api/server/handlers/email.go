@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+//HandleGETEmailPreferences - Route to retrieve the email preferences of the current user
+// @summary Retrieves my email preferences
+// @description Retrieves the email notifications wanted by the user named by the X-YAITS-User header, every kind
+// @description being wanted until an address is set
+// @tags Notifications
+// @accept json
+// @produce json
+// @success 200 {object} models.EmailPreferences
+// @failure 401 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /me/email-preferences [get]
+func HandleGETEmailPreferences(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-email-preferences")
+
+		user := currentUser(c)
+		if user == "" {
+			models.SetErrorStatusJSON(c, http.StatusUnauthorized, "no current user")
+			return
+		}
+
+		preferences, err := storage.RetrieveEmailPreferences(user)
+
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusOK, defaultEmailPreferences(user))
+			return
+		}
+
+		if err != nil {
+			l.Errorf("error retrieving email preferences in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, preferences)
+		return
+	}
+}
+
+//HandlePUTEmailPreferences - Route to set the email preferences of the current user
+// @summary Sets my email preferences
+// @description Sets the address and the email notifications wanted by the user named by the X-YAITS-User header,
+// @description digest batching them into periodic emails. An empty address turns emails off
+// @tags Notifications
+// @accept json
+// @produce json
+// @param preferencesRequest body models.EmailPreferencesRequest true "YAITS email preferences"
+// @success 200 {object} models.EmailPreferences
+// @failure 400 {object} models.ErrorWrapper
+// @failure 401 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /me/email-preferences [put]
+func HandlePUTEmailPreferences(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[PUT] set-email-preferences")
+
+		user := currentUser(c)
+		if user == "" {
+			models.SetErrorStatusJSON(c, http.StatusUnauthorized, "no current user")
+			return
+		}
+
+		var req models.EmailPreferencesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			l.Errorf("couldn't bind to email preferences request: %s", err.Error())
+			setBindingError(c, err)
+			return
+		}
+
+		token := make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		preferences := models.EmailPreferences{
+			User:             user,
+			Email:            req.Email,
+			Assigned:         req.Assigned,
+			StatusChanged:    req.StatusChanged,
+			Commented:        req.Commented,
+			Mentioned:        req.Mentioned,
+			Digest:           req.Digest,
+			UnsubscribeToken: hex.EncodeToString(token),
+		}
+
+		if err := storage.SaveEmailPreferences(preferences); err != nil {
+			l.Errorf("couldn't save email preferences: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("email preferences saved")
+		c.JSON(http.StatusOK, preferences)
+		return
+	}
+}
+
+//HandleGETUnsubscribe - Route to unsubscribe from email notifications
+// @summary Unsubscribe from emails
+// @description Turns off every email notification of the user owning the token, as linked from every email
+// @tags Notifications
+// @produce plain
+// @Param token query string true "Unsubscribe token"
+// @success 200 {string} string
+// @failure 404 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /unsubscribe [get]
+func HandleGETUnsubscribe(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] unsubscribe")
+
+		token := c.Query("token")
+		if token == "" {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "unknown unsubscribe token")
+			return
+		}
+
+		user, err := storage.UnsubscribeEmails(token)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "unknown unsubscribe token")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("couldn't unsubscribe: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debugw("unsubscribed from emails", "user", user)
+		c.String(http.StatusOK, "%s will no longer receive YAITS emails.", user)
+		return
+	}
+}
+
+func defaultEmailPreferences(user string) models.EmailPreferences {
+	return models.EmailPreferences{User: user, Assigned: true, StatusChanged: true, Commented: true, Mentioned: true}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// issueETag returns the entity tag of a version of an issue, the version changing on every update
+func issueETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// matchETag tells whether an If-Match or If-None-Match header lists the entity tag of a version of an issue, * matching
+// any version. If-Match compares tags strongly, weak tags never matching, and If-None-Match weakly
+func matchETag(header string, version int64, weak bool) bool {
+	etag := issueETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
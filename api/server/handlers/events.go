@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// keepAliveInterval is how often an idle event stream sends a comment so that proxies keep the connection open
+var keepAliveInterval = 30 * time.Second
+
+//HandleGETEvents - Route to stream the events of every issue
+// @summary Streams issue events
+// @description Streams the issue.created, issue.updated, issue.deleted and issue.commented events as server-sent
+// @description events. Reconnecting with the Last-Event-ID header (or lastEventID query parameter) replays the recent
+// @description events that were missed
+// @tags Events
+// @produce text/event-stream
+// @Param Last-Event-ID header int false "ID of the last event received"
+// @Param lastEventID query int false "ID of the last event received, for clients unable to set headers"
+// @success 200 {object} models.IssueEvent
+// @failure 400 {object} models.ErrorWrapper
+// @router /events [get]
+func HandleGETEvents(hub *events.Hub) gin.HandlerFunc {
+	return streamEvents(hub)
+}
+
+//HandleGETIssueEvents - Route to stream the events of an issue
+// @summary Streams the events of an issue
+// @description Streams the events of an issue as server-sent events, resuming like the stream of every issue
+// @tags Events
+// @produce text/event-stream
+// @Param id path int true "ID of the issue"
+// @Param Last-Event-ID header int false "ID of the last event received"
+// @Param lastEventID query int false "ID of the last event received, for clients unable to set headers"
+// @success 200 {object} models.IssueEvent
+// @failure 400 {object} models.ErrorWrapper
+// @router /issue/{id}/events [get]
+func HandleGETIssueEvents(hub *events.Hub) gin.HandlerFunc {
+	return streamEvents(hub)
+}
+
+// streamEvents streams the events of the issue in the path, or of every issue when there is none
+func streamEvents(hub *events.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] stream-events")
+
+		var issueID int64
+		var err error
+		if param := c.Param("issueID"); param != "" {
+			issueID, err = strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+				return
+			}
+		}
+
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("lastEventID")
+		}
+
+		var lastID int64
+		if lastEventID != "" {
+			lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+			if err != nil {
+				models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid last event id format")
+				return
+			}
+		}
+
+		l = l.With("issueID", issueID, "lastEventID", lastID)
+		l.Debug("event stream opened")
+
+		subscription := hub.Subscribe(issueID, lastID)
+		defer hub.Unsubscribe(subscription)
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		// send the headers right away so that clients know the stream is open before the first event
+		c.Writer.Flush()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case event, ok := <-subscription.Events:
+				if !ok {
+					l.Debug("event stream lagged behind, closing")
+					return false
+				}
+
+				c.Render(-1, sse.Event{Id: strconv.FormatInt(event.ID, 10), Event: event.Event, Data: event})
+				return true
+			case <-keepAlive.C:
+				_, err := io.WriteString(w, ": keep-alive\n\n")
+				return err == nil
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+
+		l.Debug("event stream closed")
+		return
+	}
+}
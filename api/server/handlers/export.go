@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/export"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+//HandleGETExport - Route to export issues to a file
+// @summary Export issues
+// @description Exports the issues matching the filters of the listing endpoints to a csv, ndjson or xlsx file,
+// @description streamed as issues are read from the database
+// @tags Export
+// @produce text/csv
+// @produce application/x-ndjson
+// @produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @param format query string false "csv (the default), ndjson or xlsx"
+// @param project query string false "project key"
+// @param type query string false "issue type"
+// @param status query string false "status"
+// @param start query int false "lowest priority"
+// @param end query int false "highest priority"
+// @param columns query string false "comma separated columns to export, such as id,summary,status"
+// @param comments query bool false "export the comments of the issues"
+// @success 200 {file} file
+// @failure 400 {object} models.ErrorWrapper
+// @router /issues/export [get]
+func HandleGETExport(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] export")
+
+		var query models.ExportQueryParam
+		if err := c.ShouldBindQuery(&query); err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if query.Format == "" {
+			query.Format = export.FormatCSV
+		}
+
+		columns, err := export.ParseColumns(query.Columns, query.Comments)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if query.Format != export.FormatCSV && query.Format != export.FormatNDJSON && query.Format != export.FormatXLSX {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, export.ErrUnknownFormat.Error())
+			return
+		}
+
+		l = l.With("query", query)
+		l.Debug("received export")
+
+		filename := fmt.Sprintf("issues-%s.%s", time.Now().UTC().Format("20060102-150405"), query.Format)
+		c.Header("Content-Type", export.ContentType(query.Format))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Status(http.StatusOK)
+
+		var count int64
+		writer, err := export.NewWriter(query.Format, c.Writer, columns)
+		if err == nil {
+			err = storage.ExportIssues(query, func(issue models.IssueResponse) error {
+				count++
+				return writer.Write(issue)
+			})
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+
+		if err != nil {
+			l.Errorf("couldn't export issues: %s", err.Error())
+
+			// the status is sent along with the first rows, an export failing midway being cut short
+			if !c.Writer.Written() {
+				c.Writer.Header().Del("Content-Type")
+				c.Writer.Header().Del("Content-Disposition")
+				models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+			c.Abort()
+			return
+		}
+
+		l.Debugf("%d issues exported", count)
+		return
+	}
+}
@@ -13,10 +13,12 @@ import (
 
 //HandleGETAllIssues - Route to retrieve all issues
 // @summary Retrieves all existing issues
-// @description Retrieves all issues
+// @description Retrieves all issues, optionally filtered by project and issue type
 // @tags Retrieval
 // @accept json
 // @produce json
+// @param project query string false "project key"
+// @param type query string false "issue type"
 // @success 200 {object} models.IssueResponse
 // @failure 400 {object} models.ErrorWrapper
 // @failure 404 {object} models.ErrorWrapper
@@ -26,7 +28,17 @@ func HandleGETAllIssues(storage persistence.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-all-issues")
 
-		issuesResponse, err := storage.RetrieveIssues()
+		var filterQuery models.IssueFilterQueryParam
+		err := c.ShouldBindQuery(&filterQuery)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "could not filter issues")
+			return
+		}
+
+		issuesResponse, err := storage.RetrieveIssues(filterQuery)
+		if err == nil {
+			err = applySLA(storage, issuesResponse)
+		}
 
 		if err == sql.ErrNoRows {
 			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find issue")
@@ -52,7 +64,9 @@ func HandleGETAllIssues(storage persistence.Storage) gin.HandlerFunc {
 // @accept json
 // @produce json
 // @Param id path int true "ID of the issue"
+// @param If-None-Match header string false "ETag of the version of the issue already retrieved"
 // @success 200 {object} models.IssueResponse
+// @success 304 "the issue is still at the version of If-None-Match"
 // @failure 400 {object} models.ErrorWrapper
 // @failure 404 {object} models.ErrorWrapper
 // @failure 500 {object} models.ErrorWrapper
@@ -68,6 +82,9 @@ func HandleGETByID(storage persistence.Storage) gin.HandlerFunc {
 		}
 
 		issueResponse, err := storage.RetrieveIssueByID(issueID)
+		if err == nil {
+			err = applyIssueSLA(storage, &issueResponse)
+		}
 
 		if err == sql.ErrNoRows {
 			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find issue")
@@ -80,6 +97,13 @@ func HandleGETByID(storage persistence.Storage) gin.HandlerFunc {
 			return
 		}
 
+		c.Header("ETag", issueETag(issueResponse.Version))
+		c.Header("Accept-Patch", acceptPatch)
+		if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && matchETag(ifNoneMatch, issueResponse.Version, true) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
 		l.Debug("issue successfully retrieved")
 		c.JSON(200, issueResponse)
 		return
@@ -93,6 +117,8 @@ func HandleGETByID(storage persistence.Storage) gin.HandlerFunc {
 // @accept json
 // @produce json
 // @param status query models.StatusQueryParam false "issue priority request"
+// @param project query string false "project key"
+// @param type query string false "issue type"
 // @success 200 {object} models.IssueResponse
 // @failure 400 {object} models.ErrorWrapper
 // @failure 404 {object} models.ErrorWrapper
@@ -103,13 +129,20 @@ func HandleGETByStatus(storage persistence.Storage) gin.HandlerFunc {
 		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-issue-by-status")
 
 		var statusQuery models.StatusQueryParam
+		var filterQuery models.IssueFilterQueryParam
 		err := c.ShouldBindQuery(&statusQuery)
+		if err == nil {
+			err = c.ShouldBindQuery(&filterQuery)
+		}
 		if err != nil {
 			models.SetErrorStatusJSON(c, http.StatusBadRequest, "could not filter by status")
 			return
 		}
 
-		issueResponse, err := storage.RetrieveIssueByStatus(statusQuery.Status)
+		issueResponse, err := storage.RetrieveIssueByStatus(statusQuery.Status, filterQuery)
+		if err == nil {
+			err = applySLA(storage, issueResponse)
+		}
 
 		if err == sql.ErrNoRows {
 			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find issue")
@@ -136,6 +169,8 @@ func HandleGETByStatus(storage persistence.Storage) gin.HandlerFunc {
 // @produce json
 // @param start query models.PriorityQueryParam false "priority start bound"
 // @param end query models.PriorityQueryParam false "priority end bound"
+// @param project query string false "project key"
+// @param type query string false "issue type"
 // @success 200 {object} models.IssueResponse
 // @failure 400 {object} models.ErrorWrapper
 // @failure 500 {object} models.ErrorWrapper
@@ -145,13 +180,20 @@ func HandleGETByPriority(storage persistence.Storage) gin.HandlerFunc {
 		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-issue-by-priority")
 
 		var priorityQuery models.PriorityQueryParam
+		var filterQuery models.IssueFilterQueryParam
 		err := c.ShouldBindQuery(&priorityQuery)
+		if err == nil {
+			err = c.ShouldBindQuery(&filterQuery)
+		}
 		if err != nil {
 			models.SetErrorStatusJSON(c, http.StatusBadRequest, "could not filter by priority")
 			return
 		}
 
-		issueResponse, err := storage.RetrieveIssueByPriority(priorityQuery.PriorityStart, priorityQuery.PriorityEnd)
+		issueResponse, err := storage.RetrieveIssueByPriority(priorityQuery.PriorityStart, priorityQuery.PriorityEnd, filterQuery)
+		if err == nil {
+			err = applySLA(storage, issueResponse)
+		}
 
 		if err != nil {
 			l.Errorf("error retrieving issue in db: %s", err.Error())
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// maxGraphQLComplexity bounds the estimated cost of a GraphQL query, see queryComplexity
+const maxGraphQLComplexity = 5000
+
+// defaultGraphQLPage is the number of issues or comments a list field returns when not given a first argument, and
+// maxGraphQLPage the most it returns
+const (
+	defaultGraphQLPage = 20
+	maxGraphQLPage     = 100
+)
+
+// graphQLStateKey is the context key of the per-request graphQLState
+type graphQLStateKey struct{}
+
+// graphQLState is what the resolvers of a GraphQL request share: the requesting user, the request logger and the
+// loaders batching the lookups of the query
+type graphQLState struct {
+	user string
+	l    *zap.SugaredLogger
+
+	comments *loader
+	fields   *loader
+	users    *loader
+	projects *loader
+}
+
+//HandlePOSTGraphQL - Route to query and update issues with GraphQL
+// @summary GraphQL endpoint
+// @description Runs a GraphQL query or mutation over issues, comments, users and projects. Mutations mirror the creation,
+// @description update and deletion routes. Queries whose estimated cost exceeds a complexity limit are rejected, list
+// @description fields multiplying the cost of their selections by the number of items they may return
+// @tags GraphQL
+// @accept json
+// @produce json
+// @param graphQLRequest body models.GraphQLRequest true "GraphQL query and variables"
+// @success 200 {object} models.GraphQLResponse
+// @failure 400 {object} models.GraphQLResponse
+// @router /graphql [post]
+func HandlePOSTGraphQL(storage persistence.Storage, attachments Attachments) gin.HandlerFunc {
+	schema, err := newGraphQLSchema(storage, attachments)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] graphql")
+
+		var req models.GraphQLRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			setBindingError(c, err)
+			return
+		}
+
+		l = l.With("operation", req.OperationName)
+		l.Debug("received graphql request")
+
+		doc, err := parser.Parse(parser.ParseParams{
+			Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, graphQLResponse(&graphql.Result{Errors: gqlerrors.FormatErrors(err)}))
+			return
+		}
+
+		if validation := graphql.ValidateDocument(&schema, doc, nil); !validation.IsValid {
+			c.JSON(http.StatusBadRequest, graphQLResponse(&graphql.Result{Errors: validation.Errors}))
+			return
+		}
+
+		if complexity := queryComplexity(doc, req.OperationName, req.Variables); complexity > maxGraphQLComplexity {
+			l.Debugf("rejected query of complexity %d", complexity)
+			c.JSON(http.StatusBadRequest, models.GraphQLResponse{Errors: []models.GraphQLError{{
+				Message: fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, maxGraphQLComplexity),
+			}}})
+			return
+		}
+
+		state := newGraphQLState(storage, currentUser(c), l)
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       context.WithValue(c.Request.Context(), graphQLStateKey{}, state),
+		})
+
+		l.Debugf("graphql request executed with %d errors", len(result.Errors))
+		c.JSON(http.StatusOK, graphQLResponse(result))
+		return
+	}
+}
+
+func graphQLResponse(result *graphql.Result) models.GraphQLResponse {
+	resp := models.GraphQLResponse{Data: result.Data}
+	for _, formatted := range result.Errors {
+		graphQLError := models.GraphQLError{Message: formatted.Message, Path: formatted.Path}
+		for _, location := range formatted.Locations {
+			graphQLError.Locations = append(graphQLError.Locations,
+				models.GraphQLErrorLocation{Line: location.Line, Column: location.Column})
+		}
+		// the extensions are those of a RequestError
+		if errs, ok := formatted.Extensions["errors"].([]models.StandardError); ok {
+			graphQLError.Extensions = &models.GraphQLErrorExtensions{Errors: errs}
+		}
+		resp.Errors = append(resp.Errors, graphQLError)
+	}
+
+	return resp
+}
+
+func requestState(ctx context.Context) *graphQLState {
+	return ctx.Value(graphQLStateKey{}).(*graphQLState)
+}
+
+// loader batches the lookups of a GraphQL query the dataloader way: load queues a key and returns a thunk, which the
+// executor only calls once the sibling fields of the key were resolved, so the first thunk called fetches the keys
+// queued by all of them in a single query
+type loader struct {
+	fetch   func(keys []interface{}) (map[interface{}]interface{}, error)
+	queued  []interface{}
+	results map[interface{}]interface{}
+	errors  map[interface{}]error
+}
+
+func newLoader(fetch func(keys []interface{}) (map[interface{}]interface{}, error)) *loader {
+	return &loader{fetch: fetch, results: make(map[interface{}]interface{}), errors: make(map[interface{}]error)}
+}
+
+func (ld *loader) load(key interface{}) func() (interface{}, error) {
+	_, loaded := ld.results[key]
+	_, failed := ld.errors[key]
+	if !loaded && !failed {
+		queued := false
+		for _, k := range ld.queued {
+			if k == key {
+				queued = true
+				break
+			}
+		}
+		if !queued {
+			ld.queued = append(ld.queued, key)
+		}
+	}
+
+	return func() (interface{}, error) {
+		if len(ld.queued) > 0 {
+			keys := ld.queued
+			ld.queued = nil
+
+			results, err := ld.fetch(keys)
+			for _, k := range keys {
+				if err != nil {
+					ld.errors[k] = err
+				} else {
+					ld.results[k] = results[k]
+				}
+			}
+		}
+
+		return ld.results[key], ld.errors[key]
+	}
+}
+
+// queryComplexity estimates the cost of the operation of a query: a field costs 1 plus the cost of its selections,
+// which is multiplied for list fields by the number of items they may return
+func queryComplexity(doc *ast.Document, operationName string, variables map[string]interface{}) int {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	var operation *ast.OperationDefinition
+
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			name := ""
+			if definition.Name != nil {
+				name = definition.Name.Value
+			}
+			if operation == nil || name == operationName {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		return 0
+	}
+
+	return selectionComplexity(operation.SelectionSet, fragments, variables, make(map[string]bool))
+}
+
+func selectionComplexity(selectionSet *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition,
+	variables map[string]interface{}, spreading map[string]bool) int {
+	if selectionSet == nil {
+		return 0
+	}
+
+	complexity := 0
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			complexity += 1 + listSize(selection, variables)*
+				selectionComplexity(selection.SelectionSet, fragments, variables, spreading)
+		case *ast.InlineFragment:
+			complexity += selectionComplexity(selection.SelectionSet, fragments, variables, spreading)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			if fragment, ok := fragments[name]; ok && !spreading[name] {
+				spreading[name] = true
+				complexity += selectionComplexity(fragment.SelectionSet, fragments, variables, spreading)
+				delete(spreading, name)
+			}
+		}
+	}
+
+	return complexity
+}
+
+// listSize returns the number of items a field may return, 1 for fields other than lists
+func listSize(field *ast.Field, variables map[string]interface{}) int {
+	switch field.Name.Value {
+	case "issues", "comments":
+		first, ok := intArgument(field, "first", variables)
+		if !ok {
+			return defaultGraphQLPage
+		}
+		// out of range pages are rejected when resolved, clamping them only keeps the estimate from going negative or
+		// overflowing
+		if first < 1 {
+			return 1
+		}
+		if first > maxGraphQLPage {
+			return maxGraphQLPage
+		}
+		return first
+	case "users", "projects":
+		return maxGraphQLPage
+	default:
+		return 1
+	}
+}
+
+// intArgument returns the value of an integer argument of a field, whether given inline or as a variable
+func intArgument(field *ast.Field, name string, variables map[string]interface{}) (int, bool) {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != name {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			i, err := strconv.Atoi(value.Value)
+			return i, err == nil
+		case *ast.Variable:
+			switch v := variables[value.Name.Value].(type) {
+			case float64:
+				return int(v), true
+			case int:
+				return v, true
+			}
+		}
+	}
+
+	return 0, false
+}
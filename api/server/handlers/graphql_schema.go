@@ -0,0 +1,495 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/validation"
+)
+
+// graphQLField is a custom field of an issue, GraphQL having no map type
+type graphQLField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func newGraphQLState(storage persistence.Storage, user string, l *zap.SugaredLogger) *graphQLState {
+	return &graphQLState{
+		user: user,
+		l:    l,
+		comments: newLoader(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			comments, err := storage.RetrieveComments(int64Keys(keys)...)
+			results := make(map[interface{}]interface{}, len(keys))
+			for _, key := range keys {
+				issueComments := comments[key.(int64)]
+				if issueComments == nil {
+					issueComments = make([]models.Comment, 0)
+				}
+				results[key] = issueComments
+			}
+			return results, err
+		}),
+		fields: newLoader(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			fields, err := storage.RetrieveFields(int64Keys(keys)...)
+			results := make(map[interface{}]interface{}, len(keys))
+			for _, key := range keys {
+				results[key] = fieldList(fields[key.(int64)])
+			}
+			return results, err
+		}),
+		users: newLoader(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			users, err := storage.RetrieveUsers(stringKeys(keys)...)
+			results := make(map[interface{}]interface{}, len(users))
+			for _, user := range users {
+				results[user.Name] = user
+			}
+			return results, err
+		}),
+		projects: newLoader(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			projects, err := storage.RetrieveProjects(stringKeys(keys)...)
+			results := make(map[interface{}]interface{}, len(projects))
+			for _, project := range projects {
+				results[project.Key] = project
+			}
+			return results, err
+		}),
+	}
+}
+
+// newGraphQLSchema builds the GraphQL schema over issues, comments, users and projects
+func newGraphQLSchema(storage persistence.Storage, attachments Attachments) (graphql.Schema, error) {
+	commentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"comment": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	fieldType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Field",
+		Description: "A custom field of an issue",
+		Fields: graphql.Fields{
+			"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "User",
+		Description: "Someone issues are assigned to or reported by",
+		Fields: graphql.Fields{
+			"name":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"assigned": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Number of issues assigned"},
+			"reported": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Number of issues reported"},
+		},
+	})
+
+	projectType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Project",
+		Fields: graphql.Fields{
+			"key":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"issues":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Number of issues"},
+			"openIssues": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Number of issues not closed"},
+		},
+	})
+
+	issueType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Issue",
+		Fields: graphql.Fields{
+			"id":                &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"summary":           &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"status":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"priority":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"type":              &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"createDate":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"dueDate":           &graphql.Field{Type: graphql.String, Resolve: resolveDate},
+			"respondedDate":     &graphql.Field{Type: graphql.String, Resolve: resolveDate},
+			"resolvedDate":      &graphql.Field{Type: graphql.String, Resolve: resolveDate},
+			"originalEstimate":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "In minutes"},
+			"remainingEstimate": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "In minutes"},
+			"timeSpent":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "In minutes"},
+			"assignee": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, sourceIssue(p).Assignee)
+				},
+			},
+			"reporter": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, sourceIssue(p).Reporter)
+				},
+			},
+			"project": &graphql.Field{
+				Type: projectType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					issue := sourceIssue(p)
+					if issue.Project == "" {
+						return nil, nil
+					}
+					return requestState(p.Context).projects.load(issue.Project), nil
+				},
+			},
+			"fields": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(fieldType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					issue := sourceIssue(p)
+					if issue.Fields != nil {
+						return fieldList(issue.Fields), nil
+					}
+					return requestState(p.Context).fields.load(issue.ID), nil
+				},
+			},
+			"comments": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(commentType))),
+				Args: graphql.FieldConfigArgument{
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultGraphQLPage},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					first, offset, err := pageArguments(p)
+					if err != nil {
+						return nil, err
+					}
+
+					issue := sourceIssue(p)
+					if issue.Comments != nil {
+						return pageComments(issue.Comments, first, offset), nil
+					}
+
+					thunk := requestState(p.Context).comments.load(issue.ID)
+					return func() (interface{}, error) {
+						comments, err := thunk()
+						if err != nil {
+							return nil, err
+						}
+						return pageComments(comments.([]models.Comment), first, offset), nil
+					}, nil
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"issue": &graphql.Field{
+				Type: issueType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					issue, err := storage.RetrieveIssueByID(int64(p.Args["id"].(int)))
+					if err == sql.ErrNoRows {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return issue, nil
+				},
+			},
+			"issues": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(issueType))),
+				Description: "Issues in id order, filtered by the given arguments",
+				Args: graphql.FieldConfigArgument{
+					"project":       &graphql.ArgumentConfig{Type: graphql.String},
+					"type":          &graphql.ArgumentConfig{Type: graphql.String},
+					"status":        &graphql.ArgumentConfig{Type: graphql.String},
+					"assignee":      &graphql.ArgumentConfig{Type: graphql.String},
+					"reporter":      &graphql.ArgumentConfig{Type: graphql.String},
+					"priorityStart": &graphql.ArgumentConfig{Type: graphql.Int},
+					"priorityEnd":   &graphql.ArgumentConfig{Type: graphql.Int},
+					"first":         &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultGraphQLPage},
+					"offset":        &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					first, offset, err := pageArguments(p)
+					if err != nil {
+						return nil, err
+					}
+
+					search := models.IssueSearchQuery{Limit: int64(first), Offset: int64(offset)}
+					search.Project, _ = p.Args["project"].(string)
+					search.Type, _ = p.Args["type"].(string)
+					search.Status, _ = p.Args["status"].(string)
+					search.Assignee, _ = p.Args["assignee"].(string)
+					search.Reporter, _ = p.Args["reporter"].(string)
+					if priority, ok := p.Args["priorityStart"].(int); ok {
+						search.PriorityStart = int64(priority)
+					}
+					if priority, ok := p.Args["priorityEnd"].(int); ok {
+						search.PriorityEnd = int64(priority)
+					}
+
+					return storage.SearchIssues(search)
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, p.Args["name"].(string))
+				},
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return storage.RetrieveUsers()
+				},
+			},
+			"project": &graphql.Field{
+				Type: projectType,
+				Args: graphql.FieldConfigArgument{
+					"key": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return requestState(p.Context).projects.load(p.Args["key"].(string)), nil
+				},
+			},
+			"projects": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(projectType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return storage.RetrieveProjects()
+				},
+			},
+		},
+	})
+
+	fieldInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "FieldInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	createIssueInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateIssueInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"summary":          &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"priority":         &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"assignee":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"reporter":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"project":          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"type":             &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"fields":           &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(fieldInputType))},
+			"dueDate":          &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "RFC 3339 date"},
+			"originalEstimate": &graphql.InputObjectFieldConfig{Type: graphql.Int, Description: "In minutes"},
+		},
+	})
+
+	updateIssueInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdateIssueInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"summary":           &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"description":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"priority":          &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"assignee":          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"status":            &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"comment":           &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"dueDate":           &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "RFC 3339 date"},
+			"originalEstimate":  &graphql.InputObjectFieldConfig{Type: graphql.Int, Description: "In minutes"},
+			"remainingEstimate": &graphql.InputObjectFieldConfig{Type: graphql.Int, Description: "In minutes"},
+		},
+	})
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createIssue": &graphql.Field{
+				Type:        graphql.NewNonNull(issueType),
+				Description: "Creates an issue the way POST /issue does",
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createIssueInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return createIssue(storage, requestState(p.Context), p.Args["input"].(map[string]interface{}))
+				},
+			},
+			"updateIssue": &graphql.Field{
+				Type:        graphql.NewNonNull(issueType),
+				Description: "Updates an issue the way PATCH /issue/{id} does",
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateIssueInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return updateIssue(storage, requestState(p.Context), int64(p.Args["id"].(int)),
+						p.Args["input"].(map[string]interface{}))
+				},
+			},
+			"deleteIssue": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "Deletes an issue along with its attachments the way DELETE /issue/{id} does",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					state := requestState(p.Context)
+					issueID := int64(p.Args["id"].(int))
+					err := DeleteIssue(storage, attachments, state.l.With("mutation", "deleteIssue", "issueID", issueID),
+						state.user, issueID)
+					return err == nil, err
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+}
+
+// createIssue decodes the input of the createIssue mutation for CreateIssue
+func createIssue(storage persistence.Storage, state *graphQLState, input map[string]interface{}) (interface{}, error) {
+	var req models.NewIssueRequest
+	if err := decodeInput(input, &req); err != nil {
+		return nil, err
+	}
+	if fields, ok := input["fields"].([]interface{}); ok {
+		req.Fields = make(map[string]string, len(fields))
+		for _, field := range fields {
+			field := field.(map[string]interface{})
+			req.Fields[field["name"].(string)] = field["value"].(string)
+		}
+	}
+
+	issue, err := CreateIssue(storage, state.l.With("mutation", "createIssue"), state.user, req)
+	return issue, mutationError(err)
+}
+
+// updateIssue decodes the input of the updateIssue mutation for UpdateIssue
+func updateIssue(storage persistence.Storage, state *graphQLState, issueID int64,
+	input map[string]interface{}) (interface{}, error) {
+	var req models.UpdateIssueRequest
+	if err := decodeInput(input, &req); err != nil {
+		return nil, err
+	}
+
+	issue, err := UpdateIssue(storage, state.l.With("mutation", "updateIssue", "issueID", issueID), state.user, issueID,
+		req)
+	return issue, mutationError(err)
+}
+
+// mutationError turns the constraint a mutation violated into a RequestError, for the field in violation to be in
+// the extensions of the GraphQL error as it is for the violations found before writing
+func mutationError(err error) error {
+	if constraint, ok := persistence.AsConstraintError(err); ok {
+		return violations(validation.Errors{{Field: constraint.Field, Code: constraint.Reason,
+			Message: constraint.Message}})
+	}
+
+	return err
+}
+
+// decodeInput decodes a mutation input into the request of the matching route, custom fields being left out as they
+// are a list rather than a map
+func decodeInput(input map[string]interface{}, req interface{}) error {
+	values := make(map[string]interface{}, len(input))
+	for name, value := range input {
+		if name != "fields" {
+			values[name] = value
+		}
+	}
+
+	b, err := json.Marshal(values)
+	if err == nil {
+		err = json.Unmarshal(b, req)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid input: %s", err.Error())
+	}
+
+	return nil
+}
+
+func sourceIssue(p graphql.ResolveParams) models.IssueResponse {
+	return p.Source.(models.IssueResponse)
+}
+
+// resolveDate resolves the dates an issue may not have to null rather than the empty string
+func resolveDate(p graphql.ResolveParams) (interface{}, error) {
+	date, err := graphql.DefaultResolveFn(p)
+	if date == "" {
+		return nil, err
+	}
+
+	return date, err
+}
+
+func loadUser(p graphql.ResolveParams, name string) (interface{}, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	return requestState(p.Context).users.load(name), nil
+}
+
+func pageArguments(p graphql.ResolveParams) (int, int, error) {
+	first, _ := p.Args["first"].(int)
+	offset, _ := p.Args["offset"].(int)
+
+	if first < 1 || first > maxGraphQLPage {
+		return 0, 0, fmt.Errorf("first must be between 1 and %d", maxGraphQLPage)
+	}
+	if offset < 0 {
+		return 0, 0, errors.New("offset must not be negative")
+	}
+
+	return first, offset, nil
+}
+
+func pageComments(comments []models.Comment, first, offset int) []models.Comment {
+	if offset > len(comments) {
+		offset = len(comments)
+	}
+	comments = comments[offset:]
+	if first < len(comments) {
+		comments = comments[:first]
+	}
+
+	return comments
+}
+
+// fieldList turns custom fields into a list sorted by name
+func fieldList(fields map[string]string) []graphQLField {
+	list := make([]graphQLField, 0, len(fields))
+	for name, value := range fields {
+		list = append(list, graphQLField{Name: name, Value: value})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+func int64Keys(keys []interface{}) []int64 {
+	ids := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key.(int64))
+	}
+
+	return ids
+}
+
+func stringKeys(keys []interface{}) []string {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.(string))
+	}
+
+	return names
+}
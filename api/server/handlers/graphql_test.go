@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	persistence "github.com/YAITS/api/persistence/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStorage records the issues the comments of which are retrieved, one call per batch
+type countingStorage struct {
+	persistence.Storage
+	batches [][]int64
+}
+
+func (storage *countingStorage) SearchIssues(_ models.IssueSearchQuery) ([]models.IssueResponse, error) {
+	issues := make([]models.IssueResponse, 0)
+	for id := int64(1); id <= 3; id++ {
+		issue := persistence.MockIssueResponse
+		issue.ID = id
+		issue.Comments = nil
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+func (storage *countingStorage) RetrieveComments(issueIDs ...int64) (map[int64][]models.Comment, error) {
+	storage.batches = append(storage.batches, issueIDs)
+	return storage.Storage.RetrieveComments(issueIDs...)
+}
+
+func TestGraphQLBatching(t *testing.T) {
+	storage := &countingStorage{}
+	schema, err := newGraphQLSchema(storage, Attachments{})
+	if err != nil {
+		t.Fatalf("couldn't build the schema: %s", err)
+	}
+
+	state := newGraphQLState(storage, "", zap.NewNop().Sugar())
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ issues { id comments { comment } } }`,
+		Context:       context.WithValue(context.Background(), graphQLStateKey{}, state),
+	})
+
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, [][]int64{{1, 2, 3}}, storage.batches, "the comments of every issue are retrieved at once")
+	assert.Len(t, result.Data.(map[string]interface{})["issues"], 3)
+}
+
+func TestQueryComplexity(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		variables     map[string]interface{}
+		complexity    int
+	}{
+		{"Fields", `{ issue(id: 1) { id summary } }`, "", nil, 3},
+		{"DefaultPage", `{ issues { id comments { id } } }`, "", nil, 1 + 20*(1+1+20*1)},
+		{"First", `{ issues(first: 5) { id comments(first: 2) { id } } }`, "", nil, 1 + 5*(1+1+2*1)},
+		{"Variable", `query($n: Int) { issues(first: $n) { id } }`, "", map[string]interface{}{"n": float64(50)}, 1 + 50},
+		{"OutOfRange", `{ issues(first: 100000) { id } }`, "", nil, 1 + maxGraphQLPage},
+		{"Fragment", `{ issues(first: 2) { ...parts } } fragment parts on Issue { id summary }`, "", nil, 1 + 2*2},
+		{"Operation", `query a { users { name } } query b { issue(id: 1) { id } }`, "b", nil, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: test.query})
+			if err != nil {
+				t.Fatalf("couldn't parse the query: %s", err)
+			}
+
+			assert.Equal(t, test.complexity, queryComplexity(doc, test.operationName, test.variables))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/importer"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// defaultImportPriority is the priority of the imported issues when neither the file, the issue type nor the import
+// options give one
+const defaultImportPriority = 3
+
+//HandlePOSTImport - Route to import issues from a file
+// @summary Import issues
+// @description Imports the issues of a csv, ndjson, GitHub issues JSON or Jira csv export file. Options map the
+// @description columns of csv files to issue fields and the users, statuses and priorities of the file to those of
+// @description YAITS. A dry run reports what prevents each row from being imported; otherwise the valid rows are
+// @description imported in the background by a job whose progress is retrieved from /import/{jobID}
+// @tags Import
+// @accept multipart/form-data
+// @produce json
+// @Param file formData file true "file to import"
+// @Param options formData string true "JSON import options such as {\"format\":\"jira\",\"users\":{\"jdoe\":\"John Doe\"}}"
+// @Param dryRun query bool false "only validate the file"
+// @success 200 {object} models.ImportReport
+// @success 202 {object} models.ImportJob
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /import [post]
+func HandlePOSTImport(storage persistence.Storage, imports *importer.Importer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] import")
+
+		var options models.ImportOptions
+		if err := binding.JSON.BindBody([]byte(c.PostForm("options")), &options); err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid import options: "+err.Error())
+			return
+		}
+		if !importer.ValidFormat(options.Format) {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, importer.ErrUnknownFormat.Error())
+			return
+		}
+		if options.DefaultPriority < 0 || options.DefaultPriority > 10 {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "default priority is out of the 1 to 10 range")
+			return
+		}
+
+		dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "a file is required")
+			return
+		}
+
+		l = l.With("format", options.Format, "filename", fileHeader.Filename, "dryRun", dryRun)
+		l.Debug("received import")
+
+		f, err := fileHeader.Open()
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		issues, rowErrors, err := importer.Read(f, options)
+		f.Close()
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		issues, rowErrors, err = prepareImport(storage, issues, rowErrors, options, currentUser(c))
+		if err != nil {
+			l.Errorf("error retrieving issue types in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		total := int64(len(issues) + len(rowErrors))
+		if dryRun {
+			l.Debugf("dry run: %d of %d rows valid", len(issues), total)
+			c.JSON(http.StatusOK, models.ImportReport{Total: total, Valid: int64(len(issues)), Errors: rowErrors})
+			return
+		}
+
+		job, err := imports.Start(models.ImportJob{
+			Format:    options.Format,
+			CreatedBy: currentUser(c),
+			Total:     total,
+			Processed: int64(len(rowErrors)),
+			Failed:    int64(len(rowErrors)),
+			Errors:    rowErrors,
+		}, issues)
+		if err != nil {
+			l.Errorf("couldn't start import: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debugf("import job %d started", job.ID)
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+}
+
+//HandleGETImport - Route to retrieve the progress of an import
+// @summary Retrieves an import job
+// @description Retrieves the status and the progress of an import along with the rows that were not imported
+// @tags Import
+// @accept json
+// @produce json
+// @Param jobID path int true "ID of the import job"
+// @success 200 {object} models.ImportJob
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /import/{jobID} [get]
+func HandleGETImport(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-import")
+
+		jobID, err := strconv.ParseInt(c.Param("jobID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid import job id format")
+			return
+		}
+
+		job, err := storage.RetrieveImportJob(jobID)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "import job not found")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("error retrieving import job in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("import job successfully retrieved")
+		c.JSON(http.StatusOK, job)
+		return
+	}
+}
+
+// prepareImport completes the imported issues the way HandlePOST completes created ones: issue types apply their
+// defaults and required fields, the description defaults to the summary and the reporter to the importing user
+func prepareImport(storage persistence.Storage, issues []models.ImportIssue, rowErrors []models.ImportRowError,
+	options models.ImportOptions, user string) ([]models.ImportIssue, []models.ImportRowError, error) {
+	issueTypes := make(map[string]*models.IssueType)
+	prepared := make([]models.ImportIssue, 0, len(issues))
+
+	for _, issue := range issues {
+		if issue.Type != "" {
+			key := strings.ToLower(issue.Project + "/" + issue.Type)
+			issueType, ok := issueTypes[key]
+			if !ok {
+				retrieved, err := storage.RetrieveIssueType(issue.Project, issue.Type)
+				if err != nil && err != sql.ErrNoRows {
+					return nil, nil, err
+				}
+				if err == nil {
+					issueType = &retrieved
+				}
+				issueTypes[key] = issueType
+			}
+
+			if issueType == nil {
+				rowErrors = append(rowErrors, models.ImportRowError{Row: issue.Row,
+					Errors: []string{"unknown issue type " + issue.Type}})
+				continue
+			}
+
+			if missing := applyIssueType(&issue.NewIssueRequest, *issueType); len(missing) > 0 {
+				rowErrors = append(rowErrors, models.ImportRowError{Row: issue.Row,
+					Errors: []string{"missing required fields: " + strings.Join(missing, ", ")}})
+				continue
+			}
+		}
+
+		if issue.Description == "" {
+			issue.Description = issue.Summary
+		}
+		if issue.Priority == 0 {
+			issue.Priority = options.DefaultPriority
+		}
+		if issue.Priority == 0 {
+			issue.Priority = defaultImportPriority
+		}
+		if issue.Reporter == "" {
+			issue.Reporter = user
+		}
+
+		prepared = append(prepared, issue)
+	}
+
+	sort.Slice(rowErrors, func(i, j int) bool {
+		return rowErrors[i].Row < rowErrors[j].Row
+	})
+
+	return prepared, rowErrors, nil
+}
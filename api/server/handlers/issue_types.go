@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/validation"
+	"github.com/gin-gonic/gin"
+)
+
+//HandleGETIssueTypes - Route to retrieve the issue types of a project
+// @summary Retrieves issue types
+// @description Retrieves the issue types available to a project, including the shared ones
+// @tags Issue Types
+// @accept json
+// @produce json
+// @param project query string false "project key"
+// @success 200 {array} models.IssueType
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue-types [get]
+func HandleGETIssueTypes(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-issue-types")
+
+		issueTypes, err := storage.RetrieveIssueTypes(c.Query("project"))
+
+		if err != nil {
+			l.Errorf("error retrieving issue types in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("issue types successfully retrieved")
+		c.JSON(http.StatusOK, issueTypes)
+		return
+	}
+}
+
+//HandlePOSTIssueType - Route to configure an issue type
+// @summary Create an issue type
+// @description Configures a new issue type for a project, or for all projects when no project is given
+// @tags Issue Types
+// @accept json
+// @produce json
+// @param issueTypeRequest body models.NewIssueTypeRequest true "YAITS issue type creation request"
+// @success 201 {object} models.IssueIDResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue-types [post]
+func HandlePOSTIssueType(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] create-issue-type")
+
+		var req models.NewIssueTypeRequest
+		err := c.ShouldBindJSON(&req)
+
+		l = l.With("request", req)
+		l.Debug("received issue type creation request")
+
+		if err != nil {
+			l.Errorf("couldn't bind to issue type request: %s", err.Error())
+			setBindingError(c, err)
+			return
+		}
+
+		if req.Workflow == "" {
+			req.Workflow = defaultWorkflow
+		}
+		if req.DefaultPriority == 0 {
+			req.DefaultPriority = defaultPriority
+		}
+		if err = validation.NewIssueType(req); err != nil {
+			setValidationError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		id, err := storage.CreateIssueType(req)
+
+		if err != nil {
+			l.Errorf("couldn't insert into db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("insertion successful")
+		c.JSON(http.StatusCreated, models.IssueIDResponse{ID: id})
+		return
+	}
+}
+
+//HandleDELETEIssueType - Route to delete an issue type
+// @summary Delete an issue type
+// @description Deletes an issue type given its id, existing issues keep their type
+// @tags Issue Types
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue type"
+// @success 204 {} No Content
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue-types/{id} [delete]
+func HandleDELETEIssueType(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[DELETE] delete-issue-type")
+
+		issueTypeID, err := strconv.ParseInt(c.Param("issueTypeID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue type id format")
+			return
+		}
+
+		l = l.With("issueTypeID", issueTypeID)
+
+		err = storage.DeleteIssueTypeByID(issueTypeID)
+
+		if err != nil {
+			l.Errorf("couldn't delete: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("issue type deleted")
+		c.Status(http.StatusNoContent)
+		return
+	}
+}
+
+const (
+	defaultWorkflow = "default"
+	defaultPriority = int64(1)
+)
+
+// applyIssueType fills in the defaults of the issue type and returns the required fields left empty
+func applyIssueType(req *models.NewIssueRequest, issueType models.IssueType) []string {
+	if req.Priority == 0 {
+		req.Priority = issueType.DefaultPriority
+	}
+	if req.Description == "" {
+		req.Description = issueType.DescriptionTemplate
+	}
+
+	missing := make([]string, 0)
+	for _, field := range issueType.RequiredFields {
+		if issueFieldValue(*req, field) == "" {
+			missing = append(missing, field)
+		}
+	}
+
+	return missing
+}
+
+// missingFields returns the violations of an issue missing the fields its issue type requires
+func missingFields(missing []string) validation.Errors {
+	errs := make(validation.Errors, 0, len(missing))
+	for _, field := range missing {
+		errs.Add(field, validation.CodeRequired, field+" is required by the issue type")
+	}
+
+	return errs
+}
+
+// issueFieldValue looks a field up in the issue request, built-in fields first then custom fields
+func issueFieldValue(req models.NewIssueRequest, field string) string {
+	switch strings.ToLower(field) {
+	case "summary":
+		return req.Summary
+	case "description":
+		return req.Description
+	case "assignee":
+		return req.Assignee
+	}
+
+	return strings.TrimSpace(req.Fields[field])
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/validation"
+)
+
+// ErrIssueNotFound is returned updating or deleting an issue that doesn't exist
+var ErrIssueNotFound = errors.New("could not find issue")
+
+// RequestError is an issue request rejected with a 400, as opposed to the storage failing. Errors lists the fields
+// in violation, if any
+type RequestError struct {
+	Message string
+	Errors  validation.Errors
+}
+
+func (err *RequestError) Error() string {
+	return err.Message
+}
+
+// Extensions returns the fields in violation as the extensions of a GraphQL error, nil when there are none
+func (err *RequestError) Extensions() map[string]interface{} {
+	if len(err.Errors) == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{"errors": err.Errors.Wrapper(http.StatusBadRequest).Errors}
+}
+
+// CreateIssue creates an issue for every API: the issue type applies its defaults and required fields, the reporter
+// defaults to the actor, and the reporter and assignee watch the issue
+func CreateIssue(storage persistence.Storage, l *zap.SugaredLogger, actor string,
+	req models.NewIssueRequest) (models.IssueResponse, error) {
+	if req.Reporter == "" {
+		req.Reporter = actor
+	}
+
+	if req.Type != "" {
+		issueType, err := storage.RetrieveIssueType(req.Project, req.Type)
+		if err == sql.ErrNoRows {
+			return models.IssueResponse{}, &RequestError{Message: "unknown issue type"}
+		}
+		if err != nil {
+			l.Errorf("error retrieving issue type in db: %s", err.Error())
+			return models.IssueResponse{}, err
+		}
+
+		if missing := applyIssueType(&req, issueType); len(missing) > 0 {
+			return models.IssueResponse{}, violations(missingFields(missing))
+		}
+	}
+
+	return insertIssue(storage, l, actor, req)
+}
+
+// insertIssue validates and inserts an issue its issue type was applied to, the reporter and assignee watching it
+func insertIssue(storage persistence.Storage, l *zap.SugaredLogger, actor string,
+	req models.NewIssueRequest) (models.IssueResponse, error) {
+	// the schema limits are checked before the insertion, which would fail on some of them
+	if err := validation.NewIssue(req); err != nil {
+		return models.IssueResponse{}, violations(err.(validation.Errors))
+	}
+
+	id, err := storage.CreateIssue(req, models.IssueEvent{Event: events.IssueCreated, Actor: actor})
+	if err != nil {
+		l.Errorf("couldn't insert into db: %s", err.Error())
+		return models.IssueResponse{}, err
+	}
+
+	watchIssue(storage, l, id, req.Reporter, req.Assignee)
+
+	l.Debug("insertion successful")
+	return storage.RetrieveIssueByID(id)
+}
+
+// UpdateIssue updates an issue for every API, persistence.ErrVersionConflict being returned when ExpectedVersion
+// is set and the issue is at another version
+func UpdateIssue(storage persistence.Storage, l *zap.SugaredLogger, actor string, issueID int64,
+	req models.UpdateIssueRequest) (models.IssueResponse, error) {
+	if err := validation.UpdateIssue(req); err != nil {
+		return models.IssueResponse{}, violations(err.(validation.Errors))
+	}
+
+	issueEvents := make([]models.IssueEvent, 0)
+	if changes := describeUpdate(req); changes != "" {
+		issueEvents = append(issueEvents, models.IssueEvent{Event: events.IssueUpdated, Actor: actor,
+			Changes: changes, Changed: changedFields(req)})
+	}
+	if req.Comment != "" {
+		issueEvents = append(issueEvents, models.IssueEvent{Event: events.IssueCommented, Actor: actor,
+			Comment: req.Comment})
+	}
+
+	issue, err := storage.UpdateIssue(req, issueID, issueEvents...)
+	if err == nil {
+		err = applyIssueSLA(storage, issue)
+	}
+
+	if err == sql.ErrNoRows {
+		return models.IssueResponse{}, ErrIssueNotFound
+	}
+	if err == persistence.ErrVersionConflict {
+		return models.IssueResponse{}, err
+	}
+	if err != nil {
+		l.Errorf("couldn't update: %s", err.Error())
+		return models.IssueResponse{}, err
+	}
+
+	watchIssue(storage, l, issueID, req.Assignee)
+
+	l.Debug("update successful")
+	return *issue, nil
+}
+
+// DeleteIssue deletes an issue along with its attachments for every API, their contents being released once the
+// attachment records went away with the issue
+func DeleteIssue(storage persistence.Storage, attachments Attachments, l *zap.SugaredLogger, actor string,
+	issueID int64) error {
+	issueAttachments, err := storage.RetrieveAttachments(issueID)
+	if err == nil {
+		err = storage.DeleteIssueByID(issueID, models.IssueEvent{Event: events.IssueDeleted, Actor: actor})
+	}
+
+	if err == sql.ErrNoRows {
+		return ErrIssueNotFound
+	}
+	if err != nil {
+		l.Errorf("couldn't delete: %s", err.Error())
+		return err
+	}
+
+	for _, attachment := range issueAttachments {
+		releaseBlob(storage, attachments.Store, attachment.Hash, l)
+	}
+
+	l.Debug("issue deleted")
+	return nil
+}
+
+// violations returns the RequestError of the fields of a request in violation
+func violations(errs validation.Errors) *RequestError {
+	return &RequestError{Message: errs.Error(), Errors: errs}
+}
+
+// setIssueError responds to an error of the shared issue logic: a 400 for a rejected request, a 404 for an issue
+// that doesn't exist, and a storage error otherwise
+func setIssueError(c *gin.Context, err error) {
+	if requestErr, ok := err.(*RequestError); ok {
+		if len(requestErr.Errors) > 0 {
+			setValidationError(c, http.StatusBadRequest, requestErr.Errors)
+		} else {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, requestErr.Message)
+		}
+		return
+	}
+	if err == ErrIssueNotFound {
+		models.SetErrorStatusJSON(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	setStorageError(c, err)
+}
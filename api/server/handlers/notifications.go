@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// UserHeader is the request header naming the user acting on the API
+const UserHeader = "X-YAITS-User"
+
+//HandleGETWatchers - Route to retrieve the watchers of an issue
+// @summary Retrieves the watchers of an issue
+// @description Retrieves the users notified of the changes to an issue
+// @tags Notifications
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @success 200 {array} string
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/watchers [get]
+func HandleGETWatchers(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-watchers")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		watchers, err := storage.RetrieveWatchers(issueID)
+
+		if err != nil {
+			l.Errorf("error retrieving watchers in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("watchers successfully retrieved")
+		c.JSON(http.StatusOK, watchers)
+		return
+	}
+}
+
+//HandlePUTWatcher - Route to watch an issue
+// @summary Watch an issue
+// @description Makes a user watch an issue
+// @tags Notifications
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @Param user path string true "user name"
+// @success 204 {} No Content
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/watchers/{user} [put]
+func HandlePUTWatcher(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[PUT] watch-issue")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		l = l.With("issueID", issueID, "user", c.Param("user"))
+
+		err = storage.WatchIssue(issueID, c.Param("user"))
+
+		if err != nil {
+			l.Errorf("couldn't watch issue: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("issue watched")
+		c.Status(http.StatusNoContent)
+		return
+	}
+}
+
+//HandleDELETEWatcher - Route to unwatch an issue
+// @summary Unwatch an issue
+// @description Stops a user from watching an issue
+// @tags Notifications
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @Param user path string true "user name"
+// @success 204 {} No Content
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/watchers/{user} [delete]
+func HandleDELETEWatcher(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[DELETE] unwatch-issue")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		l = l.With("issueID", issueID, "user", c.Param("user"))
+
+		err = storage.UnwatchIssue(issueID, c.Param("user"))
+
+		if err != nil {
+			l.Errorf("couldn't unwatch issue: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("issue unwatched")
+		c.Status(http.StatusNoContent)
+		return
+	}
+}
+
+//HandleGETNotifications - Route to retrieve the notifications of the current user
+// @summary Retrieves my notifications
+// @description Retrieves the notifications of the user named by the X-YAITS-User header, most recent first
+// @tags Notifications
+// @accept json
+// @produce json
+// @param unread query bool false "only unread notifications"
+// @param issueID query int false "issue filter"
+// @param kind query string false "kind filter (updated, commented, deleted)"
+// @param limit query int false "page size, 50 by default"
+// @param offset query int false "page offset"
+// @success 200 {object} models.NotificationsResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 401 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /me/notifications [get]
+func HandleGETNotifications(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-notifications")
+
+		user := currentUser(c)
+		if user == "" {
+			models.SetErrorStatusJSON(c, http.StatusUnauthorized, "no current user")
+			return
+		}
+
+		var notificationQuery models.NotificationQueryParam
+		err := c.ShouldBindQuery(&notificationQuery)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "could not filter notifications")
+			return
+		}
+
+		var resp models.NotificationsResponse
+		resp.Notifications, err = storage.RetrieveNotifications(user, notificationQuery)
+		if err == nil {
+			resp.UnreadCount, err = storage.CountUnreadNotifications(user)
+		}
+
+		if err != nil {
+			l.Errorf("error retrieving notifications in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("notifications successfully retrieved")
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+}
+
+//HandleGETUnreadNotificationCount - Route to count the unread notifications of the current user
+// @summary Counts my unread notifications
+// @description Counts the unread notifications of the user named by the X-YAITS-User header
+// @tags Notifications
+// @accept json
+// @produce json
+// @success 200 {object} models.NotificationsResponse
+// @failure 401 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /me/notifications/unread [get]
+func HandleGETUnreadNotificationCount(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] count-unread-notifications")
+
+		user := currentUser(c)
+		if user == "" {
+			models.SetErrorStatusJSON(c, http.StatusUnauthorized, "no current user")
+			return
+		}
+
+		count, err := storage.CountUnreadNotifications(user)
+
+		if err != nil {
+			l.Errorf("error counting notifications in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NotificationsResponse{UnreadCount: count, Notifications: []models.Notification{}})
+		return
+	}
+}
+
+//HandlePOSTNotificationsRead - Route to mark notifications of the current user as read
+// @summary Mark my notifications as read
+// @description Marks a notification as read, or all of them (only those of an issue when issueID is given)
+// @tags Notifications
+// @accept json
+// @produce json
+// @Param notificationID path int false "ID of the notification"
+// @param issueID query int false "issue filter"
+// @success 200 {object} models.NotificationsResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 401 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /me/notifications/read [post]
+// @router /me/notifications/{notificationID}/read [post]
+func HandlePOSTNotificationsRead(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] mark-notifications-read")
+
+		user := currentUser(c)
+		if user == "" {
+			models.SetErrorStatusJSON(c, http.StatusUnauthorized, "no current user")
+			return
+		}
+
+		// gin can't route /me/notifications/read next to /me/notifications/:notificationID/read, marking them all
+		// comes through /me/notifications/:notificationID instead
+		notificationParam := c.Param("notificationID")
+		if strings.HasSuffix(c.FullPath(), "/:notificationID") {
+			if notificationParam != "read" {
+				models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find route")
+				return
+			}
+			notificationParam = ""
+		}
+
+		var notificationID, issueID int64
+		var err error
+		if notificationParam != "" {
+			if notificationID, err = strconv.ParseInt(notificationParam, 10, 64); err != nil {
+				models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid notification id format")
+				return
+			}
+		}
+		if c.Query("issueID") != "" {
+			if issueID, err = strconv.ParseInt(c.Query("issueID"), 10, 64); err != nil {
+				models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+				return
+			}
+		}
+
+		_, err = storage.MarkNotificationsRead(user, notificationID, issueID)
+
+		var count int64
+		if err == nil {
+			count, err = storage.CountUnreadNotifications(user)
+		}
+
+		if err != nil {
+			l.Errorf("couldn't mark notifications as read: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("notifications marked as read")
+		c.JSON(http.StatusOK, models.NotificationsResponse{UnreadCount: count, Notifications: []models.Notification{}})
+		return
+	}
+}
+
+// currentUser returns the user acting on the API, empty when anonymous
+func currentUser(c *gin.Context) string {
+	return c.GetString("user")
+}
+
+// watchIssue makes users watch an issue, failures only being logged as they should not fail the request
+func watchIssue(storage persistence.Storage, l *zap.SugaredLogger, issueID int64, users ...string) {
+	for _, user := range users {
+		if user == "" {
+			continue
+		}
+
+		if err := storage.WatchIssue(issueID, user); err != nil {
+			l.Errorf("couldn't make %s watch issue: %s", user, err.Error())
+		}
+	}
+}
+
+// describeUpdate summarizes the changes requested by an update, empty when only commenting
+func describeUpdate(req models.UpdateIssueRequest) string {
+	changes := make([]string, 0)
+
+	if req.Status != "" {
+		changes = append(changes, "status set to "+req.Status)
+	}
+	if req.Assignee != "" {
+		changes = append(changes, "assigned to "+req.Assignee)
+	} else if req.Sets("assignee") {
+		changes = append(changes, "unassigned")
+	}
+	if req.Priority != 0 {
+		changes = append(changes, fmt.Sprintf("priority set to %d", req.Priority))
+	}
+	if req.DueDate != nil {
+		changes = append(changes, "due date set to "+req.DueDate.Format(dateFormat))
+	} else if req.Sets("dueDate") {
+		changes = append(changes, "due date removed")
+	}
+	if req.Summary != "" {
+		changes = append(changes, "summary updated")
+	}
+	if req.Description != "" {
+		changes = append(changes, "description updated")
+	} else if req.Sets("description") {
+		changes = append(changes, "description removed")
+	}
+	if req.OriginalEstimate != nil || req.RemainingEstimate != nil || req.Sets("originalEstimate") ||
+		req.Sets("remainingEstimate") {
+		changes = append(changes, "estimates updated")
+	}
+
+	return strings.Join(changes, ", ")
+}
+
+// changedFields lists the issue fields changed by an update, those cleared by a patch included
+func changedFields(req models.UpdateIssueRequest) []string {
+	fields := make([]string, 0)
+
+	if req.Status != "" {
+		fields = append(fields, "status")
+	}
+	if req.Assignee != "" || req.Sets("assignee") {
+		fields = append(fields, "assignee")
+	}
+	if req.Priority != 0 {
+		fields = append(fields, "priority")
+	}
+	if req.DueDate != nil || req.Sets("dueDate") {
+		fields = append(fields, "dueDate")
+	}
+	if req.Summary != "" {
+		fields = append(fields, "summary")
+	}
+	if req.Description != "" || req.Sets("description") {
+		fields = append(fields, "description")
+	}
+	if req.OriginalEstimate != nil || req.Sets("originalEstimate") {
+		fields = append(fields, "originalEstimate")
+	}
+	if req.RemainingEstimate != nil || req.Sets("remainingEstimate") {
+		fields = append(fields, "remainingEstimate")
+	}
+
+	return fields
+}
@@ -1,33 +1,56 @@
 package handlers
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/patch"
 	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/validation"
 	"github.com/gin-gonic/gin"
 )
 
+// acceptPatch lists the patch formats of issue updates, advertised by the Accept-Patch header
+const acceptPatch = patch.MergePatchType + ", " + patch.JSONPatchType
+
 //HandlePATCH - Route to update an issue
 // @summary Update an issue
-// @description Updates an issue given an issue id
+// @description Updates an issue given an issue id, notifying its watchers and the subscribed webhooks. With If-Match,
+// @description the issue is only updated at the version of its ETag, 412 being returned otherwise.
+// @description
+// @description Besides a JSON update request, where empty fields are left unchanged, the body may be a JSON Merge Patch
+// @description (application/merge-patch+json) or a JSON Patch (application/json-patch+json) of the document of the
+// @description summary, description, priority, assignee, status, dueDate, originalEstimate and remainingEstimate of
+// @description the issue, fields set to null or removed being cleared. Patches failing to apply are rejected with 409
+// @description and patched documents that are not valid with 422
 // @tags Update
 // @accept json
+// @accept application/merge-patch+json
+// @accept application/json-patch+json
 // @produce json
 // @Param id path int true "ID of the issue"
 // @param updateIssueRequest body models.UpdateIssueRequest true "YAITS update request"
+// @param If-Match header string false "ETag of the version of the issue the update is based on"
 // @success 200 {object} models.IssueResponse
 // @failure 400 {object} models.ErrorWrapper
 // @failure 404 {object} models.ErrorWrapper
+// @failure 409 {object} models.ErrorWrapper
+// @failure 412 {object} models.ErrorWrapper
+// @failure 422 {object} models.ErrorWrapper
 // @failure 500 {object} models.ErrorWrapper
 // @router /issue/{id} [patch]
 func HandlePATCH(storage persistence.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[PATCH] update-issue")
+		c.Header("Accept-Patch", acceptPatch)
 
 		// Retrieve issue to update
 		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
@@ -36,33 +59,229 @@ func HandlePATCH(storage persistence.Storage) gin.HandlerFunc {
 			return
 		}
 
+		// bodies of other content types are read as update requests, as they always were
+		contentType := c.ContentType()
+		isPatch := contentType == patch.MergePatchType || contentType == patch.JSONPatchType
+
 		var req models.UpdateIssueRequest
-		err = c.ShouldBindJSON(&req)
+		var body []byte
+		if isPatch {
+			body, err = c.GetRawData()
+		} else {
+			err = c.ShouldBindJSON(&req)
+		}
 
 		l = l.With("request", req, "issueID", issueID)
 		l.Debug("received issue update request")
 
 		if err != nil {
 			l.Errorf("couldn't bind to issue request: %s", err.Error())
-			c.JSON(http.StatusBadRequest, err.Error())
+			setBindingError(c, err)
 			return
 		}
 
-		issue, err := storage.UpdateIssue(req.Summary, req.Description, req.Assignee, req.Status, req.Comment, req.Priority, issueID)
+		// patches apply to the current version of the issue, as does the update when If-Match is set
+		ifMatch := c.GetHeader("If-Match")
+		if ifMatch != "" || isPatch {
+			current, err := storage.RetrieveIssueByID(issueID)
+			if err == sql.ErrNoRows {
+				models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find issue")
+				return
+			}
+			if err != nil {
+				l.Errorf("couldn't retrieve the issue to update: %s", err.Error())
+				models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			if ifMatch != "" && !matchETag(ifMatch, current.Version, false) {
+				c.Header("ETag", issueETag(current.Version))
+				models.SetErrorStatusJSON(c, http.StatusPreconditionFailed, persistence.ErrVersionConflict.Error())
+				return
+			}
+
+			if isPatch {
+				if req, err = patchIssue(current, contentType, body); err != nil {
+					l.Debugf("couldn't patch the issue: %s", err.Error())
+					setValidationError(c, err.(*patchError).status, err.(*patchError).err)
+					return
+				}
+				l = l.With("changed", req.Changed)
+
+				// a patch changing nothing leaves the issue and its version as they are
+				if len(req.Changed) == 0 {
+					if err = applyIssueSLA(storage, &current); err != nil {
+						l.Errorf("couldn't apply the SLA: %s", err.Error())
+						models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+						return
+					}
+					c.Header("ETag", issueETag(current.Version))
+					c.JSON(http.StatusOK, current)
+					return
+				}
+			}
+			req.ExpectedVersion = current.Version
+		}
 
-		if err == sql.ErrNoRows {
-			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find issue")
+		issue, err := UpdateIssue(storage, l, currentUser(c), issueID, req)
+
+		// without If-Match, the issue was updated by someone else between being read and written
+		if err == persistence.ErrVersionConflict {
+			status := http.StatusConflict
+			if ifMatch != "" {
+				status = http.StatusPreconditionFailed
+			}
+			models.SetErrorStatusJSON(c, status, err.Error())
 			return
 		}
 
 		if err != nil {
-			l.Errorf("couldn't update: %s", err.Error())
-			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			setIssueError(c, err)
 			return
 		}
 
-		l.Debug("update successful")
+		c.Header("ETag", issueETag(issue.Version))
 		c.JSON(http.StatusOK, issue)
 		return
 	}
 }
+
+// issueDocument is the document of an issue patches apply to, fields removed or set to null being cleared
+type issueDocument struct {
+	Summary           *string    `json:"summary"`
+	Description       *string    `json:"description"`
+	Priority          *int64     `json:"priority"`
+	Assignee          *string    `json:"assignee"`
+	Status            *string    `json:"status"`
+	DueDate           *time.Time `json:"dueDate"`
+	OriginalEstimate  *int64     `json:"originalEstimate"`
+	RemainingEstimate *int64     `json:"remainingEstimate"`
+}
+
+// patchError is a patch that was rejected, with the status to respond with
+type patchError struct {
+	status int
+	err    error
+}
+
+func (err *patchError) Error() string {
+	return err.err.Error()
+}
+
+// patchIssue applies a merge or JSON patch to the document of an issue, returning the update request of the fields it
+// changes, all listed in Changed. Malformed patches are rejected with 400, patches that can't be applied with 409 and
+// patched documents that are not valid with 422
+func patchIssue(issue models.IssueResponse, contentType string, body []byte) (models.UpdateIssueRequest, error) {
+	before := newIssueDocument(issue)
+	doc, err := json.Marshal(before)
+	if err != nil {
+		return models.UpdateIssueRequest{}, &patchError{status: http.StatusInternalServerError, err: err}
+	}
+
+	if contentType == patch.MergePatchType {
+		doc, err = patch.Merge(doc, body)
+	} else {
+		doc, err = patch.Apply(doc, body)
+	}
+	switch err.(type) {
+	case nil:
+	case *patch.ConflictError:
+		return models.UpdateIssueRequest{}, &patchError{status: http.StatusConflict, err: err}
+	default:
+		return models.UpdateIssueRequest{}, &patchError{status: http.StatusBadRequest, err: err}
+	}
+
+	var after issueDocument
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&after); err != nil {
+		return models.UpdateIssueRequest{}, &patchError{status: http.StatusUnprocessableEntity,
+			err: fmt.Errorf("invalid patched issue: %s", err.Error())}
+	}
+
+	// only the fields the patch changes are set, those it leaves as they are being kept even when they would not
+	// pass, so issues predating a rule stay patchable
+	req := patchedUpdate(before, after)
+	if err = validation.UpdateIssue(req); err != nil {
+		return models.UpdateIssueRequest{}, &patchError{status: http.StatusUnprocessableEntity, err: err}
+	}
+
+	return req, nil
+}
+
+func newIssueDocument(issue models.IssueResponse) issueDocument {
+	doc := issueDocument{
+		Summary:           &issue.Summary,
+		Description:       &issue.Description,
+		Priority:          &issue.Priority,
+		Assignee:          &issue.Assignee,
+		Status:            &issue.Status,
+		OriginalEstimate:  &issue.OriginalEstimate,
+		RemainingEstimate: &issue.RemainingEstimate,
+	}
+	if dueDate, err := time.Parse(time.RFC3339, issue.DueDate); err == nil {
+		doc.DueDate = &dueDate
+	}
+
+	return doc
+}
+
+// patchedUpdate compares the document of an issue before and after a patch, the update setting the fields that differ
+func patchedUpdate(before, after issueDocument) models.UpdateIssueRequest {
+	req := models.UpdateIssueRequest{Changed: make([]string, 0)}
+
+	if summary := stringValue(after.Summary); summary != stringValue(before.Summary) {
+		req.Summary = summary
+		req.Changed = append(req.Changed, "summary")
+	}
+	if description := stringValue(after.Description); description != stringValue(before.Description) {
+		req.Description = description
+		req.Changed = append(req.Changed, "description")
+	}
+	if priority := persistence.Int64Value(after.Priority); priority != persistence.Int64Value(before.Priority) {
+		req.Priority = priority
+		req.Changed = append(req.Changed, "priority")
+	}
+	if assignee := assigneeValue(after.Assignee); assignee != assigneeValue(before.Assignee) {
+		req.Assignee = assignee
+		req.Changed = append(req.Changed, "assignee")
+	}
+	if status := stringValue(after.Status); status != stringValue(before.Status) {
+		req.Status = status
+		req.Changed = append(req.Changed, "status")
+	}
+	if (after.DueDate == nil) != (before.DueDate == nil) ||
+		(after.DueDate != nil && !after.DueDate.Equal(*before.DueDate)) {
+		req.DueDate = after.DueDate
+		req.Changed = append(req.Changed, "dueDate")
+	}
+	original := persistence.Int64Value(after.OriginalEstimate)
+	if original != persistence.Int64Value(before.OriginalEstimate) {
+		req.OriginalEstimate = &original
+		req.Changed = append(req.Changed, "originalEstimate")
+	}
+	remaining := persistence.Int64Value(after.RemainingEstimate)
+	if remaining != persistence.Int64Value(before.RemainingEstimate) {
+		req.RemainingEstimate = &remaining
+		req.Changed = append(req.Changed, "remainingEstimate")
+	}
+
+	return req
+}
+
+// assigneeValue reads an assignee, issues assigned to nobody having no assignee
+func assigneeValue(value *string) string {
+	if value == nil || *value == persistence.Unassigned {
+		return ""
+	}
+
+	return *value
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
@@ -12,13 +12,14 @@ import (
 
 //HandlePOST - Route to create an issue
 // @summary Create an issue
-// @description Create a new issue
+// @description Create a new issue, applying the defaults and required fields of its issue type, and notify the subscribed webhooks
 // @tags Creation
 // @accept json
 // @produce json
 // @param issueRequest body models.NewIssueRequest true "YAITS creation request"
 // @success 201 {object} models.IssueIDResponse
 // @failure 400 {object} models.ErrorWrapper
+// @failure 422 {object} models.ErrorWrapper
 // @failure 500 {object} models.ErrorWrapper
 // @router /issue [post]
 func HandlePOST(storage persistence.Storage) gin.HandlerFunc {
@@ -33,20 +34,17 @@ func HandlePOST(storage persistence.Storage) gin.HandlerFunc {
 
 		if err != nil {
 			l.Errorf("couldn't bind to issue request: %s", err.Error())
-			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			setBindingError(c, err)
 			return
 		}
 
-		id, err := storage.CreateIssue(req.Summary, req.Description, req.Assignee, req.Priority)
-
+		issue, err := CreateIssue(storage, l, currentUser(c), req)
 		if err != nil {
-			l.Errorf("couldn't insert into db: %s", err.Error())
-			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			setIssueError(c, err)
 			return
 		}
 
-		l.Debug("insertion successful")
-		c.JSON(http.StatusCreated, models.IssueIDResponse{ID: id})
+		c.JSON(http.StatusCreated, models.IssueIDResponse{ID: issue.ID})
 		return
 	}
 }
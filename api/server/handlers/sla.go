@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/sla"
+	"github.com/YAITS/api/validation"
+	"github.com/gin-gonic/gin"
+)
+
+//HandleGETBySLA - Route to retrieve the issues whose SLA is at risk or breached
+// @summary Retrieves issues at risk or breaching their SLA
+// @description Retrieves issues with an SLA target in the given state (at risk, breached), both when no state is given
+// @tags Retrieval
+// @accept json
+// @produce json
+// @param state query string false "SLA state (at risk, breached)"
+// @param project query string false "project key"
+// @param type query string false "issue type"
+// @success 200 {array} models.IssueResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issues/sla [get]
+func HandleGETBySLA(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-issue-by-sla")
+
+		var slaQuery models.SLAQueryParam
+		var filterQuery models.IssueFilterQueryParam
+		err := c.ShouldBindQuery(&slaQuery)
+		if err == nil {
+			err = c.ShouldBindQuery(&filterQuery)
+		}
+		if err != nil || (slaQuery.State != "" && slaQuery.State != sla.StateAtRisk && slaQuery.State != sla.StateBreached) {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "could not filter by sla state")
+			return
+		}
+
+		issues, err := storage.RetrieveIssues(filterQuery)
+		if err == nil {
+			err = applySLA(storage, issues)
+		}
+
+		if err != nil {
+			l.Errorf("error retrieving issue in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		issueResponse := make([]models.IssueResponse, 0)
+		for _, issue := range issues {
+			if sla.Flagged(issue.SLA, slaQuery.State) {
+				issueResponse = append(issueResponse, issue)
+			}
+		}
+
+		l.Debug("issues successfully retrieved")
+		c.JSON(http.StatusOK, issueResponse)
+		return
+	}
+}
+
+//HandleGETSLABreaches - Route to retrieve the SLA breaches of an issue
+// @summary Retrieves the SLA breaches of an issue
+// @description Retrieves the SLA breach events recorded for an issue
+// @tags SLA
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @success 200 {array} models.SLABreach
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/sla-breaches [get]
+func HandleGETSLABreaches(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-sla-breaches")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		breaches, err := storage.RetrieveSLABreaches(issueID)
+
+		if err != nil {
+			l.Errorf("error retrieving sla breaches in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("sla breaches successfully retrieved")
+		c.JSON(http.StatusOK, breaches)
+		return
+	}
+}
+
+//HandleGETSLAPolicies - Route to retrieve the SLA policies
+// @summary Retrieves SLA policies
+// @description Retrieves every SLA policy with its business-hours calendar
+// @tags SLA
+// @accept json
+// @produce json
+// @success 200 {array} models.SLAPolicy
+// @failure 500 {object} models.ErrorWrapper
+// @router /sla-policies [get]
+func HandleGETSLAPolicies(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-sla-policies")
+
+		policies, err := storage.RetrieveSLAPolicies()
+
+		if err != nil {
+			l.Errorf("error retrieving sla policies in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("sla policies successfully retrieved")
+		c.JSON(http.StatusOK, policies)
+		return
+	}
+}
+
+//HandlePOSTSLAPolicy - Route to create an SLA policy
+// @summary Create an SLA policy
+// @description Creates a policy with response and resolution targets in minutes for a project and/or priority
+// @tags SLA
+// @accept json
+// @produce json
+// @param policyRequest body models.NewSLAPolicyRequest true "YAITS SLA policy creation request"
+// @success 201 {object} models.IssueIDResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /sla-policies [post]
+func HandlePOSTSLAPolicy(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] create-sla-policy")
+
+		var req models.NewSLAPolicyRequest
+		err := c.ShouldBindJSON(&req)
+
+		l = l.With("request", req)
+		l.Debug("received sla policy creation request")
+
+		if err != nil {
+			l.Errorf("couldn't bind to sla policy request: %s", err.Error())
+			setBindingError(c, err)
+			return
+		}
+		if err = validation.NewSLAPolicy(req); err != nil {
+			setValidationError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.ResponseMinutes <= 0 && req.ResolutionMinutes <= 0 {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "a response or resolution target is required")
+			return
+		}
+
+		id, err := storage.CreateSLAPolicy(req)
+
+		if err != nil {
+			l.Errorf("couldn't insert into db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("insertion successful")
+		c.JSON(http.StatusCreated, models.IssueIDResponse{ID: id})
+		return
+	}
+}
+
+//HandleDELETESLAPolicy - Route to delete an SLA policy
+// @summary Delete an SLA policy
+// @description Deletes an SLA policy given its id
+// @tags SLA
+// @accept json
+// @produce json
+// @Param id path int true "ID of the SLA policy"
+// @success 204 {} No Content
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /sla-policies/{id} [delete]
+func HandleDELETESLAPolicy(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[DELETE] delete-sla-policy")
+
+		policyID, err := strconv.ParseInt(c.Param("policyID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid sla policy id format")
+			return
+		}
+
+		l = l.With("policyID", policyID)
+
+		err = storage.DeleteSLAPolicyByID(policyID)
+
+		if err != nil {
+			l.Errorf("couldn't delete: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("sla policy deleted")
+		c.Status(http.StatusNoContent)
+		return
+	}
+}
+
+//HandleGETBusinessCalendars - Route to retrieve the business-hours calendars
+// @summary Retrieves business-hours calendars
+// @description Retrieves every business-hours calendar usable by SLA policies
+// @tags SLA
+// @accept json
+// @produce json
+// @success 200 {array} models.BusinessCalendar
+// @failure 500 {object} models.ErrorWrapper
+// @router /calendars [get]
+func HandleGETBusinessCalendars(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-calendars")
+
+		calendars, err := storage.RetrieveBusinessCalendars()
+
+		if err != nil {
+			l.Errorf("error retrieving calendars in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("calendars successfully retrieved")
+		c.JSON(http.StatusOK, calendars)
+		return
+	}
+}
+
+//HandlePOSTBusinessCalendar - Route to create a business-hours calendar
+// @summary Create a business-hours calendar
+// @description Creates a calendar of working days (0 being sunday) and hours in a timezone
+// @tags SLA
+// @accept json
+// @produce json
+// @param calendarRequest body models.NewBusinessCalendarRequest true "YAITS calendar creation request"
+// @success 201 {object} models.IssueIDResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /calendars [post]
+func HandlePOSTBusinessCalendar(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] create-calendar")
+
+		var req models.NewBusinessCalendarRequest
+		err := c.ShouldBindJSON(&req)
+
+		l = l.With("request", req)
+		l.Debug("received calendar creation request")
+
+		if err != nil {
+			l.Errorf("couldn't bind to calendar request: %s", err.Error())
+			setBindingError(c, err)
+			return
+		}
+
+		if req.StartHour < 0 || req.EndHour > 24 || req.StartHour >= req.EndHour {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "business hours must be within 0 and 24 and start before they end")
+			return
+		}
+
+		if _, err = time.LoadLocation(req.Timezone); err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "unknown timezone")
+			return
+		}
+
+		id, err := storage.CreateBusinessCalendar(req)
+
+		if err != nil {
+			l.Errorf("couldn't insert into db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("insertion successful")
+		c.JSON(http.StatusCreated, models.IssueIDResponse{ID: id})
+		return
+	}
+}
+
+// applySLA computes the SLA status of each issue against the configured policies
+func applySLA(storage persistence.Storage, issues []models.IssueResponse) error {
+	policies, err := storage.RetrieveSLAPolicies()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range issues {
+		issues[i].SLA = sla.Evaluate(issues[i], policies, now)
+	}
+
+	return nil
+}
+
+// applyIssueSLA computes the SLA status of a single issue
+func applyIssueSLA(storage persistence.Storage, issue *models.IssueResponse) error {
+	issues := []models.IssueResponse{*issue}
+
+	err := applySLA(storage, issues)
+	issue.SLA = issues[0].SLA
+
+	return err
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/slack"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCommandPriority is the priority of the issues created from Slack when neither the command nor the issue
+// type gives one
+const defaultCommandPriority = 3
+
+// Slack holds the signing secret of the Slack app slash commands are verified with and the public URL of the API
+// replies link to
+type Slack struct {
+	SigningSecret string
+	BaseURL       string
+	// Users maps Slack user ids to users, Slack user names being used otherwise
+	Users map[string]string
+}
+
+//HandlePOSTSlackCommand - Route to run a /yaits slash command
+// @summary Run a Slack slash command
+// @description Runs a /yaits slash command sent by Slack: create, show or assign an issue. Requests must be signed
+// @description with the signing secret of the Slack app in the X-Slack-Signature header
+// @tags Slack
+// @accept x-www-form-urlencoded
+// @produce json
+// @Param X-Slack-Signature header string true "v0= followed by the hex HMAC-SHA256 of v0:timestamp:body"
+// @Param X-Slack-Request-Timestamp header string true "Unix time the request was sent at"
+// @Param text formData string false "text following the command, such as show API-12"
+// @Param user_id formData string false "Slack id of the user running the command"
+// @Param user_name formData string false "Slack name of the user running the command"
+// @success 200 {object} slack.Message
+// @failure 401 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /slack/commands [post]
+func HandlePOSTSlackCommand(storage persistence.Storage, slackConfig Slack) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] slack-command")
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = slack.Verify(slackConfig.SigningSecret, c.GetHeader(slack.TimestampHeader),
+			c.GetHeader(slack.SignatureHeader), body, time.Now())
+		if err != nil {
+			l.Warnf("rejected slack command: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		user := slackUser(slackConfig, form.Get("user_id"), form.Get("user_name"))
+		l = l.With("user", user, "text", form.Get("text"))
+		l.Debug("received slack command")
+
+		command, err := slack.ParseCommand(form.Get("text"))
+		if err != nil {
+			c.JSON(http.StatusOK, slack.TextMessage(err.Error()+"\n"+slack.Usage, slack.ResponseEphemeral))
+			return
+		}
+
+		var reply slack.Message
+		switch command.Name {
+		case slack.CommandCreate:
+			reply, err = createFromCommand(storage, l, slackConfig, command, user)
+		case slack.CommandShow:
+			var issue models.IssueResponse
+			if issue, err = commandIssue(storage, command); err == nil {
+				reply = slack.IssueMessage(issue, slackConfig.BaseURL, slack.ResponseEphemeral)
+			}
+		case slack.CommandAssign:
+			reply, err = assignFromCommand(storage, l, slackConfig, command, user)
+		default:
+			reply = slack.TextMessage(slack.Usage, slack.ResponseEphemeral)
+		}
+
+		if err == sql.ErrNoRows {
+			key := slack.IssueKey(command.Project, command.IssueID)
+			c.JSON(http.StatusOK, slack.TextMessage("Could not find issue "+key, slack.ResponseEphemeral))
+			return
+		}
+
+		if err != nil {
+			l.Errorf("couldn't run slack command: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("slack command successful")
+		c.JSON(http.StatusOK, reply)
+		return
+	}
+}
+
+// commandIssue retrieves the issue of a command, issues of another project than the one of the key not being found
+func commandIssue(storage persistence.Storage, command slack.Command) (models.IssueResponse, error) {
+	issue, err := storage.RetrieveIssueByID(command.IssueID)
+	if err == nil && command.Project != "" && !strings.EqualFold(issue.Project, command.Project) {
+		return issue, sql.ErrNoRows
+	}
+
+	return issue, err
+}
+
+// createFromCommand creates an issue the way HandlePOST does, missing required fields and violations being replied to
+// the user
+func createFromCommand(storage persistence.Storage, l *zap.SugaredLogger, slackConfig Slack, command slack.Command,
+	user string) (slack.Message, error) {
+	req := models.NewIssueRequest{
+		Summary:     command.Summary,
+		Description: command.Description,
+		Priority:    command.Priority,
+		Project:     command.Project,
+		Type:        command.Type,
+		Reporter:    user,
+	}
+
+	if req.Type != "" {
+		issueType, err := storage.RetrieveIssueType(req.Project, req.Type)
+		if err == sql.ErrNoRows {
+			return slack.TextMessage("Unknown issue type "+req.Type, slack.ResponseEphemeral), nil
+		}
+		if err != nil {
+			return slack.Message{}, err
+		}
+
+		if missing := applyIssueType(&req, issueType); len(missing) > 0 {
+			text := "Missing required fields: " + strings.Join(missing, ", ")
+			return slack.TextMessage(text, slack.ResponseEphemeral), nil
+		}
+	}
+
+	if req.Description == "" {
+		req.Description = req.Summary
+	}
+	if req.Priority == 0 {
+		req.Priority = defaultCommandPriority
+	}
+
+	issue, err := insertIssue(storage, l, user, req)
+	if requestErr, ok := err.(*RequestError); ok {
+		return slack.TextMessage(requestErr.Message, slack.ResponseEphemeral), nil
+	}
+	if err != nil {
+		return slack.Message{}, err
+	}
+
+	reply := slack.IssueMessage(issue, slackConfig.BaseURL, slack.ResponseInChannel)
+	reply.Text = fmt.Sprintf("%s created %s", user, reply.Text)
+	return reply, nil
+}
+
+// assignFromCommand assigns an issue the way HandlePATCH does, to the user running the command when none is given,
+// violations being replied to the user
+func assignFromCommand(storage persistence.Storage, l *zap.SugaredLogger, slackConfig Slack, command slack.Command,
+	user string) (slack.Message, error) {
+	if _, err := commandIssue(storage, command); err != nil {
+		return slack.Message{}, err
+	}
+
+	req := models.UpdateIssueRequest{Assignee: user}
+	if command.Assignee != "" || command.AssigneeID != "" {
+		req.Assignee = slackUser(slackConfig, command.AssigneeID, command.Assignee)
+	}
+
+	issue, err := UpdateIssue(storage, l, user, command.IssueID, req)
+	if requestErr, ok := err.(*RequestError); ok {
+		return slack.TextMessage(requestErr.Message, slack.ResponseEphemeral), nil
+	}
+	if err == ErrIssueNotFound {
+		return slack.Message{}, sql.ErrNoRows
+	}
+	if err != nil {
+		return slack.Message{}, err
+	}
+
+	reply := slack.IssueMessage(issue, slackConfig.BaseURL, slack.ResponseInChannel)
+	reply.Text = fmt.Sprintf("%s assigned %s to %s", user, reply.Text, req.Assignee)
+	return reply, nil
+}
+
+// slackUser maps a Slack user to a user, by id when configured or by name
+func slackUser(slackConfig Slack, id, name string) string {
+	if user, ok := slackConfig.Users[id]; ok {
+		return user
+	}
+	if name != "" {
+		return name
+	}
+
+	return id
+}
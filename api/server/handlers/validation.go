@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/validation"
+)
+
+// setBindingError responds with a 400 listing the fields of a request body that couldn't be bound
+func setBindingError(c *gin.Context, err error) {
+	setValidationError(c, http.StatusBadRequest, validation.Binding(err))
+}
+
+// setValidationError responds with an error per violation when the error lists the violations of a request, with a
+// single error otherwise
+func setValidationError(c *gin.Context, status int, err error) {
+	if errs, ok := err.(validation.Errors); ok {
+		c.JSON(status, errs.Wrapper(status))
+		return
+	}
+
+	models.SetErrorStatusJSON(c, status, err.Error())
+}
+
+// setStorageError responds to a write the storage failed, with a 422 naming the field when the schema rejected it and
+// a 500 otherwise
+func setStorageError(c *gin.Context, err error) {
+	if constraint, ok := persistence.AsConstraintError(err); ok {
+		setValidationError(c, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: constraint.Field, Code: constraint.Reason, Message: constraint.Message}})
+		return
+	}
+
+	models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/YAITS/api/models"
+)
+
+func TestSetStorageError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	setStorageError(c, &mysql.MySQLError{Number: 3819, Message: "Check constraint 'priority_range' is violated."})
+
+	var errorResponse models.ErrorWrapper
+	_ = json.Unmarshal(recorder.Body.Bytes(), &errorResponse)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, []models.StandardError{{Code: http.StatusUnprocessableEntity, Title: "Unprocessable Entity",
+		Description: "Check constraint 'priority_range' is violated.", Field: "priority", Reason: "out_of_range"}},
+		errorResponse.Errors)
+
+	recorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(recorder)
+	setStorageError(c, errors.New("connection refused"))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/vcs"
+	"github.com/gin-gonic/gin"
+)
+
+// maxLinkTitleLength bounds the title of a link as stored
+const maxLinkTitleLength = 256
+
+// VCS holds the secret GitHub, GitLab and Gitea webhooks are signed with, webhooks being refused when empty
+type VCS struct {
+	Secret string
+}
+
+// Integrations holds the configuration of the third-party services calling the API
+type Integrations struct {
+	Slack Slack
+	VCS   VCS
+}
+
+//HandlePOSTVCSWebhook - Route to link the commits and pull requests of a push or pull request webhook to issues
+// @summary Link commits and pull requests to issues
+// @description Links the commits and pull requests of a GitHub, GitLab or Gitea webhook to the issues they refer to
+// @description as #12 or API-12. Issues preceded by a closing keyword such as "fixes #12" are closed by commits pushed
+// @description to the default branch and by merged pull requests. Webhooks must be signed with the configured secret
+// @tags VCS
+// @accept json
+// @produce json
+// @Param X-Hub-Signature-256 header string false "sha256= followed by the hex HMAC-SHA256 of the body, for GitHub"
+// @Param X-Gitea-Signature header string false "hex HMAC-SHA256 of the body, for Gitea"
+// @Param X-Gitlab-Token header string false "secret of the webhook, for GitLab"
+// @success 200 {object} models.VCSWebhookResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 401 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /vcs/webhook [post]
+func HandlePOSTVCSWebhook(storage persistence.Storage, vcsConfig VCS) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] vcs-webhook")
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = vcs.Verify(c.Request.Header, body, vcsConfig.Secret)
+		if err == vcs.ErrUnknownProvider {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			l.Warnf("rejected vcs webhook: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		webhook, err := vcs.Parse(c.Request.Header, body)
+		if err == vcs.ErrIgnoredEvent {
+			l.Debugf("ignored %s event", webhook.Event)
+			c.JSON(http.StatusOK, models.VCSWebhookResponse{})
+			return
+		}
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		l = l.With("provider", webhook.Provider, "repository", webhook.Repository)
+
+		var resp models.VCSWebhookResponse
+		for _, change := range webhook.Changes {
+			for _, reference := range vcs.ParseReferences(change.Text) {
+				linked, closed, err := linkChange(storage, l, webhook.Repository, change, reference)
+				if err != nil {
+					l.Errorf("couldn't link %s %s to issue %d: %s", change.Kind, change.ID, reference.IssueID,
+						err.Error())
+					models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+					return
+				}
+
+				if linked {
+					resp.Linked++
+				}
+				if closed {
+					resp.Closed++
+				}
+			}
+		}
+
+		l.Debugf("linked %d and closed %d issues", resp.Linked, resp.Closed)
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+}
+
+//HandleGETIssueLinks - Route to retrieve the commits and pull requests linked to an issue
+// @summary Retrieves the links of an issue
+// @description Retrieves the commits and pull requests referring to an issue
+// @tags VCS
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @success 200 {array} models.IssueLink
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/links [get]
+func HandleGETIssueLinks(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-issue-links")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		links, err := storage.RetrieveIssueLinks(issueID)
+
+		if err != nil {
+			l.Errorf("error retrieving issue links in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("issue links successfully retrieved")
+		c.JSON(http.StatusOK, links)
+		return
+	}
+}
+
+// linkChange links a change to the issue it refers to, closing it first when the change fixes it so that a webhook
+// delivered again neither links nor closes twice. References to unknown issues, or given with the key of another
+// project, are skipped
+func linkChange(storage persistence.Storage, l *zap.SugaredLogger, repository string, change vcs.Change,
+	reference vcs.Reference) (linked bool, closed bool, err error) {
+	issue, err := storage.RetrieveIssueByID(reference.IssueID)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	if reference.Project != "" && !strings.EqualFold(issue.Project, reference.Project) {
+		return false, false, nil
+	}
+
+	if change.Closes && reference.Closes && issue.Status != "closed" {
+		req := models.UpdateIssueRequest{
+			Status:  "closed",
+			Comment: fmt.Sprintf("Closed by %s %s in %s: %s", changeName(change.Kind), change.ID, repository, change.URL),
+		}
+
+		_, err := UpdateIssue(storage, l, change.Author, reference.IssueID, req)
+		if requestErr, ok := err.(*RequestError); ok {
+			l.Warnf("couldn't close issue %d: %s", reference.IssueID, requestErr.Message)
+		} else if err != nil {
+			return false, false, err
+		} else {
+			l.Infof("issue %d closed by %s %s", reference.IssueID, change.Kind, change.ID)
+			closed = true
+		}
+	}
+
+	title := []rune(change.Title)
+	if len(title) > maxLinkTitleLength {
+		title = title[:maxLinkTitleLength]
+	}
+
+	linked, err = storage.CreateIssueLink(models.IssueLink{
+		IssueID:    reference.IssueID,
+		Kind:       change.Kind,
+		Repository: repository,
+		Ref:        change.ID,
+		URL:        change.URL,
+		Title:      string(title),
+		Author:     change.Author,
+	})
+
+	return linked, closed, err
+}
+
+func changeName(kind string) string {
+	if kind == vcs.KindPullRequest {
+		return "pull request"
+	}
+
+	return kind
+}
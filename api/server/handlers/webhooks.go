@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+//HandleGETWebhooks - Route to retrieve the webhook subscriptions
+// @summary Retrieves webhooks
+// @description Retrieves every webhook subscription, without their secret
+// @tags Webhooks
+// @accept json
+// @produce json
+// @success 200 {array} models.Webhook
+// @failure 500 {object} models.ErrorWrapper
+// @router /webhooks [get]
+func HandleGETWebhooks(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-webhooks")
+
+		webhooks, err := storage.RetrieveWebhooks()
+
+		if err != nil {
+			l.Errorf("error retrieving webhooks in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("webhooks successfully retrieved")
+		c.JSON(http.StatusOK, webhooks)
+		return
+	}
+}
+
+//HandlePOSTWebhook - Route to create a webhook subscription
+// @summary Create a webhook
+// @description Subscribes a URL to issue events (issue.created, issue.updated, issue.deleted, issue.commented),
+// @description optionally for a single project. Deliveries are signed with the secret in the X-YAITS-Signature header.
+// @description Webhooks of the slack format post the events as messages to a Slack incoming webhook and need no secret.
+// @description Deliveries to loopback, private and link-local addresses are refused unless webhooks.allowPrivateNetworks is set
+// @tags Webhooks
+// @accept json
+// @produce json
+// @param webhookRequest body models.NewWebhookRequest true "YAITS webhook creation request"
+// @success 201 {object} models.IssueIDResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /webhooks [post]
+func HandlePOSTWebhook(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] create-webhook")
+
+		var req models.NewWebhookRequest
+		err := c.ShouldBindJSON(&req)
+
+		l = l.With("url", req.URL, "events", req.Events, "project", req.Project)
+		l.Debug("received webhook creation request")
+
+		if err != nil {
+			l.Errorf("couldn't bind to webhook request: %s", err.Error())
+			setBindingError(c, err)
+			return
+		}
+
+		if !webhook.ValidURL(req.URL) {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "webhook url must be an http or https url with a host")
+			return
+		}
+
+		if req.Format == "" {
+			req.Format = webhook.FormatJSON
+		}
+		if !webhook.ValidFormat(req.Format) {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "unknown webhook format "+req.Format)
+			return
+		}
+		if req.Format == webhook.FormatJSON && req.Secret == "" {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "a secret is required to sign json deliveries")
+			return
+		}
+
+		for _, event := range req.Events {
+			if !events.Valid(event) {
+				models.SetErrorStatusJSON(c, http.StatusBadRequest, "unknown event "+event)
+				return
+			}
+		}
+
+		id, err := storage.CreateWebhook(req)
+
+		if err != nil {
+			l.Errorf("couldn't insert into db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("insertion successful")
+		c.JSON(http.StatusCreated, models.IssueIDResponse{ID: id})
+		return
+	}
+}
+
+//HandleDELETEWebhook - Route to delete a webhook subscription
+// @summary Delete a webhook
+// @description Deletes a webhook given its id, along with its delivery log
+// @tags Webhooks
+// @accept json
+// @produce json
+// @Param id path int true "ID of the webhook"
+// @success 204 {} No Content
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /webhooks/{id} [delete]
+func HandleDELETEWebhook(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[DELETE] delete-webhook")
+
+		webhookID, err := strconv.ParseInt(c.Param("webhookID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid webhook id format")
+			return
+		}
+
+		l = l.With("webhookID", webhookID)
+
+		err = storage.DeleteWebhookByID(webhookID)
+
+		if err != nil {
+			l.Errorf("couldn't delete: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("webhook deleted")
+		c.Status(http.StatusNoContent)
+		return
+	}
+}
+
+//HandleGETWebhookDeliveries - Route to retrieve the delivery log of a webhook
+// @summary Retrieves the deliveries of a webhook
+// @description Retrieves the deliveries of a webhook, most recent first, with the outcome of their last attempt
+// @tags Webhooks
+// @accept json
+// @produce json
+// @Param id path int true "ID of the webhook"
+// @Param status query string false "Delivery status: pending, delivered or dead"
+// @Param limit query int false "Maximum number of deliveries"
+// @Param offset query int false "Number of deliveries to skip"
+// @success 200 {array} models.WebhookDelivery
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /webhooks/{id}/deliveries [get]
+func HandleGETWebhookDeliveries(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-webhook-deliveries")
+
+		webhookID, err := strconv.ParseInt(c.Param("webhookID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid webhook id format")
+			return
+		}
+
+		var query models.WebhookDeliveryQueryParam
+		if err := c.ShouldBindQuery(&query); err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		l = l.With("webhookID", webhookID, "query", query)
+
+		deliveries, err := storage.RetrieveWebhookDeliveries(webhookID, query)
+
+		if err != nil {
+			l.Errorf("error retrieving webhook deliveries in db: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("webhook deliveries successfully retrieved")
+		c.JSON(http.StatusOK, deliveries)
+		return
+	}
+}
+
+//HandlePOSTWebhookRedeliver - Route to redeliver a webhook delivery
+// @summary Redeliver a webhook delivery
+// @description Queues a delivery again with a fresh set of attempts, whether it was delivered or dead-lettered
+// @tags Webhooks
+// @accept json
+// @produce json
+// @Param id path int true "ID of the webhook"
+// @Param deliveryID path int true "ID of the delivery"
+// @success 202 {} Accepted
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /webhooks/{id}/deliveries/{deliveryID}/redeliver [post]
+func HandlePOSTWebhookRedeliver(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] redeliver-webhook")
+
+		webhookID, err := strconv.ParseInt(c.Param("webhookID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid webhook id format")
+			return
+		}
+
+		deliveryID, err := strconv.ParseInt(c.Param("deliveryID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid delivery id format")
+			return
+		}
+
+		l = l.With("webhookID", webhookID, "deliveryID", deliveryID)
+
+		err = storage.RedeliverWebhookDelivery(webhookID, deliveryID)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find delivery")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("couldn't queue redelivery: %s", err.Error())
+			models.SetErrorStatusJSON(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		l.Debug("redelivery queued")
+		c.Status(http.StatusAccepted)
+		return
+	}
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+const dateFormat = "2006-01-02"
+
+//HandleGETWorklogs - Route to retrieve the work logged on an issue
+// @summary Retrieves the worklogs of an issue
+// @description Retrieves the work logged on an issue
+// @tags Time Tracking
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @success 200 {array} models.Worklog
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/worklogs [get]
+func HandleGETWorklogs(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-worklogs")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		worklogs, err := storage.RetrieveWorklogs(issueID)
+
+		if err != nil {
+			l.Errorf("error retrieving worklogs in db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("worklogs successfully retrieved")
+		c.JSON(http.StatusOK, worklogs)
+		return
+	}
+}
+
+//HandlePOSTWorklog - Route to log work on an issue
+// @summary Log work on an issue
+// @description Logs work on an issue, reducing its remaining estimate unless adjustEstimate is leave
+// @tags Time Tracking
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @param worklogRequest body models.NewWorklogRequest true "YAITS worklog creation request"
+// @success 201 {object} models.IssueIDResponse
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/worklogs [post]
+func HandlePOSTWorklog(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[POST] create-worklog")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		var req models.NewWorklogRequest
+		err = c.ShouldBindJSON(&req)
+
+		l = l.With("request", req, "issueID", issueID)
+		l.Debug("received worklog creation request")
+
+		if err != nil {
+			l.Errorf("couldn't bind to worklog request: %s", err.Error())
+			setBindingError(c, err)
+			return
+		}
+
+		if req.WorkDate == "" {
+			req.WorkDate = time.Now().Format(dateFormat)
+		}
+		if _, err = time.Parse(dateFormat, req.WorkDate); err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "work date must be formatted as YYYY-MM-DD")
+			return
+		}
+		if req.AdjustEstimate != "" && req.AdjustEstimate != "auto" && req.AdjustEstimate != persistence.AdjustEstimateLeave {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "adjust estimate must be auto or leave")
+			return
+		}
+
+		id, err := storage.CreateWorklog(req, issueID)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find issue")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("couldn't insert into db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("insertion successful")
+		c.JSON(http.StatusCreated, models.IssueIDResponse{ID: id})
+		return
+	}
+}
+
+//HandleDELETEWorklog - Route to delete logged work
+// @summary Delete a worklog
+// @description Deletes a worklog, giving its time back to the remaining estimate of the issue
+// @tags Time Tracking
+// @accept json
+// @produce json
+// @Param id path int true "ID of the issue"
+// @Param worklogID path int true "ID of the worklog"
+// @success 204 {} No Content
+// @failure 400 {object} models.ErrorWrapper
+// @failure 404 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /issue/{id}/worklogs/{worklogID} [delete]
+func HandleDELETEWorklog(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[DELETE] delete-worklog")
+
+		issueID, err := strconv.ParseInt(c.Param("issueID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid issue id format")
+			return
+		}
+
+		worklogID, err := strconv.ParseInt(c.Param("worklogID"), 10, 64)
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "invalid worklog id format")
+			return
+		}
+
+		l = l.With("issueID", issueID, "worklogID", worklogID)
+
+		err = storage.DeleteWorklog(issueID, worklogID)
+
+		if err == sql.ErrNoRows {
+			models.SetErrorStatusJSON(c, http.StatusNotFound, "could not find worklog")
+			return
+		}
+
+		if err != nil {
+			l.Errorf("couldn't delete: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("worklog deleted")
+		c.Status(http.StatusNoContent)
+		return
+	}
+}
+
+//HandleGETWorklogTotals - Route to aggregate logged work
+// @summary Aggregates logged work
+// @description Sums the work logged per issue, user or project over an optional date range
+// @tags Time Tracking
+// @accept json
+// @produce json
+// @param from query string false "first day included (YYYY-MM-DD)"
+// @param to query string false "last day included (YYYY-MM-DD)"
+// @param groupBy query string false "issue (default), user or project"
+// @param user query string false "user filter"
+// @param project query string false "project filter"
+// @param issueID query int false "issue filter"
+// @success 200 {array} models.WorklogTotal
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /worklogs/totals [get]
+func HandleGETWorklogTotals(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-worklog-totals")
+
+		var worklogQuery models.WorklogQueryParam
+		err := c.ShouldBindQuery(&worklogQuery)
+		if err == nil && !validDates(worklogQuery.From, worklogQuery.To) {
+			err = errInvalidDate
+		}
+		if err == nil && worklogQuery.GroupBy != "" && worklogQuery.GroupBy != "issue" &&
+			worklogQuery.GroupBy != "user" && worklogQuery.GroupBy != "project" {
+			err = errInvalidGroupBy
+		}
+		if err != nil {
+			models.SetErrorStatusJSON(c, http.StatusBadRequest, "could not aggregate worklogs: "+err.Error())
+			return
+		}
+
+		totals, err := storage.RetrieveWorklogTotals(worklogQuery)
+
+		if err != nil {
+			l.Errorf("error aggregating worklogs in db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("worklog totals successfully retrieved")
+		c.JSON(http.StatusOK, totals)
+		return
+	}
+}
+
+//HandleGETTimesheet - Route to retrieve the weekly timesheet of a user
+// @summary Retrieves the timesheet of a user
+// @description Retrieves the work logged by a user over the week (monday to sunday) containing the given day
+// @tags Time Tracking
+// @accept json
+// @produce json
+// @Param user path string true "user name"
+// @param week query string false "any day of the week (YYYY-MM-DD), defaults to the current week"
+// @success 200 {object} models.Timesheet
+// @failure 400 {object} models.ErrorWrapper
+// @failure 500 {object} models.ErrorWrapper
+// @router /timesheets/{user} [get]
+func HandleGETTimesheet(storage persistence.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := c.MustGet("logger").(*zap.SugaredLogger).With("handler", "[GET] get-timesheet")
+
+		day := time.Now()
+		if week := c.Query("week"); week != "" {
+			var err error
+			if day, err = time.Parse(dateFormat, week); err != nil {
+				models.SetErrorStatusJSON(c, http.StatusBadRequest, "week must be formatted as YYYY-MM-DD")
+				return
+			}
+		}
+
+		// weeks start on monday
+		offset := (int(day.Weekday()) + 6) % 7
+		weekStart := time.Date(day.Year(), day.Month(), day.Day()-offset, 0, 0, 0, 0, time.UTC)
+		weekEnd := weekStart.AddDate(0, 0, 6)
+
+		user := c.Param("user")
+		worklogs, err := storage.RetrieveUserWorklogs(user, weekStart.Format(dateFormat), weekEnd.Format(dateFormat))
+
+		if err != nil {
+			l.Errorf("error retrieving worklogs in db: %s", err.Error())
+			setStorageError(c, err)
+			return
+		}
+
+		l.Debug("timesheet successfully retrieved")
+		c.JSON(http.StatusOK, buildTimesheet(user, weekStart, worklogs))
+		return
+	}
+}
+
+// buildTimesheet spreads the worklogs over the seven days of the week
+func buildTimesheet(user string, weekStart time.Time, worklogs []models.Worklog) models.Timesheet {
+	timesheet := models.Timesheet{
+		User:      user,
+		WeekStart: weekStart.Format(dateFormat),
+		WeekEnd:   weekStart.AddDate(0, 0, 6).Format(dateFormat),
+		Days:      make([]models.TimesheetDay, 7),
+	}
+
+	for i := range timesheet.Days {
+		timesheet.Days[i] = models.TimesheetDay{
+			Date:     weekStart.AddDate(0, 0, i).Format(dateFormat),
+			Worklogs: make([]models.Worklog, 0),
+		}
+	}
+
+	for _, worklog := range worklogs {
+		for i := range timesheet.Days {
+			if timesheet.Days[i].Date == worklog.WorkDate {
+				timesheet.Days[i].Minutes += worklog.Minutes
+				timesheet.Days[i].Worklogs = append(timesheet.Days[i].Worklogs, worklog)
+				timesheet.TotalMinutes += worklog.Minutes
+			}
+		}
+	}
+
+	return timesheet
+}
+
+var (
+	errInvalidDate    = errors.New("dates must be formatted as YYYY-MM-DD")
+	errInvalidGroupBy = errors.New("group by must be issue, user or project")
+)
+
+func validDates(dates ...string) bool {
+	for _, date := range dates {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dateFormat, date); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
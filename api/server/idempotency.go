@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+)
+
+const (
+	// IdempotencyKeyHeader is the header of the key clients send with writes they may retry, for them to be applied once
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on responses replayed to the retries of a request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// defaultIdempotencyTTL is how long the responses to writes with an Idempotency-Key are kept unless configured
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// defaultIdempotencyMaxBodySize is the largest body of a write with an Idempotency-Key unless configured, its body
+// being read in memory to fingerprint the request
+const defaultIdempotencyMaxBodySize = 16 << 20
+
+// idempotentMethods are the methods an Idempotency-Key is honoured on
+var idempotentMethods = map[string]bool{http.MethodPost: true, http.MethodPatch: true, http.MethodDelete: true}
+
+// replayedHeaders are the response headers stored along with the body, to be replayed
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// idempotency makes writes sent with an Idempotency-Key apply once: the response to the first request is stored and
+// replayed to the requests reusing the key until it expires, keys being scoped to their user. Reusing a key for a
+// different request is rejected with 422, and while the first request is in progress with 409. Responses with a 5xx
+// status are not stored, for the request to be retried. Bodies larger than maxBodySize are rejected with 413
+func idempotency(storage persistence.Storage, ttl time.Duration, maxBodySize int64) gin.HandlerFunc {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	if maxBodySize <= 0 {
+		maxBodySize = defaultIdempotencyMaxBodySize
+	}
+
+	return func(context *gin.Context) {
+		key := context.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !idempotentMethods[context.Request.Method] {
+			return
+		}
+
+		l := context.MustGet("logger").(*zap.SugaredLogger).With("idempotencyKey", key)
+		if len(key) > maxIdempotencyKeyLength {
+			context.AbortWithStatusJSON(http.StatusBadRequest, models.NewErrorWrapper(http.StatusBadRequest,
+				"the Idempotency-Key header can't be longer than 255 characters"))
+			return
+		}
+
+		body, err := ioutil.ReadAll(io.LimitReader(context.Request.Body, maxBodySize+1))
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, models.NewErrorWrapper(http.StatusBadRequest, err.Error()))
+			return
+		}
+		if int64(len(body)) > maxBodySize {
+			context.AbortWithStatusJSON(http.StatusRequestEntityTooLarge,
+				models.NewErrorWrapper(http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("requests with an Idempotency-Key are limited to %d bytes", maxBodySize)))
+			return
+		}
+		context.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		reservation := models.IdempotentResponse{
+			User:        context.GetString("user"),
+			Key:         key,
+			Fingerprint: requestFingerprint(context.Request, body),
+			ExpireDate:  time.Now().Add(ttl),
+		}
+
+		stored, reserved, err := storage.ReserveIdempotencyKey(reservation)
+		if err != nil {
+			l.Errorf("error reserving the idempotency key in db: %s", err.Error())
+			context.AbortWithStatusJSON(http.StatusInternalServerError,
+				models.NewErrorWrapper(http.StatusInternalServerError, err.Error()))
+			return
+		}
+
+		if !reserved {
+			switch {
+			case stored.Fingerprint != reservation.Fingerprint:
+				context.AbortWithStatusJSON(http.StatusUnprocessableEntity,
+					models.NewErrorWrapper(http.StatusUnprocessableEntity,
+						"the Idempotency-Key was already used for a different request"))
+			case stored.Status == 0:
+				context.AbortWithStatusJSON(http.StatusConflict, models.NewErrorWrapper(http.StatusConflict,
+					"a request with this Idempotency-Key is in progress"))
+			default:
+				l.Debug("replaying the response to the idempotency key")
+				for name, value := range stored.Header {
+					context.Header(name, value)
+				}
+				context.Header(IdempotentReplayedHeader, "true")
+				context.Status(stored.Status)
+				context.Writer.WriteHeaderNow()
+				context.Writer.Write(stored.Body)
+				context.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: context.Writer}
+		context.Writer = recorder
+
+		// the key is released when the request fails or panics, for it to be retried
+		saved := false
+		defer func() {
+			if !saved {
+				if err := storage.ReleaseIdempotencyKey(reservation.User, key); err != nil {
+					l.Errorf("error releasing the idempotency key in db: %s", err.Error())
+				}
+			}
+		}()
+
+		context.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		response := reservation
+		response.Status = status
+		response.Body = recorder.body.Bytes()
+		response.Header = map[string]string{}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				response.Header[name] = value
+			}
+		}
+
+		if err := storage.SaveIdempotentResponse(response); err != nil {
+			l.Errorf("error saving the idempotent response in db: %s", err.Error())
+			return
+		}
+		saved = true
+	}
+}
+
+// requestFingerprint identifies a request by its method, URL and body
+func requestFingerprint(request *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(request.Method + " " + request.URL.RequestURI() + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the body written, for the response to be stored
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
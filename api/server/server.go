@@ -1,44 +1,135 @@
 package server
 
 import (
+	"database/sql"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"net/http"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/importer"
+	"github.com/YAITS/api/models"
 	"github.com/YAITS/api/persistence"
 	"github.com/YAITS/api/server/handlers"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-func NewServer(address string, logger *zap.SugaredLogger, storage persistence.Storage) *http.Server {
-	router := BuildRouter(logger, storage)
+// Config is the configuration of the API
+type Config struct {
+	// RequireToken makes the API reject requests without a valid bearer token instead of trusting the X-YAITS-User
+	// header, configuration changes then requiring an administrator token
+	RequireToken bool
+
+	// IdempotencyTTL is how long the responses to writes with an Idempotency-Key are kept for their retries to
+	// replay, 24 hours when zero
+	IdempotencyTTL time.Duration
+
+	// IdempotencyMaxBodySize is the largest body in bytes of a write with an Idempotency-Key, 16 MiB when zero
+	IdempotencyMaxBodySize int64
+}
+
+// tokenExemptRoutes are authenticated their own way, by signature or by unsubscribe token, or public
+var tokenExemptRoutes = map[string]bool{
+	"/api/slack/commands": true,
+	"/api/vcs/webhook":    true,
+	"/api/unsubscribe":    true,
+	"/api/swagger/*any":   true,
+}
+
+func NewServer(address string, logger *zap.SugaredLogger, storage persistence.Storage, attachments handlers.Attachments,
+	hub *events.Hub, integrations handlers.Integrations, config Config) *http.Server {
+	router := BuildRouter(logger, storage, attachments, hub, integrations, config)
 	return &http.Server{
 		Addr:    address,
 		Handler: router,
 	}
 }
 
-func BuildRouter(logger *zap.SugaredLogger, storage persistence.Storage) *gin.Engine {
+func BuildRouter(logger *zap.SugaredLogger, storage persistence.Storage, attachments handlers.Attachments,
+	hub *events.Hub, integrations handlers.Integrations, config Config) *gin.Engine {
 	router := gin.New()
 
 	router.Use(setupLogger(logger))
+	router.Use(setupUser(storage, config.RequireToken))
 	router.Use(gin.Recovery())
 
 	apiGroup := router.Group("/api")
+	apiGroup.Use(idempotency(storage, config.IdempotencyTTL, config.IdempotencyMaxBodySize))
+	adminOnly := requireAdmin(config.RequireToken)
 
 	apiGroup.GET("/issue/:issueID", handlers.HandleGETByID(storage))
 	apiGroup.GET("/issues", handlers.HandleGETAllIssues(storage))
 	apiGroup.GET("/issues/status", handlers.HandleGETByStatus(storage))
 	apiGroup.GET("/issues/priority", handlers.HandleGETByPriority(storage))
+	apiGroup.GET("/issues/sla", handlers.HandleGETBySLA(storage))
+	apiGroup.GET("/issues/export", handlers.HandleGETExport(storage))
+	apiGroup.GET("/issue/:issueID/sla-breaches", handlers.HandleGETSLABreaches(storage))
+	apiGroup.GET("/issue/:issueID/worklogs", handlers.HandleGETWorklogs(storage))
+	apiGroup.GET("/issue/:issueID/attachments", handlers.HandleGETAttachments(storage))
+	apiGroup.GET("/attachments/:attachmentID", handlers.HandleGETAttachmentContent(storage, attachments))
+	apiGroup.GET("/worklogs/totals", handlers.HandleGETWorklogTotals(storage))
+	apiGroup.GET("/timesheets/:user", handlers.HandleGETTimesheet(storage))
 
 	apiGroup.POST("/issue", handlers.HandlePOST(storage))
+	apiGroup.POST("/issue/:issueID/comments", handlers.HandlePOSTComment(storage))
+	apiGroup.POST("/issue/:issueID/worklogs", handlers.HandlePOSTWorklog(storage))
+	apiGroup.POST("/issue/:issueID/attachments", handlers.HandlePOSTAttachment(storage, attachments))
+	apiGroup.POST("/issue/:issueID/comments/:commentID/attachments", handlers.HandlePOSTAttachment(storage, attachments))
 
 	apiGroup.PATCH("/issue/:issueID", handlers.HandlePATCH(storage))
 
-	apiGroup.DELETE("/issue/:issueID", handlers.HandleDELETE(storage))
+	apiGroup.POST("/issues/bulk", handlers.HandlePOSTBulk(storage, attachments))
+	apiGroup.GET("/issues/bulk/:jobID", handlers.HandleGETBulkJob(storage))
+
+	apiGroup.DELETE("/issue/:issueID", handlers.HandleDELETE(storage, attachments))
+	apiGroup.DELETE("/issue/:issueID/worklogs/:worklogID", handlers.HandleDELETEWorklog(storage))
+	apiGroup.DELETE("/attachments/:attachmentID", handlers.HandleDELETEAttachment(storage, attachments))
+
+	apiGroup.GET("/issue-types", handlers.HandleGETIssueTypes(storage))
+	apiGroup.POST("/issue-types", adminOnly, handlers.HandlePOSTIssueType(storage))
+	apiGroup.DELETE("/issue-types/:issueTypeID", adminOnly, handlers.HandleDELETEIssueType(storage))
+
+	apiGroup.GET("/sla-policies", handlers.HandleGETSLAPolicies(storage))
+	apiGroup.POST("/sla-policies", adminOnly, handlers.HandlePOSTSLAPolicy(storage))
+	apiGroup.DELETE("/sla-policies/:policyID", adminOnly, handlers.HandleDELETESLAPolicy(storage))
+	apiGroup.GET("/calendars", handlers.HandleGETBusinessCalendars(storage))
+	apiGroup.POST("/calendars", adminOnly, handlers.HandlePOSTBusinessCalendar(storage))
+
+	apiGroup.GET("/issue/:issueID/watchers", handlers.HandleGETWatchers(storage))
+	apiGroup.PUT("/issue/:issueID/watchers/:user", handlers.HandlePUTWatcher(storage))
+	apiGroup.DELETE("/issue/:issueID/watchers/:user", handlers.HandleDELETEWatcher(storage))
+	apiGroup.GET("/me/notifications", handlers.HandleGETNotifications(storage))
+	apiGroup.GET("/me/notifications/unread", handlers.HandleGETUnreadNotificationCount(storage))
+	apiGroup.POST("/me/notifications/:notificationID", handlers.HandlePOSTNotificationsRead(storage))
+	apiGroup.POST("/me/notifications/:notificationID/read", handlers.HandlePOSTNotificationsRead(storage))
+	apiGroup.GET("/me/email-preferences", handlers.HandleGETEmailPreferences(storage))
+	apiGroup.PUT("/me/email-preferences", handlers.HandlePUTEmailPreferences(storage))
+	apiGroup.GET("/unsubscribe", handlers.HandleGETUnsubscribe(storage))
+
+	apiGroup.GET("/webhooks", handlers.HandleGETWebhooks(storage))
+	apiGroup.POST("/webhooks", adminOnly, handlers.HandlePOSTWebhook(storage))
+	apiGroup.DELETE("/webhooks/:webhookID", adminOnly, handlers.HandleDELETEWebhook(storage))
+	apiGroup.GET("/webhooks/:webhookID/deliveries", handlers.HandleGETWebhookDeliveries(storage))
+	apiGroup.POST("/webhooks/:webhookID/deliveries/:deliveryID/redeliver", adminOnly, handlers.HandlePOSTWebhookRedeliver(storage))
+
+	apiGroup.POST("/slack/commands", handlers.HandlePOSTSlackCommand(storage, integrations.Slack))
+
+	imports := importer.NewImporter(storage, logger)
+	apiGroup.POST("/import", handlers.HandlePOSTImport(storage, imports))
+	apiGroup.GET("/import/:jobID", handlers.HandleGETImport(storage))
+
+	apiGroup.POST("/vcs/webhook", handlers.HandlePOSTVCSWebhook(storage, integrations.VCS))
+	apiGroup.GET("/issue/:issueID/links", handlers.HandleGETIssueLinks(storage))
+
+	apiGroup.POST("/graphql", handlers.HandlePOSTGraphQL(storage, attachments))
+
+	apiGroup.GET("/events", handlers.HandleGETEvents(hub))
+	apiGroup.GET("/issue/:issueID/events", handlers.HandleGETIssueEvents(hub))
 
 	apiGroup.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -52,3 +143,47 @@ func setupLogger(logger *zap.SugaredLogger) gin.HandlerFunc {
 		context.Set("logger", loggerWithReqID)
 	}
 }
+
+// setupUser sets the user owning the bearer token of the request, anonymous requests acting as the user of the
+// X-YAITS-User header unless tokens are required
+func setupUser(storage persistence.Storage, requireToken bool) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		token := strings.TrimSpace(strings.TrimPrefix(context.GetHeader("Authorization"), "Bearer "))
+		if token == "" {
+			if requireToken && !tokenExemptRoutes[context.FullPath()] {
+				context.AbortWithStatusJSON(http.StatusUnauthorized,
+					models.NewErrorWrapper(http.StatusUnauthorized, "a bearer token is required"))
+				return
+			}
+
+			context.Set("user", context.GetHeader(handlers.UserHeader))
+			return
+		}
+
+		user, admin, err := storage.RetrieveTokenUser(token)
+		if err == sql.ErrNoRows {
+			context.AbortWithStatusJSON(http.StatusUnauthorized,
+				models.NewErrorWrapper(http.StatusUnauthorized, "invalid bearer token"))
+			return
+		}
+		if err != nil {
+			context.MustGet("logger").(*zap.SugaredLogger).Errorf("error retrieving token in db: %s", err.Error())
+			context.AbortWithStatusJSON(http.StatusInternalServerError,
+				models.NewErrorWrapper(http.StatusInternalServerError, err.Error()))
+			return
+		}
+
+		context.Set("user", user)
+		context.Set("admin", admin)
+	}
+}
+
+// requireAdmin restricts a configuration route to administrators when tokens are required
+func requireAdmin(requireToken bool) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if requireToken && !context.GetBool("admin") {
+			context.AbortWithStatusJSON(http.StatusForbidden,
+				models.NewErrorWrapper(http.StatusForbidden, "an administrator token is required"))
+		}
+	}
+}
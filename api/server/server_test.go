@@ -1,26 +1,41 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"net/textproto"
+	neturl "net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/YAITS/api/blob"
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/export"
 	"github.com/YAITS/api/models"
 	persistence "github.com/YAITS/api/persistence/mock"
+	"github.com/YAITS/api/server/handlers"
+	"github.com/YAITS/api/slack"
+	"github.com/YAITS/api/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewServer(t *testing.T) {
 	gin.SetMode(gin.ReleaseMode)
-	server := getServer()
+	server := getServer(Config{})
 	startServer(server)
 
 	t.Run("api", func(t *testing.T) {
@@ -53,7 +68,16 @@ func TestNewServer(t *testing.T) {
 			_ = json.Unmarshal(body, &issueResponse)
 
 			assert.Equal(t, persistence.MockIssueResponse, issueResponse, "issue response matches mock")
+			assert.Equal(t, `"1"`, response.Header.Get("ETag"))
 			verifyResponse(t, response, err, http.StatusOK)
+
+			t.Run("IfNoneMatch", func(t *testing.T) {
+				response, err := sendRequestWithHeader(url, "GET", "", "If-None-Match", `"1"`)
+				verifyResponse(t, response, err, http.StatusNotModified)
+
+				response, err = sendRequestWithHeader(url, "GET", "", "If-None-Match", `W/"0", "2"`)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
 		})
 
 		t.Run("HandleGETByStatus", func(t *testing.T) {
@@ -98,6 +122,67 @@ func TestNewServer(t *testing.T) {
 
 			assert.Equal(t, int64(1), resp.ID, "a new issue id was returned")
 			verifyResponse(t, response, err, http.StatusCreated)
+
+			t.Run("IdempotencyKey", func(t *testing.T) {
+				response, err := sendRequestWithHeader(url, "POST", string(requestBodyJSON), "Idempotency-Key", "create-1")
+				verifyResponse(t, response, err, http.StatusCreated)
+				first, _ := ioutil.ReadAll(response.Body)
+				assert.Empty(t, response.Header.Get("Idempotent-Replayed"))
+
+				response, err = sendRequestWithHeader(url, "POST", string(requestBodyJSON), "Idempotency-Key", "create-1")
+				verifyResponse(t, response, err, http.StatusCreated)
+				replayed, _ := ioutil.ReadAll(response.Body)
+				assert.Equal(t, "true", response.Header.Get("Idempotent-Replayed"))
+				assert.Equal(t, string(first), string(replayed))
+				assert.Equal(t, "application/json; charset=utf-8", response.Header.Get("Content-Type"))
+
+				response, err = sendRequestWithHeader(url, "POST", `{"summary":"Other"}`, "Idempotency-Key", "create-1")
+				verifyResponse(t, response, err, http.StatusUnprocessableEntity)
+
+				response, err = sendRequestWithHeader(url, "POST", `{"description":"no summary"}`, "Idempotency-Key",
+					"create-2")
+				verifyResponse(t, response, err, http.StatusBadRequest)
+				response, err = sendRequestWithHeader(url, "POST", `{"description":"no summary"}`, "Idempotency-Key",
+					"create-2")
+				verifyResponse(t, response, err, http.StatusBadRequest)
+				assert.Equal(t, "true", response.Header.Get("Idempotent-Replayed"))
+
+				response, err = sendRequestWithHeader(url, "POST", string(requestBodyJSON), "Idempotency-Key",
+					strings.Repeat("k", 256))
+				verifyResponse(t, response, err, http.StatusBadRequest)
+
+				t.Run("BodyTooLarge", func(t *testing.T) {
+					limitedServer := getServer(Config{IdempotencyMaxBodySize: 16})
+					startServer(limitedServer)
+					defer limitedServer.Close()
+
+					response, err := sendRequestWithHeader(fmt.Sprintf("http://%s/api/issue", limitedServer.Addr),
+						"POST", string(requestBodyJSON), "Idempotency-Key", "create-3")
+					verifyResponse(t, response, err, http.StatusRequestEntityTooLarge)
+				})
+			})
+
+			t.Run("Validation", func(t *testing.T) {
+				response, err := sendRequest(url, "POST", `{"summary":"Login fails","description":"Blank page","priority":11}`)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+
+				var errorResponse models.ErrorWrapper
+				body, _ := ioutil.ReadAll(response.Body)
+				_ = json.Unmarshal(body, &errorResponse)
+				assert.Equal(t, []models.StandardError{{Code: http.StatusBadRequest, Title: "Bad Request",
+					Description: "priority must be between 1 and 10", Field: "priority", Reason: "out_of_range"}},
+					errorResponse.Errors, "the priority is checked before reaching the database")
+
+				response, err = sendRequest(url, "POST", `{"summary":"Login fails","priority":"high"}`)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+
+				body, _ = ioutil.ReadAll(response.Body)
+				_ = json.Unmarshal(body, &errorResponse)
+				if assert.Len(t, errorResponse.Errors, 1) {
+					assert.Equal(t, "priority", errorResponse.Errors[0].Field)
+					assert.Equal(t, "invalid_type", errorResponse.Errors[0].Reason)
+				}
+			})
 		})
 
 		t.Run("HandlePATCH", func(t *testing.T) {
@@ -117,7 +202,849 @@ func TestNewServer(t *testing.T) {
 			_ = json.Unmarshal(body, &issueResponse)
 
 			assert.Equal(t, persistence.MockIssueResponse, issueResponse, "a new issue id was returned")
+			assert.Equal(t, `"1"`, response.Header.Get("ETag"))
+			verifyResponse(t, response, err, http.StatusOK)
+
+			t.Run("Malformed", func(t *testing.T) {
+				response, err := sendRequest(url, "PATCH", `{"summary":`)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+
+				var errorResponse models.ErrorWrapper
+				body, _ := ioutil.ReadAll(response.Body)
+				assert.NoError(t, json.Unmarshal(body, &errorResponse), "the error is wrapped")
+				if assert.Len(t, errorResponse.Errors, 1) {
+					assert.Equal(t, "malformed", errorResponse.Errors[0].Reason)
+				}
+			})
+
+			t.Run("IfMatch", func(t *testing.T) {
+				response, err := sendRequestWithHeader(url, "PATCH", string(requestBodyJSON), "If-Match", `"1"`)
+				verifyResponse(t, response, err, http.StatusOK)
+
+				response, err = sendRequestWithHeader(url, "PATCH", string(requestBodyJSON), "If-Match", `"0"`)
+				verifyResponse(t, response, err, http.StatusPreconditionFailed)
+				assert.Equal(t, `"1"`, response.Header.Get("ETag"))
+
+				response, err = sendRequestWithHeader(url, "PATCH", string(requestBodyJSON), "If-Match", `W/"1"`)
+				verifyResponse(t, response, err, http.StatusPreconditionFailed)
+			})
+
+			t.Run("MergePatch", func(t *testing.T) {
+				response, err := sendRequestWithHeader(url, "PATCH", `{"assignee":null,"dueDate":null}`,
+					"Content-Type", "application/merge-patch+json")
+				verifyResponse(t, response, err, http.StatusOK)
+				assert.Equal(t, "application/merge-patch+json, application/json-patch+json",
+					response.Header.Get("Accept-Patch"))
+
+				response, err = sendRequestWithHeader(url, "PATCH", `{"summary":null}`,
+					"Content-Type", "application/merge-patch+json")
+				verifyResponse(t, response, err, http.StatusUnprocessableEntity)
+
+				response, err = sendRequestWithHeader(url, "PATCH", `{"comment":"Done"}`,
+					"Content-Type", "application/merge-patch+json")
+				verifyResponse(t, response, err, http.StatusUnprocessableEntity)
+
+				response, err = sendRequestWithHeader(url, "PATCH", `{"summary":`,
+					"Content-Type", "application/merge-patch+json")
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+
+			t.Run("JSONPatch", func(t *testing.T) {
+				body := fmt.Sprintf(`[{"op":"test","path":"/summary","value":%q},`+
+					`{"op":"replace","path":"/status","value":"closed"},{"op":"remove","path":"/assignee"}]`, persistence.Summary)
+				response, err := sendRequestWithHeader(url, "PATCH", body, "Content-Type", "application/json-patch+json")
+				verifyResponse(t, response, err, http.StatusOK)
+
+				response, err = sendRequestWithHeader(url, "PATCH", `[{"op":"test","path":"/summary","value":"Other"}]`,
+					"Content-Type", "application/json-patch+json")
+				verifyResponse(t, response, err, http.StatusConflict)
+
+				response, err = sendRequestWithHeader(url, "PATCH", `[{"op":"replace","path":"/priority","value":11}]`,
+					"Content-Type", "application/json-patch+json")
+				verifyResponse(t, response, err, http.StatusUnprocessableEntity)
+
+				response, err = sendRequestWithHeader(url, "PATCH", `[{"op":"merge","path":"/priority","value":1}]`,
+					"Content-Type", "application/json-patch+json")
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+		})
+
+		t.Run("HandleGETEvents", func(t *testing.T) {
+			stream, err := sendRequest(fmt.Sprintf("%s/issue/1/events", baseURL), "GET", "")
+			verifyResponse(t, stream, err, http.StatusOK)
+			defer stream.Body.Close()
+
+			assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+
+			// events are published by the outbox relay
+			testHub.Publish(models.IssueEvent{Event: "issue.commented", IssueID: persistence.IssueID, Comment: "This is a comment"})
+
+			fields := make(map[string]string)
+			reader := bufio.NewReader(stream.Body)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == "\n" {
+					break
+				}
+
+				parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+				fields[parts[0]] = parts[1]
+			}
+
+			var event models.IssueEvent
+			_ = json.Unmarshal([]byte(fields["data"]), &event)
+
+			assert.NotEmpty(t, fields["id"], "events are numbered for resumption")
+			assert.Equal(t, "issue.commented", fields["event"])
+			assert.Equal(t, "This is a comment", event.Comment)
+			assert.Equal(t, persistence.IssueID, event.IssueID)
+		})
+
+		t.Run("HandlePOSTWithIssueType", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issue", baseURL)
+
+			t.Run("MissingRequiredField", func(t *testing.T) {
+				requestBody := models.NewIssueRequest{
+					Summary: persistence.MockIssueResponse.Summary,
+					Type:    persistence.MockIssueType.Name,
+				}
+
+				requestBodyJSON, _ := json.Marshal(requestBody)
+
+				response, err := sendRequest(url, "POST", string(requestBodyJSON))
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+
+			t.Run("DefaultsApplied", func(t *testing.T) {
+				requestBody := models.NewIssueRequest{
+					Summary: persistence.MockIssueResponse.Summary,
+					Type:    persistence.MockIssueType.Name,
+					Fields:  map[string]string{"steps to reproduce": "open the app"},
+				}
+
+				requestBodyJSON, _ := json.Marshal(requestBody)
+
+				response, err := sendRequest(url, "POST", string(requestBodyJSON))
+				verifyResponse(t, response, err, http.StatusCreated)
+			})
+		})
+
+		t.Run("HandleGETIssueTypes", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issue-types?project=API", baseURL)
+			response, err := sendRequest(url, "GET", "")
+
+			body, _ := ioutil.ReadAll(response.Body)
+			var issueTypes []models.IssueType
+			_ = json.Unmarshal(body, &issueTypes)
+
+			assert.Equal(t, []models.IssueType{persistence.MockIssueType}, issueTypes, "issue types match mock")
+			verifyResponse(t, response, err, http.StatusOK)
+		})
+
+		t.Run("HandlePOSTIssueType", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issue-types", baseURL)
+			requestBody := models.NewIssueTypeRequest{
+				Project:        "API",
+				Name:           "Incident",
+				RequiredFields: []string{"impact"},
+			}
+
+			requestBodyJSON, _ := json.Marshal(requestBody)
+
+			response, err := sendRequest(url, "POST", string(requestBodyJSON))
+			verifyResponse(t, response, err, http.StatusCreated)
+		})
+
+		t.Run("HandleGETBySLA", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issues/sla?state=breached", baseURL)
+			response, err := sendRequest(url, "GET", "")
+
+			body, _ := ioutil.ReadAll(response.Body)
+			var issueResponse []models.IssueResponse
+			_ = json.Unmarshal(body, &issueResponse)
+
+			assert.Empty(t, issueResponse, "mock issue has no computable sla")
+			verifyResponse(t, response, err, http.StatusOK)
+
+			response, err = sendRequest(fmt.Sprintf("%s/issues/sla?state=late", baseURL), "GET", "")
+			verifyResponse(t, response, err, http.StatusBadRequest)
+		})
+
+		t.Run("HandlePOSTSLAPolicy", func(t *testing.T) {
+			url := fmt.Sprintf("%s/sla-policies", baseURL)
+			requestBody := models.NewSLAPolicyRequest{
+				Name:              "P1",
+				Priority:          1,
+				ResponseMinutes:   60,
+				ResolutionMinutes: 24 * 60,
+			}
+
+			requestBodyJSON, _ := json.Marshal(requestBody)
+
+			response, err := sendRequest(url, "POST", string(requestBodyJSON))
+			verifyResponse(t, response, err, http.StatusCreated)
+		})
+
+		t.Run("HandlePOSTWebhook", func(t *testing.T) {
+			url := fmt.Sprintf("%s/webhooks", baseURL)
+
+			t.Run("Valid", func(t *testing.T) {
+				requestBody := models.NewWebhookRequest{
+					URL:    "https://ci.local/hooks/yaits",
+					Secret: "s3cr3t",
+					Events: []string{"issue.created", "issue.commented"},
+				}
+
+				requestBodyJSON, _ := json.Marshal(requestBody)
+
+				response, err := sendRequest(url, "POST", string(requestBodyJSON))
+				verifyResponse(t, response, err, http.StatusCreated)
+			})
+
+			t.Run("UnknownEvent", func(t *testing.T) {
+				requestBody := models.NewWebhookRequest{
+					URL:    "https://ci.local/hooks/yaits",
+					Secret: "s3cr3t",
+					Events: []string{"issue.exploded"},
+				}
+
+				requestBodyJSON, _ := json.Marshal(requestBody)
+
+				response, err := sendRequest(url, "POST", string(requestBodyJSON))
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+
+			t.Run("Slack", func(t *testing.T) {
+				requestBody := models.NewWebhookRequest{
+					URL:    "https://hooks.slack.com/services/T000/B000/XXXX",
+					Events: []string{"issue.created"},
+					Format: "slack",
+				}
+
+				requestBodyJSON, _ := json.Marshal(requestBody)
+
+				response, err := sendRequest(url, "POST", string(requestBodyJSON))
+				verifyResponse(t, response, err, http.StatusCreated)
+			})
+
+			t.Run("MissingSecret", func(t *testing.T) {
+				requestBody := models.NewWebhookRequest{
+					URL:    "https://ci.local/hooks/yaits",
+					Events: []string{"issue.created"},
+				}
+
+				requestBodyJSON, _ := json.Marshal(requestBody)
+
+				response, err := sendRequest(url, "POST", string(requestBodyJSON))
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+
+			t.Run("InvalidURL", func(t *testing.T) {
+				requestBody := models.NewWebhookRequest{
+					URL:    "file:///etc/passwd",
+					Secret: "s3cr3t",
+					Events: []string{"issue.created"},
+				}
+
+				requestBodyJSON, _ := json.Marshal(requestBody)
+
+				response, err := sendRequest(url, "POST", string(requestBodyJSON))
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+		})
+
+		t.Run("HandlePOSTSlackCommand", func(t *testing.T) {
+			url := fmt.Sprintf("%s/slack/commands", baseURL)
+
+			readMessage := func(response *http.Response) slack.Message {
+				var message slack.Message
+				if response != nil {
+					body, _ := ioutil.ReadAll(response.Body)
+					_ = json.Unmarshal(body, &message)
+				}
+				return message
+			}
+
+			t.Run("InvalidSignature", func(t *testing.T) {
+				response, err := sendSlackCommand(url, "show 1", "not the secret")
+				verifyResponse(t, response, err, http.StatusUnauthorized)
+			})
+
+			t.Run("Show", func(t *testing.T) {
+				response, err := sendSlackCommand(url, "show #1", testSlack.SigningSecret)
+				message := readMessage(response)
+
+				assert.Equal(t, slack.ResponseEphemeral, message.ResponseType)
+				assert.Equal(t, "#1 "+persistence.Summary, message.Text)
+				assert.Len(t, message.Blocks, 1)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("ShowOtherProject", func(t *testing.T) {
+				response, err := sendSlackCommand(url, "show API-1", testSlack.SigningSecret)
+				message := readMessage(response)
+
+				assert.Equal(t, "Could not find issue API-1", message.Text)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Create", func(t *testing.T) {
+				response, err := sendSlackCommand(url, "create priority:2 Login fails | since this morning", testSlack.SigningSecret)
+				message := readMessage(response)
+
+				assert.Equal(t, slack.ResponseInChannel, message.ResponseType)
+				assert.True(t, strings.HasPrefix(message.Text, persistence.Assignee+" created #1"),
+					"slack users are mapped to users")
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("CreateInvalid", func(t *testing.T) {
+				response, err := sendSlackCommand(url, "create "+strings.Repeat("s", 65), testSlack.SigningSecret)
+				message := readMessage(response)
+
+				assert.Equal(t, slack.ResponseEphemeral, message.ResponseType)
+				assert.Equal(t, "summary can't be longer than 64 characters", message.Text,
+					"issues are validated as HandlePOST does")
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Assign", func(t *testing.T) {
+				response, err := sendSlackCommand(url, "assign 1 @janedoe", testSlack.SigningSecret)
+				message := readMessage(response)
+
+				assert.Equal(t, slack.ResponseInChannel, message.ResponseType)
+				assert.True(t, strings.HasSuffix(message.Text, "to janedoe"))
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Unknown", func(t *testing.T) {
+				response, err := sendSlackCommand(url, "explode 1", testSlack.SigningSecret)
+				message := readMessage(response)
+
+				assert.Equal(t, slack.ResponseEphemeral, message.ResponseType)
+				assert.Contains(t, message.Text, "unknown command `explode`")
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+		})
+
+		t.Run("HandleGETExport", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issues/export", baseURL)
+
+			t.Run("CSV", func(t *testing.T) {
+				response, err := sendRequest(url+"?columns=id,summary&comments=true&project=API", "GET", "")
+
+				var body []byte
+				if response != nil {
+					body, _ = ioutil.ReadAll(response.Body)
+					assert.Equal(t, "text/csv; charset=utf-8", response.Header.Get("Content-Type"))
+					assert.Contains(t, response.Header.Get("Content-Disposition"), "attachment")
+				}
+
+				assert.Equal(t, "id,summary,comments\n1,"+persistence.Summary+",\n", string(body))
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("XLSX", func(t *testing.T) {
+				response, err := sendRequest(url+"?format=xlsx", "GET", "")
+				if response != nil {
+					assert.Equal(t, export.ContentType(export.FormatXLSX), response.Header.Get("Content-Type"))
+				}
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("UnknownColumn", func(t *testing.T) {
+				response, err := sendRequest(url+"?columns=id,sla", "GET", "")
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+
+			t.Run("UnknownFormat", func(t *testing.T) {
+				response, err := sendRequest(url+"?format=pdf", "GET", "")
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+		})
+
+		t.Run("HandlePOSTImport", func(t *testing.T) {
+			url := fmt.Sprintf("%s/import", baseURL)
+			file := "Title,Priority\nLogin fails,2\n,3\n"
+			options := `{"format":"csv","columns":{"summary":"Title"}}`
+
+			t.Run("DryRun", func(t *testing.T) {
+				response, err := sendImport(url+"?dryRun=true", options, file)
+
+				var report models.ImportReport
+				if response != nil {
+					body, _ := ioutil.ReadAll(response.Body)
+					_ = json.Unmarshal(body, &report)
+				}
+
+				assert.Equal(t, models.ImportReport{Total: 2, Valid: 1, Errors: []models.ImportRowError{
+					{Row: 3, Errors: []string{"summary is required"}}}}, report)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Job", func(t *testing.T) {
+				response, err := sendImport(url, options, file)
+
+				var job models.ImportJob
+				if response != nil {
+					body, _ := ioutil.ReadAll(response.Body)
+					_ = json.Unmarshal(body, &job)
+				}
+
+				assert.Equal(t, persistence.MockImportJob.ID, job.ID)
+				assert.Equal(t, int64(2), job.Total)
+				assert.Equal(t, int64(1), job.Failed)
+				verifyResponse(t, response, err, http.StatusAccepted)
+			})
+
+			t.Run("UnknownFormat", func(t *testing.T) {
+				response, err := sendImport(url, `{"format":"xml"}`, file)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+
+			t.Run("MissingColumn", func(t *testing.T) {
+				response, err := sendImport(url, `{"format":"csv","columns":{"summary":"Name"}}`, file)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+		})
+
+		t.Run("HandleGETImport", func(t *testing.T) {
+			url := fmt.Sprintf("%s/import/1", baseURL)
+			response, err := sendRequest(url, "GET", "")
+			verifyResponse(t, response, err, http.StatusOK)
+		})
+
+		t.Run("HandlePOSTBulk", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issues/bulk", baseURL)
+
+			readJob := func(response *http.Response) models.BulkJob {
+				var job models.BulkJob
+				if response != nil {
+					body, _ := ioutil.ReadAll(response.Body)
+					_ = json.Unmarshal(body, &job)
+				}
+				return job
+			}
+
+			t.Run("IDs", func(t *testing.T) {
+				response, err := sendRequest(url, "POST", `{"ids":[1,2,1],"operation":"setStatus","status":"closed"}`)
+				job := readJob(response)
+
+				assert.Equal(t, []models.BulkItemResult{{IssueID: 1, Success: true},
+					{IssueID: 2, Error: "could not find issue"}}, job.Results, "duplicate ids are changed once")
+				assert.Equal(t, "completed", job.Status)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("AllOrNothing", func(t *testing.T) {
+				response, err := sendRequest(url, "POST",
+					`{"ids":[1,2],"operation":"addLabel","label":"backend","allOrNothing":true}`)
+				job := readJob(response)
+
+				assert.Equal(t, int64(2), job.Failed)
+				assert.Equal(t, "failed", job.Status)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Filter", func(t *testing.T) {
+				response, err := sendRequest(url, "POST",
+					`{"filter":{"project":"API","status":"open"},"operation":"assign","assignee":""}`)
+				job := readJob(response)
+
+				assert.Equal(t, []models.BulkItemResult{{IssueID: persistence.IssueID, Success: true}}, job.Results)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Async", func(t *testing.T) {
+				response, err := sendRequest(url, "POST", `{"ids":[1],"operation":"delete","async":true}`)
+				job := readJob(response)
+
+				assert.Equal(t, persistence.MockBulkJob.ID, job.ID)
+				assert.Equal(t, "queued", job.Status)
+				verifyResponse(t, response, err, http.StatusAccepted)
+			})
+
+			t.Run("Invalid", func(t *testing.T) {
+				for _, body := range []string{
+					`{"operation":"delete"}`,
+					`{"ids":[1],"filter":{"project":"API"},"operation":"delete"}`,
+					`{"filter":{},"operation":"delete"}`,
+					`{"ids":[1],"operation":"archive"}`,
+					`{"ids":[1],"operation":"setPriority","priority":11}`,
+					`{"ids":[1],"operation":"setStatus","status":"done"}`,
+					`{"ids":[1],"operation":"addLabel","label":"a,b"}`,
+					`{"ids":[1],"operation":"addComment","comment":" "}`,
+				} {
+					response, err := sendRequest(url, "POST", body)
+					verifyResponse(t, response, err, http.StatusBadRequest)
+				}
+			})
+		})
+
+		t.Run("HandleGETBulkJob", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issues/bulk/1", baseURL)
+			response, err := sendRequest(url, "GET", "")
 			verifyResponse(t, response, err, http.StatusOK)
+
+			response, err = sendRequest(fmt.Sprintf("%s/issues/bulk/job", baseURL), "GET", "")
+			verifyResponse(t, response, err, http.StatusBadRequest)
+		})
+
+		t.Run("HandlePOSTVCSWebhook", func(t *testing.T) {
+			url := fmt.Sprintf("%s/vcs/webhook", baseURL)
+			push := `{"ref":"refs/heads/main","repository":{"full_name":"yaits/api","default_branch":"main"},
+				"commits":[{"id":"0d1a26e","message":"Fix login redirect\n\nFixes #1, see API-1",
+				"url":"https://github.com/yaits/api/commit/0d1a26e","author":{"username":"johndoe"}}]}`
+
+			readResponse := func(response *http.Response) models.VCSWebhookResponse {
+				var resp models.VCSWebhookResponse
+				if response != nil {
+					body, _ := ioutil.ReadAll(response.Body)
+					_ = json.Unmarshal(body, &resp)
+				}
+				return resp
+			}
+
+			t.Run("Push", func(t *testing.T) {
+				response, err := sendVCSWebhook(url, "push", push, testVCS.Secret)
+				resp := readResponse(response)
+
+				assert.Equal(t, models.VCSWebhookResponse{Linked: 1, Closed: 1}, resp,
+					"references to issues of another project are skipped")
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Ping", func(t *testing.T) {
+				response, err := sendVCSWebhook(url, "ping", `{"zen":"Keep it logically awesome."}`, testVCS.Secret)
+				assert.Equal(t, models.VCSWebhookResponse{}, readResponse(response))
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("InvalidSignature", func(t *testing.T) {
+				response, err := sendVCSWebhook(url, "push", push, "not the secret")
+				verifyResponse(t, response, err, http.StatusUnauthorized)
+			})
+
+			t.Run("UnknownProvider", func(t *testing.T) {
+				response, err := sendRequest(url, "POST", push)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+		})
+
+		t.Run("HandleGETIssueLinks", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issue/1/links", baseURL)
+			response, err := sendRequest(url, "GET", "")
+			verifyResponse(t, response, err, http.StatusOK)
+		})
+
+		t.Run("HandleGETWebhookDeliveries", func(t *testing.T) {
+			url := fmt.Sprintf("%s/webhooks/1/deliveries?status=delivered", baseURL)
+			response, err := sendRequest(url, "GET", "")
+
+			body, _ := ioutil.ReadAll(response.Body)
+			var deliveries []models.WebhookDelivery
+			_ = json.Unmarshal(body, &deliveries)
+
+			assert.Equal(t, []models.WebhookDelivery{persistence.MockWebhookDelivery}, deliveries)
+			verifyResponse(t, response, err, http.StatusOK)
+		})
+
+		t.Run("HandlePOSTWebhookRedeliver", func(t *testing.T) {
+			url := fmt.Sprintf("%s/webhooks/1/deliveries/1/redeliver", baseURL)
+			response, err := sendRequest(url, "POST", "")
+			verifyResponse(t, response, err, http.StatusAccepted)
+		})
+
+		t.Run("HandlePOSTBusinessCalendar", func(t *testing.T) {
+			url := fmt.Sprintf("%s/calendars", baseURL)
+			requestBody := models.NewBusinessCalendarRequest{
+				Name:      "Office hours",
+				Timezone:  "Europe/Paris",
+				StartHour: 18,
+				EndHour:   9,
+			}
+
+			requestBodyJSON, _ := json.Marshal(requestBody)
+
+			response, err := sendRequest(url, "POST", string(requestBodyJSON))
+			verifyResponse(t, response, err, http.StatusBadRequest)
+		})
+
+		t.Run("HandlePOSTComment", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issue/1/comments", baseURL)
+
+			response, err := sendRequest(url, "POST", `{"comment":"Reproduced on Firefox"}`)
+			verifyResponse(t, response, err, http.StatusCreated)
+
+			response, err = sendRequest(url, "POST", `{"comment":""}`)
+			verifyResponse(t, response, err, http.StatusBadRequest)
+		})
+
+		t.Run("HandlePOSTWorklog", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issue/1/worklogs", baseURL)
+			requestBody := models.NewWorklogRequest{
+				User:    persistence.MockWorklog.User,
+				Minutes: persistence.MockWorklog.Minutes,
+			}
+
+			requestBodyJSON, _ := json.Marshal(requestBody)
+
+			response, err := sendRequest(url, "POST", string(requestBodyJSON))
+			verifyResponse(t, response, err, http.StatusCreated)
+
+			requestBody.WorkDate = "08/09/2020"
+			requestBodyJSON, _ = json.Marshal(requestBody)
+
+			response, err = sendRequest(url, "POST", string(requestBodyJSON))
+			verifyResponse(t, response, err, http.StatusBadRequest)
+		})
+
+		t.Run("HandleGETWorklogTotals", func(t *testing.T) {
+			url := fmt.Sprintf("%s/worklogs/totals?groupBy=user&from=2020-09-01", baseURL)
+			response, err := sendRequest(url, "GET", "")
+			verifyResponse(t, response, err, http.StatusOK)
+
+			response, err = sendRequest(fmt.Sprintf("%s/worklogs/totals?groupBy=day", baseURL), "GET", "")
+			verifyResponse(t, response, err, http.StatusBadRequest)
+		})
+
+		t.Run("HandleGETTimesheet", func(t *testing.T) {
+			url := fmt.Sprintf("%s/timesheets/johndoe?week=2020-09-10", baseURL)
+			response, err := sendRequest(url, "GET", "")
+
+			body, _ := ioutil.ReadAll(response.Body)
+			var timesheet models.Timesheet
+			_ = json.Unmarshal(body, &timesheet)
+
+			assert.Equal(t, "2020-09-07", timesheet.WeekStart, "week starts on monday")
+			assert.Len(t, timesheet.Days, 7)
+			assert.Equal(t, persistence.MockWorklog.Minutes, timesheet.Days[1].Minutes, "work is logged on tuesday")
+			assert.Equal(t, persistence.MockWorklog.Minutes, timesheet.TotalMinutes)
+			verifyResponse(t, response, err, http.StatusOK)
+		})
+
+		t.Run("HandlePOSTAttachment", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issue/1/attachments", baseURL)
+
+			t.Run("Stored", func(t *testing.T) {
+				response, err := sendFile(url, "steps.txt", "text/plain", persistence.Description)
+
+				body, _ := ioutil.ReadAll(response.Body)
+				var attachment models.Attachment
+				_ = json.Unmarshal(body, &attachment)
+
+				assert.Equal(t, persistence.MockAttachment.Hash, attachment.Hash, "content is addressed by its hash")
+				verifyResponse(t, response, err, http.StatusCreated)
+			})
+
+			t.Run("OnComment", func(t *testing.T) {
+				response, err := sendFile(fmt.Sprintf("%s/issue/1/comments/1/attachments", baseURL), "steps.txt", "text/plain", persistence.Description)
+				verifyResponse(t, response, err, http.StatusCreated)
+			})
+
+			t.Run("TypeNotAllowed", func(t *testing.T) {
+				response, err := sendFile(url, "report.pdf", "application/pdf", "%PDF-1.4")
+				verifyResponse(t, response, err, http.StatusUnsupportedMediaType)
+			})
+
+			t.Run("TooLarge", func(t *testing.T) {
+				response, err := sendFile(url, "large.txt", "text/plain", string(bytes.Repeat([]byte("a"), 65)))
+				verifyResponse(t, response, err, http.StatusRequestEntityTooLarge)
+			})
+
+			t.Run("BodyTooLarge", func(t *testing.T) {
+				response, err := sendFile(url, "large.txt", "text/plain", string(bytes.Repeat([]byte("a"), 1<<20)))
+				verifyResponse(t, response, err, http.StatusRequestEntityTooLarge)
+			})
+		})
+
+		t.Run("HandleGETAttachmentContent", func(t *testing.T) {
+			url := fmt.Sprintf("%s/attachments/1", baseURL)
+			response, err := sendRequest(url, "GET", "")
+
+			body, _ := ioutil.ReadAll(response.Body)
+
+			assert.Equal(t, persistence.Description, string(body), "attachment content is downloaded")
+			assert.Equal(t, "text/plain", response.Header.Get("Content-Type"))
+			assert.Equal(t, `attachment; filename=steps.txt`, response.Header.Get("Content-Disposition"))
+			verifyResponse(t, response, err, http.StatusOK)
+		})
+
+		t.Run("HandlePUTWatcher", func(t *testing.T) {
+			url := fmt.Sprintf("%s/issue/1/watchers/janedoe", baseURL)
+			response, err := sendRequest(url, "PUT", "")
+			verifyResponse(t, response, err, http.StatusNoContent)
+		})
+
+		t.Run("HandleGETNotifications", func(t *testing.T) {
+			url := fmt.Sprintf("%s/me/notifications?unread=true", baseURL)
+
+			t.Run("Anonymous", func(t *testing.T) {
+				response, err := sendRequest(url, "GET", "")
+				verifyResponse(t, response, err, http.StatusUnauthorized)
+			})
+
+			t.Run("CurrentUser", func(t *testing.T) {
+				response, err := sendRequestAs(url, "GET", "", persistence.Assignee)
+
+				body, _ := ioutil.ReadAll(response.Body)
+				var notifications models.NotificationsResponse
+				_ = json.Unmarshal(body, &notifications)
+
+				assert.Equal(t, int64(1), notifications.UnreadCount)
+				assert.Equal(t, []models.Notification{persistence.MockNotification}, notifications.Notifications)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+		})
+
+		t.Run("HandlePUTEmailPreferences", func(t *testing.T) {
+			url := fmt.Sprintf("%s/me/email-preferences", baseURL)
+
+			t.Run("Anonymous", func(t *testing.T) {
+				response, err := sendRequest(url, "PUT", `{"email": "john.doe@example.com"}`)
+				verifyResponse(t, response, err, http.StatusUnauthorized)
+			})
+
+			t.Run("InvalidEmail", func(t *testing.T) {
+				response, err := sendRequestAs(url, "PUT", `{"email": "john.doe"}`, persistence.Assignee)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+
+			t.Run("Valid", func(t *testing.T) {
+				response, err := sendRequestAs(url, "PUT", `{"email": "john.doe@example.com", "digest": true}`, persistence.Assignee)
+
+				body, _ := ioutil.ReadAll(response.Body)
+				var preferences models.EmailPreferences
+				_ = json.Unmarshal(body, &preferences)
+
+				assert.Equal(t, models.EmailPreferences{User: persistence.Assignee, Email: "john.doe@example.com", Digest: true}, preferences)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+		})
+
+		t.Run("HandleGETUnsubscribe", func(t *testing.T) {
+			response, err := sendRequest(fmt.Sprintf("%s/unsubscribe?token=0123456789abcdef", baseURL), "GET", "")
+			verifyResponse(t, response, err, http.StatusOK)
+
+			response, err = sendRequest(fmt.Sprintf("%s/unsubscribe", baseURL), "GET", "")
+			verifyResponse(t, response, err, http.StatusNotFound)
+		})
+
+		t.Run("HandlePOSTNotificationsRead", func(t *testing.T) {
+			response, err := sendRequestAs(fmt.Sprintf("%s/me/notifications/1/read", baseURL), "POST", "", persistence.Assignee)
+			verifyResponse(t, response, err, http.StatusOK)
+
+			response, err = sendRequestAs(fmt.Sprintf("%s/me/notifications/read?issueID=1", baseURL), "POST", "", persistence.Assignee)
+			verifyResponse(t, response, err, http.StatusOK)
+
+			response, err = sendRequestAs(fmt.Sprintf("%s/me/notifications/1", baseURL), "POST", "", persistence.Assignee)
+			verifyResponse(t, response, err, http.StatusNotFound)
+		})
+
+		t.Run("HandlePOSTGraphQL", func(t *testing.T) {
+			url := fmt.Sprintf("%s/graphql", baseURL)
+
+			t.Run("Query", func(t *testing.T) {
+				response, err := sendRequest(url, "POST",
+					`{"query":"{ issues(status: \"open\", first: 10) { id summary dueDate assignee { name assigned } comments(first: 1) { comment } } }"}`)
+
+				var graphQLResponse models.GraphQLResponse
+				if response != nil {
+					body, _ := ioutil.ReadAll(response.Body)
+					_ = json.Unmarshal(body, &graphQLResponse)
+				}
+
+				assert.Empty(t, graphQLResponse.Errors)
+				assert.Equal(t, map[string]interface{}{"issues": []interface{}{map[string]interface{}{
+					"id":       float64(persistence.IssueID),
+					"summary":  persistence.Summary,
+					"dueDate":  nil,
+					"assignee": map[string]interface{}{"name": persistence.Assignee, "assigned": float64(1)},
+					"comments": []interface{}{map[string]interface{}{"comment": persistence.MockComment.Comment}},
+				}}}, graphQLResponse.Data)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Mutation", func(t *testing.T) {
+				response, err := sendRequestAs(url, "POST",
+					`{"query":"mutation($input: CreateIssueInput!) { createIssue(input: $input) { id } }",`+
+						`"variables":{"input":{"summary":"Login fails","description":"Blank page","priority":2,`+
+						`"fields":[{"name":"browser","value":"Firefox"}]}}}`, persistence.Assignee)
+
+				var graphQLResponse models.GraphQLResponse
+				if response != nil {
+					body, _ := ioutil.ReadAll(response.Body)
+					_ = json.Unmarshal(body, &graphQLResponse)
+				}
+
+				assert.Empty(t, graphQLResponse.Errors)
+				assert.Equal(t, map[string]interface{}{"createIssue": map[string]interface{}{
+					"id": float64(persistence.IssueID)}}, graphQLResponse.Data)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("MissingPriority", func(t *testing.T) {
+				response, err := sendRequest(url, "POST",
+					`{"query":"mutation { createIssue(input: {summary: \"Login fails\", description: \"Blank page\"}) { id } }"}`)
+
+				var graphQLResponse models.GraphQLResponse
+				if response != nil {
+					body, _ := ioutil.ReadAll(response.Body)
+					_ = json.Unmarshal(body, &graphQLResponse)
+				}
+
+				if assert.Len(t, graphQLResponse.Errors, 1) {
+					assert.Equal(t, "priority is required", graphQLResponse.Errors[0].Message)
+					assert.Equal(t, &models.GraphQLErrorExtensions{Errors: []models.StandardError{{
+						Code: http.StatusBadRequest, Title: "Bad Request", Description: "priority is required",
+						Field: "priority", Reason: validation.CodeRequired}}}, graphQLResponse.Errors[0].Extensions,
+						"the fields in violation are in the extensions")
+				}
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("TooComplex", func(t *testing.T) {
+				response, err := sendRequest(url, "POST",
+					`{"query":"{ issues(first: 100) { id comments(first: 100) { id comment } } }"}`)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+
+			t.Run("Invalid", func(t *testing.T) {
+				response, err := sendRequest(url, "POST", `{"query":"{ issues { unknown } }"}`)
+				verifyResponse(t, response, err, http.StatusBadRequest)
+			})
+		})
+
+		t.Run("BearerToken", func(t *testing.T) {
+			url := fmt.Sprintf("%s/me/notifications", baseURL)
+
+			t.Run("Valid", func(t *testing.T) {
+				response, err := sendRequestWithToken(url, "GET", "", persistence.Token)
+				verifyResponse(t, response, err, http.StatusOK)
+			})
+
+			t.Run("Invalid", func(t *testing.T) {
+				response, err := sendRequestWithToken(url, "GET", "", "forged-token")
+				verifyResponse(t, response, err, http.StatusUnauthorized)
+			})
+
+			t.Run("Required", func(t *testing.T) {
+				tokenServer := getServer(Config{RequireToken: true})
+				startServer(tokenServer)
+				defer tokenServer.Close()
+				tokenURL := fmt.Sprintf("http://%s/api", tokenServer.Addr)
+
+				response, err := sendRequestAs(fmt.Sprintf("%s/me/notifications", tokenURL), "GET", "",
+					persistence.Assignee)
+				verifyResponse(t, response, err, http.StatusUnauthorized)
+
+				response, err = sendRequest(fmt.Sprintf("%s/unsubscribe?token=abc", tokenURL), "GET", "")
+				verifyResponse(t, response, err, http.StatusOK)
+
+				response, err = sendRequestWithToken(fmt.Sprintf("%s/issue-types/1", tokenURL), "DELETE", "",
+					persistence.Token)
+				verifyResponse(t, response, err, http.StatusNoContent)
+			})
 		})
 
 		t.Run("HandleDELETE", func(t *testing.T) {
@@ -126,8 +1053,7 @@ func TestNewServer(t *testing.T) {
 			response, err := sendRequest(url, "DELETE", "")
 
 			body, _ := ioutil.ReadAll(response.Body)
-			var issueResponse models.IssueResponse
-			_ = json.Unmarshal(body, &issueResponse)
+			assert.Empty(t, body, "no content is sent")
 
 			verifyResponse(t, response, err, http.StatusNoContent)
 		})
@@ -143,6 +1069,10 @@ func startServer(s *http.Server) {
 }
 
 func sendRequest(url string, method string, body string) (*http.Response, error) {
+	return sendRequestAs(url, method, body, "")
+}
+
+func sendRequestAs(url string, method string, body string, user string) (*http.Response, error) {
 	client := &http.Client{}
 	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
 
@@ -150,9 +1080,35 @@ func sendRequest(url string, method string, body string) (*http.Response, error)
 		return nil, err
 	}
 
+	if user != "" {
+		req.Header.Set(handlers.UserHeader, user)
+	}
+
 	return client.Do(req)
 }
 
+func sendRequestWithToken(url string, method string, body string, token string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return http.DefaultClient.Do(req)
+}
+
+func sendRequestWithHeader(url string, method string, body string, header string, value string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(header, value)
+
+	return http.DefaultClient.Do(req)
+}
+
 func verifyResponse(t *testing.T, response *http.Response, err error, expectedStatus int) {
 	if err != nil {
 		t.Errorf("Error on response from server: %s", err)
@@ -168,7 +1124,51 @@ func verifyResponse(t *testing.T, response *http.Response, err error, expectedSt
 	}
 }
 
-func getServer() *http.Server {
+func sendFile(url, filename, contentType, content string) (*http.Response, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, filename))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = part.Write([]byte(content))
+	_ = writer.Close()
+
+	return http.Post(url, writer.FormDataContentType(), body)
+}
+
+// sendImport uploads a file to import along with its import options
+func sendImport(url, options, content string) (*http.Response, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	_ = writer.WriteField("options", options)
+	part, err := writer.CreateFormFile("file", "issues.csv")
+	if err != nil {
+		return nil, err
+	}
+	_, _ = part.Write([]byte(content))
+	_ = writer.Close()
+
+	return http.Post(url, writer.FormDataContentType(), body)
+}
+
+var testHub = events.NewHub(10)
+
+var testSlack = handlers.Slack{
+	SigningSecret: "8f742231b10e8888abcd99yyyzzz85a5",
+	BaseURL:       "http://localhost:8080",
+	Users:         map[string]string{"U2CERLKJA": persistence.Assignee},
+}
+
+var testVCS = handlers.VCS{Secret: "It's a Secret to Everybody"}
+
+func getServer(config Config) *http.Server {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		panic(err)
@@ -179,7 +1179,58 @@ func getServer() *http.Server {
 	port := listener.Addr().(*net.TCPAddr).Port
 	address := fmt.Sprintf("127.0.0.1:%d", port)
 
+	blobRoot, err := ioutil.TempDir("", "yaits-attachments")
+	if err != nil {
+		panic(err)
+	}
+
+	blobStore, err := blob.NewLocalStore(blobRoot)
+	if err != nil {
+		panic(err)
+	}
+
+	attachments := handlers.Attachments{
+		Store:        blobStore,
+		MaxSize:      64,
+		AllowedTypes: []string{"text/*", "image/png"},
+	}
+
 	logger := zap.NewNop().Sugar()
 	storage := persistence.NewMockStorage()
-	return NewServer(address, logger, storage)
+	return NewServer(address, logger, storage, attachments, testHub,
+		handlers.Integrations{Slack: testSlack, VCS: testVCS}, config)
+}
+
+// sendSlackCommand sends a /yaits slash command signed as Slack does
+func sendSlackCommand(url, text, secret string) (*http.Response, error) {
+	body := "command=%2Fyaits&user_id=U2CERLKJA&user_name=roadrunner&text=" + neturl.QueryEscape(text)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest("POST", url, bytes.NewBufferString(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(slack.TimestampHeader, timestamp)
+	req.Header.Set(slack.SignatureHeader, slack.Sign(secret, timestamp, []byte(body)))
+
+	return http.DefaultClient.Do(req)
+}
+
+// sendVCSWebhook sends a GitHub webhook signed as GitHub does
+func sendVCSWebhook(url, event, body, secret string) (*http.Response, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewBufferString(body))
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	return http.DefaultClient.Do(req)
 }
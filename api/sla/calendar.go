@@ -0,0 +1,75 @@
+package sla
+
+import (
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// Calendar counts time only during business hours, a zero Calendar counting around the clock
+type Calendar struct {
+	location  *time.Location
+	workdays  map[time.Weekday]bool
+	startHour int
+	endHour   int
+}
+
+// NewCalendar builds a Calendar out of its stored definition, nil meaning around the clock
+func NewCalendar(calendar *models.BusinessCalendar) Calendar {
+	if calendar == nil || calendar.EndHour <= calendar.StartHour {
+		return Calendar{}
+	}
+
+	location, err := time.LoadLocation(calendar.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	workdays := make(map[time.Weekday]bool)
+	for _, day := range calendar.Workdays {
+		workdays[time.Weekday(day%7)] = true
+	}
+
+	// default to monday through friday
+	if len(workdays) == 0 {
+		for day := time.Monday; day <= time.Friday; day++ {
+			workdays[day] = true
+		}
+	}
+
+	return Calendar{
+		location:  location,
+		workdays:  workdays,
+		startHour: calendar.StartHour,
+		endHour:   calendar.EndHour,
+	}
+}
+
+// Add returns the moment when the given amount of business time has elapsed since start
+func (cal Calendar) Add(start time.Time, d time.Duration) time.Time {
+	if cal.location == nil {
+		return start.Add(d)
+	}
+
+	cursor := start.In(cal.location)
+
+	for {
+		year, month, day := cursor.Date()
+		opening := time.Date(year, month, day, cal.startHour, 0, 0, 0, cal.location)
+		closing := time.Date(year, month, day, cal.endHour, 0, 0, 0, cal.location)
+
+		if cal.workdays[cursor.Weekday()] && cursor.Before(closing) {
+			if cursor.Before(opening) {
+				cursor = opening
+			}
+
+			available := closing.Sub(cursor)
+			if d <= available {
+				return cursor.Add(d)
+			}
+			d -= available
+		}
+
+		cursor = time.Date(year, month, day+1, 0, 0, 0, 0, cal.location)
+	}
+}
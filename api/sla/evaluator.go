@@ -0,0 +1,120 @@
+package sla
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+)
+
+// Evaluator periodically checks the SLA of the issues that may have missed a target and records the breaches it finds
+type Evaluator struct {
+	storage  persistence.Storage
+	logger   *zap.SugaredLogger
+	interval time.Duration
+
+	// evaluated is when the last evaluation ran, targets done since then being checked once more
+	evaluated time.Time
+}
+
+// NewEvaluator creates an Evaluator running every interval
+func NewEvaluator(storage persistence.Storage, logger *zap.SugaredLogger, interval time.Duration) *Evaluator {
+	return &Evaluator{storage: storage, logger: logger.With("worker", "sla-evaluator"), interval: interval}
+}
+
+// Run evaluates the SLAs until the context is cancelled
+func (e *Evaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Evaluate(time.Now()); err != nil {
+			e.logger.Errorf("error evaluating slas: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate records a breach event for every target missed at the given time. Only the issues old enough for the
+// shortest target of the policies to have passed are evaluated, calendars only pushing deadlines further, and among
+// them those with a target running, or done since the last evaluation, whose breach isn't recorded yet
+func (e *Evaluator) Evaluate(now time.Time) error {
+	policies, err := e.storage.RetrieveSLAPolicies()
+	if err != nil {
+		return err
+	}
+
+	response, resolution := shortestTargets(policies)
+	if response == 0 && resolution == 0 {
+		return nil
+	}
+
+	issues, err := e.storage.RetrieveSLADueIssues(createdBefore(now, response), createdBefore(now, resolution),
+		e.evaluated)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		status := Evaluate(issue, policies, now)
+		if status == nil {
+			continue
+		}
+
+		if status.ResponseState == StateBreached {
+			e.record(models.SLABreach{IssueID: issue.ID, Target: TargetResponse, Policy: status.Policy})
+		}
+		if status.ResolutionState == StateBreached {
+			e.record(models.SLABreach{IssueID: issue.ID, Target: TargetResolution, Policy: status.Policy})
+		}
+	}
+
+	e.evaluated = now
+	return nil
+}
+
+// shortestTargets returns the shortest response and resolution targets of the policies in minutes, 0 when no policy
+// sets one
+func shortestTargets(policies []models.SLAPolicy) (int64, int64) {
+	var response, resolution int64
+	for _, policy := range policies {
+		if policy.ResponseMinutes > 0 && (response == 0 || policy.ResponseMinutes < response) {
+			response = policy.ResponseMinutes
+		}
+		if policy.ResolutionMinutes > 0 && (resolution == 0 || policy.ResolutionMinutes < resolution) {
+			resolution = policy.ResolutionMinutes
+		}
+	}
+
+	return response, resolution
+}
+
+// createdBefore returns the creation date issues must precede to have missed a target of the given minutes, the zero
+// time matching no issue when no policy sets the target
+func createdBefore(now time.Time, minutes int64) time.Time {
+	if minutes == 0 {
+		return time.Time{}
+	}
+
+	return now.Add(-time.Duration(minutes) * time.Minute)
+}
+
+func (e *Evaluator) record(breach models.SLABreach) {
+	recorded, err := e.storage.RecordSLABreach(breach)
+	if err != nil {
+		e.logger.Errorf("couldn't record sla breach: %s", err.Error())
+		return
+	}
+
+	if recorded {
+		e.logger.Infow("sla breached", "issueID", breach.IssueID, "target", breach.Target, "policy", breach.Policy)
+	}
+}
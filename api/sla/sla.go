@@ -0,0 +1,122 @@
+package sla
+
+import (
+	"time"
+
+	"github.com/YAITS/api/models"
+)
+
+// SLA target states
+const (
+	StateOK       = "ok"
+	StateAtRisk   = "at risk"
+	StateBreached = "breached"
+	StateMet      = "met"
+)
+
+// SLA targets
+const (
+	TargetResponse   = "response"
+	TargetResolution = "resolution"
+)
+
+// AtRiskRatio is the fraction of a target left before it is reported as at risk
+var AtRiskRatio = 0.2
+
+// MatchPolicy returns the most specific policy applying to the issue, project and priority matches
+// winning over policies left blank for any project or priority
+func MatchPolicy(issue models.IssueResponse, policies []models.SLAPolicy) *models.SLAPolicy {
+	var match *models.SLAPolicy
+	bestScore := -1
+
+	for i, policy := range policies {
+		if policy.Project != "" && policy.Project != issue.Project {
+			continue
+		}
+		if policy.Priority != 0 && policy.Priority != issue.Priority {
+			continue
+		}
+
+		score := 0
+		if policy.Project != "" {
+			score += 2
+		}
+		if policy.Priority != 0 {
+			score++
+		}
+
+		if score > bestScore {
+			match = &policies[i]
+			bestScore = score
+		}
+	}
+
+	return match
+}
+
+// Evaluate computes the SLA status of an issue at the given time, nil when no policy applies
+// or the creation date is unknown
+func Evaluate(issue models.IssueResponse, policies []models.SLAPolicy, now time.Time) *models.SLAStatus {
+	policy := MatchPolicy(issue, policies)
+	if policy == nil {
+		return nil
+	}
+
+	created, err := time.Parse(time.RFC3339, issue.CreateDate)
+	if err != nil {
+		return nil
+	}
+
+	calendar := NewCalendar(policy.Calendar)
+	status := &models.SLAStatus{Policy: policy.Name}
+
+	if policy.ResponseMinutes > 0 {
+		due, state := evaluateTarget(calendar, created, policy.ResponseMinutes, issue.RespondedDate, now)
+		status.ResponseDue = due.UTC().Format(time.RFC3339)
+		status.ResponseState = state
+	}
+
+	if policy.ResolutionMinutes > 0 {
+		due, state := evaluateTarget(calendar, created, policy.ResolutionMinutes, issue.ResolvedDate, now)
+		status.ResolutionDue = due.UTC().Format(time.RFC3339)
+		status.ResolutionState = state
+	}
+
+	return status
+}
+
+// Flagged tells whether an SLA status has a target in the given state, or at risk or breached when no state is given
+func Flagged(status *models.SLAStatus, state string) bool {
+	if status == nil {
+		return false
+	}
+
+	if state != "" {
+		return status.ResponseState == state || status.ResolutionState == state
+	}
+
+	return Flagged(status, StateAtRisk) || Flagged(status, StateBreached)
+}
+
+func evaluateTarget(calendar Calendar, start time.Time, minutes int64, doneDate string, now time.Time) (time.Time, string) {
+	target := time.Duration(minutes) * time.Minute
+	due := calendar.Add(start, target)
+
+	if done, err := time.Parse(time.RFC3339, doneDate); err == nil {
+		if done.After(due) {
+			return due, StateBreached
+		}
+		return due, StateMet
+	}
+
+	if now.After(due) {
+		return due, StateBreached
+	}
+
+	riskPoint := calendar.Add(start, time.Duration(float64(target)*(1-AtRiskRatio)))
+	if !now.Before(riskPoint) {
+		return due, StateAtRisk
+	}
+
+	return due, StateOK
+}
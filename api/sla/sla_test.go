@@ -0,0 +1,151 @@
+package sla
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	mock "github.com/YAITS/api/persistence/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var officeHours = &models.BusinessCalendar{
+	Name:      "Office hours",
+	Timezone:  "UTC",
+	Workdays:  []int{1, 2, 3, 4, 5},
+	StartHour: 9,
+	EndHour:   17,
+}
+
+func TestCalendar_Add(t *testing.T) {
+	// friday 2020-09-04
+	friday := time.Date(2020, 9, 4, 16, 0, 0, 0, time.UTC)
+
+	t.Run("AroundTheClock", func(t *testing.T) {
+		due := NewCalendar(nil).Add(friday, 2*time.Hour)
+		assert.Equal(t, friday.Add(2*time.Hour), due, "no calendar adds wall time")
+	})
+
+	t.Run("WithinBusinessHours", func(t *testing.T) {
+		due := NewCalendar(officeHours).Add(friday, 30*time.Minute)
+		assert.Equal(t, time.Date(2020, 9, 4, 16, 30, 0, 0, time.UTC), due, "due the same day")
+	})
+
+	t.Run("OverTheWeekend", func(t *testing.T) {
+		due := NewCalendar(officeHours).Add(friday, 2*time.Hour)
+		assert.Equal(t, time.Date(2020, 9, 7, 10, 0, 0, 0, time.UTC), due, "weekend hours are skipped")
+	})
+
+	t.Run("BeforeOpening", func(t *testing.T) {
+		early := time.Date(2020, 9, 7, 6, 0, 0, 0, time.UTC)
+		due := NewCalendar(officeHours).Add(early, time.Hour)
+		assert.Equal(t, time.Date(2020, 9, 7, 10, 0, 0, 0, time.UTC), due, "time starts counting at opening")
+	})
+}
+
+func TestMatchPolicy(t *testing.T) {
+	policies := []models.SLAPolicy{
+		{Name: "default", ResolutionMinutes: 60},
+		{Name: "p1", Priority: 1, ResolutionMinutes: 60},
+		{Name: "api", Project: "API", ResolutionMinutes: 60},
+		{Name: "other", Project: "WEB", Priority: 1, ResolutionMinutes: 60},
+	}
+
+	assert.Equal(t, "api", MatchPolicy(models.IssueResponse{Project: "API", Priority: 1}, policies).Name, "project wins over priority")
+	assert.Equal(t, "p1", MatchPolicy(models.IssueResponse{Priority: 1}, policies).Name, "priority wins over default")
+	assert.Equal(t, "default", MatchPolicy(models.IssueResponse{Priority: 3}, policies).Name, "default policy applies")
+	assert.Nil(t, MatchPolicy(models.IssueResponse{}, policies[3:]), "no policy applies")
+}
+
+func TestEvaluate(t *testing.T) {
+	policies := []models.SLAPolicy{{Name: "p1", Priority: 1, ResponseMinutes: 60, ResolutionMinutes: 24 * 60}}
+	created := time.Date(2020, 9, 4, 12, 0, 0, 0, time.UTC)
+	issue := models.IssueResponse{Priority: 1, CreateDate: created.Format(time.RFC3339)}
+
+	t.Run("OK", func(t *testing.T) {
+		status := Evaluate(issue, policies, created.Add(10*time.Minute))
+		assert.Equal(t, StateOK, status.ResponseState)
+		assert.Equal(t, StateOK, status.ResolutionState)
+		assert.Equal(t, "2020-09-04T13:00:00Z", status.ResponseDue)
+	})
+
+	t.Run("AtRisk", func(t *testing.T) {
+		status := Evaluate(issue, policies, created.Add(50*time.Minute))
+		assert.Equal(t, StateAtRisk, status.ResponseState)
+		assert.True(t, Flagged(status, ""))
+	})
+
+	t.Run("Breached", func(t *testing.T) {
+		status := Evaluate(issue, policies, created.Add(2*time.Hour))
+		assert.Equal(t, StateBreached, status.ResponseState)
+		assert.True(t, Flagged(status, StateBreached))
+	})
+
+	t.Run("Met", func(t *testing.T) {
+		responded := issue
+		responded.RespondedDate = created.Add(30 * time.Minute).Format(time.RFC3339)
+
+		status := Evaluate(responded, policies, created.Add(2*time.Hour))
+		assert.Equal(t, StateMet, status.ResponseState)
+		assert.False(t, Flagged(status, ""))
+	})
+
+	t.Run("NoPolicy", func(t *testing.T) {
+		assert.Nil(t, Evaluate(models.IssueResponse{Priority: 2}, policies, created))
+	})
+}
+
+// dueStorage serves a due issue, recording the queries of the evaluator and the breaches it records
+type dueStorage struct {
+	mock.Storage
+	policies []models.SLAPolicy
+	issue    models.IssueResponse
+	queries  [][3]time.Time
+	breaches []models.SLABreach
+}
+
+func (storage *dueStorage) RetrieveSLAPolicies() ([]models.SLAPolicy, error) {
+	return storage.policies, nil
+}
+
+func (storage *dueStorage) RetrieveSLADueIssues(responseCreatedBefore, resolutionCreatedBefore,
+	doneSince time.Time) ([]models.IssueResponse, error) {
+	storage.queries = append(storage.queries, [3]time.Time{responseCreatedBefore, resolutionCreatedBefore, doneSince})
+	return []models.IssueResponse{storage.issue}, nil
+}
+
+func (storage *dueStorage) RecordSLABreach(breach models.SLABreach) (bool, error) {
+	storage.breaches = append(storage.breaches, breach)
+	return true, nil
+}
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	created := time.Date(2020, 9, 4, 12, 0, 0, 0, time.UTC)
+	now := created.Add(2 * time.Hour)
+	storage := &dueStorage{
+		policies: []models.SLAPolicy{
+			{Name: "p1", Priority: 1, ResponseMinutes: 60, ResolutionMinutes: 24 * 60},
+			{Name: "p2", Priority: 2, ResponseMinutes: 4 * 60},
+		},
+		issue: models.IssueResponse{ID: 1, Priority: 1, CreateDate: created.Format(time.RFC3339)},
+	}
+	evaluator := NewEvaluator(storage, zap.NewNop().Sugar(), time.Minute)
+
+	assert.Nil(t, evaluator.Evaluate(now))
+	assert.Nil(t, evaluator.Evaluate(now.Add(time.Minute)))
+
+	if assert.Len(t, storage.queries, 2) {
+		assert.Equal(t, [3]time.Time{now.Add(-time.Hour), now.Add(-24 * time.Hour), {}}, storage.queries[0],
+			"issues are selected by the shortest target of each kind")
+		assert.Equal(t, now, storage.queries[1][2], "targets done since the last evaluation are checked")
+	}
+	assert.Equal(t, models.SLABreach{IssueID: 1, Target: TargetResponse, Policy: "p1"}, storage.breaches[0])
+
+	t.Run("NoTarget", func(t *testing.T) {
+		storage := &dueStorage{policies: []models.SLAPolicy{{Name: "none"}}}
+		assert.Nil(t, NewEvaluator(storage, zap.NewNop().Sugar(), time.Minute).Evaluate(now))
+		assert.Empty(t, storage.queries, "no issue is retrieved without targets")
+	})
+}
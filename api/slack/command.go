@@ -0,0 +1,191 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers Slack signs its requests with, the signature being v0= followed by the hex HMAC-SHA256 of
+// v0:<timestamp>:<body> keyed by the signing secret of the app
+const (
+	SignatureHeader = "X-Slack-Signature"
+	TimestampHeader = "X-Slack-Request-Timestamp"
+)
+
+// maxRequestAge bounds how old a signed request may be, for recorded requests not to be replayed
+const maxRequestAge = 5 * time.Minute
+
+// Slash commands
+const (
+	CommandCreate = "create"
+	CommandShow   = "show"
+	CommandAssign = "assign"
+	CommandHelp   = "help"
+)
+
+// Usage describes the slash commands
+const Usage = "Usage:\n" +
+	"• `/yaits create [project:API] [priority:2] [type:Bug] summary [| description]` creates an issue\n" +
+	"• `/yaits show API-12` shows an issue\n" +
+	"• `/yaits assign API-12 [@user]` assigns an issue, to yourself when no user is given"
+
+// Errors returned verifying a request
+var (
+	ErrInvalidSignature = errors.New("invalid request signature")
+	ErrStaleRequest     = errors.New("request timestamp is too old")
+)
+
+var issueKeyPattern = regexp.MustCompile(`^(?:([A-Za-z][A-Za-z0-9_]*)-|#)?(\d+)$`)
+
+var mentionPattern = regexp.MustCompile(`^<@([A-Z0-9]+)(?:\|([^>]*))?>$`)
+
+// createOptions are the options a create command may start with, a summary starting with anything else
+var createOptions = map[string]bool{"project": true, "priority": true, "type": true}
+
+// Command is a parsed slash command, Project being the project of the issue key or the one to create the issue in
+type Command struct {
+	Name        string
+	IssueID     int64
+	Project     string
+	Assignee    string
+	AssigneeID  string
+	Summary     string
+	Description string
+	Priority    int64
+	Type        string
+}
+
+// Sign returns the signature header value of a request body sent at the given Unix timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify authenticates a request signed by Slack, rejecting requests signed more than five minutes from now
+func Verify(secret, timestamp, signature string, body []byte, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return ErrStaleRequest
+	}
+
+	if secret == "" || !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// ParseCommand parses the text following /yaits, an empty text asking for help
+func ParseCommand(text string) (Command, error) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return Command{Name: CommandHelp}, nil
+	}
+
+	command := Command{Name: strings.ToLower(words[0])}
+	args := words[1:]
+
+	switch command.Name {
+	case CommandHelp:
+	case CommandShow:
+		if len(args) != 1 {
+			return command, errors.New("`show` expects an issue key such as API-12")
+		}
+		return command, command.parseKey(args[0])
+	case CommandAssign:
+		if len(args) < 1 || len(args) > 2 {
+			return command, errors.New("`assign` expects an issue key and optionally a user")
+		}
+		if len(args) == 2 {
+			command.parseUser(args[1])
+		}
+		return command, command.parseKey(args[0])
+	case CommandCreate:
+		return command, command.parseCreate(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), words[0])))
+	default:
+		return command, fmt.Errorf("unknown command `%s`", words[0])
+	}
+
+	return command, nil
+}
+
+func (command *Command) parseKey(key string) error {
+	match := issueKeyPattern.FindStringSubmatch(key)
+	if match == nil {
+		return fmt.Errorf("`%s` is not an issue key such as API-12", key)
+	}
+
+	command.Project = match[1]
+	command.IssueID, _ = strconv.ParseInt(match[2], 10, 64)
+
+	return nil
+}
+
+// parseUser reads a user given as @name or, when Slack escapes mentions, as <@U024BE7LH|name>
+func (command *Command) parseUser(user string) {
+	match := mentionPattern.FindStringSubmatch(user)
+	if match == nil {
+		command.Assignee = strings.TrimPrefix(user, "@")
+		return
+	}
+
+	command.AssigneeID, command.Assignee = match[1], match[2]
+}
+
+// parseCreate reads the leading options of a create command, the rest being the summary and the description
+// following a |
+func (command *Command) parseCreate(text string) error {
+	for {
+		words := strings.SplitN(text, " ", 2)
+		option := strings.SplitN(words[0], ":", 2)
+		if len(option) != 2 || option[1] == "" || !createOptions[strings.ToLower(option[0])] {
+			break
+		}
+
+		switch strings.ToLower(option[0]) {
+		case "project":
+			command.Project = option[1]
+		case "type":
+			command.Type = option[1]
+		case "priority":
+			priority, err := strconv.ParseInt(option[1], 10, 64)
+			if err != nil || priority < 1 {
+				return fmt.Errorf("invalid priority `%s`", option[1])
+			}
+			command.Priority = priority
+		}
+
+		if len(words) < 2 {
+			text = ""
+			break
+		}
+		text = strings.TrimSpace(words[1])
+	}
+
+	parts := strings.SplitN(text, "|", 2)
+	command.Summary = strings.TrimSpace(parts[0])
+	if len(parts) == 2 {
+		command.Description = strings.TrimSpace(parts[1])
+	}
+
+	if command.Summary == "" {
+		return errors.New("`create` expects a summary")
+	}
+
+	return nil
+}
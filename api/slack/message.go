@@ -0,0 +1,166 @@
+package slack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+)
+
+// Response types of slash command replies, ephemeral ones being only shown to the user who ran the command
+const (
+	ResponseEphemeral = "ephemeral"
+	ResponseInChannel = "in_channel"
+)
+
+// eventColors are the colors of the attachment bar of event messages
+var eventColors = map[string]string{
+	events.IssueCreated:   "#2eb67d",
+	events.IssueUpdated:   "#1d9bd1",
+	events.IssueCommented: "#9e9ea6",
+	events.IssueDeleted:   "#e01e5a",
+}
+
+// Message is a Slack message as posted to incoming webhooks or replied to slash commands, Text being the fallback
+// shown in notifications
+type Message struct {
+	ResponseType string       `json:"response_type,omitempty"`
+	Text         string       `json:"text"`
+	Blocks       []Block      `json:"blocks,omitempty"`
+	Attachments  []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a secondary part of a message, shown with a colored bar
+type Attachment struct {
+	Color  string  `json:"color,omitempty"`
+	Blocks []Block `json:"blocks"`
+}
+
+// Block is a Block Kit layout block, sections holding a text and fields and contexts holding elements
+type Block struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text,omitempty"`
+	Fields   []Text `json:"fields,omitempty"`
+	Elements []Text `json:"elements,omitempty"`
+}
+
+// Text is a Block Kit text object
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Markdown returns a mrkdwn text object
+func Markdown(text string) Text {
+	return Text{Type: "mrkdwn", Text: text}
+}
+
+// Escape escapes the characters mrkdwn gives a meaning to
+func Escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// IssueKey returns the key users refer to an issue with, such as API-12, or #12 for issues of no project
+func IssueKey(project string, issueID int64) string {
+	if project == "" {
+		return "#" + strconv.FormatInt(issueID, 10)
+	}
+
+	return strings.ToUpper(project) + "-" + strconv.FormatInt(issueID, 10)
+}
+
+// EventMessage formats an issue event for a channel, baseURL being the public URL of the API issues are linked to
+func EventMessage(event models.IssueEvent, baseURL string) Message {
+	key := IssueKey(event.Project, event.IssueID)
+	summary := ""
+	if event.Issue != nil {
+		summary = event.Issue.Summary
+	}
+
+	var verb, detail string
+	switch event.Event {
+	case events.IssueCreated:
+		verb = "created"
+		if event.Issue != nil {
+			detail = event.Issue.Description
+		}
+	case events.IssueUpdated:
+		verb, detail = "updated", event.Changes
+	case events.IssueCommented:
+		verb, detail = "commented on", event.Comment
+	case events.IssueDeleted:
+		verb = "deleted"
+	default:
+		verb = event.Event
+	}
+
+	actor := event.Actor
+	if actor == "" {
+		actor = "someone"
+	}
+
+	title := fmt.Sprintf("*%s %s*", key, Escape(summary))
+	if event.Event != events.IssueDeleted {
+		title = fmt.Sprintf("*<%s|%s %s>*", issueURL(baseURL, event.IssueID), key, Escape(summary))
+	}
+	if detail != "" {
+		title += "\n" + Escape(detail)
+	}
+
+	blocks := []Block{{Type: "section", Text: &Text{Type: "mrkdwn", Text: title}}}
+	if event.Issue != nil && event.Event != events.IssueDeleted {
+		blocks[0].Fields = issueFields(*event.Issue)
+	}
+	blocks = append(blocks, Block{Type: "context", Elements: []Text{
+		Markdown(fmt.Sprintf("%s %s %s", Escape(actor), verb, key)),
+	}})
+
+	return Message{
+		Text:        strings.TrimSpace(fmt.Sprintf("%s %s %s %s", actor, verb, key, summary)),
+		Attachments: []Attachment{{Color: eventColors[event.Event], Blocks: blocks}},
+	}
+}
+
+// IssueMessage formats an issue in reply to a slash command
+func IssueMessage(issue models.IssueResponse, baseURL, responseType string) Message {
+	key := IssueKey(issue.Project, issue.ID)
+
+	text := fmt.Sprintf("*<%s|%s %s>*", issueURL(baseURL, issue.ID), key, Escape(issue.Summary))
+	if issue.Description != "" {
+		text += "\n" + Escape(issue.Description)
+	}
+
+	return Message{
+		ResponseType: responseType,
+		Text:         fmt.Sprintf("%s %s", key, issue.Summary),
+		Blocks: []Block{{
+			Type:   "section",
+			Text:   &Text{Type: "mrkdwn", Text: text},
+			Fields: issueFields(issue),
+		}},
+	}
+}
+
+// TextMessage is a plain reply to a slash command
+func TextMessage(text, responseType string) Message {
+	return Message{ResponseType: responseType, Text: text}
+}
+
+func issueFields(issue models.IssueResponse) []Text {
+	fields := []Text{
+		Markdown("*Status*\n" + Escape(issue.Status)),
+		Markdown("*Assignee*\n" + Escape(issue.Assignee)),
+		Markdown("*Priority*\n" + strconv.FormatInt(issue.Priority, 10)),
+	}
+	if issue.Reporter != "" {
+		fields = append(fields, Markdown("*Reporter*\n"+Escape(issue.Reporter)))
+	}
+
+	return fields
+}
+
+func issueURL(baseURL string, issueID int64) string {
+	return fmt.Sprintf("%s/api/issue/%d", strings.TrimSuffix(baseURL, "/"), issueID)
+}
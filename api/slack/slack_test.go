@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorded request from the Slack documentation on verifying requests
+const (
+	recordedSecret    = "8f742231b10e8888abcd99yyyzzz85a5"
+	recordedTimestamp = "1531420618"
+	recordedSignature = "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+	recordedBody      = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V" +
+		"&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=" +
+		"&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN" +
+		"&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+)
+
+func TestVerify(t *testing.T) {
+	sent := time.Unix(1531420618, 0)
+	body := []byte(recordedBody)
+
+	assert.Nil(t, Verify(recordedSecret, recordedTimestamp, recordedSignature, body, sent.Add(time.Minute)))
+	assert.Equal(t, ErrInvalidSignature, Verify("other", recordedTimestamp, recordedSignature, body, sent))
+	assert.Equal(t, ErrInvalidSignature, Verify(recordedSecret, recordedTimestamp, recordedSignature, body[1:], sent))
+	assert.Equal(t, ErrInvalidSignature, Verify("", recordedTimestamp, Sign("", recordedTimestamp, body), body, sent),
+		"requests are refused without a signing secret")
+	assert.Equal(t, ErrStaleRequest, Verify(recordedSecret, recordedTimestamp, recordedSignature, body,
+		sent.Add(10*time.Minute)), "recorded requests cannot be replayed")
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Command
+		err      bool
+	}{
+		{text: "", expected: Command{Name: CommandHelp}},
+		{text: "show API-12", expected: Command{Name: CommandShow, Project: "API", IssueID: 12}},
+		{text: "SHOW #12", expected: Command{Name: CommandShow, IssueID: 12}},
+		{text: "show API", err: true},
+		{text: "assign 12", expected: Command{Name: CommandAssign, IssueID: 12}},
+		{text: "assign API-12 @janedoe", expected: Command{Name: CommandAssign, Project: "API", IssueID: 12,
+			Assignee: "janedoe"}},
+		{text: "assign API-12 <@U024BE7LH|janedoe>", expected: Command{Name: CommandAssign, Project: "API",
+			IssueID: 12, Assignee: "janedoe", AssigneeID: "U024BE7LH"}},
+		{text: "create project:API priority:2 Login fails: page reloads | since this morning",
+			expected: Command{Name: CommandCreate, Project: "API", Priority: 2, Summary: "Login fails: page reloads",
+				Description: "since this morning"}},
+		{text: "create http://example.com is down", expected: Command{Name: CommandCreate,
+			Summary: "http://example.com is down"}},
+		{text: "create priority:high Login fails", err: true},
+		{text: "create type:Bug", err: true},
+		{text: "close API-12", err: true},
+	}
+
+	for _, test := range tests {
+		command, err := ParseCommand(test.text)
+		if test.err {
+			assert.NotNil(t, err, test.text)
+			continue
+		}
+
+		assert.Nil(t, err, test.text)
+		assert.Equal(t, test.expected, command, test.text)
+	}
+}
+
+func TestEventMessage(t *testing.T) {
+	event := models.IssueEvent{Event: events.IssueCommented, IssueID: 12, Project: "api", Actor: "bob",
+		Comment: "Fixed by <clearing> cookies", Issue: &models.IssueResponse{ID: 12, Summary: "Login fails",
+			Status: "open", Assignee: "janedoe", Priority: 2}}
+
+	message := EventMessage(event, "http://localhost:8080/")
+
+	assert.Equal(t, "bob commented on API-12 Login fails", message.Text)
+	assert.Len(t, message.Attachments, 1)
+	assert.Equal(t, eventColors[events.IssueCommented], message.Attachments[0].Color)
+
+	section := message.Attachments[0].Blocks[0]
+	assert.Equal(t, "*<http://localhost:8080/api/issue/12|API-12 Login fails>*\nFixed by &lt;clearing&gt; cookies",
+		section.Text.Text, "mrkdwn is escaped")
+	assert.Equal(t, Markdown("*Assignee*\njanedoe"), section.Fields[1])
+
+	payload, err := json.Marshal(message)
+	assert.Nil(t, err)
+	assert.Contains(t, string(payload), `"type":"context"`)
+}
@@ -0,0 +1,318 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/YAITS/api/models"
+)
+
+// Codes of the violations, for clients to tell them apart without parsing messages
+const (
+	CodeRequired    = "required"
+	CodeTooLong     = "too_long"
+	CodeOutOfRange  = "out_of_range"
+	CodeInvalid     = "invalid"
+	CodeInvalidType = "invalid_type"
+	CodeMalformed   = "malformed"
+	CodeDuplicate   = "duplicate"
+	CodeNotFound    = "not_found"
+)
+
+// Limits of the fields of the requests, those of the columns of the schema
+const (
+	MinPriority             = 1
+	MaxPriority             = 10
+	MaxSummaryLength        = 64
+	MaxDescriptionLength    = 256
+	MaxUserLength           = 64
+	MaxProjectLength        = 16
+	MaxTypeLength           = 32
+	MaxCommentLength        = 1024
+	MaxFieldNameLength      = 64
+	MaxFieldValueLength     = 1024
+	MaxNameLength           = 64
+	MaxWorkflowLength       = 64
+	MaxRequiredFieldsLength = 256
+)
+
+// Statuses are the statuses of an issue
+var Statuses = []string{"open", "in progress", "closed"}
+
+// FieldError is the violation of a rule by a field of a request, Field being its JSON name or empty when the
+// violation is about the whole request
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Errors are the violations found in a request, nil when it is valid
+type Errors []FieldError
+
+func (errs Errors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Wrapper returns the response of the violations, one error per violation
+func (errs Errors) Wrapper(status int) models.ErrorWrapper {
+	wrapper := models.ErrorWrapper{Errors: make([]models.StandardError, 0, len(errs))}
+	for _, err := range errs {
+		wrapper.Errors = append(wrapper.Errors, models.StandardError{Code: status, Title: http.StatusText(status),
+			Description: err.Message, Field: err.Field, Reason: err.Code})
+	}
+
+	return wrapper
+}
+
+// Err returns the violations as an error, nil when there are none
+func (errs Errors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// Add records a violation
+func (errs *Errors) Add(field, code, message string) {
+	*errs = append(*errs, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Required checks a field is not blank
+func (errs *Errors) Required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		errs.Add(field, CodeRequired, field+" is required")
+	}
+}
+
+// MaxLength checks a field holds at most max characters, as counted by MySQL
+func (errs *Errors) MaxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		errs.Add(field, CodeTooLong, fmt.Sprintf("%s can't be longer than %d characters", field, max))
+	}
+}
+
+// Range checks a number is within min and max
+func (errs *Errors) Range(field string, value, min, max int64) {
+	if value < min || value > max {
+		errs.Add(field, CodeOutOfRange, fmt.Sprintf("%s must be between %d and %d", field, min, max))
+	}
+}
+
+// NotNegative checks a number is not negative
+func (errs *Errors) NotNegative(field string, value int64) {
+	if value < 0 {
+		errs.Add(field, CodeOutOfRange, field+" can't be negative")
+	}
+}
+
+// OneOf checks a field holds one of the values given
+func (errs *Errors) OneOf(field, value string, values []string) {
+	for _, v := range values {
+		if value == v {
+			return
+		}
+	}
+
+	errs.Add(field, CodeInvalid, fmt.Sprintf("%s must be one of %s", field, strings.Join(values, ", ")))
+}
+
+// NewIssue checks an issue about to be created, once the defaults of its issue type are applied
+func NewIssue(req models.NewIssueRequest) error {
+	var errs Errors
+
+	errs.Required("summary", req.Summary)
+	errs.MaxLength("summary", req.Summary, MaxSummaryLength)
+	errs.Required("description", req.Description)
+	errs.MaxLength("description", req.Description, MaxDescriptionLength)
+	if req.Priority == 0 {
+		errs.Add("priority", CodeRequired, "priority is required")
+	} else {
+		errs.Range("priority", req.Priority, MinPriority, MaxPriority)
+	}
+	errs.MaxLength("assignee", req.Assignee, MaxUserLength)
+	errs.MaxLength("reporter", req.Reporter, MaxUserLength)
+	errs.MaxLength("project", req.Project, MaxProjectLength)
+	errs.MaxLength("type", req.Type, MaxTypeLength)
+	errs.NotNegative("originalEstimate", req.OriginalEstimate)
+	errs.fields(req.Fields)
+
+	return errs.Err()
+}
+
+// UpdateIssue checks an update of an issue, the fields it leaves unchanged being skipped
+func UpdateIssue(req models.UpdateIssueRequest) error {
+	var errs Errors
+
+	if req.Summary != "" || req.Sets("summary") {
+		errs.Required("summary", req.Summary)
+		errs.MaxLength("summary", req.Summary, MaxSummaryLength)
+	}
+	errs.MaxLength("description", req.Description, MaxDescriptionLength)
+	if req.Priority != 0 || req.Sets("priority") {
+		errs.Range("priority", req.Priority, MinPriority, MaxPriority)
+	}
+	if req.Status != "" || req.Sets("status") {
+		errs.OneOf("status", req.Status, Statuses)
+	}
+	errs.MaxLength("assignee", req.Assignee, MaxUserLength)
+	errs.MaxLength("comment", req.Comment, MaxCommentLength)
+	if req.OriginalEstimate != nil {
+		errs.NotNegative("originalEstimate", *req.OriginalEstimate)
+	}
+	if req.RemainingEstimate != nil {
+		errs.NotNegative("remainingEstimate", *req.RemainingEstimate)
+	}
+
+	return errs.Err()
+}
+
+// NewIssueType checks an issue type about to be configured, once its defaults are applied
+func NewIssueType(req models.NewIssueTypeRequest) error {
+	var errs Errors
+
+	errs.Required("name", req.Name)
+	errs.MaxLength("name", req.Name, MaxTypeLength)
+	errs.MaxLength("project", req.Project, MaxProjectLength)
+	errs.MaxLength("workflow", req.Workflow, MaxWorkflowLength)
+	errs.MaxLength("requiredFields", strings.Join(req.RequiredFields, ","), MaxRequiredFieldsLength)
+	errs.Range("defaultPriority", req.DefaultPriority, MinPriority, MaxPriority)
+	errs.MaxLength("descriptionTemplate", req.DescriptionTemplate, MaxDescriptionLength)
+
+	return errs.Err()
+}
+
+// NewSLAPolicy checks an SLA policy about to be created, a priority of 0 applying to every priority
+func NewSLAPolicy(req models.NewSLAPolicyRequest) error {
+	var errs Errors
+
+	errs.Required("name", req.Name)
+	errs.MaxLength("name", req.Name, MaxNameLength)
+	errs.MaxLength("project", req.Project, MaxProjectLength)
+	if req.Priority != 0 {
+		errs.Range("priority", req.Priority, MinPriority, MaxPriority)
+	}
+	errs.NotNegative("responseMinutes", req.ResponseMinutes)
+	errs.NotNegative("resolutionMinutes", req.ResolutionMinutes)
+
+	return errs.Err()
+}
+
+// Comment checks a comment about to be added to an issue
+func Comment(comment string) error {
+	var errs Errors
+
+	errs.Required("comment", comment)
+	errs.MaxLength("comment", comment, MaxCommentLength)
+
+	return errs.Err()
+}
+
+func (errs *Errors) fields(fields map[string]string) {
+	for name, value := range fields {
+		if utf8.RuneCountInString(name) > MaxFieldNameLength {
+			errs.Add("fields", CodeTooLong, fmt.Sprintf("custom field names can't be longer than %d characters",
+				MaxFieldNameLength))
+		}
+		errs.MaxLength("fields."+name, value, MaxFieldValueLength)
+	}
+}
+
+// Binding returns the violations of a request body that couldn't be bound, one per field failing its binding rules
+func Binding(err error) Errors {
+	var errs Errors
+
+	switch e := err.(type) {
+	case validator.ValidationErrors:
+		for _, fieldErr := range e {
+			errs = append(errs, bindingRule(fieldErr))
+		}
+	case *json.UnmarshalTypeError:
+		errs.Add(e.Field, CodeInvalidType, fmt.Sprintf("%s must be %s", e.Field, jsonType(e.Type)))
+	case *json.SyntaxError:
+		errs.Add("", CodeMalformed, "malformed JSON: "+e.Error())
+	case *time.ParseError:
+		errs.Add("", CodeInvalid, "dates must be RFC 3339 dates: "+e.Error())
+	default:
+		if err == io.EOF {
+			errs.Add("", CodeMalformed, "the request body is empty")
+		} else if err == io.ErrUnexpectedEOF {
+			errs.Add("", CodeMalformed, "malformed JSON: unexpected end of input")
+		} else {
+			errs.Add("", CodeInvalid, err.Error())
+		}
+	}
+
+	return errs
+}
+
+func bindingRule(err validator.FieldError) FieldError {
+	field := err.Field()
+
+	switch err.Tag() {
+	case "required":
+		return FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	case "min", "gte":
+		return FieldError{Field: field, Code: CodeOutOfRange, Message: fmt.Sprintf("%s must be at least %s", field,
+			err.Param())}
+	case "max", "lte":
+		return FieldError{Field: field, Code: CodeOutOfRange, Message: fmt.Sprintf("%s must be at most %s", field,
+			err.Param())}
+	}
+
+	return FieldError{Field: field, Code: CodeInvalid, Message: fmt.Sprintf("%s fails the %s rule", field, err.Tag())}
+}
+
+// jsonType names the JSON type a Go type is decoded from
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Ptr:
+		return jsonType(t.Elem())
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return "an RFC 3339 date"
+		}
+		return "an object"
+	case reflect.Map:
+		return "an object"
+	}
+
+	return "of type " + t.String()
+}
+
+// the binding rules report fields by their JSON name, as clients send them
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/YAITS/api/models"
+)
+
+func TestNewIssue(t *testing.T) {
+	req := models.NewIssueRequest{Summary: "Login fails", Description: "Blank page", Priority: 3}
+	assert.Nil(t, NewIssue(req))
+
+	req.Summary = strings.Repeat("é", MaxSummaryLength)
+	assert.Nil(t, NewIssue(req), "lengths are counted in characters")
+
+	req = models.NewIssueRequest{Summary: strings.Repeat("s", MaxSummaryLength+1), Priority: 11,
+		Fields: map[string]string{"component": strings.Repeat("c", MaxFieldValueLength+1)}}
+	assert.Equal(t, Errors{
+		{Field: "summary", Code: CodeTooLong, Message: "summary can't be longer than 64 characters"},
+		{Field: "description", Code: CodeRequired, Message: "description is required"},
+		{Field: "priority", Code: CodeOutOfRange, Message: "priority must be between 1 and 10"},
+		{Field: "fields.component", Code: CodeTooLong, Message: "fields.component can't be longer than 1024 characters"},
+	}, NewIssue(req))
+
+	err := NewIssue(models.NewIssueRequest{Summary: "Login fails", Description: "Blank page"})
+	assert.EqualError(t, err, "priority is required")
+}
+
+func TestUpdateIssue(t *testing.T) {
+	assert.Nil(t, UpdateIssue(models.UpdateIssueRequest{Comment: "Reproduced"}), "unchanged fields are skipped")
+
+	estimate := int64(-5)
+	err := UpdateIssue(models.UpdateIssueRequest{Priority: 0, Status: "done", RemainingEstimate: &estimate,
+		Changed: []string{"summary", "priority"}})
+	assert.Equal(t, Errors{
+		{Field: "summary", Code: CodeRequired, Message: "summary is required"},
+		{Field: "priority", Code: CodeOutOfRange, Message: "priority must be between 1 and 10"},
+		{Field: "status", Code: CodeInvalid, Message: "status must be one of open, in progress, closed"},
+		{Field: "remainingEstimate", Code: CodeOutOfRange, Message: "remainingEstimate can't be negative"},
+	}, err)
+}
+
+func TestBinding(t *testing.T) {
+	var worklog models.NewWorklogRequest
+	err := binding.JSON.BindBody([]byte(`{"minutes":0}`), &worklog)
+	assert.Equal(t, Errors{
+		{Field: "user", Code: CodeRequired, Message: "user is required"},
+		{Field: "minutes", Code: CodeRequired, Message: "minutes is required"},
+	}, Binding(err), "fields are named as in JSON")
+
+	var issue models.NewIssueRequest
+	err = binding.JSON.BindBody([]byte(`{"summary":"Login fails","priority":"high"}`), &issue)
+	assert.Equal(t, Errors{{Field: "priority", Code: CodeInvalidType, Message: "priority must be a number"}},
+		Binding(err))
+
+	err = binding.JSON.BindBody([]byte(`{"summary":`), &issue)
+	if errs := Binding(err); assert.Len(t, errs, 1) {
+		assert.Equal(t, CodeMalformed, errs[0].Code)
+	}
+}
+
+func TestErrors_Wrapper(t *testing.T) {
+	errs := Errors{{Field: "priority", Code: CodeOutOfRange, Message: "priority must be between 1 and 10"}}
+
+	assert.Equal(t, models.ErrorWrapper{Errors: []models.StandardError{{Code: http.StatusBadRequest,
+		Title: "Bad Request", Description: "priority must be between 1 and 10", Field: "priority",
+		Reason: CodeOutOfRange}}}, errs.Wrapper(http.StatusBadRequest))
+	assert.Nil(t, Errors{}.Err())
+}
@@ -0,0 +1,42 @@
+package vcs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// referencePattern matches issue references such as #12 or API-12, optionally preceded by a closing keyword
+var referencePattern = regexp.MustCompile(`(?i)(?:\b(close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+)?(?:\b([A-Za-z][A-Za-z0-9_]*)-|#)(\d+)\b`)
+
+// Reference is an issue a message refers to, Project being the project of its key when given as API-12
+type Reference struct {
+	Project string
+	IssueID int64
+	Closes  bool
+}
+
+// ParseReferences returns the issues a message refers to in order of appearance, an issue preceded by a closing
+// keyword such as "fixes #12" being closed
+func ParseReferences(text string) []Reference {
+	references := make([]Reference, 0)
+	index := make(map[int64]int)
+
+	for _, match := range referencePattern.FindAllStringSubmatch(text, -1) {
+		issueID, err := strconv.ParseInt(match[3], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		closes := match[1] != ""
+		if i, ok := index[issueID]; ok {
+			references[i].Closes = references[i].Closes || closes
+			continue
+		}
+
+		index[issueID] = len(references)
+		references = append(references, Reference{Project: strings.ToUpper(match[2]), IssueID: issueID, Closes: closes})
+	}
+
+	return references
+}
@@ -0,0 +1,314 @@
+package vcs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Providers whose webhooks are understood
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+	Gitea  = "gitea"
+)
+
+// Kinds of changes issues are linked to
+const (
+	KindCommit      = "commit"
+	KindPullRequest = "pull_request"
+)
+
+// Headers identifying the provider and the event of a webhook, and authenticating it
+const (
+	githubEventHeader     = "X-GitHub-Event"
+	githubSignatureHeader = "X-Hub-Signature-256"
+	gitlabEventHeader     = "X-Gitlab-Event"
+	gitlabTokenHeader     = "X-Gitlab-Token"
+	giteaEventHeader      = "X-Gitea-Event"
+	giteaSignatureHeader  = "X-Gitea-Signature"
+)
+
+// Errors returned reading a webhook
+var (
+	ErrUnknownProvider  = errors.New("not a github, gitlab or gitea webhook")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrIgnoredEvent is returned for the events other than pushes and pull requests, such as pings
+	ErrIgnoredEvent = errors.New("event is neither a push nor a pull request")
+)
+
+// Change is a commit or a pull request, Closes telling whether its closing keywords take effect: commits pushed to
+// the default branch and merged pull requests close the issues they fix
+type Change struct {
+	Kind   string
+	ID     string
+	URL    string
+	Title  string
+	Text   string
+	Author string
+	Closes bool
+}
+
+// Webhook is a push or a pull request webhook reduced to its changes
+type Webhook struct {
+	Provider   string
+	Event      string
+	Repository string
+	Changes    []Change
+}
+
+// Provider tells which provider sent a webhook, Gitea being checked first as it also sends GitHub headers
+func Provider(header http.Header) string {
+	switch {
+	case header.Get(giteaEventHeader) != "":
+		return Gitea
+	case header.Get(githubEventHeader) != "":
+		return GitHub
+	case header.Get(gitlabEventHeader) != "":
+		return GitLab
+	default:
+		return ""
+	}
+}
+
+// Verify authenticates a webhook: GitHub and Gitea sign the body with an HMAC-SHA256 keyed by the secret while GitLab
+// sends the secret as a token
+func Verify(header http.Header, body []byte, secret string) error {
+	if secret == "" {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	var valid bool
+	switch Provider(header) {
+	case Gitea:
+		valid = hmac.Equal([]byte(signature), []byte(header.Get(giteaSignatureHeader)))
+	case GitHub:
+		valid = hmac.Equal([]byte("sha256="+signature), []byte(header.Get(githubSignatureHeader)))
+	case GitLab:
+		valid = hmac.Equal([]byte(secret), []byte(header.Get(gitlabTokenHeader)))
+	default:
+		return ErrUnknownProvider
+	}
+
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Parse reads the push or pull request webhook of a provider
+func Parse(header http.Header, body []byte) (Webhook, error) {
+	webhook := Webhook{Provider: Provider(header)}
+
+	var err error
+	switch webhook.Provider {
+	case GitHub, Gitea:
+		webhook.Event = header.Get(githubEventHeader)
+		if webhook.Provider == Gitea {
+			webhook.Event = header.Get(giteaEventHeader)
+		}
+
+		switch webhook.Event {
+		case "push":
+			err = parseGitHubPush(body, &webhook)
+		case "pull_request":
+			err = parseGitHubPullRequest(body, &webhook)
+		default:
+			err = ErrIgnoredEvent
+		}
+	case GitLab:
+		webhook.Event = header.Get(gitlabEventHeader)
+
+		switch webhook.Event {
+		case "Push Hook":
+			err = parseGitLabPush(body, &webhook)
+		case "Merge Request Hook":
+			err = parseGitLabMergeRequest(body, &webhook)
+		default:
+			err = ErrIgnoredEvent
+		}
+	default:
+		err = ErrUnknownProvider
+	}
+
+	return webhook, err
+}
+
+// githubPush is a GitHub push, Gitea sending the same payload
+type githubPush struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		FullName      string `json:"full_name"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+	Commits []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name     string `json:"name"`
+			Username string `json:"username"`
+		} `json:"author"`
+	} `json:"commits"`
+}
+
+func parseGitHubPush(body []byte, webhook *Webhook) error {
+	var push githubPush
+	if err := json.Unmarshal(body, &push); err != nil {
+		return err
+	}
+
+	webhook.Repository = push.Repository.FullName
+	defaultBranch := push.Ref == "refs/heads/"+push.Repository.DefaultBranch
+
+	for _, commit := range push.Commits {
+		author := commit.Author.Username
+		if author == "" {
+			author = commit.Author.Name
+		}
+
+		webhook.Changes = append(webhook.Changes, Change{
+			Kind:   KindCommit,
+			ID:     commit.ID,
+			URL:    commit.URL,
+			Title:  firstLine(commit.Message),
+			Text:   commit.Message,
+			Author: author,
+			Closes: defaultBranch,
+		})
+	}
+
+	return nil
+}
+
+// githubPullRequest is a GitHub pull request event, Gitea sending the same payload
+type githubPullRequest struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number  int64  `json:"number"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		Merged  bool   `json:"merged"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+func parseGitHubPullRequest(body []byte, webhook *Webhook) error {
+	var event githubPullRequest
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+
+	webhook.Repository = event.Repository.FullName
+	pr := event.PullRequest
+
+	webhook.Changes = []Change{{
+		Kind:   KindPullRequest,
+		ID:     strconv.FormatInt(pr.Number, 10),
+		URL:    pr.HTMLURL,
+		Title:  pr.Title,
+		Text:   pr.Title + "\n" + pr.Body,
+		Author: pr.User.Login,
+		Closes: event.Action == "closed" && pr.Merged,
+	}}
+
+	return nil
+}
+
+type gitlabPush struct {
+	Ref     string `json:"ref"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		DefaultBranch     string `json:"default_branch"`
+	} `json:"project"`
+	Commits []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commits"`
+}
+
+func parseGitLabPush(body []byte, webhook *Webhook) error {
+	var push gitlabPush
+	if err := json.Unmarshal(body, &push); err != nil {
+		return err
+	}
+
+	webhook.Repository = push.Project.PathWithNamespace
+	defaultBranch := push.Ref == "refs/heads/"+push.Project.DefaultBranch
+
+	for _, commit := range push.Commits {
+		webhook.Changes = append(webhook.Changes, Change{
+			Kind:   KindCommit,
+			ID:     commit.ID,
+			URL:    commit.URL,
+			Title:  firstLine(commit.Message),
+			Text:   commit.Message,
+			Author: commit.Author.Name,
+			Closes: defaultBranch,
+		})
+	}
+
+	return nil
+}
+
+type gitlabMergeRequest struct {
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID         int64  `json:"iid"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		URL         string `json:"url"`
+		Action      string `json:"action"`
+	} `json:"object_attributes"`
+}
+
+func parseGitLabMergeRequest(body []byte, webhook *Webhook) error {
+	var event gitlabMergeRequest
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+
+	webhook.Repository = event.Project.PathWithNamespace
+	mr := event.ObjectAttributes
+
+	webhook.Changes = []Change{{
+		Kind:   KindPullRequest,
+		ID:     strconv.FormatInt(mr.IID, 10),
+		URL:    mr.URL,
+		Title:  mr.Title,
+		Text:   mr.Title + "\n" + mr.Description,
+		Author: event.User.Username,
+		Closes: mr.Action == "merge",
+	}}
+
+	return nil
+}
+
+func firstLine(message string) string {
+	return strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+}
@@ -0,0 +1,131 @@
+package vcs
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorded signature from the GitHub documentation on validating webhook deliveries
+const (
+	recordedSecret    = "It's a Secret to Everybody"
+	recordedBody      = "Hello, World!"
+	recordedSignature = "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+)
+
+func header(values ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i+1 < len(values); i += 2 {
+		h.Set(values[i], values[i+1])
+	}
+	return h
+}
+
+func TestParseReferences(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []Reference
+	}{
+		{"Fix login redirect", []Reference{}},
+		{"Refactor the session store (#12)", []Reference{{IssueID: 12}}},
+		{"Fixes #12, see api-7", []Reference{{IssueID: 12, Closes: true}, {Project: "API", IssueID: 7}}},
+		{"closes: API-3 and resolved #4", []Reference{{Project: "API", IssueID: 3, Closes: true}, {IssueID: 4, Closes: true}}},
+		{"Related to #5\n\nFixed #5", []Reference{{IssueID: 5, Closes: true}}},
+		{"Prefixes #6 are not closing keywords", []Reference{{IssueID: 6}}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ParseReferences(test.text), test.text)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(recordedBody)
+
+	assert.Nil(t, Verify(header(githubEventHeader, "push", githubSignatureHeader, recordedSignature), body,
+		recordedSecret))
+	assert.Nil(t, Verify(header(giteaEventHeader, "push", githubEventHeader, "push",
+		giteaSignatureHeader, recordedSignature[len("sha256="):]), body, recordedSecret),
+		"gitea signatures are checked rather than the github ones it also sends")
+	assert.Nil(t, Verify(header(gitlabEventHeader, "Push Hook", gitlabTokenHeader, recordedSecret), body,
+		recordedSecret))
+
+	assert.Equal(t, ErrInvalidSignature, Verify(header(githubEventHeader, "push", githubSignatureHeader,
+		recordedSignature), body[1:], recordedSecret))
+	assert.Equal(t, ErrInvalidSignature, Verify(header(gitlabEventHeader, "Push Hook", gitlabTokenHeader, "other"),
+		body, recordedSecret))
+	assert.Equal(t, ErrInvalidSignature, Verify(header(gitlabEventHeader, "Push Hook"), body, ""),
+		"webhooks are refused without a secret")
+	assert.Equal(t, ErrUnknownProvider, Verify(header(), body, recordedSecret))
+}
+
+func TestParse(t *testing.T) {
+	t.Run("GitHubPush", func(t *testing.T) {
+		body := `{"ref":"refs/heads/main","repository":{"full_name":"yaits/api","default_branch":"main"},
+			"commits":[{"id":"0d1a26e","message":"Fix login redirect\n\nFixes #12","url":"https://github.com/yaits/api/commit/0d1a26e",
+			"author":{"name":"John Doe","username":"johndoe"}}]}`
+
+		webhook, err := Parse(header(githubEventHeader, "push"), []byte(body))
+		assert.Nil(t, err)
+		assert.Equal(t, Webhook{Provider: GitHub, Event: "push", Repository: "yaits/api", Changes: []Change{{
+			Kind: KindCommit, ID: "0d1a26e", URL: "https://github.com/yaits/api/commit/0d1a26e",
+			Title: "Fix login redirect", Text: "Fix login redirect\n\nFixes #12", Author: "johndoe", Closes: true,
+		}}}, webhook)
+	})
+
+	t.Run("GiteaPushToBranch", func(t *testing.T) {
+		body := `{"ref":"refs/heads/login","repository":{"full_name":"yaits/api","default_branch":"main"},
+			"commits":[{"id":"0d1a26e","message":"Fixes #12","author":{"name":"John Doe"}}]}`
+
+		webhook, err := Parse(header(giteaEventHeader, "push", githubEventHeader, "push"), []byte(body))
+		assert.Nil(t, err)
+		assert.Equal(t, Gitea, webhook.Provider)
+		assert.Len(t, webhook.Changes, 1)
+		assert.Equal(t, "John Doe", webhook.Changes[0].Author)
+		assert.False(t, webhook.Changes[0].Closes, "commits pushed to other branches do not close issues")
+	})
+
+	t.Run("GitHubMergedPullRequest", func(t *testing.T) {
+		body := `{"action":"closed","repository":{"full_name":"yaits/api"},"pull_request":{"number":42,
+			"title":"Fix login","body":"Fixes API-12","html_url":"https://github.com/yaits/api/pull/42","merged":true,
+			"user":{"login":"johndoe"}}}`
+
+		webhook, err := Parse(header(githubEventHeader, "pull_request"), []byte(body))
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{{Kind: KindPullRequest, ID: "42", URL: "https://github.com/yaits/api/pull/42",
+			Title: "Fix login", Text: "Fix login\nFixes API-12", Author: "johndoe", Closes: true}}, webhook.Changes)
+	})
+
+	t.Run("GitLabPush", func(t *testing.T) {
+		body := `{"ref":"refs/heads/main","project":{"path_with_namespace":"yaits/api","default_branch":"main"},
+			"commits":[{"id":"0d1a26e","message":"Resolves #12","url":"https://gitlab.com/yaits/api/-/commit/0d1a26e",
+			"author":{"name":"John Doe"}}]}`
+
+		webhook, err := Parse(header(gitlabEventHeader, "Push Hook"), []byte(body))
+		assert.Nil(t, err)
+		assert.Equal(t, "yaits/api", webhook.Repository)
+		assert.Len(t, webhook.Changes, 1)
+		assert.True(t, webhook.Changes[0].Closes)
+	})
+
+	t.Run("GitLabOpenedMergeRequest", func(t *testing.T) {
+		body := `{"user":{"username":"johndoe"},"project":{"path_with_namespace":"yaits/api"},
+			"object_attributes":{"iid":7,"title":"Fix login","description":"Closes #12",
+			"url":"https://gitlab.com/yaits/api/-/merge_requests/7","action":"open"}}`
+
+		webhook, err := Parse(header(gitlabEventHeader, "Merge Request Hook"), []byte(body))
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{{Kind: KindPullRequest, ID: "7", URL: "https://gitlab.com/yaits/api/-/merge_requests/7",
+			Title: "Fix login", Text: "Fix login\nCloses #12", Author: "johndoe"}}, webhook.Changes,
+			"merge requests only close issues once merged")
+	})
+
+	t.Run("Ignored", func(t *testing.T) {
+		_, err := Parse(header(githubEventHeader, "ping"), []byte(`{}`))
+		assert.Equal(t, ErrIgnoredEvent, err)
+
+		_, err = Parse(header(), []byte(`{}`))
+		assert.Equal(t, ErrUnknownProvider, err)
+	})
+}
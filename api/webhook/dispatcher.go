@@ -0,0 +1,207 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	"github.com/YAITS/api/slack"
+)
+
+// maxErrorLength bounds the part of a failed response body kept in the delivery log
+const maxErrorLength = 512
+
+// Dispatcher periodically delivers the queued webhook deliveries, retrying failed ones with an exponential backoff
+// until they run out of attempts and are dead-lettered
+type Dispatcher struct {
+	storage  persistence.Storage
+	logger   *zap.SugaredLogger
+	interval time.Duration
+
+	Client      *http.Client
+	MaxAttempts int64
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	BatchSize   int64
+	// BaseURL is the public URL of the API the issues of Slack messages link to
+	BaseURL string
+	// AllowPrivateNetworks lets deliveries reach loopback, private and link-local addresses, refused otherwise for
+	// webhooks not to reach the services of the network the API runs in
+	AllowPrivateNetworks bool
+}
+
+// NewDispatcher creates a Dispatcher running every interval, with 8 attempts starting 30 seconds apart by default
+func NewDispatcher(storage persistence.Storage, logger *zap.SugaredLogger, interval time.Duration) *Dispatcher {
+	d := &Dispatcher{
+		storage:     storage,
+		logger:      logger.With("worker", "webhook-dispatcher"),
+		interval:    interval,
+		MaxAttempts: 8,
+		Backoff:     30 * time.Second,
+		MaxBackoff:  6 * time.Hour,
+		BatchSize:   100,
+		BaseURL:     "http://localhost:8080",
+	}
+
+	// deliveries go straight to their URL, for the address dialed to be the one checked
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: d.checkAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	d.Client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+
+	return d
+}
+
+// Run delivers the due deliveries until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Dispatch(time.Now()); err != nil {
+			d.logger.Errorf("error dispatching webhooks: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch attempts every delivery due at the given time
+func (d *Dispatcher) Dispatch(now time.Time) error {
+	deliveries, err := d.storage.RetrieveDueWebhookDeliveries(now, d.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		delivery = d.deliver(delivery)
+
+		nextAttempt := now
+		if delivery.Status == persistence.WebhookDeliveryPending {
+			nextAttempt = now.Add(d.backoff(delivery.Attempts))
+		}
+
+		if err := d.storage.UpdateWebhookDelivery(delivery, nextAttempt); err != nil {
+			d.logger.Errorf("couldn't record webhook delivery %d: %s", delivery.ID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// deliver attempts a delivery once, any 2xx response marking it delivered
+func (d *Dispatcher) deliver(delivery models.WebhookDelivery) models.WebhookDelivery {
+	l := d.logger.With("delivery", delivery.ID, "webhookID", delivery.WebhookID, "event", delivery.Event)
+
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+
+	err := d.post(&delivery)
+	if err == nil {
+		delivery.Status = persistence.WebhookDeliveryDelivered
+		l.Debug("webhook delivered")
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.MaxAttempts {
+		delivery.Status = persistence.WebhookDeliveryDead
+		l.Warnf("webhook delivery dead-lettered after %d attempts: %s", delivery.Attempts, err.Error())
+		return delivery
+	}
+
+	delivery.Status = persistence.WebhookDeliveryPending
+	l.Infof("webhook delivery attempt %d failed: %s", delivery.Attempts, err.Error())
+	return delivery
+}
+
+func (d *Dispatcher) post(delivery *models.WebhookDelivery) error {
+	payload := []byte(delivery.Payload)
+
+	if delivery.Format == FormatSlack {
+		var event models.IssueEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return err
+		}
+
+		var err error
+		if payload, err = json.Marshal(slack.EventMessage(event, d.BaseURL)); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "YAITS-Webhook")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	delivery.ResponseStatus = int64(resp.StatusCode)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+	return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+}
+
+// checkAddress refuses to connect to loopback, private and link-local addresses unless AllowPrivateNetworks is set.
+// Addresses are checked once resolved, so that neither a host name nor a redirect can lead to one of them
+func (d *Dispatcher) checkAddress(_, address string, _ syscall.RawConn) error {
+	if d.AllowPrivateNetworks {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || PrivateIP(ip) {
+		return fmt.Errorf("refusing to deliver to private address %s", host)
+	}
+
+	return nil
+}
+
+// backoff returns the delay before the attempt following the given number of attempts, doubling every attempt
+func (d *Dispatcher) backoff(attempts int64) time.Duration {
+	delay := d.Backoff
+	for i := int64(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= d.MaxBackoff {
+			return d.MaxBackoff
+		}
+	}
+
+	return delay
+}
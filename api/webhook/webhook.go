@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+)
+
+// Headers set on every delivery, the signature being the hex HMAC-SHA256 of the body keyed by the webhook secret
+const (
+	SignatureHeader = "X-YAITS-Signature"
+	EventHeader     = "X-YAITS-Event"
+	DeliveryHeader  = "X-YAITS-Delivery"
+)
+
+// Formats of the deliveries: events as is, or Slack messages for Slack incoming webhooks which are not signed
+const (
+	FormatJSON  = "json"
+	FormatSlack = "slack"
+)
+
+// ValidFormat tells whether a webhook format is supported
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatSlack
+}
+
+// privateNetworks are the loopback, private, shared, link-local and unspecified networks deliveries are refused to
+var privateNetworks = parseNetworks("0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10")
+
+// ValidURL tells whether a webhook URL is an http or https URL with a host
+func ValidURL(rawURL string) bool {
+	target, err := url.Parse(rawURL)
+	return err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Hostname() != ""
+}
+
+// PrivateIP tells whether an address is a loopback, private or link-local one, IPv4 addresses mapped to IPv6
+// included
+func PrivateIP(ip net.IP) bool {
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+// Sign returns the signature header value of a payload, sha256= followed by its hex HMAC-SHA256
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells whether a signature header value matches a payload, for receivers to authenticate deliveries
+func Verify(secret string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, payload)), []byte(signature))
+}
@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/YAITS/api/events"
+	"github.com/YAITS/api/models"
+	"github.com/YAITS/api/persistence"
+	mock "github.com/YAITS/api/persistence/mock"
+	"github.com/YAITS/api/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "s3cr3t"
+
+// queueStorage serves a fixed queue of deliveries and records the outcome of every attempt
+type queueStorage struct {
+	*mock.Storage
+	queue   []models.WebhookDelivery
+	updates []models.WebhookDelivery
+	next    []time.Time
+}
+
+func (storage *queueStorage) RetrieveDueWebhookDeliveries(_ time.Time, _ int64) ([]models.WebhookDelivery, error) {
+	return storage.queue, nil
+}
+
+func (storage *queueStorage) UpdateWebhookDelivery(delivery models.WebhookDelivery, nextAttempt time.Time) error {
+	storage.updates = append(storage.updates, delivery)
+	storage.next = append(storage.next, nextAttempt)
+	return nil
+}
+
+// newDispatcher creates a Dispatcher allowed to deliver to the receivers of the tests, which listen on loopback
+func newDispatcher(storage persistence.Storage) *Dispatcher {
+	dispatcher := NewDispatcher(storage, zap.NewNop().Sugar(), time.Minute)
+	dispatcher.AllowPrivateNetworks = true
+	return dispatcher
+}
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"event":"issue.created"}`)
+	signature := Sign(secret, payload)
+
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.True(t, Verify(secret, payload, signature))
+	assert.False(t, Verify("other", payload, signature), "signature depends on the secret")
+	assert.False(t, Verify(secret, []byte(`{}`), signature), "signature depends on the payload")
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	now := time.Date(2020, 9, 8, 12, 0, 0, 0, time.UTC)
+	payload := `{"event":"issue.created","issueID":1}`
+
+	t.Run("Delivered", func(t *testing.T) {
+		var received *http.Request
+		var body []byte
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer receiver.Close()
+
+		storage := &queueStorage{queue: []models.WebhookDelivery{
+			{ID: 7, WebhookID: 1, Event: events.IssueCreated, Payload: payload, URL: receiver.URL, Secret: secret},
+		}}
+
+		err := newDispatcher(storage).Dispatch(now)
+		assert.Nil(t, err)
+
+		assert.Equal(t, payload, string(body))
+		assert.Equal(t, events.IssueCreated, received.Header.Get(EventHeader))
+		assert.Equal(t, "7", received.Header.Get(DeliveryHeader))
+		assert.True(t, Verify(secret, body, received.Header.Get(SignatureHeader)), "delivery is signed")
+
+		assert.Len(t, storage.updates, 1)
+		assert.Equal(t, persistence.WebhookDeliveryDelivered, storage.updates[0].Status)
+		assert.Equal(t, int64(1), storage.updates[0].Attempts)
+		assert.Equal(t, int64(http.StatusAccepted), storage.updates[0].ResponseStatus)
+	})
+
+	t.Run("Slack", func(t *testing.T) {
+		var message slack.Message
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&message)
+		}))
+		defer receiver.Close()
+
+		storage := &queueStorage{queue: []models.WebhookDelivery{
+			{ID: 7, WebhookID: 1, Event: events.IssueCreated, Payload: payload, URL: receiver.URL, Format: FormatSlack},
+		}}
+
+		err := newDispatcher(storage).Dispatch(now)
+		assert.Nil(t, err)
+
+		assert.Equal(t, "someone created #1", message.Text, "events are posted as slack messages")
+		assert.Len(t, message.Attachments, 1)
+		assert.Equal(t, persistence.WebhookDeliveryDelivered, storage.updates[0].Status)
+	})
+
+	t.Run("Retried", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+		}))
+		defer receiver.Close()
+
+		storage := &queueStorage{queue: []models.WebhookDelivery{
+			{ID: 7, Event: events.IssueCreated, Payload: payload, URL: receiver.URL, Secret: secret, Attempts: 2},
+		}}
+
+		err := newDispatcher(storage).Dispatch(now)
+		assert.Nil(t, err)
+
+		assert.Equal(t, persistence.WebhookDeliveryPending, storage.updates[0].Status)
+		assert.Equal(t, int64(3), storage.updates[0].Attempts)
+		assert.Equal(t, int64(http.StatusServiceUnavailable), storage.updates[0].ResponseStatus)
+		assert.Contains(t, storage.updates[0].LastError, "try again later")
+		assert.Equal(t, now.Add(2*time.Minute), storage.next[0], "third attempt waits four times the backoff")
+	})
+
+	t.Run("DeadLettered", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		storage := &queueStorage{queue: []models.WebhookDelivery{
+			{ID: 7, Event: events.IssueCreated, Payload: payload, URL: receiver.URL, Secret: secret, Attempts: 7},
+		}}
+
+		err := newDispatcher(storage).Dispatch(now)
+		assert.Nil(t, err)
+
+		assert.Equal(t, persistence.WebhookDeliveryDead, storage.updates[0].Status)
+		assert.Equal(t, int64(8), storage.updates[0].Attempts)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		receiver := httptest.NewServer(http.NotFoundHandler())
+		receiver.Close()
+
+		storage := &queueStorage{queue: []models.WebhookDelivery{
+			{ID: 7, Event: events.IssueCreated, Payload: payload, URL: receiver.URL, Secret: secret},
+		}}
+
+		err := newDispatcher(storage).Dispatch(now)
+		assert.Nil(t, err)
+
+		assert.Equal(t, persistence.WebhookDeliveryPending, storage.updates[0].Status)
+		assert.Equal(t, int64(0), storage.updates[0].ResponseStatus)
+		assert.NotEmpty(t, storage.updates[0].LastError)
+		assert.Equal(t, now.Add(30*time.Second), storage.next[0])
+	})
+
+	t.Run("PrivateAddress", func(t *testing.T) {
+		var received bool
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = true
+		}))
+		defer receiver.Close()
+
+		storage := &queueStorage{queue: []models.WebhookDelivery{
+			{ID: 7, Event: events.IssueCreated, Payload: payload, URL: receiver.URL, Secret: secret},
+		}}
+
+		err := NewDispatcher(storage, zap.NewNop().Sugar(), time.Minute).Dispatch(now)
+		assert.Nil(t, err)
+
+		assert.False(t, received, "loopback addresses are refused")
+		assert.Equal(t, persistence.WebhookDeliveryPending, storage.updates[0].Status)
+		assert.Contains(t, storage.updates[0].LastError, "refusing to deliver to private address 127.0.0.1")
+	})
+}
+
+func TestValidURL(t *testing.T) {
+	assert.True(t, ValidURL("https://ci.example.com/hooks/yaits"))
+	assert.True(t, ValidURL("http://ci.example.com:8080/hooks"))
+	assert.False(t, ValidURL("ftp://ci.example.com/hooks"))
+	assert.False(t, ValidURL("file:///etc/passwd"))
+	assert.False(t, ValidURL("https:///hooks"), "a host is required")
+}
+
+func TestPrivateIP(t *testing.T) {
+	for _, address := range []string{"127.0.0.1", "10.1.2.3", "172.20.0.5", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+		assert.True(t, PrivateIP(net.ParseIP(address)), address)
+	}
+
+	for _, address := range []string{"8.8.8.8", "172.32.0.1", "2606:4700::1111"} {
+		assert.False(t, PrivateIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestDispatcher_backoff(t *testing.T) {
+	dispatcher := newDispatcher(nil)
+
+	assert.Equal(t, 30*time.Second, dispatcher.backoff(1))
+	assert.Equal(t, time.Minute, dispatcher.backoff(2))
+	assert.Equal(t, 4*time.Minute, dispatcher.backoff(4))
+	assert.Equal(t, 6*time.Hour, dispatcher.backoff(20), "backoff is capped")
+}
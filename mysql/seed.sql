@@ -8,8 +8,18 @@ priority int not null default 1,
 status ENUM('open', 'in progress', 'closed') not null default 'open',
 assignee varchar(64) not null default 'unassigned',
 reporter varchar(64),
+project varchar(16) not null default '',
+issueType varchar(32) not null default '',
 createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+dueDate timestamp NULL DEFAULT NULL,
+respondedDate timestamp NULL DEFAULT NULL,
+resolvedDate timestamp NULL DEFAULT NULL,
+originalEstimate int not null default 0,
+remainingEstimate int not null default 0,
+timeSpent int not null default 0,
+version int unsigned not null default 1,
 PRIMARY KEY (`id`),
+KEY `issues_project_type` (project, issueType),
 constraint `priority_range` check (priority > 0 and priority < 11)
 );
 
@@ -21,3 +31,298 @@ createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
 PRIMARY KEY (`commentID`),
 CONSTRAINT `comments_fk_1` FOREIGN KEY (`issueID`) REFERENCES `issues` (`id`) ON DELETE CASCADE
 );
+
+Create table `issue_types` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+project varchar(16) not null default '',
+name varchar(32) not null,
+workflow varchar(64) not null default 'default',
+requiredFields varchar(256) not null default '',
+defaultPriority int not null default 1,
+descriptionTemplate varchar(256) not null default '',
+PRIMARY KEY (`id`),
+UNIQUE KEY `issue_types_project_name` (project, name),
+constraint `default_priority_range` check (defaultPriority > 0 and defaultPriority < 11)
+);
+
+Create table `issue_fields` (
+issueID int(10) unsigned NOT NULL,
+name varchar(64) not null,
+value varchar(1024) not null default '',
+PRIMARY KEY (`issueID`, `name`),
+CONSTRAINT `issue_fields_fk_1` FOREIGN KEY (`issueID`) REFERENCES `issues` (`id`) ON DELETE CASCADE
+);
+
+Create table `business_calendars` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+name varchar(64) not null,
+timezone varchar(64) not null default 'UTC',
+workdays varchar(16) not null default '1,2,3,4,5',
+startHour int not null default 9,
+endHour int not null default 17,
+PRIMARY KEY (`id`),
+constraint `business_hours_range` check (startHour >= 0 and endHour <= 24 and startHour < endHour)
+);
+
+Create table `sla_policies` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+name varchar(64) not null,
+project varchar(16) not null default '',
+priority int not null default 0,
+responseMinutes int not null default 0,
+resolutionMinutes int not null default 0,
+calendarID int(10) unsigned NULL,
+PRIMARY KEY (`id`),
+CONSTRAINT `sla_policies_fk_1` FOREIGN KEY (`calendarID`) REFERENCES `business_calendars` (`id`) ON DELETE SET NULL
+);
+
+Create table `sla_breaches` (
+issueID int(10) unsigned NOT NULL,
+target ENUM('response', 'resolution') not null,
+policy varchar(64) not null,
+breachDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`issueID`, `target`),
+CONSTRAINT `sla_breaches_fk_1` FOREIGN KEY (`issueID`) REFERENCES `issues` (`id`) ON DELETE CASCADE
+);
+
+Create table `worklogs` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+issueID int(10) unsigned NOT NULL,
+username varchar(64) not null,
+minutes int not null,
+workDate date not null,
+note varchar(256) not null default '',
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`id`),
+KEY `worklogs_user_date` (username, workDate),
+CONSTRAINT `worklogs_fk_1` FOREIGN KEY (`issueID`) REFERENCES `issues` (`id`) ON DELETE CASCADE,
+constraint `worklog_minutes` check (minutes > 0)
+);
+
+Create table `attachments` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+issueID int(10) unsigned NOT NULL,
+commentID int(10) unsigned NULL,
+filename varchar(256) not null,
+contentType varchar(128) not null,
+size bigint not null,
+hash char(64) not null,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`id`),
+KEY `attachments_hash` (hash),
+CONSTRAINT `attachments_fk_1` FOREIGN KEY (`issueID`) REFERENCES `issues` (`id`) ON DELETE CASCADE,
+CONSTRAINT `attachments_fk_2` FOREIGN KEY (`commentID`) REFERENCES `comments` (`commentID`) ON DELETE CASCADE
+);
+
+Create table `watchers` (
+issueID int(10) unsigned NOT NULL,
+username varchar(64) not null,
+PRIMARY KEY (`issueID`, `username`)
+);
+
+Create table `notifications` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+eventID int(10) unsigned NULL,
+username varchar(64) not null,
+issueID int(10) unsigned NOT NULL,
+kind varchar(16) not null,
+actor varchar(64) not null default '',
+message varchar(1024) not null default '',
+isRead boolean not null default false,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`id`),
+KEY `notifications_user_read` (username, isRead),
+UNIQUE KEY `notifications_event` (eventID, username)
+);
+
+Create table `webhooks` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+url varchar(2048) not null,
+secret varchar(256) not null default '',
+events varchar(256) not null,
+project varchar(64) not null default '',
+format varchar(16) not null default 'json',
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`id`)
+);
+
+Create table `webhook_deliveries` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+eventID int(10) unsigned NULL,
+webhookID int(10) unsigned NOT NULL,
+event varchar(32) not null,
+payload mediumtext not null,
+status varchar(16) not null default 'pending',
+attempts int not null default 0,
+nextAttempt timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+responseStatus int not null default 0,
+lastError varchar(1024) not null default '',
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+deliveredDate timestamp NULL,
+PRIMARY KEY (`id`),
+KEY `webhook_deliveries_due` (status, nextAttempt),
+UNIQUE KEY `webhook_deliveries_event` (eventID, webhookID),
+CONSTRAINT `webhook_deliveries_fk_1` FOREIGN KEY (`webhookID`) REFERENCES `webhooks` (`id`) ON DELETE CASCADE
+);
+
+Create table `outbox` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+event varchar(32) not null,
+issueID int(10) unsigned NOT NULL,
+payload mediumtext not null,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`id`)
+);
+
+Create table `outbox_consumed` (
+consumer varchar(32) not null,
+eventID int(10) unsigned NOT NULL,
+PRIMARY KEY (`consumer`, `eventID`),
+KEY `outbox_consumed_event` (eventID),
+CONSTRAINT `outbox_consumed_fk_1` FOREIGN KEY (`eventID`) REFERENCES `outbox` (`id`) ON DELETE CASCADE
+);
+
+Create table `email_preferences` (
+username varchar(64) not null,
+email varchar(256) not null default '',
+assigned boolean not null default true,
+statusChanged boolean not null default true,
+commented boolean not null default true,
+mentioned boolean not null default true,
+digest boolean not null default false,
+unsubscribeToken char(32) not null,
+PRIMARY KEY (`username`),
+UNIQUE KEY `email_preferences_token` (unsubscribeToken),
+KEY `email_preferences_email` (email)
+);
+
+Create table `emails` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+eventID int(10) unsigned NOT NULL,
+username varchar(64) not null,
+kind varchar(16) not null,
+issueID int(10) unsigned NOT NULL,
+subject varchar(512) not null,
+textBody text not null,
+htmlBody text not null,
+digest boolean not null default false,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+sentDate timestamp NULL,
+PRIMARY KEY (`id`),
+UNIQUE KEY `emails_event` (eventID, username, kind),
+KEY `emails_pending` (sentDate, digest)
+);
+
+Create table `issue_links` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+issueID int(10) unsigned NOT NULL,
+kind varchar(16) not null,
+repository varchar(256) not null,
+ref varchar(64) not null,
+url varchar(2048) not null default '',
+title varchar(256) not null default '',
+author varchar(64) not null default '',
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`id`),
+UNIQUE KEY `issue_links_ref` (issueID, kind, repository, ref),
+CONSTRAINT `issue_links_fk_1` FOREIGN KEY (`issueID`) REFERENCES `issues` (`id`) ON DELETE CASCADE
+);
+
+Create table `import_jobs` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+format varchar(16) not null,
+status ENUM('queued', 'running', 'completed', 'failed') not null default 'queued',
+createdBy varchar(64) not null default '',
+total int not null default 0,
+processed int not null default 0,
+created int not null default 0,
+failed int not null default 0,
+errors mediumtext,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+updateDate timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+PRIMARY KEY (`id`)
+);
+
+Create table `inbound_messages` (
+messageID varchar(255) not null,
+issueID int(10) unsigned NOT NULL,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`messageID`),
+KEY `inbound_messages_issue` (issueID)
+);
+
+Insert into `issue_types` (name, requiredFields, defaultPriority, descriptionTemplate) values
+('Bug', 'steps to reproduce', 3, 'Expected behaviour:\n\nActual behaviour:'),
+('Feature', '', 5, 'As a ... I want ... so that ...'),
+('Task', '', 5, '');
+
+Create table `api_tokens` (
+username varchar(64) not null,
+tokenHash char(64) not null,
+admin boolean not null default false,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+rotateDate timestamp NULL DEFAULT NULL,
+PRIMARY KEY (`username`),
+UNIQUE KEY `api_tokens_hash` (tokenHash)
+);
+
+Create table `idempotency_keys` (
+username varchar(64) not null,
+idempotencyKey varchar(255) not null,
+fingerprint char(64) not null,
+status int not null default 0,
+header text,
+body mediumblob,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+expireDate timestamp NOT NULL,
+PRIMARY KEY (`username`, `idempotencyKey`),
+KEY `idempotency_keys_expire` (expireDate)
+);
+
+Create table `bulk_jobs` (
+id int(10) unsigned NOT NULL AUTO_INCREMENT,
+operation varchar(16) not null,
+status ENUM('queued', 'running', 'completed', 'failed') not null default 'queued',
+createdBy varchar(64) not null default '',
+allOrNothing boolean not null default false,
+total int not null default 0,
+processed int not null default 0,
+succeeded int not null default 0,
+failed int not null default 0,
+results mediumtext,
+createDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+updateDate timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+PRIMARY KEY (`id`)
+);
+
+Create table `attachment_contents` (
+hash char(64) not null,
+PRIMARY KEY (`hash`)
+);
+
+Create table `schema_migrations` (
+version int not null,
+name varchar(64) not null,
+appliedDate timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+PRIMARY KEY (`version`)
+);
+
+Insert into `schema_migrations` (version, name) values
+(1, 'baseline'),
+(2, 'issue types'),
+(3, 'sla policies'),
+(4, 'time tracking'),
+(5, 'attachments'),
+(6, 'notifications'),
+(7, 'webhooks'),
+(8, 'outbox'),
+(9, 'email notifications'),
+(10, 'inbound email'),
+(11, 'webhook formats'),
+(12, 'issue links'),
+(13, 'import jobs'),
+(14, 'api tokens'),
+(15, 'issue versions'),
+(16, 'idempotency keys'),
+(17, 'bulk jobs'),
+(18, 'attachment contents');